@@ -0,0 +1,103 @@
+package apikeyapp
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/felipecooper/log-horizon/app/sdk/actor"
+	"github.com/felipecooper/log-horizon/app/sdk/proto/mlog"
+	"github.com/felipecooper/log-horizon/business/domain/apikey"
+	"github.com/felipecooper/log-horizon/foundation/logger"
+	"github.com/oklog/ulid/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type App struct {
+	log    logger.Logger
+	apikey *apikey.Business
+	mlog.UnimplementedKeyAdminServer
+}
+
+func NewApp(log logger.Logger, apikey *apikey.Business) *App {
+	return &App{
+		log:    log,
+		apikey: apikey,
+	}
+}
+
+func (a *App) CreateKey(ctx context.Context, req *mlog.CreateApiKeyRequest) (*mlog.ApiKeySecret, error) {
+	nk := NewKeyFromProto(req)
+	nk.CreatedBy = actor.FromContext(ctx)
+	a.log.Info(ctx, "create api key request received", "name", nk.Name, "scopes", nk.Scopes, "actor", nk.CreatedBy)
+
+	key, secret, err := a.apikey.Create(ctx, nk)
+	if err != nil {
+		a.log.Error(ctx, "error creating api key", "error", err)
+		if errors.Is(err, apikey.ErrInvalidKey) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to create api key")
+	}
+
+	return ToProtoKeySecret(key, secret), nil
+}
+
+func (a *App) RotateKey(ctx context.Context, req *mlog.RotateApiKeyRequest) (*mlog.ApiKeySecret, error) {
+	by := actor.FromContext(ctx)
+	a.log.Info(ctx, "rotate api key request received", "id", req.Id, "actor", by)
+
+	id, err := ulid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid api key id")
+	}
+	if req.GraceSeconds < 0 {
+		return nil, status.Error(codes.InvalidArgument, "grace must not be negative")
+	}
+
+	key, secret, err := a.apikey.Rotate(ctx, id, time.Duration(req.GraceSeconds)*time.Second, by)
+	if err != nil {
+		a.log.Error(ctx, "error rotating api key", "error", err)
+		if errors.Is(err, apikey.ErrKeyNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		if errors.Is(err, apikey.ErrKeyRevoked) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to rotate api key")
+	}
+
+	return ToProtoKeySecret(key, secret), nil
+}
+
+func (a *App) RevokeKey(ctx context.Context, req *mlog.RevokeApiKeyRequest) (*mlog.ApiKey, error) {
+	by := actor.FromContext(ctx)
+	a.log.Info(ctx, "revoke api key request received", "id", req.Id, "actor", by)
+
+	id, err := ulid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid api key id")
+	}
+
+	key, err := a.apikey.Revoke(ctx, id, by)
+	if err != nil {
+		a.log.Error(ctx, "error revoking api key", "error", err)
+		if errors.Is(err, apikey.ErrKeyNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to revoke api key")
+	}
+
+	return ToProtoKey(key), nil
+}
+
+func (a *App) ListKeys(ctx context.Context, req *mlog.ListApiKeysRequest) (*mlog.ApiKeys, error) {
+	keys, err := a.apikey.Keys(ctx, req.IncludeRevoked)
+	if err != nil {
+		a.log.Error(ctx, "error listing api keys", "error", err)
+		return nil, status.Error(codes.Internal, "failed to list api keys")
+	}
+
+	return ToProtoKeys(keys), nil
+}
@@ -0,0 +1,74 @@
+package apikeyapp
+
+import (
+	"time"
+
+	"github.com/felipecooper/log-horizon/app/sdk/proto/mlog"
+	"github.com/felipecooper/log-horizon/business/domain/apikey"
+)
+
+func NewKeyFromProto(proto *mlog.CreateApiKeyRequest) apikey.NewKey {
+	nk := apikey.NewKey{
+		Name:   proto.Name,
+		Tenant: proto.Tenant,
+		Role:   proto.Role,
+	}
+
+	for _, s := range proto.Scopes {
+		nk.Scopes = append(nk.Scopes, apikey.Scope(s))
+	}
+
+	if proto.ExpiresAt != 0 {
+		nk.ExpiresAt = time.Unix(proto.ExpiresAt, 0)
+	}
+
+	return nk
+}
+
+func ToProtoKey(key apikey.Key) *mlog.ApiKey {
+	resp := mlog.ApiKey{
+		Id:        key.ID.String(),
+		Name:      key.Name,
+		Tenant:    key.Tenant,
+		Role:      key.Role,
+		CreatedBy: key.CreatedBy,
+		CreatedAt: key.CreatedAt.Unix(),
+		RevokedBy: key.RevokedBy,
+	}
+
+	for _, s := range key.Scopes {
+		resp.Scopes = append(resp.Scopes, string(s))
+	}
+
+	if !key.ExpiresAt.IsZero() {
+		resp.ExpiresAt = key.ExpiresAt.Unix()
+	}
+	if !key.RotatedAt.IsZero() {
+		resp.RotatedAt = key.RotatedAt.Unix()
+	}
+	if !key.PreviousUntil.IsZero() {
+		resp.PreviousValidUntil = key.PreviousUntil.Unix()
+	}
+	if !key.RevokedAt.IsZero() {
+		resp.RevokedAt = key.RevokedAt.Unix()
+	}
+
+	return &resp
+}
+
+func ToProtoKeySecret(key apikey.Key, secret string) *mlog.ApiKeySecret {
+	return &mlog.ApiKeySecret{
+		Key:    ToProtoKey(key),
+		Secret: secret,
+	}
+}
+
+func ToProtoKeys(keys []apikey.Key) *mlog.ApiKeys {
+	resp := mlog.ApiKeys{
+		Keys: make([]*mlog.ApiKey, len(keys)),
+	}
+	for i, k := range keys {
+		resp.Keys[i] = ToProtoKey(k)
+	}
+	return &resp
+}
@@ -0,0 +1,58 @@
+package auditapp
+
+import (
+	"context"
+
+	"github.com/felipecooper/log-horizon/app/sdk/proto/mlog"
+	"github.com/felipecooper/log-horizon/business/domain/audit"
+	"github.com/felipecooper/log-horizon/foundation/logger"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type App struct {
+	log   logger.Logger
+	audit *audit.Business
+	mlog.UnimplementedAuditLogServer
+}
+
+func NewApp(log logger.Logger, audit *audit.Business) *App {
+	return &App{
+		log:   log,
+		audit: audit,
+	}
+}
+
+func (a *App) Query(ctx context.Context, req *mlog.AuditQuery) (*mlog.AuditEntries, error) {
+	if req.Page < 0 || req.PageSize < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page and page size must not be negative")
+	}
+
+	entries, hasMore, err := a.audit.Query(ctx, NewFilterFromProto(req), int(req.Page), int(req.PageSize))
+	if err != nil {
+		a.log.Error(ctx, "error querying audit trail", "error", err)
+		return nil, status.Error(codes.Internal, "failed to query audit trail")
+	}
+
+	resp := ToProtoEntries(entries)
+	resp.HasMore = hasMore
+	if hasMore {
+		resp.NextPage = req.Page + 1
+	}
+
+	return resp, nil
+}
+
+func (a *App) Verify(ctx context.Context, req *mlog.VerifyAuditRequest) (*mlog.AuditVerification, error) {
+	v, err := a.audit.Verify(ctx)
+	if err != nil {
+		a.log.Error(ctx, "error verifying audit trail", "error", err)
+		return nil, status.Error(codes.Internal, "failed to verify audit trail")
+	}
+
+	if !v.Intact {
+		a.log.Error(ctx, "audit trail is broken", "broken_at", v.BrokenAt.String(), "reason", v.Reason)
+	}
+
+	return ToProtoVerification(v), nil
+}
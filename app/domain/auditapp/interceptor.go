@@ -0,0 +1,161 @@
+package auditapp
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/felipecooper/log-horizon/app/sdk/actor"
+	"github.com/felipecooper/log-horizon/app/sdk/proto/mlog"
+	"github.com/felipecooper/log-horizon/business/domain/audit"
+	mlogbus "github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/foundation/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// audited reports whether calls to method are recorded: every call but
+// writes, which the log itself records, and the gRPC services of the server.
+func audited(method string) bool {
+	return !strings.HasPrefix(method, "/logs.LogWriter/") && !strings.HasPrefix(method, "/grpc.")
+}
+
+// UnaryServerInterceptor records audited unary calls. An entry is recorded
+// before the handler runs, and a call whose entry cannot be recorded fails
+// without running, so no read goes unrecorded. The entry recording how the
+// call ended follows it; failing to record that one is only logged, as the
+// call has taken effect by then.
+func UnaryServerInterceptor(log logger.Logger, a *audit.Business) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !audited(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		pending, err := recordPending(ctx, log, a, info.FullMethod, req)
+		if err != nil {
+			return nil, err
+		}
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		e := newEntry(ctx, info.FullMethod, req, start, err)
+		e.PendingID = pending.ID
+		countResponse(&e, resp)
+		recordEnd(ctx, log, a, e)
+
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor records audited streaming calls. The entry
+// recorded before the handler runs is taken once the request is received,
+// and a stream whose entry cannot be recorded fails there.
+func StreamServerInterceptor(log logger.Logger, a *audit.Business) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !audited(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		start := time.Now()
+		as := &auditStream{ServerStream: ss, log: log, audit: a, method: info.FullMethod}
+		err := handler(srv, as)
+
+		e := newEntry(ss.Context(), info.FullMethod, as.req, start, err)
+		e.PendingID = as.pending.ID
+		e.Results = as.results
+		recordEnd(ss.Context(), log, a, e)
+
+		return err
+	}
+}
+
+// recordPending records the entry of a call about to run.
+func recordPending(ctx context.Context, log logger.Logger, a *audit.Business, method string, req any) (audit.Entry, error) {
+	e := newEntry(ctx, method, req, time.Now(), nil)
+	e.Code = audit.CodePending
+
+	pending, err := a.Record(context.WithoutCancel(ctx), e)
+	if err != nil {
+		log.Error(ctx, "failed to audit call", "method", method, "error", err)
+		return audit.Entry{}, status.Error(codes.Internal, "failed to record audit entry")
+	}
+
+	return pending, nil
+}
+
+// recordEnd records how a call ended. The call has run already, so a
+// failure is logged with the pending entry left as the only record.
+func recordEnd(ctx context.Context, log logger.Logger, a *audit.Business, e audit.Entry) {
+	if _, err := a.Record(context.WithoutCancel(ctx), e); err != nil {
+		log.Error(ctx, "audit entry of a completed call lost", "method", e.Method, "pending_id", e.PendingID.String(), "code", e.Code, "error", err)
+	}
+}
+
+func newEntry(ctx context.Context, method string, req any, start time.Time, err error) audit.Entry {
+	e := audit.Entry{
+		Actor:    actor.FromContext(ctx),
+		Tenant:   mlogbus.TenantFrom(ctx),
+		Method:   method,
+		Duration: time.Since(start),
+		Code:     status.Code(err).String(),
+	}
+
+	if msg, ok := req.(proto.Message); ok {
+		if data, err := protojson.Marshal(msg); err == nil {
+			e.Request = string(data)
+		}
+	}
+
+	return e
+}
+
+// countResponse sets the results and bytes of e from the response of a
+// unary call.
+func countResponse(e *audit.Entry, resp any) {
+	switch r := resp.(type) {
+	case *mlog.Logs:
+		e.Results = int64(len(r.Logs))
+	case *mlog.FileResponse:
+		e.Bytes = r.FileSize
+	case *mlog.DeleteResponse:
+		e.Results = r.Deleted + r.Redacted
+	case *mlog.AuditEntries:
+		e.Results = int64(len(r.Entries))
+	case *mlog.ApiKeys:
+		e.Results = int64(len(r.Keys))
+	}
+}
+
+// auditStream records the pending entry of a server stream once its request
+// is received, and counts the logs it sends.
+type auditStream struct {
+	grpc.ServerStream
+	log    logger.Logger
+	audit  *audit.Business
+	method string
+
+	req     any
+	pending audit.Entry
+	results int64
+}
+
+func (s *auditStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil && s.req == nil {
+		s.req = m
+		s.pending, err = recordPending(s.Context(), s.log, s.audit, s.method, m)
+	}
+	return err
+}
+
+func (s *auditStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if logs, ok := m.(*mlog.Logs); ok && err == nil {
+		s.results += int64(len(logs.Logs))
+	}
+	return err
+}
@@ -0,0 +1,68 @@
+package auditapp
+
+import (
+	"time"
+
+	"github.com/felipecooper/log-horizon/app/sdk/proto/mlog"
+	"github.com/felipecooper/log-horizon/business/domain/audit"
+	"github.com/oklog/ulid/v2"
+)
+
+func NewFilterFromProto(proto *mlog.AuditQuery) audit.Filter {
+	filter := audit.Filter{
+		Actor:  proto.Actor,
+		Method: proto.Method,
+	}
+
+	if proto.StartTime != 0 {
+		filter.StartTime = time.Unix(proto.StartTime, 0)
+	}
+	if proto.EndTime != 0 {
+		filter.EndTime = time.Unix(proto.EndTime, 0)
+	}
+
+	return filter
+}
+
+func ToProtoEntry(e audit.Entry) *mlog.AuditEntry {
+	resp := mlog.AuditEntry{
+		Id:         e.ID.String(),
+		Time:       e.Time.Unix(),
+		Actor:      e.Actor,
+		Tenant:     e.Tenant,
+		Method:     e.Method,
+		Request:    e.Request,
+		Results:    e.Results,
+		Bytes:      e.Bytes,
+		DurationMs: e.Duration.Milliseconds(),
+		Code:       e.Code,
+		PrevHash:   e.PrevHash,
+		Hash:       e.Hash,
+	}
+	if e.PendingID != (ulid.ULID{}) {
+		resp.PendingId = e.PendingID.String()
+	}
+	return &resp
+}
+
+func ToProtoEntries(entries []audit.Entry) *mlog.AuditEntries {
+	resp := mlog.AuditEntries{
+		Entries: make([]*mlog.AuditEntry, len(entries)),
+	}
+	for i, e := range entries {
+		resp.Entries[i] = ToProtoEntry(e)
+	}
+	return &resp
+}
+
+func ToProtoVerification(v audit.Verification) *mlog.AuditVerification {
+	resp := mlog.AuditVerification{
+		Checked: v.Checked,
+		Intact:  v.Intact,
+		Reason:  v.Reason,
+	}
+	if !v.Intact {
+		resp.BrokenAt = v.BrokenAt.String()
+	}
+	return &resp
+}
@@ -0,0 +1,46 @@
+package chainapp
+
+import (
+	"context"
+	"errors"
+
+	"github.com/felipecooper/log-horizon/app/sdk/proto/mlog"
+	"github.com/felipecooper/log-horizon/business/domain/chain"
+	domain "github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/foundation/logger"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type App struct {
+	log   logger.Logger
+	chain *chain.Business
+	mlog.UnimplementedLogChainServer
+}
+
+func NewApp(log logger.Logger, chain *chain.Business) *App {
+	return &App{
+		log:   log,
+		chain: chain,
+	}
+}
+
+func (a *App) Verify(ctx context.Context, req *mlog.VerifyChainRequest) (*mlog.ChainVerification, error) {
+	v, err := a.chain.Verify(ctx, req.Tenant, NewTimeRangeFromProto(req))
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidTimeRange) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, domain.ErrNotSupported) {
+			return nil, status.Error(codes.Unimplemented, err.Error())
+		}
+		a.log.Error(ctx, "error verifying log chain", "error", err)
+		return nil, status.Error(codes.Internal, "failed to verify log chain")
+	}
+
+	if !v.Intact {
+		a.log.Error(ctx, "log chain is broken", "tenant", v.Partition, "issues", len(v.Issues)+int(v.OmittedIssues))
+	}
+
+	return ToProtoVerification(v, a.chain.PublicKey()), nil
+}
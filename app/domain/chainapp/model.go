@@ -0,0 +1,55 @@
+package chainapp
+
+import (
+	"encoding/base64"
+	"time"
+
+	"github.com/felipecooper/log-horizon/app/sdk/proto/mlog"
+	"github.com/felipecooper/log-horizon/business/domain/chain"
+	domain "github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/oklog/ulid/v2"
+)
+
+func NewTimeRangeFromProto(proto *mlog.VerifyChainRequest) domain.TimeRange {
+	var tr domain.TimeRange
+	if proto.StartTime != 0 {
+		tr.StartTime = time.Unix(proto.StartTime, 0)
+	}
+	if proto.EndTime != 0 {
+		tr.EndTime = time.Unix(proto.EndTime, 0)
+	}
+	return tr
+}
+
+func ToProtoIssue(issue chain.Issue) *mlog.ChainIssue {
+	resp := mlog.ChainIssue{
+		Kind:   string(issue.Kind),
+		Seq:    issue.Seq,
+		Detail: issue.Detail,
+	}
+	if issue.LogID != (ulid.ULID{}) {
+		resp.LogId = issue.LogID.String()
+	}
+	return &resp
+}
+
+func ToProtoVerification(v chain.Verification, publicKey []byte) *mlog.ChainVerification {
+	resp := mlog.ChainVerification{
+		Tenant:        v.Partition,
+		Links:         v.Links,
+		Logs:          v.Logs,
+		Checkpoints:   v.Checkpoints,
+		Removed:       v.Removed,
+		Redacted:      v.Redacted,
+		Intact:        v.Intact,
+		Issues:        make([]*mlog.ChainIssue, len(v.Issues)),
+		OmittedIssues: v.OmittedIssues,
+	}
+	for i, issue := range v.Issues {
+		resp.Issues[i] = ToProtoIssue(issue)
+	}
+	if publicKey != nil {
+		resp.PublicKey = base64.StdEncoding.EncodeToString(publicKey)
+	}
+	return &resp
+}
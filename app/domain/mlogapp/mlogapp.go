@@ -5,9 +5,11 @@ import (
 	"errors"
 	"time"
 
+	"github.com/felipecooper/log-horizon/app/sdk/actor"
 	"github.com/felipecooper/log-horizon/app/sdk/proto/mlog"
 	domain "github.com/felipecooper/log-horizon/business/domain/mlog"
 	"github.com/felipecooper/log-horizon/foundation/logger"
+	"github.com/oklog/ulid/v2"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -17,6 +19,7 @@ type App struct {
 	mlog *domain.Business
 	mlog.UnimplementedLogWriterServer
 	mlog.UnimplementedLogReaderServer
+	mlog.UnimplementedLogAdminServer
 }
 
 func NewApp(log logger.Logger, mlog *domain.Business) *App {
@@ -28,13 +31,19 @@ func NewApp(log logger.Logger, mlog *domain.Business) *App {
 
 func (a *App) Register(ctx context.Context, req *mlog.NewLog) (*mlog.LogResponse, error) {
 	log := NewLogFromProto(req)
-	a.log.Info(ctx, "log received", "message", log.Message, "level", log.Level)
+	a.log.Info(ctx, "log received", "level", log.Level)
 
-	domainLog, err := a.mlog.Register(ctx, log.Message, domain.Level(log.Level), log.Metadata)
+	domainLog, err := a.mlog.RegisterWithKey(ctx, log.IdempotencyKey, log.Message, domain.Level(log.Level), log.Metadata)
 	if err != nil {
-		if errors.Is(err, domain.ErrInvalidLevel) {
+		if errors.Is(err, domain.ErrInvalidLevel) || errors.Is(err, domain.ErrInvalidIdempotencyKey) || errors.Is(err, domain.ErrInvalidTenant) {
 			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
+		if errors.Is(err, domain.ErrTenantRequired) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if errors.Is(err, domain.ErrOverloaded) {
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		}
 		return nil, status.Error(codes.Internal, "fail to register log")
 	}
 
@@ -54,14 +63,24 @@ func (a *App) Search(ctx context.Context, req *mlog.SearchQuery) (*mlog.Logs, er
 		search.StartTime,
 		search.EndTime,
 		domain.Level(search.Level),
+		search.Message,
 		search.Page,
 		search.PageSize,
 	)
 	if err != nil {
 		a.log.Error(ctx, "error searching logs", "error", err)
-		if errors.Is(err, domain.ErrInvalidLevel) || errors.Is(err, domain.ErrInvalidTimeRange) {
+		if errors.Is(err, domain.ErrInvalidLevel) || errors.Is(err, domain.ErrInvalidTimeRange) || errors.Is(err, domain.ErrInvalidTenant) {
 			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
+		if errors.Is(err, domain.ErrTenantRequired) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if errors.Is(err, domain.ErrRestricted) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		if errors.Is(err, domain.ErrNotSupported) {
+			return nil, status.Error(codes.Unimplemented, err.Error())
+		}
 		return nil, status.Error(codes.Internal, "failed on search logs")
 	}
 
@@ -81,12 +100,22 @@ func (a *App) ExportToFile(ctx context.Context, req *mlog.SearchQuery) (*mlog.Fi
 		search.StartTime,
 		search.EndTime,
 		domain.Level(search.Level),
+		search.Message,
 	)
 	if err != nil {
 		a.log.Error(ctx, "error exporting logs to file", "error", err)
-		if errors.Is(err, domain.ErrInvalidLevel) || errors.Is(err, domain.ErrInvalidTimeRange) {
+		if errors.Is(err, domain.ErrInvalidLevel) || errors.Is(err, domain.ErrInvalidTimeRange) || errors.Is(err, domain.ErrInvalidTenant) {
 			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
+		if errors.Is(err, domain.ErrTenantRequired) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if errors.Is(err, domain.ErrRestricted) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		if errors.Is(err, domain.ErrNotSupported) {
+			return nil, status.Error(codes.Unimplemented, err.Error())
+		}
 		return nil, status.Error(codes.Internal, "failed to export logs to file")
 	}
 
@@ -107,38 +136,227 @@ func (a *App) StreamFile(req *mlog.SearchQuery, stream mlog.LogReader_StreamFile
 		pageSize = search.PageSize
 	}
 
-	page := 0
-	hasMore := true
-
-	for hasMore {
-		result, err := a.mlog.Query(
-			ctx,
-			search.StartTime,
-			search.EndTime,
-			domain.Level(search.Level),
-			page,
-			pageSize,
-		)
-		if err != nil {
-			a.log.Error(ctx, "error streaming logs", "error", err, "page", page)
-			if errors.Is(err, domain.ErrInvalidLevel) || errors.Is(err, domain.ErrInvalidTimeRange) {
-				return status.Error(codes.InvalidArgument, err.Error())
+	var sendErr error
+	err := a.mlog.Stream(
+		ctx,
+		search.StartTime,
+		search.EndTime,
+		domain.Level(search.Level),
+		search.Message,
+		pageSize,
+		func(result domain.SearchResult) error {
+			if sendErr = stream.Send(ToProtoLogs(result)); sendErr != nil {
+				return sendErr
 			}
-			return status.Error(codes.Internal, "failed on search logs")
+			if result.HasMore {
+				time.Sleep(10 * time.Millisecond)
+			}
+			return nil
+		},
+	)
+	if sendErr != nil {
+		a.log.Error(ctx, "error sending stream chunk", "error", sendErr)
+		return status.Error(codes.Internal, "failed on send stream chunk")
+	}
+	if err != nil {
+		a.log.Error(ctx, "error streaming logs", "error", err)
+		if errors.Is(err, domain.ErrInvalidLevel) || errors.Is(err, domain.ErrInvalidTimeRange) || errors.Is(err, domain.ErrInvalidTenant) {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, domain.ErrTenantRequired) {
+			return status.Error(codes.Unauthenticated, err.Error())
+		}
+		if errors.Is(err, domain.ErrRestricted) {
+			return status.Error(codes.PermissionDenied, err.Error())
 		}
+		if errors.Is(err, domain.ErrNotSupported) {
+			return status.Error(codes.Unimplemented, err.Error())
+		}
+		return status.Error(codes.Internal, "failed on search logs")
+	}
+
+	return nil
+}
+
+func (a *App) Stats(ctx context.Context, req *mlog.StatsRequest) (*mlog.StatsResponse, error) {
+	a.log.Info(ctx, "stats request received", "refresh", req.Refresh)
 
-		if err := stream.Send(ToProtoLogs(result)); err != nil {
-			a.log.Error(ctx, "error sending stream chunk", "error", err, "page", page)
-			return status.Error(codes.Internal, "failed on send stream chunk")
+	stats, err := a.mlog.Stats(ctx, req.Refresh)
+	if err != nil {
+		a.log.Error(ctx, "error computing stats", "error", err)
+		if errors.Is(err, domain.ErrNotSupported) {
+			return nil, status.Error(codes.Unimplemented, err.Error())
 		}
+		return nil, status.Error(codes.Internal, "failed to compute stats")
+	}
 
-		hasMore = result.HasMore
-		page = result.NextPage
+	return ToProtoStats(stats), nil
+}
 
-		if hasMore {
-			time.Sleep(10 * time.Millisecond)
+func (a *App) ListRetentionPolicies(ctx context.Context, req *mlog.ListRetentionPoliciesRequest) (*mlog.RetentionPolicies, error) {
+	policies, metrics, err := a.mlog.RetentionPolicies(ctx)
+	if err != nil {
+		a.log.Error(ctx, "error listing retention policies", "error", err)
+		if errors.Is(err, domain.ErrNotSupported) {
+			return nil, status.Error(codes.Unimplemented, err.Error())
 		}
+		return nil, status.Error(codes.Internal, "failed to list retention policies")
 	}
 
-	return nil
+	return ToProtoRetentionPolicies(policies, metrics), nil
+}
+
+func (a *App) SetRetentionPolicy(ctx context.Context, req *mlog.RetentionPolicy) (*mlog.RetentionPolicy, error) {
+	a.log.Info(ctx, "set retention policy request received", "name", req.Name, "level", req.Level, "maxAge", req.MaxAgeSeconds)
+
+	policy, err := a.mlog.SetRetentionPolicy(ctx, NewRetentionPolicyFromProto(req))
+	if err != nil {
+		a.log.Error(ctx, "error saving retention policy", "error", err)
+		if errors.Is(err, domain.ErrInvalidRetentionPolicy) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, domain.ErrNotSupported) {
+			return nil, status.Error(codes.Unimplemented, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to save retention policy")
+	}
+
+	return ToProtoRetentionPolicy(policy, domain.RetentionMetrics{}), nil
+}
+
+func (a *App) DeleteRetentionPolicy(ctx context.Context, req *mlog.DeleteRetentionPolicyRequest) (*mlog.DeleteRetentionPolicyResponse, error) {
+	a.log.Info(ctx, "delete retention policy request received", "name", req.Name)
+
+	if err := a.mlog.DeleteRetentionPolicy(ctx, req.Name); err != nil {
+		a.log.Error(ctx, "error deleting retention policy", "error", err)
+		if errors.Is(err, domain.ErrRetentionPolicyNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		if errors.Is(err, domain.ErrNotSupported) {
+			return nil, status.Error(codes.Unimplemented, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to delete retention policy")
+	}
+
+	return &mlog.DeleteRetentionPolicyResponse{Status: "success"}, nil
+}
+
+func (a *App) EnforceRetention(ctx context.Context, req *mlog.EnforceRetentionRequest) (*mlog.EnforceRetentionResponse, error) {
+	a.log.Info(ctx, "enforce retention request received", "dryRun", req.DryRun)
+
+	results, err := a.mlog.EnforceRetention(ctx, req.DryRun)
+	if err != nil {
+		a.log.Error(ctx, "error enforcing retention", "error", err)
+		if errors.Is(err, domain.ErrNotSupported) {
+			return nil, status.Error(codes.Unimplemented, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to enforce retention")
+	}
+
+	return ToProtoRetentionResults(results), nil
+}
+
+func (a *App) Delete(ctx context.Context, req *mlog.DeleteRequest) (*mlog.DeleteResponse, error) {
+	criteria := NewDeleteCriteriaFromProto(req)
+	criteria.Actor = actor.FromContext(ctx)
+	a.log.Info(ctx, "delete request received",
+		"actor", criteria.Actor,
+		"mode", criteria.Mode,
+		"dryRun", criteria.DryRun,
+		"expectedCount", criteria.ExpectedCount,
+	)
+
+	result, err := a.mlog.Delete(ctx, criteria)
+	if err != nil {
+		a.log.Error(ctx, "error deleting logs", "error", err)
+		switch {
+		case errors.Is(err, domain.ErrUnboundedCriteria),
+			errors.Is(err, domain.ErrInvalidEraseMode),
+			errors.Is(err, domain.ErrInvalidLevel),
+			errors.Is(err, domain.ErrInvalidTimeRange),
+			errors.Is(err, domain.ErrInvalidTenant):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errors.Is(err, domain.ErrTenantRequired):
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		case errors.Is(err, domain.ErrDryRunMismatch), errors.Is(err, domain.ErrTooManyMatches):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		case errors.Is(err, domain.ErrNotSupported):
+			return nil, status.Error(codes.Unimplemented, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to delete logs")
+	}
+
+	return ToProtoDeleteResponse(result), nil
+}
+
+func (a *App) CreateLegalHold(ctx context.Context, req *mlog.LegalHold) (*mlog.LegalHold, error) {
+	hold := NewLegalHoldFromProto(req)
+	hold.CreatedBy = actor.FromContext(ctx)
+	a.log.Info(ctx, "create legal hold request received", "name", hold.Name, "actor", hold.CreatedBy)
+
+	hold, err := a.mlog.CreateLegalHold(ctx, hold)
+	if err != nil {
+		a.log.Error(ctx, "error creating legal hold", "error", err)
+		if errors.Is(err, domain.ErrInvalidLegalHold) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, domain.ErrNotSupported) {
+			return nil, status.Error(codes.Unimplemented, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to create legal hold")
+	}
+
+	return ToProtoLegalHold(hold), nil
+}
+
+func (a *App) ListLegalHolds(ctx context.Context, req *mlog.ListLegalHoldsRequest) (*mlog.LegalHolds, error) {
+	holds, err := a.mlog.LegalHolds(ctx, req.IncludeReleased)
+	if err != nil {
+		a.log.Error(ctx, "error listing legal holds", "error", err)
+		if errors.Is(err, domain.ErrNotSupported) {
+			return nil, status.Error(codes.Unimplemented, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to list legal holds")
+	}
+
+	return ToProtoLegalHolds(holds), nil
+}
+
+func (a *App) ReleaseLegalHold(ctx context.Context, req *mlog.ReleaseLegalHoldRequest) (*mlog.LegalHold, error) {
+	by := actor.FromContext(ctx)
+	a.log.Info(ctx, "release legal hold request received", "id", req.Id, "actor", by)
+
+	id, err := ulid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid legal hold id")
+	}
+
+	hold, err := a.mlog.ReleaseLegalHold(ctx, id, by)
+	if err != nil {
+		a.log.Error(ctx, "error releasing legal hold", "error", err)
+		if errors.Is(err, domain.ErrLegalHoldNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		if errors.Is(err, domain.ErrNotSupported) {
+			return nil, status.Error(codes.Unimplemented, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to release legal hold")
+	}
+
+	return ToProtoLegalHold(hold), nil
+}
+
+func (a *App) RotateEncryptionKey(ctx context.Context, req *mlog.RotateEncryptionKeyRequest) (*mlog.RotateEncryptionKeyResponse, error) {
+	a.log.Info(ctx, "rotate encryption key request received", "actor", actor.FromContext(ctx))
+
+	id, err := a.mlog.RotateEncryptionKey(ctx)
+	if err != nil {
+		a.log.Error(ctx, "error rotating encryption key", "error", err)
+		if errors.Is(err, domain.ErrNotSupported) {
+			return nil, status.Error(codes.Unimplemented, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to rotate encryption key")
+	}
+
+	return &mlog.RotateEncryptionKeyResponse{KeyId: id}, nil
 }
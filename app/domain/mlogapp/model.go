@@ -5,13 +5,15 @@ import (
 
 	"github.com/felipecooper/log-horizon/app/sdk/proto/mlog"
 	domain "github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/oklog/ulid/v2"
 )
 
 type LogInput struct {
-	Message   string
-	Level     string
-	Timestamp time.Time
-	Metadata  map[string]string
+	Message        string
+	Level          string
+	Timestamp      time.Time
+	Metadata       map[string]string
+	IdempotencyKey string
 }
 
 func NewLogFromProto(proto *mlog.NewLog) LogInput {
@@ -21,6 +23,8 @@ func NewLogFromProto(proto *mlog.NewLog) LogInput {
 		Level:     proto.Level,
 		Timestamp: t,
 		Metadata:  proto.Metadata,
+
+		IdempotencyKey: proto.IdempotencyKey,
 	}
 }
 
@@ -28,6 +32,7 @@ type SearchInput struct {
 	StartTime time.Time
 	EndTime   time.Time
 	Level     string
+	Message   string
 	PageSize  int
 	Page      int
 	AsFile    bool
@@ -38,6 +43,7 @@ func NewSearchFromProto(proto *mlog.SearchQuery) SearchInput {
 		StartTime: time.Unix(proto.StartTime, 0),
 		EndTime:   time.Unix(proto.EndTime, 0),
 		Level:     proto.Level,
+		Message:   proto.Message,
 		PageSize:  int(proto.PageSize),
 		Page:      int(proto.Page),
 		AsFile:    proto.AsFile,
@@ -51,6 +57,7 @@ func ToProtoLog(log domain.Log) *mlog.Log {
 		Level:     string(log.Level),
 		Timestamp: log.Timestamp.Unix(),
 		Metadata:  log.Metadata,
+		Tenant:    log.Tenant,
 	}
 }
 
@@ -82,3 +89,233 @@ func ToProtoFileResponse(fileURL string, fileSize int64) *mlog.FileResponse {
 		Compression: "gzip",
 	}
 }
+
+func ToProtoStats(stats domain.Stats) *mlog.StatsResponse {
+	resp := mlog.StatsResponse{
+		Total:            stats.Total,
+		Compressed:       stats.Compressed,
+		Uncompressed:     stats.Uncompressed,
+		RawBytes:         stats.RawBytes,
+		StoredBytes:      stats.StoredBytes,
+		CompressionRatio: stats.Ratio(),
+		ComputedAt:       stats.ComputedAt.Unix(),
+		Backlog: &mlog.Backlog{
+			Queued:        stats.Backlog.Queued,
+			Spooled:       stats.Backlog.Spooled,
+			SpoolBytes:    stats.Backlog.SpoolBytes,
+			SpoolSegments: int32(stats.Backlog.SpoolSegments),
+			Replayed:      stats.Backlog.Replayed,
+			DeadLettered:  stats.Backlog.DeadLettered,
+			Spooling:      stats.Backlog.Spooling,
+		},
+	}
+
+	if !stats.Backlog.OldestSpooled.IsZero() {
+		resp.Backlog.OldestSpooled = stats.Backlog.OldestSpooled.Unix()
+	}
+
+	for _, r := range stats.Redactions {
+		resp.Redactions = append(resp.Redactions, &mlog.RedactionStats{
+			Rule:  r.Rule,
+			Count: r.Count,
+		})
+	}
+
+	for _, l := range stats.Levels {
+		resp.Levels = append(resp.Levels, &mlog.LevelStats{
+			Level: string(l.Level),
+			Count: l.Count,
+		})
+	}
+
+	for _, d := range stats.Days {
+		resp.Days = append(resp.Days, &mlog.DayStats{
+			Day:   d.Day.Format(time.DateOnly),
+			Count: d.Count,
+		})
+	}
+
+	for _, a := range stats.Algorithms {
+		resp.Algorithms = append(resp.Algorithms, &mlog.AlgorithmStats{
+			Algorithm:   a.Algorithm,
+			Count:       a.Count,
+			RawBytes:    a.RawBytes,
+			StoredBytes: a.StoredBytes,
+			Ratio:       a.Ratio(),
+		})
+	}
+
+	for _, t := range stats.Tenants {
+		resp.Tenants = append(resp.Tenants, &mlog.TenantStats{
+			Tenant: t.Tenant,
+			Count:  t.Count,
+		})
+	}
+
+	for _, c := range stats.Collections {
+		resp.Collections = append(resp.Collections, &mlog.CollectionStats{
+			Name:        c.Name,
+			Count:       c.Count,
+			Size:        c.Size,
+			StorageSize: c.StorageSize,
+			IndexSize:   c.IndexSize,
+			IndexSizes:  c.IndexSizes,
+		})
+	}
+
+	return &resp
+}
+
+func NewRetentionPolicyFromProto(proto *mlog.RetentionPolicy) domain.RetentionPolicy {
+	return domain.RetentionPolicy{
+		Name:     proto.Name,
+		Level:    domain.Level(proto.Level),
+		Metadata: proto.Metadata,
+		MaxAge:   time.Duration(proto.MaxAgeSeconds) * time.Second,
+	}
+}
+
+func ToProtoRetentionPolicy(policy domain.RetentionPolicy, metrics domain.RetentionMetrics) *mlog.RetentionPolicy {
+	p := mlog.RetentionPolicy{
+		Name:          policy.Name,
+		Level:         string(policy.Level),
+		Metadata:      policy.Metadata,
+		MaxAgeSeconds: int64(policy.MaxAge / time.Second),
+		UpdatedAt:     policy.UpdatedAt.Unix(),
+		Metrics: &mlog.RetentionMetrics{
+			Runs:         metrics.Runs,
+			Deleted:      metrics.Deleted,
+			DeletedBytes: metrics.Bytes,
+			Estimated:    metrics.Estimated,
+		},
+	}
+
+	if !metrics.LastRun.IsZero() {
+		p.Metrics.LastRun = metrics.LastRun.Unix()
+	}
+
+	return &p
+}
+
+func ToProtoRetentionPolicies(policies []domain.RetentionPolicy, metrics map[string]domain.RetentionMetrics) *mlog.RetentionPolicies {
+	protoPolicies := make([]*mlog.RetentionPolicy, len(policies))
+
+	for i, policy := range policies {
+		protoPolicies[i] = ToProtoRetentionPolicy(policy, metrics[policy.Name])
+	}
+
+	return &mlog.RetentionPolicies{
+		Policies: protoPolicies,
+	}
+}
+
+func ToProtoRetentionResults(results []domain.RetentionResult) *mlog.EnforceRetentionResponse {
+	protoResults := make([]*mlog.RetentionResult, len(results))
+
+	for i, r := range results {
+		protoResults[i] = &mlog.RetentionResult{
+			Policy:       r.Policy,
+			Deleted:      r.Deleted,
+			DeletedBytes: r.Bytes,
+			DryRun:       r.DryRun,
+			Ttl:          r.TTL,
+			Estimated:    r.Estimated,
+		}
+	}
+
+	return &mlog.EnforceRetentionResponse{
+		Results: protoResults,
+	}
+}
+
+func NewDeleteCriteriaFromProto(proto *mlog.DeleteRequest) domain.DeleteCriteria {
+	criteria := domain.DeleteCriteria{
+		Level:         domain.Level(proto.Level),
+		Metadata:      proto.Metadata,
+		Message:       proto.Message,
+		Mode:          domain.EraseMode(proto.Mode),
+		DryRun:        proto.DryRun,
+		ExpectedCount: proto.ExpectedCount,
+		Reason:        proto.Reason,
+	}
+
+	if proto.StartTime != 0 {
+		criteria.TimeRange.StartTime = time.Unix(proto.StartTime, 0)
+	}
+	if proto.EndTime != 0 {
+		criteria.TimeRange.EndTime = time.Unix(proto.EndTime, 0)
+	}
+
+	return criteria
+}
+
+func ToProtoDeleteResponse(result domain.DeleteResult) *mlog.DeleteResponse {
+	resp := mlog.DeleteResponse{
+		Matched:  result.Matched,
+		Deleted:  result.Deleted,
+		Redacted: result.Redacted,
+		Held:     result.Held,
+		DryRun:   result.DryRun,
+	}
+
+	if result.AuditID != (ulid.ULID{}) {
+		resp.AuditId = result.AuditID.String()
+	}
+
+	return &resp
+}
+
+func NewLegalHoldFromProto(proto *mlog.LegalHold) domain.LegalHold {
+	hold := domain.LegalHold{
+		Name:     proto.Name,
+		Reason:   proto.Reason,
+		Level:    domain.Level(proto.Level),
+		Metadata: proto.Metadata,
+	}
+
+	if proto.StartTime != 0 {
+		hold.TimeRange.StartTime = time.Unix(proto.StartTime, 0)
+	}
+	if proto.EndTime != 0 {
+		hold.TimeRange.EndTime = time.Unix(proto.EndTime, 0)
+	}
+
+	return hold
+}
+
+func ToProtoLegalHold(hold domain.LegalHold) *mlog.LegalHold {
+	p := mlog.LegalHold{
+		Id:         hold.ID.String(),
+		Name:       hold.Name,
+		Reason:     hold.Reason,
+		Level:      string(hold.Level),
+		Metadata:   hold.Metadata,
+		CreatedBy:  hold.CreatedBy,
+		CreatedAt:  hold.CreatedAt.Unix(),
+		ReleasedBy: hold.ReleasedBy,
+	}
+
+	if !hold.TimeRange.StartTime.IsZero() {
+		p.StartTime = hold.TimeRange.StartTime.Unix()
+	}
+	if !hold.TimeRange.EndTime.IsZero() {
+		p.EndTime = hold.TimeRange.EndTime.Unix()
+	}
+	if !hold.Active() {
+		p.ReleasedAt = hold.ReleasedAt.Unix()
+	}
+
+	return &p
+}
+
+func ToProtoLegalHolds(holds []domain.LegalHold) *mlog.LegalHolds {
+	protoHolds := make([]*mlog.LegalHold, len(holds))
+
+	for i, hold := range holds {
+		protoHolds[i] = ToProtoLegalHold(hold)
+	}
+
+	return &mlog.LegalHolds{
+		Holds: protoHolds,
+	}
+}
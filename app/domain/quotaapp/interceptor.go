@@ -0,0 +1,95 @@
+package quotaapp
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/felipecooper/log-horizon/app/sdk/proto/mlog"
+	"github.com/felipecooper/log-horizon/foundation/logger"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// RetryAfterTrailer is the trailer holding the seconds to wait before
+// retrying a call refused for going over a limit.
+const RetryAfterTrailer = "retry-after"
+
+// queries lists the reads held to the concurrent query limit.
+var queries = map[string]bool{
+	mlog.LogReader_Search_FullMethodName:       true,
+	mlog.LogReader_ExportToFile_FullMethodName: true,
+	mlog.LogReader_StreamFile_FullMethodName:   true,
+}
+
+// UnaryServerInterceptor holds writes to the rates and daily quotas of their
+// subject, and reads to its concurrent query limit.
+func UnaryServerInterceptor(log logger.Logger, l *Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		subject := l.Subject(ctx)
+
+		switch {
+		case info.FullMethod == mlog.LogWriter_Register_FullMethodName:
+			var size int
+			if msg, ok := req.(proto.Message); ok {
+				size = proto.Size(msg)
+			}
+			if err := l.Ingest(subject, 1, int64(size)); err != nil {
+				return nil, refuse(ctx, log, err, func(md metadata.MD) { grpc.SetTrailer(ctx, md) })
+			}
+
+		case queries[info.FullMethod]:
+			release, err := l.Query(subject)
+			if err != nil {
+				return nil, refuse(ctx, log, err, func(md metadata.MD) { grpc.SetTrailer(ctx, md) })
+			}
+			defer release()
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor holds streaming reads to the concurrent query
+// limit of their subject.
+func StreamServerInterceptor(log logger.Logger, l *Limiter) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !queries[info.FullMethod] {
+			return handler(srv, ss)
+		}
+
+		release, err := l.Query(l.Subject(ss.Context()))
+		if err != nil {
+			return refuse(ss.Context(), log, err, ss.SetTrailer)
+		}
+		defer release()
+
+		return handler(srv, ss)
+	}
+}
+
+// refuse returns the status of a call refused by the limiter, carrying the
+// retry delay both as RetryInfo details and as the retry-after trailer.
+func refuse(ctx context.Context, log logger.Logger, err error, setTrailer func(metadata.MD)) error {
+	var exceeded *ExceededError
+	if !errors.As(err, &exceeded) {
+		return status.Error(codes.Internal, "failed to apply limits")
+	}
+
+	log.Info(ctx, "call refused by limits", "subject", exceeded.Subject, "limit", exceeded.Limit, "retryAfter", exceeded.RetryAfter)
+
+	seconds := int64(math.Ceil(exceeded.RetryAfter.Seconds()))
+	setTrailer(metadata.Pairs(RetryAfterTrailer, strconv.FormatInt(seconds, 10)))
+
+	st := status.New(codes.ResourceExhausted, exceeded.Error())
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(exceeded.RetryAfter)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
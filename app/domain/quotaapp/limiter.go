@@ -0,0 +1,362 @@
+package quotaapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/felipecooper/log-horizon/app/sdk/tenant"
+	"github.com/felipecooper/log-horizon/business/domain/apikey"
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/foundation/ratelimit"
+)
+
+// By names what callers are grouped by when applying limits.
+type By string
+
+const (
+	// ByTenant shares the limits of a tenant among all of its callers.
+	ByTenant By = "tenant"
+	// ByClient gives each API key its own limits. Callers without a key
+	// fall back to their tenant.
+	ByClient By = "client"
+)
+
+func (b By) IsValid() bool {
+	return b == ByTenant || b == ByClient
+}
+
+// Limits bounds what a subject may do. Zero fields are unlimited.
+type Limits struct {
+	LogsPerSecond  int64
+	LogsBurst      int64
+	BytesPerSecond int64
+	BytesBurst     int64
+	DailyLogs      int64
+	DailyBytes     int64
+	// MaxQueries caps the reads of a subject running at the same time.
+	MaxQueries int
+}
+
+// Validate checks that no limit is negative.
+func (l Limits) Validate() error {
+	if l.LogsPerSecond < 0 || l.LogsBurst < 0 || l.BytesPerSecond < 0 || l.BytesBurst < 0 ||
+		l.DailyLogs < 0 || l.DailyBytes < 0 || l.MaxQueries < 0 {
+		return errors.New("limits must not be negative")
+	}
+	return nil
+}
+
+// IsZero reports whether l limits nothing.
+func (l Limits) IsZero() bool {
+	return l == Limits{}
+}
+
+// ExceededError reports a call refused for going over a limit.
+type ExceededError struct {
+	Subject string
+	Limit   string
+	// RetryAfter is how long until the call may succeed.
+	RetryAfter time.Duration
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("%s exceeded for %q, retry after %s", e.Limit, e.Subject, e.RetryAfter.Round(time.Millisecond))
+}
+
+// Usage reports the limits of a subject and what it has used of them.
+type Usage struct {
+	Subject        string
+	Limits         Limits
+	LogsAvailable  float64
+	BytesAvailable float64
+	DailyLogs      int64
+	DailyBytes     int64
+	// QuotaResetsAt is when the daily counts start over.
+	QuotaResetsAt time.Time
+	ActiveQueries int
+	// Rejected counts the calls refused since the subject was last idle.
+	Rejected int64
+}
+
+// Limiter applies Limits to each subject. Usage is kept in memory, so
+// daily counts start over when the server restarts. Subjects that have
+// been idle for a while are forgotten once forgetting them changes nothing
+// they are held to.
+type Limiter struct {
+	by            By
+	defaults      Limits
+	subjects      map[string]Limits
+	authenticated bool
+	now           func() time.Time
+
+	mu    sync.Mutex
+	usage map[string]*usage
+	swept time.Time
+}
+
+type usage struct {
+	limits     Limits
+	logs       *ratelimit.Bucket
+	bytes      *ratelimit.Bucket
+	day        time.Time
+	dailyLogs  int64
+	dailyBytes int64
+	queries    int
+	rejected   int64
+	// last is when the subject last wrote or read.
+	last time.Time
+}
+
+// idleAfter is how long a subject goes without calls before it may be
+// forgotten, and sweepInterval how often idle subjects are looked for.
+const (
+	idleAfter     = 10 * time.Minute
+	sweepInterval = time.Minute
+)
+
+// Option configures a Limiter.
+type Option func(*Limiter)
+
+// WithAuthentication tells the limiter that callers are authenticated, so
+// a tenant only named in the tenant header, by a caller not bound to a
+// tenant, does not pick the subject of the call.
+func WithAuthentication() Option {
+	return func(l *Limiter) {
+		l.authenticated = true
+	}
+}
+
+// NewLimiter returns a limiter applying defaults to every subject missing
+// from subjects.
+func NewLimiter(by By, defaults Limits, subjects map[string]Limits, options ...Option) *Limiter {
+	l := &Limiter{
+		by:       by,
+		defaults: defaults,
+		subjects: subjects,
+		now:      time.Now,
+		usage:    make(map[string]*usage),
+	}
+	for _, option := range options {
+		option(l)
+	}
+	return l
+}
+
+// Subject returns the subject the limits of the call are applied to. With
+// authentication, callers that only name their tenant in the header are
+// held to the limits of their API key, or of no tenant without one, so
+// they cannot spread their calls over tenants of their choosing.
+func (l *Limiter) Subject(ctx context.Context) string {
+	key, hasKey := apikey.KeyFrom(ctx)
+	if l.by == ByClient && hasKey {
+		return "key:" + key.ID.String()
+	}
+
+	if l.authenticated && tenant.Claimed(ctx) {
+		if hasKey {
+			return "key:" + key.ID.String()
+		}
+		return ""
+	}
+	return mlog.TenantFrom(ctx)
+}
+
+// Ingest charges subject for writing logs totalling bytes, or returns an
+// *ExceededError without charging it when that would go over a limit.
+func (l *Limiter) Ingest(subject string, logs, bytes int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	u := l.get(subject, now)
+	u.last = now
+
+	var (
+		limit string
+		wait  time.Duration
+	)
+	switch {
+	case u.limits.DailyLogs > 0 && u.dailyLogs+logs > u.limits.DailyLogs:
+		limit, wait = "daily log quota", u.day.AddDate(0, 0, 1).Sub(now)
+	case u.limits.DailyBytes > 0 && u.dailyBytes+bytes > u.limits.DailyBytes:
+		limit, wait = "daily byte quota", u.day.AddDate(0, 0, 1).Sub(now)
+	case u.logs != nil && u.logs.Delay(float64(logs), now) > 0:
+		limit, wait = "log rate", u.logs.Delay(float64(logs), now)
+	case u.bytes != nil && u.bytes.Delay(float64(bytes), now) > 0:
+		limit, wait = "byte rate", u.bytes.Delay(float64(bytes), now)
+	}
+	if limit != "" {
+		u.rejected++
+		return &ExceededError{Subject: subject, Limit: limit, RetryAfter: wait}
+	}
+
+	if u.logs != nil {
+		u.logs.Take(float64(logs), now)
+	}
+	if u.bytes != nil {
+		u.bytes.Take(float64(bytes), now)
+	}
+	u.dailyLogs += logs
+	u.dailyBytes += bytes
+
+	return nil
+}
+
+// queryRetry is the retry hint given to reads refused for running too many
+// at once, which end at no predictable time.
+const queryRetry = time.Second
+
+// Query admits a read of subject, returning the func that ends it, or an
+// *ExceededError when subject already runs as many as it may.
+func (l *Limiter) Query(subject string) (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	u := l.get(subject, now)
+	u.last = now
+	if u.limits.MaxQueries > 0 && u.queries >= u.limits.MaxQueries {
+		u.rejected++
+		return nil, &ExceededError{Subject: subject, Limit: "concurrent query limit", RetryAfter: queryRetry}
+	}
+	u.queries++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			u.queries--
+			l.mu.Unlock()
+		})
+	}, nil
+}
+
+// Usage returns the usage of subject. A subject that is not tracked reports
+// its limits untouched, without being tracked from then on.
+func (l *Limiter) Usage(subject string) Usage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if _, ok := l.usage[subject]; !ok {
+		return l.report(subject, l.newUsage(subject, now))
+	}
+	return l.report(subject, l.get(subject, now))
+}
+
+// Usages returns the usage of every subject that has not been forgotten.
+func (l *Limiter) Usages() []Usage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Sweeping first keeps get from tracking again a subject it forgets.
+	now := l.now()
+	l.sweep(now)
+
+	subjects := make([]string, 0, len(l.usage))
+	for s := range l.usage {
+		subjects = append(subjects, s)
+	}
+	sort.Strings(subjects)
+
+	usages := make([]Usage, len(subjects))
+	for i, s := range subjects {
+		usages[i] = l.report(s, l.get(s, now))
+	}
+	return usages
+}
+
+func (l *Limiter) report(subject string, u *usage) Usage {
+	r := Usage{
+		Subject:       subject,
+		Limits:        u.limits,
+		DailyLogs:     u.dailyLogs,
+		DailyBytes:    u.dailyBytes,
+		QuotaResetsAt: u.day.AddDate(0, 0, 1),
+		ActiveQueries: u.queries,
+		Rejected:      u.rejected,
+	}
+
+	now := l.now()
+	if u.logs != nil {
+		r.LogsAvailable = u.logs.Tokens(now)
+	}
+	if u.bytes != nil {
+		r.BytesAvailable = u.bytes.Tokens(now)
+	}
+
+	return r
+}
+
+// get returns the usage of subject, starting the daily counts over on a
+// new day. The caller holds l.mu.
+func (l *Limiter) get(subject string, now time.Time) *usage {
+	l.sweep(now)
+
+	u, ok := l.usage[subject]
+	if !ok {
+		u = l.newUsage(subject, now)
+		l.usage[subject] = u
+	}
+
+	if day := now.UTC().Truncate(24 * time.Hour); day.After(u.day) {
+		u.day = day
+		u.dailyLogs = 0
+		u.dailyBytes = 0
+	}
+
+	return u
+}
+
+// newUsage returns the usage of a subject yet to make a call.
+func (l *Limiter) newUsage(subject string, now time.Time) *usage {
+	limits, ok := l.subjects[subject]
+	if !ok {
+		limits = l.defaults
+	}
+
+	u := &usage{limits: limits, day: now.UTC().Truncate(24 * time.Hour), last: now}
+	if limits.LogsPerSecond > 0 {
+		u.logs = ratelimit.NewBucket(float64(limits.LogsPerSecond), float64(limits.LogsBurst), now)
+	}
+	if limits.BytesPerSecond > 0 {
+		u.bytes = ratelimit.NewBucket(float64(limits.BytesPerSecond), float64(limits.BytesBurst), now)
+	}
+	return u
+}
+
+// sweep forgets the subjects that are idle, at most once per
+// sweepInterval. The caller holds l.mu.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < sweepInterval {
+		return
+	}
+	l.swept = now
+
+	for subject, u := range l.usage {
+		if u.idle(now) {
+			delete(l.usage, subject)
+		}
+	}
+}
+
+// idle reports whether u has gone idleAfter without calls and would start
+// over as it is: no reads running, full buckets, and no daily quota partly
+// used today.
+func (u *usage) idle(now time.Time) bool {
+	if u.queries > 0 || now.Sub(u.last) < idleAfter {
+		return false
+	}
+	if (u.logs != nil && !u.logs.Full(now)) || (u.bytes != nil && !u.bytes.Full(now)) {
+		return false
+	}
+
+	quota := u.limits.DailyLogs > 0 || u.limits.DailyBytes > 0
+	used := u.dailyLogs > 0 || u.dailyBytes > 0
+	today := !now.UTC().Truncate(24 * time.Hour).After(u.day)
+	return !quota || !used || !today
+}
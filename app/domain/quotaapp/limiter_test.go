@@ -0,0 +1,244 @@
+package quotaapp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/apikey"
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/oklog/ulid/v2"
+)
+
+// clock is a time the tests move by hand.
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func newTestLimiter(defaults Limits, subjects map[string]Limits) (*Limiter, *clock) {
+	c := &clock{t: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)}
+
+	l := NewLimiter(ByTenant, defaults, subjects)
+	l.now = c.now
+	return l, c
+}
+
+func exceeded(t *testing.T, err error, limit string) *ExceededError {
+	t.Helper()
+
+	var e *ExceededError
+	if !errors.As(err, &e) {
+		t.Fatalf("error = %v, want %s exceeded", err, limit)
+	}
+	if e.Limit != limit {
+		t.Fatalf("exceeded %s, want %s", e.Limit, limit)
+	}
+	return e
+}
+
+func TestLogRate(t *testing.T) {
+	l, c := newTestLimiter(Limits{LogsPerSecond: 10, LogsBurst: 20}, nil)
+
+	if err := l.Ingest("acme", 20, 0); err != nil {
+		t.Fatalf("Ingest within the burst: %v", err)
+	}
+
+	e := exceeded(t, l.Ingest("acme", 5, 0), "log rate")
+	if e.Subject != "acme" || e.RetryAfter != 500*time.Millisecond {
+		t.Errorf("exceeded %q, retry after %s; want acme, 500ms", e.Subject, e.RetryAfter)
+	}
+
+	// Refused calls are not charged.
+	c.advance(500 * time.Millisecond)
+	if err := l.Ingest("acme", 5, 0); err != nil {
+		t.Fatalf("Ingest after the retry hint: %v", err)
+	}
+	exceeded(t, l.Ingest("acme", 1, 0), "log rate")
+
+	if u := l.Usage("acme"); u.Rejected != 2 || u.LogsAvailable != 0 {
+		t.Errorf("usage = %+v, want 2 rejected and no logs available", u)
+	}
+}
+
+func TestByteRate(t *testing.T) {
+	l, c := newTestLimiter(Limits{BytesPerSecond: 1000}, nil)
+
+	// Without a burst the bucket holds a second of bytes.
+	if err := l.Ingest("acme", 1, 1000); err != nil {
+		t.Fatalf("Ingest: %v", err)
+	}
+	e := exceeded(t, l.Ingest("acme", 1, 100), "byte rate")
+	if e.RetryAfter != 100*time.Millisecond {
+		t.Errorf("retry after %s, want 100ms", e.RetryAfter)
+	}
+
+	c.advance(time.Second)
+	if err := l.Ingest("acme", 1, 1000); err != nil {
+		t.Fatalf("Ingest after refill: %v", err)
+	}
+}
+
+func TestDailyQuota(t *testing.T) {
+	l, c := newTestLimiter(Limits{DailyLogs: 100, DailyBytes: 1 << 20}, nil)
+
+	if err := l.Ingest("acme", 60, 100); err != nil {
+		t.Fatalf("Ingest: %v", err)
+	}
+	if err := l.Ingest("acme", 40, 100); err != nil {
+		t.Fatalf("Ingest up to the quota: %v", err)
+	}
+
+	e := exceeded(t, l.Ingest("acme", 1, 1), "daily log quota")
+	if want := 12 * time.Hour; e.RetryAfter != want {
+		t.Errorf("retry after %s, want %s until midnight UTC", e.RetryAfter, want)
+	}
+
+	u := l.Usage("acme")
+	if u.DailyLogs != 100 || u.DailyBytes != 200 {
+		t.Errorf("daily usage = %d logs, %d bytes; want 100, 200", u.DailyLogs, u.DailyBytes)
+	}
+	if want := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC); !u.QuotaResetsAt.Equal(want) {
+		t.Errorf("quota resets at %s, want %s", u.QuotaResetsAt, want)
+	}
+
+	// The counts start over at midnight UTC.
+	c.advance(12 * time.Hour)
+	if err := l.Ingest("acme", 100, 100); err != nil {
+		t.Fatalf("Ingest on the next day: %v", err)
+	}
+	exceeded(t, l.Ingest("acme", 0, 1<<20), "daily byte quota")
+}
+
+// Subjects listed on their own get their limits; the others share the
+// defaults, each with counts of its own.
+func TestSubjectLimits(t *testing.T) {
+	l, _ := newTestLimiter(Limits{DailyLogs: 10}, map[string]Limits{"acme": {DailyLogs: 100}, "free": {}})
+
+	if err := l.Ingest("acme", 50, 0); err != nil {
+		t.Fatalf("Ingest acme: %v", err)
+	}
+	exceeded(t, l.Ingest("globex", 50, 0), "daily log quota")
+	if err := l.Ingest("initech", 10, 0); err != nil {
+		t.Fatalf("Ingest initech: %v", err)
+	}
+	if err := l.Ingest("free", 1000, 1<<30); err != nil {
+		t.Fatalf("Ingest unlimited subject: %v", err)
+	}
+
+	usages := l.Usages()
+	if len(usages) != 4 {
+		t.Fatalf("tracked %d subjects, want 4", len(usages))
+	}
+	if usages[0].Subject != "acme" || usages[0].Limits.DailyLogs != 100 || usages[0].DailyLogs != 50 {
+		t.Errorf("acme usage = %+v, want 50 of 100 logs", usages[0])
+	}
+}
+
+func TestConcurrentQueries(t *testing.T) {
+	l, _ := newTestLimiter(Limits{MaxQueries: 2}, nil)
+
+	first, err := l.Query("acme")
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if _, err := l.Query("acme"); err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	e := exceeded(t, errOf(l.Query("acme")), "concurrent query limit")
+	if e.RetryAfter != queryRetry {
+		t.Errorf("retry after %s, want %s", e.RetryAfter, queryRetry)
+	}
+
+	// Ending a read twice frees one slot only.
+	first()
+	first()
+	if u := l.Usage("acme"); u.ActiveQueries != 1 {
+		t.Errorf("active queries = %d, want 1", u.ActiveQueries)
+	}
+	if _, err := l.Query("acme"); err != nil {
+		t.Fatalf("Query after one ended: %v", err)
+	}
+	exceeded(t, errOf(l.Query("acme")), "concurrent query limit")
+}
+
+func errOf(_ func(), err error) error {
+	return err
+}
+
+// Idle subjects are forgotten once they would start over as they are, but
+// not while they still hold part of today's quota.
+func TestForgetIdleSubjects(t *testing.T) {
+	l, c := newTestLimiter(Limits{LogsPerSecond: 10, DailyLogs: 100}, map[string]Limits{"rate": {LogsPerSecond: 10}})
+
+	if err := l.Ingest("acme", 90, 0); err != nil {
+		t.Fatalf("Ingest: %v", err)
+	}
+	if err := l.Ingest("rate", 10, 0); err != nil {
+		t.Fatalf("Ingest: %v", err)
+	}
+
+	c.advance(idleAfter - time.Second)
+	if usages := l.Usages(); len(usages) != 2 {
+		t.Fatalf("tracked %d subjects before they were idle, want 2", len(usages))
+	}
+
+	c.advance(sweepInterval)
+	usages := l.Usages()
+	if len(usages) != 1 || usages[0].Subject != "acme" {
+		t.Fatalf("tracked %+v, want acme only", usages)
+	}
+	exceeded(t, l.Ingest("acme", 20, 0), "daily log quota")
+
+	// Usage of a forgotten subject does not track it again.
+	if u := l.Usage("rate"); u.LogsAvailable != 10 {
+		t.Errorf("forgotten subject has %v logs available, want 10", u.LogsAvailable)
+	}
+	if usages := l.Usages(); len(usages) != 1 {
+		t.Errorf("Usage tracked a forgotten subject")
+	}
+}
+
+func TestSubject(t *testing.T) {
+	key := apikey.Key{ID: ulid.Make(), Tenant: "acme"}
+	withKey := apikey.ContextWithKey(mlog.ContextWithTenant(context.Background(), "acme"), key)
+
+	tests := []struct {
+		name string
+		by   By
+		ctx  context.Context
+		want string
+	}{
+		{name: "tenant", by: ByTenant, ctx: mlog.ContextWithTenant(context.Background(), "acme"), want: "acme"},
+		{name: "tenant with key", by: ByTenant, ctx: withKey, want: "acme"},
+		{name: "client with key", by: ByClient, ctx: withKey, want: "key:" + key.ID.String()},
+		{name: "client without key", by: ByClient, ctx: mlog.ContextWithTenant(context.Background(), "acme"), want: "acme"},
+		{name: "no tenant", by: ByTenant, ctx: context.Background(), want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLimiter(tt.by, Limits{}, nil, WithAuthentication())
+			if got := l.Subject(tt.ctx); got != tt.want {
+				t.Errorf("Subject = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitsValidate(t *testing.T) {
+	if err := (Limits{DailyLogs: -1}).Validate(); err == nil {
+		t.Errorf("Validate negative quota succeeded, want an error")
+	}
+	if err := (Limits{LogsPerSecond: 10, MaxQueries: 2}).Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package quotaapp
+
+import (
+	"github.com/felipecooper/log-horizon/app/sdk/proto/mlog"
+)
+
+func ToProtoUsage(u Usage) *mlog.UsageReport {
+	return &mlog.UsageReport{
+		Subject:         u.Subject,
+		LogsPerSecond:   u.Limits.LogsPerSecond,
+		BytesPerSecond:  u.Limits.BytesPerSecond,
+		LogsAvailable:   u.LogsAvailable,
+		BytesAvailable:  u.BytesAvailable,
+		DailyLogs:       u.DailyLogs,
+		DailyBytes:      u.DailyBytes,
+		DailyLogsQuota:  u.Limits.DailyLogs,
+		DailyBytesQuota: u.Limits.DailyBytes,
+		QuotaResetsAt:   u.QuotaResetsAt.Unix(),
+		ActiveQueries:   int32(u.ActiveQueries),
+		MaxQueries:      int32(u.Limits.MaxQueries),
+		Rejected:        u.Rejected,
+	}
+}
+
+func ToProtoUsages(usages []Usage) *mlog.UsageReports {
+	resp := mlog.UsageReports{
+		Reports: make([]*mlog.UsageReport, len(usages)),
+	}
+	for i, u := range usages {
+		resp.Reports[i] = ToProtoUsage(u)
+	}
+	return &resp
+}
@@ -0,0 +1,47 @@
+package quotaapp
+
+import (
+	"context"
+
+	"github.com/felipecooper/log-horizon/app/sdk/proto/mlog"
+	mlogbus "github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/foundation/logger"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type App struct {
+	log     logger.Logger
+	limiter *Limiter
+	mlog.UnimplementedQuotaServer
+}
+
+func NewApp(log logger.Logger, limiter *Limiter) *App {
+	return &App{
+		log:     log,
+		limiter: limiter,
+	}
+}
+
+// Usage reports the usage of the caller, of the named subject or of every
+// subject. Callers bound to a tenant only see their own.
+func (a *App) Usage(ctx context.Context, req *mlog.UsageRequest) (*mlog.UsageReports, error) {
+	own := a.limiter.Subject(ctx)
+	bound := mlogbus.TenantFrom(ctx) != ""
+
+	switch {
+	case req.All:
+		if bound {
+			return nil, status.Error(codes.PermissionDenied, "callers bound to a tenant only see their own usage")
+		}
+		return ToProtoUsages(a.limiter.Usages()), nil
+
+	case req.Subject != "" && req.Subject != own:
+		if bound {
+			return nil, status.Error(codes.PermissionDenied, "callers bound to a tenant only see their own usage")
+		}
+		return ToProtoUsages([]Usage{a.limiter.Usage(req.Subject)}), nil
+	}
+
+	return ToProtoUsages([]Usage{a.limiter.Usage(own)}), nil
+}
@@ -0,0 +1,49 @@
+// Package actor names who made a gRPC call, for audit fields such as
+// CreatedBy.
+package actor
+
+import (
+	"context"
+
+	"github.com/felipecooper/log-horizon/business/domain/apikey"
+	"google.golang.org/grpc/metadata"
+)
+
+// Header is the metadata key clients name the actor with when the call is
+// not authenticated.
+const Header = "x-actor"
+
+// headerPrefix marks an actor only named by the caller, so records never
+// pass it off as one that was verified.
+const headerPrefix = "header:"
+
+type ctxKey struct{}
+
+// ContextWithActor returns a copy of ctx naming the actor of the call, for
+// callers identified by other means than an API key.
+func ContextWithActor(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, name)
+}
+
+// FromContext returns the actor of the call: "key:<name>" for calls
+// authenticated with an API key, the name set by ContextWithActor, or
+// "header:<name>" with the unverified x-actor header otherwise.
+func FromContext(ctx context.Context) string {
+	if key, ok := apikey.KeyFrom(ctx); ok {
+		return "key:" + key.Name
+	}
+
+	if name, ok := ctx.Value(ctxKey{}).(string); ok {
+		return name
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	if values := md.Get(Header); len(values) > 0 && values[0] != "" {
+		return headerPrefix + values[0]
+	}
+	return ""
+}
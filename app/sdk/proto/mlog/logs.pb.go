@@ -23,13 +23,14 @@ const (
 
 // Mensagem para registrar um novo log
 type NewLog struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
-	Level         string                 `protobuf:"bytes,2,opt,name=level,proto3" json:"level,omitempty"`
-	Timestamp     int64                  `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	Metadata      map[string]string      `protobuf:"bytes,4,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Message        string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Level          string                 `protobuf:"bytes,2,opt,name=level,proto3" json:"level,omitempty"`
+	Timestamp      int64                  `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Metadata       map[string]string      `protobuf:"bytes,4,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	IdempotencyKey string                 `protobuf:"bytes,5,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"` // Repetições da chave dentro da janela retornam o ID do log original
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *NewLog) Reset() {
@@ -90,6 +91,13 @@ func (x *NewLog) GetMetadata() map[string]string {
 	return nil
 }
 
+func (x *NewLog) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
 // Resposta ao registrar um log
 type LogResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -151,6 +159,7 @@ type Log struct {
 	Level         string                 `protobuf:"bytes,3,opt,name=level,proto3" json:"level,omitempty"`
 	Timestamp     int64                  `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
 	Metadata      map[string]string      `protobuf:"bytes,5,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Tenant        string                 `protobuf:"bytes,6,opt,name=tenant,proto3" json:"tenant,omitempty"` // Tenant dono do log, vazio se nenhum
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -220,6 +229,13 @@ func (x *Log) GetMetadata() map[string]string {
 	return nil
 }
 
+func (x *Log) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
 // Coleção de logs
 type Logs struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -290,6 +306,7 @@ type SearchQuery struct {
 	PageSize      int32                  `protobuf:"varint,4,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
 	Page          int32                  `protobuf:"varint,5,opt,name=page,proto3" json:"page,omitempty"`
 	AsFile        bool                   `protobuf:"varint,6,opt,name=as_file,json=asFile,proto3" json:"as_file,omitempty"` // Se true, retorna como arquivo ao invés de stream
+	Message       string                 `protobuf:"bytes,7,opt,name=message,proto3" json:"message,omitempty"`              // Palavras inteiras que devem aparecer na mensagem, sem distinguir maiúsculas nem acentos
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -366,6 +383,13 @@ func (x *SearchQuery) GetAsFile() bool {
 	return false
 }
 
+func (x *SearchQuery) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
 // Resposta quando os logs são retornados como arquivo
 type FileResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -427,47 +451,3370 @@ func (x *FileResponse) GetCompression() string {
 	return ""
 }
 
-var File_app_sdk_proto_mlog_logs_proto protoreflect.FileDescriptor
+// Consulta de estatísticas de armazenamento
+type StatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Refresh       bool                   `protobuf:"varint,1,opt,name=refresh,proto3" json:"refresh,omitempty"` // Se true, ignora o cache e recalcula as estatísticas
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-const file_app_sdk_proto_mlog_logs_proto_rawDesc = "" +
-	"\n" +
-	"\x1dapp/sdk/proto/mlog/logs.proto\x12\x04logs\"\xcb\x01\n" +
-	"\x06NewLog\x12\x18\n" +
-	"\amessage\x18\x01 \x01(\tR\amessage\x12\x14\n" +
-	"\x05level\x18\x02 \x01(\tR\x05level\x12\x1c\n" +
-	"\ttimestamp\x18\x03 \x01(\x03R\ttimestamp\x126\n" +
-	"\bmetadata\x18\x04 \x03(\v2\x1a.logs.NewLog.MetadataEntryR\bmetadata\x1a;\n" +
-	"\rMetadataEntry\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"5\n" +
-	"\vLogResponse\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
-	"\x06status\x18\x02 \x01(\tR\x06status\"\xd5\x01\n" +
-	"\x03Log\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\x12\x14\n" +
-	"\x05level\x18\x03 \x01(\tR\x05level\x12\x1c\n" +
-	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\x123\n" +
-	"\bmetadata\x18\x05 \x03(\v2\x17.logs.Log.MetadataEntryR\bmetadata\x1a;\n" +
-	"\rMetadataEntry\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"V\n" +
-	"\x04Logs\x12\x1d\n" +
-	"\x04logs\x18\x01 \x03(\v2\t.logs.LogR\x04logs\x12\x14\n" +
-	"\x05total\x18\x02 \x01(\x05R\x05total\x12\x19\n" +
-	"\bhas_more\x18\x03 \x01(\bR\ahasMore\"\xa7\x01\n" +
-	"\vSearchQuery\x12\x1d\n" +
-	"\n" +
-	"start_time\x18\x01 \x01(\x03R\tstartTime\x12\x19\n" +
-	"\bend_time\x18\x02 \x01(\x03R\aendTime\x12\x14\n" +
-	"\x05level\x18\x03 \x01(\tR\x05level\x12\x1b\n" +
-	"\tpage_size\x18\x04 \x01(\x05R\bpageSize\x12\x12\n" +
-	"\x04page\x18\x05 \x01(\x05R\x04page\x12\x17\n" +
-	"\aas_file\x18\x06 \x01(\bR\x06asFile\"h\n" +
-	"\fFileResponse\x12\x19\n" +
-	"\bfile_url\x18\x01 \x01(\tR\afileUrl\x12\x1b\n" +
-	"\tfile_size\x18\x02 \x01(\x03R\bfileSize\x12 \n" +
-	"\vcompression\x18\x03 \x01(\tR\vcompression28\n" +
+func (x *StatsRequest) Reset() {
+	*x = StatsRequest{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsRequest) ProtoMessage() {}
+
+func (x *StatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *StatsRequest) GetRefresh() bool {
+	if x != nil {
+		return x.Refresh
+	}
+	return false
+}
+
+// Quantidade de logs por nível
+type LevelStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Level         string                 `protobuf:"bytes,1,opt,name=level,proto3" json:"level,omitempty"`
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LevelStats) Reset() {
+	*x = LevelStats{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LevelStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LevelStats) ProtoMessage() {}
+
+func (x *LevelStats) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LevelStats.ProtoReflect.Descriptor instead.
+func (*LevelStats) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *LevelStats) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+func (x *LevelStats) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+// Quantidade de logs por dia
+type DayStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Day           string                 `protobuf:"bytes,1,opt,name=day,proto3" json:"day,omitempty"` // Dia no formato AAAA-MM-DD (UTC)
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DayStats) Reset() {
+	*x = DayStats{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DayStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DayStats) ProtoMessage() {}
+
+func (x *DayStats) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DayStats.ProtoReflect.Descriptor instead.
+func (*DayStats) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DayStats) GetDay() string {
+	if x != nil {
+		return x.Day
+	}
+	return ""
+}
+
+func (x *DayStats) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+// Estatísticas de compressão por algoritmo
+type AlgorithmStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Algorithm     string                 `protobuf:"bytes,1,opt,name=algorithm,proto3" json:"algorithm,omitempty"`
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	RawBytes      int64                  `protobuf:"varint,3,opt,name=raw_bytes,json=rawBytes,proto3" json:"raw_bytes,omitempty"`
+	StoredBytes   int64                  `protobuf:"varint,4,opt,name=stored_bytes,json=storedBytes,proto3" json:"stored_bytes,omitempty"`
+	Ratio         float64                `protobuf:"fixed64,5,opt,name=ratio,proto3" json:"ratio,omitempty"` // raw_bytes / stored_bytes
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AlgorithmStats) Reset() {
+	*x = AlgorithmStats{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AlgorithmStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AlgorithmStats) ProtoMessage() {}
+
+func (x *AlgorithmStats) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AlgorithmStats.ProtoReflect.Descriptor instead.
+func (*AlgorithmStats) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *AlgorithmStats) GetAlgorithm() string {
+	if x != nil {
+		return x.Algorithm
+	}
+	return ""
+}
+
+func (x *AlgorithmStats) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *AlgorithmStats) GetRawBytes() int64 {
+	if x != nil {
+		return x.RawBytes
+	}
+	return 0
+}
+
+func (x *AlgorithmStats) GetStoredBytes() int64 {
+	if x != nil {
+		return x.StoredBytes
+	}
+	return 0
+}
+
+func (x *AlgorithmStats) GetRatio() float64 {
+	if x != nil {
+		return x.Ratio
+	}
+	return 0
+}
+
+// Uso de armazenamento de uma coleção
+type CollectionStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Size          int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	StorageSize   int64                  `protobuf:"varint,4,opt,name=storage_size,json=storageSize,proto3" json:"storage_size,omitempty"`
+	IndexSize     int64                  `protobuf:"varint,5,opt,name=index_size,json=indexSize,proto3" json:"index_size,omitempty"`
+	IndexSizes    map[string]int64       `protobuf:"bytes,6,rep,name=index_sizes,json=indexSizes,proto3" json:"index_sizes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CollectionStats) Reset() {
+	*x = CollectionStats{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CollectionStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CollectionStats) ProtoMessage() {}
+
+func (x *CollectionStats) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CollectionStats.ProtoReflect.Descriptor instead.
+func (*CollectionStats) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *CollectionStats) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CollectionStats) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *CollectionStats) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *CollectionStats) GetStorageSize() int64 {
+	if x != nil {
+		return x.StorageSize
+	}
+	return 0
+}
+
+func (x *CollectionStats) GetIndexSize() int64 {
+	if x != nil {
+		return x.IndexSize
+	}
+	return 0
+}
+
+func (x *CollectionStats) GetIndexSizes() map[string]int64 {
+	if x != nil {
+		return x.IndexSizes
+	}
+	return nil
+}
+
+// Estatísticas de armazenamento e compressão
+type StatsResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Total            int64                  `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	Compressed       int64                  `protobuf:"varint,2,opt,name=compressed,proto3" json:"compressed,omitempty"`
+	Uncompressed     int64                  `protobuf:"varint,3,opt,name=uncompressed,proto3" json:"uncompressed,omitempty"`
+	RawBytes         int64                  `protobuf:"varint,4,opt,name=raw_bytes,json=rawBytes,proto3" json:"raw_bytes,omitempty"`
+	StoredBytes      int64                  `protobuf:"varint,5,opt,name=stored_bytes,json=storedBytes,proto3" json:"stored_bytes,omitempty"`
+	CompressionRatio float64                `protobuf:"fixed64,6,opt,name=compression_ratio,json=compressionRatio,proto3" json:"compression_ratio,omitempty"`
+	Levels           []*LevelStats          `protobuf:"bytes,7,rep,name=levels,proto3" json:"levels,omitempty"`
+	Days             []*DayStats            `protobuf:"bytes,8,rep,name=days,proto3" json:"days,omitempty"`
+	Algorithms       []*AlgorithmStats      `protobuf:"bytes,9,rep,name=algorithms,proto3" json:"algorithms,omitempty"`
+	Collections      []*CollectionStats     `protobuf:"bytes,10,rep,name=collections,proto3" json:"collections,omitempty"`
+	ComputedAt       int64                  `protobuf:"varint,11,opt,name=computed_at,json=computedAt,proto3" json:"computed_at,omitempty"` // Momento em que as estatísticas foram calculadas
+	Backlog          *Backlog               `protobuf:"bytes,12,opt,name=backlog,proto3" json:"backlog,omitempty"`                          // Lido a cada chamada, mesmo com cache
+	Tenants          []*TenantStats         `protobuf:"bytes,13,rep,name=tenants,proto3" json:"tenants,omitempty"`                          // Só o tenant da chamada, quando há um
+	Redactions       []*RedactionStats      `protobuf:"bytes,14,rep,name=redactions,proto3" json:"redactions,omitempty"`                    // Lido a cada chamada, mesmo com cache
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *StatsResponse) Reset() {
+	*x = StatsResponse{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsResponse) ProtoMessage() {}
+
+func (x *StatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsResponse.ProtoReflect.Descriptor instead.
+func (*StatsResponse) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *StatsResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetCompressed() int64 {
+	if x != nil {
+		return x.Compressed
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetUncompressed() int64 {
+	if x != nil {
+		return x.Uncompressed
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetRawBytes() int64 {
+	if x != nil {
+		return x.RawBytes
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetStoredBytes() int64 {
+	if x != nil {
+		return x.StoredBytes
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetCompressionRatio() float64 {
+	if x != nil {
+		return x.CompressionRatio
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetLevels() []*LevelStats {
+	if x != nil {
+		return x.Levels
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetDays() []*DayStats {
+	if x != nil {
+		return x.Days
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetAlgorithms() []*AlgorithmStats {
+	if x != nil {
+		return x.Algorithms
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetCollections() []*CollectionStats {
+	if x != nil {
+		return x.Collections
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetComputedAt() int64 {
+	if x != nil {
+		return x.ComputedAt
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetBacklog() *Backlog {
+	if x != nil {
+		return x.Backlog
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetTenants() []*TenantStats {
+	if x != nil {
+		return x.Tenants
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetRedactions() []*RedactionStats {
+	if x != nil {
+		return x.Redactions
+	}
+	return nil
+}
+
+// Quantidade de valores removidos por regra de redação desde o início do servidor
+type RedactionStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rule          string                 `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RedactionStats) Reset() {
+	*x = RedactionStats{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RedactionStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RedactionStats) ProtoMessage() {}
+
+func (x *RedactionStats) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RedactionStats.ProtoReflect.Descriptor instead.
+func (*RedactionStats) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RedactionStats) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *RedactionStats) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+// Quantidade de logs por tenant; tenant vazio conta os logs sem tenant
+type TenantStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tenant        string                 `protobuf:"bytes,1,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TenantStats) Reset() {
+	*x = TenantStats{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TenantStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TenantStats) ProtoMessage() {}
+
+func (x *TenantStats) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TenantStats.ProtoReflect.Descriptor instead.
+func (*TenantStats) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *TenantStats) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *TenantStats) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+// Logs aceitos pelo Register que ainda não chegaram ao armazenamento
+type Backlog struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Queued        int64                  `protobuf:"varint,1,opt,name=queued,proto3" json:"queued,omitempty"`   // Logs na fila do buffer de ingestão
+	Spooled       int64                  `protobuf:"varint,2,opt,name=spooled,proto3" json:"spooled,omitempty"` // Logs no spool em disco aguardando o armazenamento
+	SpoolBytes    int64                  `protobuf:"varint,3,opt,name=spool_bytes,json=spoolBytes,proto3" json:"spool_bytes,omitempty"`
+	SpoolSegments int32                  `protobuf:"varint,4,opt,name=spool_segments,json=spoolSegments,proto3" json:"spool_segments,omitempty"`
+	OldestSpooled int64                  `protobuf:"varint,5,opt,name=oldest_spooled,json=oldestSpooled,proto3" json:"oldest_spooled,omitempty"` // Timestamp do próximo log a reenviar
+	Replayed      int64                  `protobuf:"varint,6,opt,name=replayed,proto3" json:"replayed,omitempty"`                                // Logs reenviados do spool desde o início do servidor
+	Spooling      bool                   `protobuf:"varint,7,opt,name=spooling,proto3" json:"spooling,omitempty"`                                // Verdadeiro enquanto o armazenamento falha ou o spool é reenviado
+	DeadLettered  int64                  `protobuf:"varint,8,opt,name=dead_lettered,json=deadLettered,proto3" json:"dead_lettered,omitempty"`    // Logs recusados pelo armazenamento, movidos para o arquivo dead-letter
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Backlog) Reset() {
+	*x = Backlog{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Backlog) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Backlog) ProtoMessage() {}
+
+func (x *Backlog) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Backlog.ProtoReflect.Descriptor instead.
+func (*Backlog) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Backlog) GetQueued() int64 {
+	if x != nil {
+		return x.Queued
+	}
+	return 0
+}
+
+func (x *Backlog) GetSpooled() int64 {
+	if x != nil {
+		return x.Spooled
+	}
+	return 0
+}
+
+func (x *Backlog) GetSpoolBytes() int64 {
+	if x != nil {
+		return x.SpoolBytes
+	}
+	return 0
+}
+
+func (x *Backlog) GetSpoolSegments() int32 {
+	if x != nil {
+		return x.SpoolSegments
+	}
+	return 0
+}
+
+func (x *Backlog) GetOldestSpooled() int64 {
+	if x != nil {
+		return x.OldestSpooled
+	}
+	return 0
+}
+
+func (x *Backlog) GetReplayed() int64 {
+	if x != nil {
+		return x.Replayed
+	}
+	return 0
+}
+
+func (x *Backlog) GetSpooling() bool {
+	if x != nil {
+		return x.Spooling
+	}
+	return false
+}
+
+func (x *Backlog) GetDeadLettered() int64 {
+	if x != nil {
+		return x.DeadLettered
+	}
+	return 0
+}
+
+// Volume removido por uma política desde o início do servidor
+type RetentionMetrics struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Runs          int64                  `protobuf:"varint,1,opt,name=runs,proto3" json:"runs,omitempty"`
+	Deleted       int64                  `protobuf:"varint,2,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	DeletedBytes  int64                  `protobuf:"varint,3,opt,name=deleted_bytes,json=deletedBytes,proto3" json:"deleted_bytes,omitempty"`
+	LastRun       int64                  `protobuf:"varint,4,opt,name=last_run,json=lastRun,proto3" json:"last_run,omitempty"`
+	Estimated     bool                   `protobuf:"varint,5,opt,name=estimated,proto3" json:"estimated,omitempty"` // Se true, deleted e deleted_bytes incluem estimativas de execuções por índice TTL
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetentionMetrics) Reset() {
+	*x = RetentionMetrics{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetentionMetrics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetentionMetrics) ProtoMessage() {}
+
+func (x *RetentionMetrics) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetentionMetrics.ProtoReflect.Descriptor instead.
+func (*RetentionMetrics) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *RetentionMetrics) GetRuns() int64 {
+	if x != nil {
+		return x.Runs
+	}
+	return 0
+}
+
+func (x *RetentionMetrics) GetDeleted() int64 {
+	if x != nil {
+		return x.Deleted
+	}
+	return 0
+}
+
+func (x *RetentionMetrics) GetDeletedBytes() int64 {
+	if x != nil {
+		return x.DeletedBytes
+	}
+	return 0
+}
+
+func (x *RetentionMetrics) GetLastRun() int64 {
+	if x != nil {
+		return x.LastRun
+	}
+	return 0
+}
+
+func (x *RetentionMetrics) GetEstimated() bool {
+	if x != nil {
+		return x.Estimated
+	}
+	return false
+}
+
+// Política de retenção de logs
+type RetentionPolicy struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Level         string                 `protobuf:"bytes,2,opt,name=level,proto3" json:"level,omitempty"`                                                                                 // Vazio aplica a todos os níveis
+	Metadata      map[string]string      `protobuf:"bytes,3,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Todos os pares precisam coincidir
+	MaxAgeSeconds int64                  `protobuf:"varint,4,opt,name=max_age_seconds,json=maxAgeSeconds,proto3" json:"max_age_seconds,omitempty"`
+	UpdatedAt     int64                  `protobuf:"varint,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Metrics       *RetentionMetrics      `protobuf:"bytes,6,opt,name=metrics,proto3" json:"metrics,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetentionPolicy) Reset() {
+	*x = RetentionPolicy{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetentionPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetentionPolicy) ProtoMessage() {}
+
+func (x *RetentionPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetentionPolicy.ProtoReflect.Descriptor instead.
+func (*RetentionPolicy) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *RetentionPolicy) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RetentionPolicy) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+func (x *RetentionPolicy) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *RetentionPolicy) GetMaxAgeSeconds() int64 {
+	if x != nil {
+		return x.MaxAgeSeconds
+	}
+	return 0
+}
+
+func (x *RetentionPolicy) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+func (x *RetentionPolicy) GetMetrics() *RetentionMetrics {
+	if x != nil {
+		return x.Metrics
+	}
+	return nil
+}
+
+// Consulta das políticas de retenção
+type ListRetentionPoliciesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRetentionPoliciesRequest) Reset() {
+	*x = ListRetentionPoliciesRequest{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRetentionPoliciesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRetentionPoliciesRequest) ProtoMessage() {}
+
+func (x *ListRetentionPoliciesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRetentionPoliciesRequest.ProtoReflect.Descriptor instead.
+func (*ListRetentionPoliciesRequest) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{17}
+}
+
+// Coleção de políticas de retenção
+type RetentionPolicies struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Policies      []*RetentionPolicy     `protobuf:"bytes,1,rep,name=policies,proto3" json:"policies,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetentionPolicies) Reset() {
+	*x = RetentionPolicies{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetentionPolicies) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetentionPolicies) ProtoMessage() {}
+
+func (x *RetentionPolicies) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetentionPolicies.ProtoReflect.Descriptor instead.
+func (*RetentionPolicies) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *RetentionPolicies) GetPolicies() []*RetentionPolicy {
+	if x != nil {
+		return x.Policies
+	}
+	return nil
+}
+
+// Remoção de uma política de retenção
+type DeleteRetentionPolicyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRetentionPolicyRequest) Reset() {
+	*x = DeleteRetentionPolicyRequest{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRetentionPolicyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRetentionPolicyRequest) ProtoMessage() {}
+
+func (x *DeleteRetentionPolicyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRetentionPolicyRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRetentionPolicyRequest) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *DeleteRetentionPolicyRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// Resposta à remoção de uma política de retenção
+type DeleteRetentionPolicyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRetentionPolicyResponse) Reset() {
+	*x = DeleteRetentionPolicyResponse{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRetentionPolicyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRetentionPolicyResponse) ProtoMessage() {}
+
+func (x *DeleteRetentionPolicyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRetentionPolicyResponse.ProtoReflect.Descriptor instead.
+func (*DeleteRetentionPolicyResponse) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *DeleteRetentionPolicyResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+// Execução manual das políticas de retenção
+type EnforceRetentionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DryRun        bool                   `protobuf:"varint,1,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"` // Se true, apenas conta o que seria removido
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnforceRetentionRequest) Reset() {
+	*x = EnforceRetentionRequest{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnforceRetentionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnforceRetentionRequest) ProtoMessage() {}
+
+func (x *EnforceRetentionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnforceRetentionRequest.ProtoReflect.Descriptor instead.
+func (*EnforceRetentionRequest) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *EnforceRetentionRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+// Resultado da aplicação de uma política
+type RetentionResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Policy        string                 `protobuf:"bytes,1,opt,name=policy,proto3" json:"policy,omitempty"`
+	Deleted       int64                  `protobuf:"varint,2,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	DeletedBytes  int64                  `protobuf:"varint,3,opt,name=deleted_bytes,json=deletedBytes,proto3" json:"deleted_bytes,omitempty"`
+	DryRun        bool                   `protobuf:"varint,4,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	Ttl           bool                   `protobuf:"varint,5,opt,name=ttl,proto3" json:"ttl,omitempty"`             // Se true, a remoção é feita por um índice TTL
+	Estimated     bool                   `protobuf:"varint,6,opt,name=estimated,proto3" json:"estimated,omitempty"` // Se true, deleted e deleted_bytes são estimativas e não contagens exatas
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetentionResult) Reset() {
+	*x = RetentionResult{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetentionResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetentionResult) ProtoMessage() {}
+
+func (x *RetentionResult) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetentionResult.ProtoReflect.Descriptor instead.
+func (*RetentionResult) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *RetentionResult) GetPolicy() string {
+	if x != nil {
+		return x.Policy
+	}
+	return ""
+}
+
+func (x *RetentionResult) GetDeleted() int64 {
+	if x != nil {
+		return x.Deleted
+	}
+	return 0
+}
+
+func (x *RetentionResult) GetDeletedBytes() int64 {
+	if x != nil {
+		return x.DeletedBytes
+	}
+	return 0
+}
+
+func (x *RetentionResult) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *RetentionResult) GetTtl() bool {
+	if x != nil {
+		return x.Ttl
+	}
+	return false
+}
+
+func (x *RetentionResult) GetEstimated() bool {
+	if x != nil {
+		return x.Estimated
+	}
+	return false
+}
+
+// Resposta à execução das políticas de retenção
+type EnforceRetentionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*RetentionResult     `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnforceRetentionResponse) Reset() {
+	*x = EnforceRetentionResponse{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnforceRetentionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnforceRetentionResponse) ProtoMessage() {}
+
+func (x *EnforceRetentionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnforceRetentionResponse.ProtoReflect.Descriptor instead.
+func (*EnforceRetentionResponse) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *EnforceRetentionResponse) GetResults() []*RetentionResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+// Rotação da chave de dados usada para criptografar os logs
+type RotateEncryptionKeyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RotateEncryptionKeyRequest) Reset() {
+	*x = RotateEncryptionKeyRequest{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateEncryptionKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateEncryptionKeyRequest) ProtoMessage() {}
+
+func (x *RotateEncryptionKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateEncryptionKeyRequest.ProtoReflect.Descriptor instead.
+func (*RotateEncryptionKeyRequest) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{24}
+}
+
+// Chave de dados que passa a criptografar os novos logs
+type RotateEncryptionKeyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	KeyId         string                 `protobuf:"bytes,1,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RotateEncryptionKeyResponse) Reset() {
+	*x = RotateEncryptionKeyResponse{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateEncryptionKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateEncryptionKeyResponse) ProtoMessage() {}
+
+func (x *RotateEncryptionKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateEncryptionKeyResponse.ProtoReflect.Descriptor instead.
+func (*RotateEncryptionKeyResponse) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *RotateEncryptionKeyResponse) GetKeyId() string {
+	if x != nil {
+		return x.KeyId
+	}
+	return ""
+}
+
+// Critérios para remover ou anonimizar os logs de um titular
+type DeleteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StartTime     int64                  `protobuf:"varint,1,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	EndTime       int64                  `protobuf:"varint,2,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	Level         string                 `protobuf:"bytes,3,opt,name=level,proto3" json:"level,omitempty"`
+	Metadata      map[string]string      `protobuf:"bytes,4,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Todos os pares precisam coincidir
+	Message       string                 `protobuf:"bytes,5,opt,name=message,proto3" json:"message,omitempty"`                                                                             // Trecho procurado dentro da mensagem
+	Mode          string                 `protobuf:"bytes,6,opt,name=mode,proto3" json:"mode,omitempty"`                                                                                   // "delete" (padrão) remove os logs, "redact" substitui os trechos encontrados
+	DryRun        bool                   `protobuf:"varint,7,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`                                                                // Se true, apenas conta os logs encontrados
+	ExpectedCount int64                  `protobuf:"varint,8,opt,name=expected_count,json=expectedCount,proto3" json:"expected_count,omitempty"`                                           // Obrigatório fora do dry run: quantidade retornada pelo dry run
+	Reason        string                 `protobuf:"bytes,9,opt,name=reason,proto3" json:"reason,omitempty"`                                                                               // Motivo registrado na auditoria
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRequest) Reset() {
+	*x = DeleteRequest{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRequest) ProtoMessage() {}
+
+func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *DeleteRequest) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *DeleteRequest) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
+func (x *DeleteRequest) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+func (x *DeleteRequest) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *DeleteRequest) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *DeleteRequest) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *DeleteRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *DeleteRequest) GetExpectedCount() int64 {
+	if x != nil {
+		return x.ExpectedCount
+	}
+	return 0
+}
+
+func (x *DeleteRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// Resultado de uma remoção
+type DeleteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Matched       int64                  `protobuf:"varint,1,opt,name=matched,proto3" json:"matched,omitempty"`
+	Deleted       int64                  `protobuf:"varint,2,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	Redacted      int64                  `protobuf:"varint,3,opt,name=redacted,proto3" json:"redacted,omitempty"`
+	DryRun        bool                   `protobuf:"varint,4,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	AuditId       string                 `protobuf:"bytes,5,opt,name=audit_id,json=auditId,proto3" json:"audit_id,omitempty"` // Identificador do registro de auditoria
+	Held          int64                  `protobuf:"varint,6,opt,name=held,proto3" json:"held,omitempty"`                     // Logs encontrados mas protegidos por retenção legal
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteResponse) Reset() {
+	*x = DeleteResponse{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteResponse) ProtoMessage() {}
+
+func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteResponse.ProtoReflect.Descriptor instead.
+func (*DeleteResponse) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *DeleteResponse) GetMatched() int64 {
+	if x != nil {
+		return x.Matched
+	}
+	return 0
+}
+
+func (x *DeleteResponse) GetDeleted() int64 {
+	if x != nil {
+		return x.Deleted
+	}
+	return 0
+}
+
+func (x *DeleteResponse) GetRedacted() int64 {
+	if x != nil {
+		return x.Redacted
+	}
+	return 0
+}
+
+func (x *DeleteResponse) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *DeleteResponse) GetAuditId() string {
+	if x != nil {
+		return x.AuditId
+	}
+	return ""
+}
+
+func (x *DeleteResponse) GetHeld() int64 {
+	if x != nil {
+		return x.Held
+	}
+	return 0
+}
+
+// Retenção legal: congela os logs selecionados contra remoção
+type LegalHold struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	StartTime     int64                  `protobuf:"varint,4,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	EndTime       int64                  `protobuf:"varint,5,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	Level         string                 `protobuf:"bytes,6,opt,name=level,proto3" json:"level,omitempty"`
+	Metadata      map[string]string      `protobuf:"bytes,7,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Todos os pares precisam coincidir
+	CreatedBy     string                 `protobuf:"bytes,8,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	ReleasedBy    string                 `protobuf:"bytes,10,opt,name=released_by,json=releasedBy,proto3" json:"released_by,omitempty"`
+	ReleasedAt    int64                  `protobuf:"varint,11,opt,name=released_at,json=releasedAt,proto3" json:"released_at,omitempty"` // Zero enquanto a retenção estiver ativa
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LegalHold) Reset() {
+	*x = LegalHold{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LegalHold) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LegalHold) ProtoMessage() {}
+
+func (x *LegalHold) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LegalHold.ProtoReflect.Descriptor instead.
+func (*LegalHold) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *LegalHold) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *LegalHold) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *LegalHold) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *LegalHold) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *LegalHold) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
+func (x *LegalHold) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+func (x *LegalHold) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *LegalHold) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+func (x *LegalHold) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *LegalHold) GetReleasedBy() string {
+	if x != nil {
+		return x.ReleasedBy
+	}
+	return ""
+}
+
+func (x *LegalHold) GetReleasedAt() int64 {
+	if x != nil {
+		return x.ReleasedAt
+	}
+	return 0
+}
+
+// Consulta das retenções legais
+type ListLegalHoldsRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	IncludeReleased bool                   `protobuf:"varint,1,opt,name=include_released,json=includeReleased,proto3" json:"include_released,omitempty"` // Se true, inclui as retenções já liberadas
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ListLegalHoldsRequest) Reset() {
+	*x = ListLegalHoldsRequest{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLegalHoldsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLegalHoldsRequest) ProtoMessage() {}
+
+func (x *ListLegalHoldsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLegalHoldsRequest.ProtoReflect.Descriptor instead.
+func (*ListLegalHoldsRequest) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ListLegalHoldsRequest) GetIncludeReleased() bool {
+	if x != nil {
+		return x.IncludeReleased
+	}
+	return false
+}
+
+// Coleção de retenções legais
+type LegalHolds struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Holds         []*LegalHold           `protobuf:"bytes,1,rep,name=holds,proto3" json:"holds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LegalHolds) Reset() {
+	*x = LegalHolds{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LegalHolds) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LegalHolds) ProtoMessage() {}
+
+func (x *LegalHolds) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LegalHolds.ProtoReflect.Descriptor instead.
+func (*LegalHolds) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *LegalHolds) GetHolds() []*LegalHold {
+	if x != nil {
+		return x.Holds
+	}
+	return nil
+}
+
+// Liberação de uma retenção legal
+type ReleaseLegalHoldRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReleaseLegalHoldRequest) Reset() {
+	*x = ReleaseLegalHoldRequest{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReleaseLegalHoldRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReleaseLegalHoldRequest) ProtoMessage() {}
+
+func (x *ReleaseLegalHoldRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReleaseLegalHoldRequest.ProtoReflect.Descriptor instead.
+func (*ReleaseLegalHoldRequest) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ReleaseLegalHoldRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// Chave de API; o segredo nunca é retornado depois da criação
+type ApiKey struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Id                 string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name               string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Scopes             []string               `protobuf:"bytes,3,rep,name=scopes,proto3" json:"scopes,omitempty"` // "logs:write", "logs:read", "logs:export" ou "admin"
+	Tenant             string                 `protobuf:"bytes,4,opt,name=tenant,proto3" json:"tenant,omitempty"` // Se preenchido, toda chamada com a chave usa este tenant
+	CreatedBy          string                 `protobuf:"bytes,5,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	CreatedAt          int64                  `protobuf:"varint,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	ExpiresAt          int64                  `protobuf:"varint,7,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"` // Zero para chaves que não expiram
+	RotatedAt          int64                  `protobuf:"varint,8,opt,name=rotated_at,json=rotatedAt,proto3" json:"rotated_at,omitempty"`
+	PreviousValidUntil int64                  `protobuf:"varint,9,opt,name=previous_valid_until,json=previousValidUntil,proto3" json:"previous_valid_until,omitempty"` // Até quando o segredo anterior à rotação é aceito
+	RevokedBy          string                 `protobuf:"bytes,10,opt,name=revoked_by,json=revokedBy,proto3" json:"revoked_by,omitempty"`
+	RevokedAt          int64                  `protobuf:"varint,11,opt,name=revoked_at,json=revokedAt,proto3" json:"revoked_at,omitempty"` // Zero enquanto a chave estiver ativa
+	Role               string                 `protobuf:"bytes,12,opt,name=role,proto3" json:"role,omitempty"`                             // Se preenchido, restringe o que a chave pode ler
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *ApiKey) Reset() {
+	*x = ApiKey{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApiKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApiKey) ProtoMessage() {}
+
+func (x *ApiKey) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApiKey.ProtoReflect.Descriptor instead.
+func (*ApiKey) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *ApiKey) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ApiKey) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ApiKey) GetScopes() []string {
+	if x != nil {
+		return x.Scopes
+	}
+	return nil
+}
+
+func (x *ApiKey) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *ApiKey) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+func (x *ApiKey) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *ApiKey) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+func (x *ApiKey) GetRotatedAt() int64 {
+	if x != nil {
+		return x.RotatedAt
+	}
+	return 0
+}
+
+func (x *ApiKey) GetPreviousValidUntil() int64 {
+	if x != nil {
+		return x.PreviousValidUntil
+	}
+	return 0
+}
+
+func (x *ApiKey) GetRevokedBy() string {
+	if x != nil {
+		return x.RevokedBy
+	}
+	return ""
+}
+
+func (x *ApiKey) GetRevokedAt() int64 {
+	if x != nil {
+		return x.RevokedAt
+	}
+	return 0
+}
+
+func (x *ApiKey) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+// Criação de uma chave de API
+type CreateApiKeyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Scopes        []string               `protobuf:"bytes,2,rep,name=scopes,proto3" json:"scopes,omitempty"`
+	Tenant        string                 `protobuf:"bytes,3,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	ExpiresAt     int64                  `protobuf:"varint,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"` // Zero para não expirar
+	Role          string                 `protobuf:"bytes,5,opt,name=role,proto3" json:"role,omitempty"`                             // Papel definido em ROLES_FILE
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateApiKeyRequest) Reset() {
+	*x = CreateApiKeyRequest{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateApiKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateApiKeyRequest) ProtoMessage() {}
+
+func (x *CreateApiKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateApiKeyRequest.ProtoReflect.Descriptor instead.
+func (*CreateApiKeyRequest) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *CreateApiKeyRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateApiKeyRequest) GetScopes() []string {
+	if x != nil {
+		return x.Scopes
+	}
+	return nil
+}
+
+func (x *CreateApiKeyRequest) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *CreateApiKeyRequest) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+func (x *CreateApiKeyRequest) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+// Chave de API com o segredo, exibido uma única vez
+type ApiKeySecret struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           *ApiKey                `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Secret        string                 `protobuf:"bytes,2,opt,name=secret,proto3" json:"secret,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApiKeySecret) Reset() {
+	*x = ApiKeySecret{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApiKeySecret) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApiKeySecret) ProtoMessage() {}
+
+func (x *ApiKeySecret) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApiKeySecret.ProtoReflect.Descriptor instead.
+func (*ApiKeySecret) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ApiKeySecret) GetKey() *ApiKey {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *ApiKeySecret) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+// Rotação do segredo de uma chave de API
+type RotateApiKeyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	GraceSeconds  int64                  `protobuf:"varint,2,opt,name=grace_seconds,json=graceSeconds,proto3" json:"grace_seconds,omitempty"` // Por quanto tempo o segredo anterior continua aceito
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RotateApiKeyRequest) Reset() {
+	*x = RotateApiKeyRequest{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateApiKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateApiKeyRequest) ProtoMessage() {}
+
+func (x *RotateApiKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateApiKeyRequest.ProtoReflect.Descriptor instead.
+func (*RotateApiKeyRequest) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *RotateApiKeyRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *RotateApiKeyRequest) GetGraceSeconds() int64 {
+	if x != nil {
+		return x.GraceSeconds
+	}
+	return 0
+}
+
+// Revogação de uma chave de API
+type RevokeApiKeyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeApiKeyRequest) Reset() {
+	*x = RevokeApiKeyRequest{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeApiKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeApiKeyRequest) ProtoMessage() {}
+
+func (x *RevokeApiKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeApiKeyRequest.ProtoReflect.Descriptor instead.
+func (*RevokeApiKeyRequest) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *RevokeApiKeyRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// Consulta das chaves de API
+type ListApiKeysRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	IncludeRevoked bool                   `protobuf:"varint,1,opt,name=include_revoked,json=includeRevoked,proto3" json:"include_revoked,omitempty"` // Se true, inclui as chaves revogadas
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ListApiKeysRequest) Reset() {
+	*x = ListApiKeysRequest{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListApiKeysRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListApiKeysRequest) ProtoMessage() {}
+
+func (x *ListApiKeysRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListApiKeysRequest.ProtoReflect.Descriptor instead.
+func (*ListApiKeysRequest) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *ListApiKeysRequest) GetIncludeRevoked() bool {
+	if x != nil {
+		return x.IncludeRevoked
+	}
+	return false
+}
+
+// Coleção de chaves de API
+type ApiKeys struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          []*ApiKey              `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApiKeys) Reset() {
+	*x = ApiKeys{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApiKeys) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApiKeys) ProtoMessage() {}
+
+func (x *ApiKeys) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApiKeys.ProtoReflect.Descriptor instead.
+func (*ApiKeys) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *ApiKeys) GetKeys() []*ApiKey {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+// Registro da trilha de auditoria de uma chamada
+type AuditEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Time          int64                  `protobuf:"varint,2,opt,name=time,proto3" json:"time,omitempty"`
+	Actor         string                 `protobuf:"bytes,3,opt,name=actor,proto3" json:"actor,omitempty"`
+	Tenant        string                 `protobuf:"bytes,4,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	Method        string                 `protobuf:"bytes,5,opt,name=method,proto3" json:"method,omitempty"`    // Ex.: "/logs.LogReader/Search"
+	Request       string                 `protobuf:"bytes,6,opt,name=request,proto3" json:"request,omitempty"`  // Requisição da chamada em JSON
+	Results       int64                  `protobuf:"varint,7,opt,name=results,proto3" json:"results,omitempty"` // Logs retornados ou afetados
+	Bytes         int64                  `protobuf:"varint,8,opt,name=bytes,proto3" json:"bytes,omitempty"`     // Tamanho do arquivo exportado
+	DurationMs    int64                  `protobuf:"varint,9,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	Code          string                 `protobuf:"bytes,10,opt,name=code,proto3" json:"code,omitempty"`                         // Código gRPC de retorno, ou "Pending" no registro feito ao iniciar a chamada
+	PrevHash      string                 `protobuf:"bytes,11,opt,name=prev_hash,json=prevHash,proto3" json:"prev_hash,omitempty"` // Hash do registro anterior
+	Hash          string                 `protobuf:"bytes,12,opt,name=hash,proto3" json:"hash,omitempty"`
+	PendingId     string                 `protobuf:"bytes,13,opt,name=pending_id,json=pendingId,proto3" json:"pending_id,omitempty"` // ID do registro feito ao iniciar a chamada, no registro de seu término
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuditEntry) Reset() {
+	*x = AuditEntry{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditEntry) ProtoMessage() {}
+
+func (x *AuditEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditEntry.ProtoReflect.Descriptor instead.
+func (*AuditEntry) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *AuditEntry) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *AuditEntry) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetRequest() string {
+	if x != nil {
+		return x.Request
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetResults() int64 {
+	if x != nil {
+		return x.Results
+	}
+	return 0
+}
+
+func (x *AuditEntry) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *AuditEntry) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *AuditEntry) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetPrevHash() string {
+	if x != nil {
+		return x.PrevHash
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetPendingId() string {
+	if x != nil {
+		return x.PendingId
+	}
+	return ""
+}
+
+// Consulta da trilha de auditoria; campos vazios ou zero não filtram
+type AuditQuery struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StartTime     int64                  `protobuf:"varint,1,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	EndTime       int64                  `protobuf:"varint,2,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	Actor         string                 `protobuf:"bytes,3,opt,name=actor,proto3" json:"actor,omitempty"`
+	Method        string                 `protobuf:"bytes,4,opt,name=method,proto3" json:"method,omitempty"`
+	Page          int32                  `protobuf:"varint,5,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize      int32                  `protobuf:"varint,6,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuditQuery) Reset() {
+	*x = AuditQuery{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditQuery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditQuery) ProtoMessage() {}
+
+func (x *AuditQuery) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditQuery.ProtoReflect.Descriptor instead.
+func (*AuditQuery) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *AuditQuery) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *AuditQuery) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
+func (x *AuditQuery) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *AuditQuery) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *AuditQuery) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *AuditQuery) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+// Página de registros de auditoria, do mais recente ao mais antigo
+type AuditEntries struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*AuditEntry          `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	HasMore       bool                   `protobuf:"varint,2,opt,name=has_more,json=hasMore,proto3" json:"has_more,omitempty"`
+	NextPage      int32                  `protobuf:"varint,3,opt,name=next_page,json=nextPage,proto3" json:"next_page,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuditEntries) Reset() {
+	*x = AuditEntries{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditEntries) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditEntries) ProtoMessage() {}
+
+func (x *AuditEntries) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditEntries.ProtoReflect.Descriptor instead.
+func (*AuditEntries) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *AuditEntries) GetEntries() []*AuditEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *AuditEntries) GetHasMore() bool {
+	if x != nil {
+		return x.HasMore
+	}
+	return false
+}
+
+func (x *AuditEntries) GetNextPage() int32 {
+	if x != nil {
+		return x.NextPage
+	}
+	return 0
+}
+
+// Verificação da trilha de auditoria
+type VerifyAuditRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyAuditRequest) Reset() {
+	*x = VerifyAuditRequest{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyAuditRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyAuditRequest) ProtoMessage() {}
+
+func (x *VerifyAuditRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyAuditRequest.ProtoReflect.Descriptor instead.
+func (*VerifyAuditRequest) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{42}
+}
+
+// Resultado da verificação da cadeia de hashes
+type AuditVerification struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Checked       int64                  `protobuf:"varint,1,opt,name=checked,proto3" json:"checked,omitempty"` // Registros verificados
+	Intact        bool                   `protobuf:"varint,2,opt,name=intact,proto3" json:"intact,omitempty"`
+	BrokenAt      string                 `protobuf:"bytes,3,opt,name=broken_at,json=brokenAt,proto3" json:"broken_at,omitempty"` // Primeiro registro alterado, se houver
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuditVerification) Reset() {
+	*x = AuditVerification{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditVerification) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditVerification) ProtoMessage() {}
+
+func (x *AuditVerification) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditVerification.ProtoReflect.Descriptor instead.
+func (*AuditVerification) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *AuditVerification) GetChecked() int64 {
+	if x != nil {
+		return x.Checked
+	}
+	return 0
+}
+
+func (x *AuditVerification) GetIntact() bool {
+	if x != nil {
+		return x.Intact
+	}
+	return false
+}
+
+func (x *AuditVerification) GetBrokenAt() string {
+	if x != nil {
+		return x.BrokenAt
+	}
+	return ""
+}
+
+func (x *AuditVerification) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// Consulta do consumo dos limites de taxa e cotas
+type UsageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subject       string                 `protobuf:"bytes,1,opt,name=subject,proto3" json:"subject,omitempty"` // Vazio para o próprio chamador
+	All           bool                   `protobuf:"varint,2,opt,name=all,proto3" json:"all,omitempty"`        // Se true, retorna todos os sujeitos vistos desde o início do servidor
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UsageRequest) Reset() {
+	*x = UsageRequest{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UsageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UsageRequest) ProtoMessage() {}
+
+func (x *UsageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UsageRequest.ProtoReflect.Descriptor instead.
+func (*UsageRequest) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *UsageRequest) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *UsageRequest) GetAll() bool {
+	if x != nil {
+		return x.All
+	}
+	return false
+}
+
+// Limites de um sujeito (tenant ou chave de API) e seu consumo
+type UsageReport struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Subject         string                 `protobuf:"bytes,1,opt,name=subject,proto3" json:"subject,omitempty"`
+	LogsPerSecond   int64                  `protobuf:"varint,2,opt,name=logs_per_second,json=logsPerSecond,proto3" json:"logs_per_second,omitempty"`       // Zero para ilimitado
+	BytesPerSecond  int64                  `protobuf:"varint,3,opt,name=bytes_per_second,json=bytesPerSecond,proto3" json:"bytes_per_second,omitempty"`    // Zero para ilimitado
+	LogsAvailable   float64                `protobuf:"fixed64,4,opt,name=logs_available,json=logsAvailable,proto3" json:"logs_available,omitempty"`        // Logs que podem ser enviados agora
+	BytesAvailable  float64                `protobuf:"fixed64,5,opt,name=bytes_available,json=bytesAvailable,proto3" json:"bytes_available,omitempty"`     // Bytes que podem ser enviados agora
+	DailyLogs       int64                  `protobuf:"varint,6,opt,name=daily_logs,json=dailyLogs,proto3" json:"daily_logs,omitempty"`                     // Logs recebidos hoje (UTC)
+	DailyBytes      int64                  `protobuf:"varint,7,opt,name=daily_bytes,json=dailyBytes,proto3" json:"daily_bytes,omitempty"`                  // Bytes recebidos hoje (UTC)
+	DailyLogsQuota  int64                  `protobuf:"varint,8,opt,name=daily_logs_quota,json=dailyLogsQuota,proto3" json:"daily_logs_quota,omitempty"`    // Zero para ilimitado
+	DailyBytesQuota int64                  `protobuf:"varint,9,opt,name=daily_bytes_quota,json=dailyBytesQuota,proto3" json:"daily_bytes_quota,omitempty"` // Zero para ilimitado
+	QuotaResetsAt   int64                  `protobuf:"varint,10,opt,name=quota_resets_at,json=quotaResetsAt,proto3" json:"quota_resets_at,omitempty"`
+	ActiveQueries   int32                  `protobuf:"varint,11,opt,name=active_queries,json=activeQueries,proto3" json:"active_queries,omitempty"`
+	MaxQueries      int32                  `protobuf:"varint,12,opt,name=max_queries,json=maxQueries,proto3" json:"max_queries,omitempty"` // Zero para ilimitado
+	Rejected        int64                  `protobuf:"varint,13,opt,name=rejected,proto3" json:"rejected,omitempty"`                       // Chamadas recusadas desde que o sujeito esteve ocioso pela última vez
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *UsageReport) Reset() {
+	*x = UsageReport{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UsageReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UsageReport) ProtoMessage() {}
+
+func (x *UsageReport) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UsageReport.ProtoReflect.Descriptor instead.
+func (*UsageReport) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *UsageReport) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *UsageReport) GetLogsPerSecond() int64 {
+	if x != nil {
+		return x.LogsPerSecond
+	}
+	return 0
+}
+
+func (x *UsageReport) GetBytesPerSecond() int64 {
+	if x != nil {
+		return x.BytesPerSecond
+	}
+	return 0
+}
+
+func (x *UsageReport) GetLogsAvailable() float64 {
+	if x != nil {
+		return x.LogsAvailable
+	}
+	return 0
+}
+
+func (x *UsageReport) GetBytesAvailable() float64 {
+	if x != nil {
+		return x.BytesAvailable
+	}
+	return 0
+}
+
+func (x *UsageReport) GetDailyLogs() int64 {
+	if x != nil {
+		return x.DailyLogs
+	}
+	return 0
+}
+
+func (x *UsageReport) GetDailyBytes() int64 {
+	if x != nil {
+		return x.DailyBytes
+	}
+	return 0
+}
+
+func (x *UsageReport) GetDailyLogsQuota() int64 {
+	if x != nil {
+		return x.DailyLogsQuota
+	}
+	return 0
+}
+
+func (x *UsageReport) GetDailyBytesQuota() int64 {
+	if x != nil {
+		return x.DailyBytesQuota
+	}
+	return 0
+}
+
+func (x *UsageReport) GetQuotaResetsAt() int64 {
+	if x != nil {
+		return x.QuotaResetsAt
+	}
+	return 0
+}
+
+func (x *UsageReport) GetActiveQueries() int32 {
+	if x != nil {
+		return x.ActiveQueries
+	}
+	return 0
+}
+
+func (x *UsageReport) GetMaxQueries() int32 {
+	if x != nil {
+		return x.MaxQueries
+	}
+	return 0
+}
+
+func (x *UsageReport) GetRejected() int64 {
+	if x != nil {
+		return x.Rejected
+	}
+	return 0
+}
+
+// Coleção de relatórios de consumo
+type UsageReports struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reports       []*UsageReport         `protobuf:"bytes,1,rep,name=reports,proto3" json:"reports,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UsageReports) Reset() {
+	*x = UsageReports{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UsageReports) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UsageReports) ProtoMessage() {}
+
+func (x *UsageReports) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UsageReports.ProtoReflect.Descriptor instead.
+func (*UsageReports) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *UsageReports) GetReports() []*UsageReport {
+	if x != nil {
+		return x.Reports
+	}
+	return nil
+}
+
+// Verificação da cadeia de hashes dos logs armazenados
+type VerifyChainRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tenant        string                 `protobuf:"bytes,1,opt,name=tenant,proto3" json:"tenant,omitempty"`                         // Cadeia verificada; ignorado para chamadores vinculados a um tenant
+	StartTime     int64                  `protobuf:"varint,2,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"` // Zero para desde o início
+	EndTime       int64                  `protobuf:"varint,3,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`       // Zero para até agora
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyChainRequest) Reset() {
+	*x = VerifyChainRequest{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyChainRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyChainRequest) ProtoMessage() {}
+
+func (x *VerifyChainRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyChainRequest.ProtoReflect.Descriptor instead.
+func (*VerifyChainRequest) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *VerifyChainRequest) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *VerifyChainRequest) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *VerifyChainRequest) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
+// Problema encontrado na cadeia
+type ChainIssue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kind          string                 `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"` // Ex.: "missing_link", "modified_log", "unchained_log"
+	Seq           int64                  `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`  // Posição do elo na cadeia
+	LogId         string                 `protobuf:"bytes,3,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	Detail        string                 `protobuf:"bytes,4,opt,name=detail,proto3" json:"detail,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChainIssue) Reset() {
+	*x = ChainIssue{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChainIssue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChainIssue) ProtoMessage() {}
+
+func (x *ChainIssue) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChainIssue.ProtoReflect.Descriptor instead.
+func (*ChainIssue) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *ChainIssue) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *ChainIssue) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *ChainIssue) GetLogId() string {
+	if x != nil {
+		return x.LogId
+	}
+	return ""
+}
+
+func (x *ChainIssue) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+// Resultado da verificação da cadeia de hashes
+type ChainVerification struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tenant        string                 `protobuf:"bytes,1,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	Links         int64                  `protobuf:"varint,2,opt,name=links,proto3" json:"links,omitempty"`             // Elos verificados
+	Logs          int64                  `protobuf:"varint,3,opt,name=logs,proto3" json:"logs,omitempty"`               // Logs verificados
+	Checkpoints   int64                  `protobuf:"varint,4,opt,name=checkpoints,proto3" json:"checkpoints,omitempty"` // Checkpoints assinados verificados
+	Intact        bool                   `protobuf:"varint,5,opt,name=intact,proto3" json:"intact,omitempty"`
+	Issues        []*ChainIssue          `protobuf:"bytes,6,rep,name=issues,proto3" json:"issues,omitempty"`
+	OmittedIssues int64                  `protobuf:"varint,7,opt,name=omitted_issues,json=omittedIssues,proto3" json:"omitted_issues,omitempty"` // Problemas encontrados além dos listados
+	PublicKey     string                 `protobuf:"bytes,8,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`              // Chave pública Ed25519 dos checkpoints, em base64
+	Removed       int64                  `protobuf:"varint,9,opt,name=removed,proto3" json:"removed,omitempty"`                                  // Logs apagados ou expirados pela retenção, segundo as lápides
+	Redacted      int64                  `protobuf:"varint,10,opt,name=redacted,proto3" json:"redacted,omitempty"`                               // Logs redigidos, segundo as lápides
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChainVerification) Reset() {
+	*x = ChainVerification{}
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChainVerification) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChainVerification) ProtoMessage() {}
+
+func (x *ChainVerification) ProtoReflect() protoreflect.Message {
+	mi := &file_app_sdk_proto_mlog_logs_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChainVerification.ProtoReflect.Descriptor instead.
+func (*ChainVerification) Descriptor() ([]byte, []int) {
+	return file_app_sdk_proto_mlog_logs_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *ChainVerification) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *ChainVerification) GetLinks() int64 {
+	if x != nil {
+		return x.Links
+	}
+	return 0
+}
+
+func (x *ChainVerification) GetLogs() int64 {
+	if x != nil {
+		return x.Logs
+	}
+	return 0
+}
+
+func (x *ChainVerification) GetCheckpoints() int64 {
+	if x != nil {
+		return x.Checkpoints
+	}
+	return 0
+}
+
+func (x *ChainVerification) GetIntact() bool {
+	if x != nil {
+		return x.Intact
+	}
+	return false
+}
+
+func (x *ChainVerification) GetIssues() []*ChainIssue {
+	if x != nil {
+		return x.Issues
+	}
+	return nil
+}
+
+func (x *ChainVerification) GetOmittedIssues() int64 {
+	if x != nil {
+		return x.OmittedIssues
+	}
+	return 0
+}
+
+func (x *ChainVerification) GetPublicKey() string {
+	if x != nil {
+		return x.PublicKey
+	}
+	return ""
+}
+
+func (x *ChainVerification) GetRemoved() int64 {
+	if x != nil {
+		return x.Removed
+	}
+	return 0
+}
+
+func (x *ChainVerification) GetRedacted() int64 {
+	if x != nil {
+		return x.Redacted
+	}
+	return 0
+}
+
+var File_app_sdk_proto_mlog_logs_proto protoreflect.FileDescriptor
+
+const file_app_sdk_proto_mlog_logs_proto_rawDesc = "" +
+	"\n" +
+	"\x1dapp/sdk/proto/mlog/logs.proto\x12\x04logs\"\xf4\x01\n" +
+	"\x06NewLog\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x14\n" +
+	"\x05level\x18\x02 \x01(\tR\x05level\x12\x1c\n" +
+	"\ttimestamp\x18\x03 \x01(\x03R\ttimestamp\x126\n" +
+	"\bmetadata\x18\x04 \x03(\v2\x1a.logs.NewLog.MetadataEntryR\bmetadata\x12'\n" +
+	"\x0fidempotency_key\x18\x05 \x01(\tR\x0eidempotencyKey\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"5\n" +
+	"\vLogResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\"\xed\x01\n" +
+	"\x03Log\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x14\n" +
+	"\x05level\x18\x03 \x01(\tR\x05level\x12\x1c\n" +
+	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\x123\n" +
+	"\bmetadata\x18\x05 \x03(\v2\x17.logs.Log.MetadataEntryR\bmetadata\x12\x16\n" +
+	"\x06tenant\x18\x06 \x01(\tR\x06tenant\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"V\n" +
+	"\x04Logs\x12\x1d\n" +
+	"\x04logs\x18\x01 \x03(\v2\t.logs.LogR\x04logs\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\x12\x19\n" +
+	"\bhas_more\x18\x03 \x01(\bR\ahasMore\"\xc1\x01\n" +
+	"\vSearchQuery\x12\x1d\n" +
+	"\n" +
+	"start_time\x18\x01 \x01(\x03R\tstartTime\x12\x19\n" +
+	"\bend_time\x18\x02 \x01(\x03R\aendTime\x12\x14\n" +
+	"\x05level\x18\x03 \x01(\tR\x05level\x12\x1b\n" +
+	"\tpage_size\x18\x04 \x01(\x05R\bpageSize\x12\x12\n" +
+	"\x04page\x18\x05 \x01(\x05R\x04page\x12\x17\n" +
+	"\aas_file\x18\x06 \x01(\bR\x06asFile\x12\x18\n" +
+	"\amessage\x18\a \x01(\tR\amessage\"h\n" +
+	"\fFileResponse\x12\x19\n" +
+	"\bfile_url\x18\x01 \x01(\tR\afileUrl\x12\x1b\n" +
+	"\tfile_size\x18\x02 \x01(\x03R\bfileSize\x12 \n" +
+	"\vcompression\x18\x03 \x01(\tR\vcompression\"(\n" +
+	"\fStatsRequest\x12\x18\n" +
+	"\arefresh\x18\x01 \x01(\bR\arefresh\"8\n" +
+	"\n" +
+	"LevelStats\x12\x14\n" +
+	"\x05level\x18\x01 \x01(\tR\x05level\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\"2\n" +
+	"\bDayStats\x12\x10\n" +
+	"\x03day\x18\x01 \x01(\tR\x03day\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\"\x9a\x01\n" +
+	"\x0eAlgorithmStats\x12\x1c\n" +
+	"\talgorithm\x18\x01 \x01(\tR\talgorithm\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\x12\x1b\n" +
+	"\traw_bytes\x18\x03 \x01(\x03R\brawBytes\x12!\n" +
+	"\fstored_bytes\x18\x04 \x01(\x03R\vstoredBytes\x12\x14\n" +
+	"\x05ratio\x18\x05 \x01(\x01R\x05ratio\"\x98\x02\n" +
+	"\x0fCollectionStats\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12!\n" +
+	"\fstorage_size\x18\x04 \x01(\x03R\vstorageSize\x12\x1d\n" +
+	"\n" +
+	"index_size\x18\x05 \x01(\x03R\tindexSize\x12F\n" +
+	"\vindex_sizes\x18\x06 \x03(\v2%.logs.CollectionStats.IndexSizesEntryR\n" +
+	"indexSizes\x1a=\n" +
+	"\x0fIndexSizesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"\xc0\x04\n" +
+	"\rStatsResponse\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x03R\x05total\x12\x1e\n" +
+	"\n" +
+	"compressed\x18\x02 \x01(\x03R\n" +
+	"compressed\x12\"\n" +
+	"\funcompressed\x18\x03 \x01(\x03R\funcompressed\x12\x1b\n" +
+	"\traw_bytes\x18\x04 \x01(\x03R\brawBytes\x12!\n" +
+	"\fstored_bytes\x18\x05 \x01(\x03R\vstoredBytes\x12+\n" +
+	"\x11compression_ratio\x18\x06 \x01(\x01R\x10compressionRatio\x12(\n" +
+	"\x06levels\x18\a \x03(\v2\x10.logs.LevelStatsR\x06levels\x12\"\n" +
+	"\x04days\x18\b \x03(\v2\x0e.logs.DayStatsR\x04days\x124\n" +
+	"\n" +
+	"algorithms\x18\t \x03(\v2\x14.logs.AlgorithmStatsR\n" +
+	"algorithms\x127\n" +
+	"\vcollections\x18\n" +
+	" \x03(\v2\x15.logs.CollectionStatsR\vcollections\x12\x1f\n" +
+	"\vcomputed_at\x18\v \x01(\x03R\n" +
+	"computedAt\x12'\n" +
+	"\abacklog\x18\f \x01(\v2\r.logs.BacklogR\abacklog\x12+\n" +
+	"\atenants\x18\r \x03(\v2\x11.logs.TenantStatsR\atenants\x124\n" +
+	"\n" +
+	"redactions\x18\x0e \x03(\v2\x14.logs.RedactionStatsR\n" +
+	"redactions\":\n" +
+	"\x0eRedactionStats\x12\x12\n" +
+	"\x04rule\x18\x01 \x01(\tR\x04rule\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\";\n" +
+	"\vTenantStats\x12\x16\n" +
+	"\x06tenant\x18\x01 \x01(\tR\x06tenant\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\"\x87\x02\n" +
+	"\aBacklog\x12\x16\n" +
+	"\x06queued\x18\x01 \x01(\x03R\x06queued\x12\x18\n" +
+	"\aspooled\x18\x02 \x01(\x03R\aspooled\x12\x1f\n" +
+	"\vspool_bytes\x18\x03 \x01(\x03R\n" +
+	"spoolBytes\x12%\n" +
+	"\x0espool_segments\x18\x04 \x01(\x05R\rspoolSegments\x12%\n" +
+	"\x0eoldest_spooled\x18\x05 \x01(\x03R\roldestSpooled\x12\x1a\n" +
+	"\breplayed\x18\x06 \x01(\x03R\breplayed\x12\x1a\n" +
+	"\bspooling\x18\a \x01(\bR\bspooling\x12#\n" +
+	"\rdead_lettered\x18\b \x01(\x03R\fdeadLettered\"\x9e\x01\n" +
+	"\x10RetentionMetrics\x12\x12\n" +
+	"\x04runs\x18\x01 \x01(\x03R\x04runs\x12\x18\n" +
+	"\adeleted\x18\x02 \x01(\x03R\adeleted\x12#\n" +
+	"\rdeleted_bytes\x18\x03 \x01(\x03R\fdeletedBytes\x12\x19\n" +
+	"\blast_run\x18\x04 \x01(\x03R\alastRun\x12\x1c\n" +
+	"\testimated\x18\x05 \x01(\bR\testimated\"\xb2\x02\n" +
+	"\x0fRetentionPolicy\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05level\x18\x02 \x01(\tR\x05level\x12?\n" +
+	"\bmetadata\x18\x03 \x03(\v2#.logs.RetentionPolicy.MetadataEntryR\bmetadata\x12&\n" +
+	"\x0fmax_age_seconds\x18\x04 \x01(\x03R\rmaxAgeSeconds\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x05 \x01(\x03R\tupdatedAt\x120\n" +
+	"\ametrics\x18\x06 \x01(\v2\x16.logs.RetentionMetricsR\ametrics\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x1e\n" +
+	"\x1cListRetentionPoliciesRequest\"F\n" +
+	"\x11RetentionPolicies\x121\n" +
+	"\bpolicies\x18\x01 \x03(\v2\x15.logs.RetentionPolicyR\bpolicies\"2\n" +
+	"\x1cDeleteRetentionPolicyRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"7\n" +
+	"\x1dDeleteRetentionPolicyResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\"2\n" +
+	"\x17EnforceRetentionRequest\x12\x17\n" +
+	"\adry_run\x18\x01 \x01(\bR\x06dryRun\"\xb1\x01\n" +
+	"\x0fRetentionResult\x12\x16\n" +
+	"\x06policy\x18\x01 \x01(\tR\x06policy\x12\x18\n" +
+	"\adeleted\x18\x02 \x01(\x03R\adeleted\x12#\n" +
+	"\rdeleted_bytes\x18\x03 \x01(\x03R\fdeletedBytes\x12\x17\n" +
+	"\adry_run\x18\x04 \x01(\bR\x06dryRun\x12\x10\n" +
+	"\x03ttl\x18\x05 \x01(\bR\x03ttl\x12\x1c\n" +
+	"\testimated\x18\x06 \x01(\bR\testimated\"K\n" +
+	"\x18EnforceRetentionResponse\x12/\n" +
+	"\aresults\x18\x01 \x03(\v2\x15.logs.RetentionResultR\aresults\"\x1c\n" +
+	"\x1aRotateEncryptionKeyRequest\"4\n" +
+	"\x1bRotateEncryptionKeyResponse\x12\x15\n" +
+	"\x06key_id\x18\x01 \x01(\tR\x05keyId\"\xe1\x02\n" +
+	"\rDeleteRequest\x12\x1d\n" +
+	"\n" +
+	"start_time\x18\x01 \x01(\x03R\tstartTime\x12\x19\n" +
+	"\bend_time\x18\x02 \x01(\x03R\aendTime\x12\x14\n" +
+	"\x05level\x18\x03 \x01(\tR\x05level\x12=\n" +
+	"\bmetadata\x18\x04 \x03(\v2!.logs.DeleteRequest.MetadataEntryR\bmetadata\x12\x18\n" +
+	"\amessage\x18\x05 \x01(\tR\amessage\x12\x12\n" +
+	"\x04mode\x18\x06 \x01(\tR\x04mode\x12\x17\n" +
+	"\adry_run\x18\a \x01(\bR\x06dryRun\x12%\n" +
+	"\x0eexpected_count\x18\b \x01(\x03R\rexpectedCount\x12\x16\n" +
+	"\x06reason\x18\t \x01(\tR\x06reason\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xa8\x01\n" +
+	"\x0eDeleteResponse\x12\x18\n" +
+	"\amatched\x18\x01 \x01(\x03R\amatched\x12\x18\n" +
+	"\adeleted\x18\x02 \x01(\x03R\adeleted\x12\x1a\n" +
+	"\bredacted\x18\x03 \x01(\x03R\bredacted\x12\x17\n" +
+	"\adry_run\x18\x04 \x01(\bR\x06dryRun\x12\x19\n" +
+	"\baudit_id\x18\x05 \x01(\tR\aauditId\x12\x12\n" +
+	"\x04held\x18\x06 \x01(\x03R\x04held\"\x8f\x03\n" +
+	"\tLegalHold\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12\x1d\n" +
+	"\n" +
+	"start_time\x18\x04 \x01(\x03R\tstartTime\x12\x19\n" +
+	"\bend_time\x18\x05 \x01(\x03R\aendTime\x12\x14\n" +
+	"\x05level\x18\x06 \x01(\tR\x05level\x129\n" +
+	"\bmetadata\x18\a \x03(\v2\x1d.logs.LegalHold.MetadataEntryR\bmetadata\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\b \x01(\tR\tcreatedBy\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\t \x01(\x03R\tcreatedAt\x12\x1f\n" +
+	"\vreleased_by\x18\n" +
+	" \x01(\tR\n" +
+	"releasedBy\x12\x1f\n" +
+	"\vreleased_at\x18\v \x01(\x03R\n" +
+	"releasedAt\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"B\n" +
+	"\x15ListLegalHoldsRequest\x12)\n" +
+	"\x10include_released\x18\x01 \x01(\bR\x0fincludeReleased\"3\n" +
+	"\n" +
+	"LegalHolds\x12%\n" +
+	"\x05holds\x18\x01 \x03(\v2\x0f.logs.LegalHoldR\x05holds\")\n" +
+	"\x17ReleaseLegalHoldRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xdc\x02\n" +
+	"\x06ApiKey\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
+	"\x06scopes\x18\x03 \x03(\tR\x06scopes\x12\x16\n" +
+	"\x06tenant\x18\x04 \x01(\tR\x06tenant\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\x05 \x01(\tR\tcreatedBy\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\x03R\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\a \x01(\x03R\texpiresAt\x12\x1d\n" +
+	"\n" +
+	"rotated_at\x18\b \x01(\x03R\trotatedAt\x120\n" +
+	"\x14previous_valid_until\x18\t \x01(\x03R\x12previousValidUntil\x12\x1d\n" +
+	"\n" +
+	"revoked_by\x18\n" +
+	" \x01(\tR\trevokedBy\x12\x1d\n" +
+	"\n" +
+	"revoked_at\x18\v \x01(\x03R\trevokedAt\x12\x12\n" +
+	"\x04role\x18\f \x01(\tR\x04role\"\x8c\x01\n" +
+	"\x13CreateApiKeyRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06scopes\x18\x02 \x03(\tR\x06scopes\x12\x16\n" +
+	"\x06tenant\x18\x03 \x01(\tR\x06tenant\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x04 \x01(\x03R\texpiresAt\x12\x12\n" +
+	"\x04role\x18\x05 \x01(\tR\x04role\"F\n" +
+	"\fApiKeySecret\x12\x1e\n" +
+	"\x03key\x18\x01 \x01(\v2\f.logs.ApiKeyR\x03key\x12\x16\n" +
+	"\x06secret\x18\x02 \x01(\tR\x06secret\"J\n" +
+	"\x13RotateApiKeyRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12#\n" +
+	"\rgrace_seconds\x18\x02 \x01(\x03R\fgraceSeconds\"%\n" +
+	"\x13RevokeApiKeyRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"=\n" +
+	"\x12ListApiKeysRequest\x12'\n" +
+	"\x0finclude_revoked\x18\x01 \x01(\bR\x0eincludeRevoked\"+\n" +
+	"\aApiKeys\x12 \n" +
+	"\x04keys\x18\x01 \x03(\v2\f.logs.ApiKeyR\x04keys\"\xc5\x02\n" +
+	"\n" +
+	"AuditEntry\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04time\x18\x02 \x01(\x03R\x04time\x12\x14\n" +
+	"\x05actor\x18\x03 \x01(\tR\x05actor\x12\x16\n" +
+	"\x06tenant\x18\x04 \x01(\tR\x06tenant\x12\x16\n" +
+	"\x06method\x18\x05 \x01(\tR\x06method\x12\x18\n" +
+	"\arequest\x18\x06 \x01(\tR\arequest\x12\x18\n" +
+	"\aresults\x18\a \x01(\x03R\aresults\x12\x14\n" +
+	"\x05bytes\x18\b \x01(\x03R\x05bytes\x12\x1f\n" +
+	"\vduration_ms\x18\t \x01(\x03R\n" +
+	"durationMs\x12\x12\n" +
+	"\x04code\x18\n" +
+	" \x01(\tR\x04code\x12\x1b\n" +
+	"\tprev_hash\x18\v \x01(\tR\bprevHash\x12\x12\n" +
+	"\x04hash\x18\f \x01(\tR\x04hash\x12\x1d\n" +
+	"\n" +
+	"pending_id\x18\r \x01(\tR\tpendingId\"\xa5\x01\n" +
+	"\n" +
+	"AuditQuery\x12\x1d\n" +
+	"\n" +
+	"start_time\x18\x01 \x01(\x03R\tstartTime\x12\x19\n" +
+	"\bend_time\x18\x02 \x01(\x03R\aendTime\x12\x14\n" +
+	"\x05actor\x18\x03 \x01(\tR\x05actor\x12\x16\n" +
+	"\x06method\x18\x04 \x01(\tR\x06method\x12\x12\n" +
+	"\x04page\x18\x05 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x06 \x01(\x05R\bpageSize\"r\n" +
+	"\fAuditEntries\x12*\n" +
+	"\aentries\x18\x01 \x03(\v2\x10.logs.AuditEntryR\aentries\x12\x19\n" +
+	"\bhas_more\x18\x02 \x01(\bR\ahasMore\x12\x1b\n" +
+	"\tnext_page\x18\x03 \x01(\x05R\bnextPage\"\x14\n" +
+	"\x12VerifyAuditRequest\"z\n" +
+	"\x11AuditVerification\x12\x18\n" +
+	"\achecked\x18\x01 \x01(\x03R\achecked\x12\x16\n" +
+	"\x06intact\x18\x02 \x01(\bR\x06intact\x12\x1b\n" +
+	"\tbroken_at\x18\x03 \x01(\tR\bbrokenAt\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\":\n" +
+	"\fUsageRequest\x12\x18\n" +
+	"\asubject\x18\x01 \x01(\tR\asubject\x12\x10\n" +
+	"\x03all\x18\x02 \x01(\bR\x03all\"\xeb\x03\n" +
+	"\vUsageReport\x12\x18\n" +
+	"\asubject\x18\x01 \x01(\tR\asubject\x12&\n" +
+	"\x0flogs_per_second\x18\x02 \x01(\x03R\rlogsPerSecond\x12(\n" +
+	"\x10bytes_per_second\x18\x03 \x01(\x03R\x0ebytesPerSecond\x12%\n" +
+	"\x0elogs_available\x18\x04 \x01(\x01R\rlogsAvailable\x12'\n" +
+	"\x0fbytes_available\x18\x05 \x01(\x01R\x0ebytesAvailable\x12\x1d\n" +
+	"\n" +
+	"daily_logs\x18\x06 \x01(\x03R\tdailyLogs\x12\x1f\n" +
+	"\vdaily_bytes\x18\a \x01(\x03R\n" +
+	"dailyBytes\x12(\n" +
+	"\x10daily_logs_quota\x18\b \x01(\x03R\x0edailyLogsQuota\x12*\n" +
+	"\x11daily_bytes_quota\x18\t \x01(\x03R\x0fdailyBytesQuota\x12&\n" +
+	"\x0fquota_resets_at\x18\n" +
+	" \x01(\x03R\rquotaResetsAt\x12%\n" +
+	"\x0eactive_queries\x18\v \x01(\x05R\ractiveQueries\x12\x1f\n" +
+	"\vmax_queries\x18\f \x01(\x05R\n" +
+	"maxQueries\x12\x1a\n" +
+	"\brejected\x18\r \x01(\x03R\brejected\";\n" +
+	"\fUsageReports\x12+\n" +
+	"\areports\x18\x01 \x03(\v2\x11.logs.UsageReportR\areports\"f\n" +
+	"\x12VerifyChainRequest\x12\x16\n" +
+	"\x06tenant\x18\x01 \x01(\tR\x06tenant\x12\x1d\n" +
+	"\n" +
+	"start_time\x18\x02 \x01(\x03R\tstartTime\x12\x19\n" +
+	"\bend_time\x18\x03 \x01(\x03R\aendTime\"a\n" +
+	"\n" +
+	"ChainIssue\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x10\n" +
+	"\x03seq\x18\x02 \x01(\x03R\x03seq\x12\x15\n" +
+	"\x06log_id\x18\x03 \x01(\tR\x05logId\x12\x16\n" +
+	"\x06detail\x18\x04 \x01(\tR\x06detail\"\xb5\x02\n" +
+	"\x11ChainVerification\x12\x16\n" +
+	"\x06tenant\x18\x01 \x01(\tR\x06tenant\x12\x14\n" +
+	"\x05links\x18\x02 \x01(\x03R\x05links\x12\x12\n" +
+	"\x04logs\x18\x03 \x01(\x03R\x04logs\x12 \n" +
+	"\vcheckpoints\x18\x04 \x01(\x03R\vcheckpoints\x12\x16\n" +
+	"\x06intact\x18\x05 \x01(\bR\x06intact\x12(\n" +
+	"\x06issues\x18\x06 \x03(\v2\x10.logs.ChainIssueR\x06issues\x12%\n" +
+	"\x0eomitted_issues\x18\a \x01(\x03R\romittedIssues\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\b \x01(\tR\tpublicKey\x12\x18\n" +
+	"\aremoved\x18\t \x01(\x03R\aremoved\x12\x1a\n" +
+	"\bredacted\x18\n" +
+	" \x01(\x03R\bredacted28\n" +
 	"\tLogWriter\x12+\n" +
 	"\bRegister\x12\f.logs.NewLog\x1a\x11.logs.LogResponse2\x9a\x01\n" +
 	"\tLogReader\x12'\n" +
@@ -476,7 +3823,30 @@ const file_app_sdk_proto_mlog_logs_proto_rawDesc = "" +
 	"\fExportToFile\x12\x11.logs.SearchQuery\x1a\x12.logs.FileResponse\x12-\n" +
 	"\n" +
 	"StreamFile\x12\x11.logs.SearchQuery\x1a\n" +
-	".logs.Logs0\x01B\x14Z\x12app/sdk/proto/mlogb\x06proto3"
+	".logs.Logs0\x012\xd6\x05\n" +
+	"\bLogAdmin\x120\n" +
+	"\x05Stats\x12\x12.logs.StatsRequest\x1a\x13.logs.StatsResponse\x12T\n" +
+	"\x15ListRetentionPolicies\x12\".logs.ListRetentionPoliciesRequest\x1a\x17.logs.RetentionPolicies\x12B\n" +
+	"\x12SetRetentionPolicy\x12\x15.logs.RetentionPolicy\x1a\x15.logs.RetentionPolicy\x12`\n" +
+	"\x15DeleteRetentionPolicy\x12\".logs.DeleteRetentionPolicyRequest\x1a#.logs.DeleteRetentionPolicyResponse\x12Q\n" +
+	"\x10EnforceRetention\x12\x1d.logs.EnforceRetentionRequest\x1a\x1e.logs.EnforceRetentionResponse\x123\n" +
+	"\x06Delete\x12\x13.logs.DeleteRequest\x1a\x14.logs.DeleteResponse\x123\n" +
+	"\x0fCreateLegalHold\x12\x0f.logs.LegalHold\x1a\x0f.logs.LegalHold\x12?\n" +
+	"\x0eListLegalHolds\x12\x1b.logs.ListLegalHoldsRequest\x1a\x10.logs.LegalHolds\x12B\n" +
+	"\x10ReleaseLegalHold\x12\x1d.logs.ReleaseLegalHoldRequest\x1a\x0f.logs.LegalHold\x12Z\n" +
+	"\x13RotateEncryptionKey\x12 .logs.RotateEncryptionKeyRequest\x1a!.logs.RotateEncryptionKeyResponse2\xed\x01\n" +
+	"\bKeyAdmin\x12:\n" +
+	"\tCreateKey\x12\x19.logs.CreateApiKeyRequest\x1a\x12.logs.ApiKeySecret\x12:\n" +
+	"\tRotateKey\x12\x19.logs.RotateApiKeyRequest\x1a\x12.logs.ApiKeySecret\x124\n" +
+	"\tRevokeKey\x12\x19.logs.RevokeApiKeyRequest\x1a\f.logs.ApiKey\x123\n" +
+	"\bListKeys\x12\x18.logs.ListApiKeysRequest\x1a\r.logs.ApiKeys2v\n" +
+	"\bAuditLog\x12-\n" +
+	"\x05Query\x12\x10.logs.AuditQuery\x1a\x12.logs.AuditEntries\x12;\n" +
+	"\x06Verify\x12\x18.logs.VerifyAuditRequest\x1a\x17.logs.AuditVerification28\n" +
+	"\x05Quota\x12/\n" +
+	"\x05Usage\x12\x12.logs.UsageRequest\x1a\x12.logs.UsageReports2G\n" +
+	"\bLogChain\x12;\n" +
+	"\x06Verify\x12\x18.logs.VerifyChainRequest\x1a\x17.logs.ChainVerificationB\x14Z\x12app/sdk/proto/mlogb\x06proto3"
 
 var (
 	file_app_sdk_proto_mlog_logs_proto_rawDescOnce sync.Once
@@ -490,34 +3860,138 @@ func file_app_sdk_proto_mlog_logs_proto_rawDescGZIP() []byte {
 	return file_app_sdk_proto_mlog_logs_proto_rawDescData
 }
 
-var file_app_sdk_proto_mlog_logs_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_app_sdk_proto_mlog_logs_proto_msgTypes = make([]protoimpl.MessageInfo, 56)
 var file_app_sdk_proto_mlog_logs_proto_goTypes = []any{
-	(*NewLog)(nil),       // 0: logs.NewLog
-	(*LogResponse)(nil),  // 1: logs.LogResponse
-	(*Log)(nil),          // 2: logs.Log
-	(*Logs)(nil),         // 3: logs.Logs
-	(*SearchQuery)(nil),  // 4: logs.SearchQuery
-	(*FileResponse)(nil), // 5: logs.FileResponse
-	nil,                  // 6: logs.NewLog.MetadataEntry
-	nil,                  // 7: logs.Log.MetadataEntry
+	(*NewLog)(nil),                        // 0: logs.NewLog
+	(*LogResponse)(nil),                   // 1: logs.LogResponse
+	(*Log)(nil),                           // 2: logs.Log
+	(*Logs)(nil),                          // 3: logs.Logs
+	(*SearchQuery)(nil),                   // 4: logs.SearchQuery
+	(*FileResponse)(nil),                  // 5: logs.FileResponse
+	(*StatsRequest)(nil),                  // 6: logs.StatsRequest
+	(*LevelStats)(nil),                    // 7: logs.LevelStats
+	(*DayStats)(nil),                      // 8: logs.DayStats
+	(*AlgorithmStats)(nil),                // 9: logs.AlgorithmStats
+	(*CollectionStats)(nil),               // 10: logs.CollectionStats
+	(*StatsResponse)(nil),                 // 11: logs.StatsResponse
+	(*RedactionStats)(nil),                // 12: logs.RedactionStats
+	(*TenantStats)(nil),                   // 13: logs.TenantStats
+	(*Backlog)(nil),                       // 14: logs.Backlog
+	(*RetentionMetrics)(nil),              // 15: logs.RetentionMetrics
+	(*RetentionPolicy)(nil),               // 16: logs.RetentionPolicy
+	(*ListRetentionPoliciesRequest)(nil),  // 17: logs.ListRetentionPoliciesRequest
+	(*RetentionPolicies)(nil),             // 18: logs.RetentionPolicies
+	(*DeleteRetentionPolicyRequest)(nil),  // 19: logs.DeleteRetentionPolicyRequest
+	(*DeleteRetentionPolicyResponse)(nil), // 20: logs.DeleteRetentionPolicyResponse
+	(*EnforceRetentionRequest)(nil),       // 21: logs.EnforceRetentionRequest
+	(*RetentionResult)(nil),               // 22: logs.RetentionResult
+	(*EnforceRetentionResponse)(nil),      // 23: logs.EnforceRetentionResponse
+	(*RotateEncryptionKeyRequest)(nil),    // 24: logs.RotateEncryptionKeyRequest
+	(*RotateEncryptionKeyResponse)(nil),   // 25: logs.RotateEncryptionKeyResponse
+	(*DeleteRequest)(nil),                 // 26: logs.DeleteRequest
+	(*DeleteResponse)(nil),                // 27: logs.DeleteResponse
+	(*LegalHold)(nil),                     // 28: logs.LegalHold
+	(*ListLegalHoldsRequest)(nil),         // 29: logs.ListLegalHoldsRequest
+	(*LegalHolds)(nil),                    // 30: logs.LegalHolds
+	(*ReleaseLegalHoldRequest)(nil),       // 31: logs.ReleaseLegalHoldRequest
+	(*ApiKey)(nil),                        // 32: logs.ApiKey
+	(*CreateApiKeyRequest)(nil),           // 33: logs.CreateApiKeyRequest
+	(*ApiKeySecret)(nil),                  // 34: logs.ApiKeySecret
+	(*RotateApiKeyRequest)(nil),           // 35: logs.RotateApiKeyRequest
+	(*RevokeApiKeyRequest)(nil),           // 36: logs.RevokeApiKeyRequest
+	(*ListApiKeysRequest)(nil),            // 37: logs.ListApiKeysRequest
+	(*ApiKeys)(nil),                       // 38: logs.ApiKeys
+	(*AuditEntry)(nil),                    // 39: logs.AuditEntry
+	(*AuditQuery)(nil),                    // 40: logs.AuditQuery
+	(*AuditEntries)(nil),                  // 41: logs.AuditEntries
+	(*VerifyAuditRequest)(nil),            // 42: logs.VerifyAuditRequest
+	(*AuditVerification)(nil),             // 43: logs.AuditVerification
+	(*UsageRequest)(nil),                  // 44: logs.UsageRequest
+	(*UsageReport)(nil),                   // 45: logs.UsageReport
+	(*UsageReports)(nil),                  // 46: logs.UsageReports
+	(*VerifyChainRequest)(nil),            // 47: logs.VerifyChainRequest
+	(*ChainIssue)(nil),                    // 48: logs.ChainIssue
+	(*ChainVerification)(nil),             // 49: logs.ChainVerification
+	nil,                                   // 50: logs.NewLog.MetadataEntry
+	nil,                                   // 51: logs.Log.MetadataEntry
+	nil,                                   // 52: logs.CollectionStats.IndexSizesEntry
+	nil,                                   // 53: logs.RetentionPolicy.MetadataEntry
+	nil,                                   // 54: logs.DeleteRequest.MetadataEntry
+	nil,                                   // 55: logs.LegalHold.MetadataEntry
 }
 var file_app_sdk_proto_mlog_logs_proto_depIdxs = []int32{
-	6, // 0: logs.NewLog.metadata:type_name -> logs.NewLog.MetadataEntry
-	7, // 1: logs.Log.metadata:type_name -> logs.Log.MetadataEntry
-	2, // 2: logs.Logs.logs:type_name -> logs.Log
-	0, // 3: logs.LogWriter.Register:input_type -> logs.NewLog
-	4, // 4: logs.LogReader.Search:input_type -> logs.SearchQuery
-	4, // 5: logs.LogReader.ExportToFile:input_type -> logs.SearchQuery
-	4, // 6: logs.LogReader.StreamFile:input_type -> logs.SearchQuery
-	1, // 7: logs.LogWriter.Register:output_type -> logs.LogResponse
-	3, // 8: logs.LogReader.Search:output_type -> logs.Logs
-	5, // 9: logs.LogReader.ExportToFile:output_type -> logs.FileResponse
-	3, // 10: logs.LogReader.StreamFile:output_type -> logs.Logs
-	7, // [7:11] is the sub-list for method output_type
-	3, // [3:7] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	50, // 0: logs.NewLog.metadata:type_name -> logs.NewLog.MetadataEntry
+	51, // 1: logs.Log.metadata:type_name -> logs.Log.MetadataEntry
+	2,  // 2: logs.Logs.logs:type_name -> logs.Log
+	52, // 3: logs.CollectionStats.index_sizes:type_name -> logs.CollectionStats.IndexSizesEntry
+	7,  // 4: logs.StatsResponse.levels:type_name -> logs.LevelStats
+	8,  // 5: logs.StatsResponse.days:type_name -> logs.DayStats
+	9,  // 6: logs.StatsResponse.algorithms:type_name -> logs.AlgorithmStats
+	10, // 7: logs.StatsResponse.collections:type_name -> logs.CollectionStats
+	14, // 8: logs.StatsResponse.backlog:type_name -> logs.Backlog
+	13, // 9: logs.StatsResponse.tenants:type_name -> logs.TenantStats
+	12, // 10: logs.StatsResponse.redactions:type_name -> logs.RedactionStats
+	53, // 11: logs.RetentionPolicy.metadata:type_name -> logs.RetentionPolicy.MetadataEntry
+	15, // 12: logs.RetentionPolicy.metrics:type_name -> logs.RetentionMetrics
+	16, // 13: logs.RetentionPolicies.policies:type_name -> logs.RetentionPolicy
+	22, // 14: logs.EnforceRetentionResponse.results:type_name -> logs.RetentionResult
+	54, // 15: logs.DeleteRequest.metadata:type_name -> logs.DeleteRequest.MetadataEntry
+	55, // 16: logs.LegalHold.metadata:type_name -> logs.LegalHold.MetadataEntry
+	28, // 17: logs.LegalHolds.holds:type_name -> logs.LegalHold
+	32, // 18: logs.ApiKeySecret.key:type_name -> logs.ApiKey
+	32, // 19: logs.ApiKeys.keys:type_name -> logs.ApiKey
+	39, // 20: logs.AuditEntries.entries:type_name -> logs.AuditEntry
+	45, // 21: logs.UsageReports.reports:type_name -> logs.UsageReport
+	48, // 22: logs.ChainVerification.issues:type_name -> logs.ChainIssue
+	0,  // 23: logs.LogWriter.Register:input_type -> logs.NewLog
+	4,  // 24: logs.LogReader.Search:input_type -> logs.SearchQuery
+	4,  // 25: logs.LogReader.ExportToFile:input_type -> logs.SearchQuery
+	4,  // 26: logs.LogReader.StreamFile:input_type -> logs.SearchQuery
+	6,  // 27: logs.LogAdmin.Stats:input_type -> logs.StatsRequest
+	17, // 28: logs.LogAdmin.ListRetentionPolicies:input_type -> logs.ListRetentionPoliciesRequest
+	16, // 29: logs.LogAdmin.SetRetentionPolicy:input_type -> logs.RetentionPolicy
+	19, // 30: logs.LogAdmin.DeleteRetentionPolicy:input_type -> logs.DeleteRetentionPolicyRequest
+	21, // 31: logs.LogAdmin.EnforceRetention:input_type -> logs.EnforceRetentionRequest
+	26, // 32: logs.LogAdmin.Delete:input_type -> logs.DeleteRequest
+	28, // 33: logs.LogAdmin.CreateLegalHold:input_type -> logs.LegalHold
+	29, // 34: logs.LogAdmin.ListLegalHolds:input_type -> logs.ListLegalHoldsRequest
+	31, // 35: logs.LogAdmin.ReleaseLegalHold:input_type -> logs.ReleaseLegalHoldRequest
+	24, // 36: logs.LogAdmin.RotateEncryptionKey:input_type -> logs.RotateEncryptionKeyRequest
+	33, // 37: logs.KeyAdmin.CreateKey:input_type -> logs.CreateApiKeyRequest
+	35, // 38: logs.KeyAdmin.RotateKey:input_type -> logs.RotateApiKeyRequest
+	36, // 39: logs.KeyAdmin.RevokeKey:input_type -> logs.RevokeApiKeyRequest
+	37, // 40: logs.KeyAdmin.ListKeys:input_type -> logs.ListApiKeysRequest
+	40, // 41: logs.AuditLog.Query:input_type -> logs.AuditQuery
+	42, // 42: logs.AuditLog.Verify:input_type -> logs.VerifyAuditRequest
+	44, // 43: logs.Quota.Usage:input_type -> logs.UsageRequest
+	47, // 44: logs.LogChain.Verify:input_type -> logs.VerifyChainRequest
+	1,  // 45: logs.LogWriter.Register:output_type -> logs.LogResponse
+	3,  // 46: logs.LogReader.Search:output_type -> logs.Logs
+	5,  // 47: logs.LogReader.ExportToFile:output_type -> logs.FileResponse
+	3,  // 48: logs.LogReader.StreamFile:output_type -> logs.Logs
+	11, // 49: logs.LogAdmin.Stats:output_type -> logs.StatsResponse
+	18, // 50: logs.LogAdmin.ListRetentionPolicies:output_type -> logs.RetentionPolicies
+	16, // 51: logs.LogAdmin.SetRetentionPolicy:output_type -> logs.RetentionPolicy
+	20, // 52: logs.LogAdmin.DeleteRetentionPolicy:output_type -> logs.DeleteRetentionPolicyResponse
+	23, // 53: logs.LogAdmin.EnforceRetention:output_type -> logs.EnforceRetentionResponse
+	27, // 54: logs.LogAdmin.Delete:output_type -> logs.DeleteResponse
+	28, // 55: logs.LogAdmin.CreateLegalHold:output_type -> logs.LegalHold
+	30, // 56: logs.LogAdmin.ListLegalHolds:output_type -> logs.LegalHolds
+	28, // 57: logs.LogAdmin.ReleaseLegalHold:output_type -> logs.LegalHold
+	25, // 58: logs.LogAdmin.RotateEncryptionKey:output_type -> logs.RotateEncryptionKeyResponse
+	34, // 59: logs.KeyAdmin.CreateKey:output_type -> logs.ApiKeySecret
+	34, // 60: logs.KeyAdmin.RotateKey:output_type -> logs.ApiKeySecret
+	32, // 61: logs.KeyAdmin.RevokeKey:output_type -> logs.ApiKey
+	38, // 62: logs.KeyAdmin.ListKeys:output_type -> logs.ApiKeys
+	41, // 63: logs.AuditLog.Query:output_type -> logs.AuditEntries
+	43, // 64: logs.AuditLog.Verify:output_type -> logs.AuditVerification
+	46, // 65: logs.Quota.Usage:output_type -> logs.UsageReports
+	49, // 66: logs.LogChain.Verify:output_type -> logs.ChainVerification
+	45, // [45:67] is the sub-list for method output_type
+	23, // [23:45] is the sub-list for method input_type
+	23, // [23:23] is the sub-list for extension type_name
+	23, // [23:23] is the sub-list for extension extendee
+	0,  // [0:23] is the sub-list for field type_name
 }
 
 func init() { file_app_sdk_proto_mlog_logs_proto_init() }
@@ -531,9 +4005,9 @@ func file_app_sdk_proto_mlog_logs_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_app_sdk_proto_mlog_logs_proto_rawDesc), len(file_app_sdk_proto_mlog_logs_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   8,
+			NumMessages:   56,
 			NumExtensions: 0,
-			NumServices:   2,
+			NumServices:   7,
 		},
 		GoTypes:           file_app_sdk_proto_mlog_logs_proto_goTypes,
 		DependencyIndexes: file_app_sdk_proto_mlog_logs_proto_depIdxs,
@@ -315,3 +315,1063 @@ var LogReader_ServiceDesc = grpc.ServiceDesc{
 	},
 	Metadata: "app/sdk/proto/mlog/logs.proto",
 }
+
+const (
+	LogAdmin_Stats_FullMethodName                 = "/logs.LogAdmin/Stats"
+	LogAdmin_ListRetentionPolicies_FullMethodName = "/logs.LogAdmin/ListRetentionPolicies"
+	LogAdmin_SetRetentionPolicy_FullMethodName    = "/logs.LogAdmin/SetRetentionPolicy"
+	LogAdmin_DeleteRetentionPolicy_FullMethodName = "/logs.LogAdmin/DeleteRetentionPolicy"
+	LogAdmin_EnforceRetention_FullMethodName      = "/logs.LogAdmin/EnforceRetention"
+	LogAdmin_Delete_FullMethodName                = "/logs.LogAdmin/Delete"
+	LogAdmin_CreateLegalHold_FullMethodName       = "/logs.LogAdmin/CreateLegalHold"
+	LogAdmin_ListLegalHolds_FullMethodName        = "/logs.LogAdmin/ListLegalHolds"
+	LogAdmin_ReleaseLegalHold_FullMethodName      = "/logs.LogAdmin/ReleaseLegalHold"
+	LogAdmin_RotateEncryptionKey_FullMethodName   = "/logs.LogAdmin/RotateEncryptionKey"
+)
+
+// LogAdminClient is the client API for LogAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Serviço de administração
+type LogAdminClient interface {
+	// Retorna estatísticas de armazenamento e compressão
+	Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error)
+	// Lista as políticas de retenção
+	ListRetentionPolicies(ctx context.Context, in *ListRetentionPoliciesRequest, opts ...grpc.CallOption) (*RetentionPolicies, error)
+	// Cria ou substitui uma política de retenção
+	SetRetentionPolicy(ctx context.Context, in *RetentionPolicy, opts ...grpc.CallOption) (*RetentionPolicy, error)
+	// Remove uma política de retenção
+	DeleteRetentionPolicy(ctx context.Context, in *DeleteRetentionPolicyRequest, opts ...grpc.CallOption) (*DeleteRetentionPolicyResponse, error)
+	// Aplica as políticas de retenção imediatamente
+	EnforceRetention(ctx context.Context, in *EnforceRetentionRequest, opts ...grpc.CallOption) (*EnforceRetentionResponse, error)
+	// Remove ou anonimiza logs de um titular (LGPD/GDPR)
+	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	// Cria uma retenção legal
+	CreateLegalHold(ctx context.Context, in *LegalHold, opts ...grpc.CallOption) (*LegalHold, error)
+	// Lista as retenções legais
+	ListLegalHolds(ctx context.Context, in *ListLegalHoldsRequest, opts ...grpc.CallOption) (*LegalHolds, error)
+	// Libera uma retenção legal
+	ReleaseLegalHold(ctx context.Context, in *ReleaseLegalHoldRequest, opts ...grpc.CallOption) (*LegalHold, error)
+	// Gera uma nova chave de dados; os logs antigos são recriptografados em segundo plano
+	RotateEncryptionKey(ctx context.Context, in *RotateEncryptionKeyRequest, opts ...grpc.CallOption) (*RotateEncryptionKeyResponse, error)
+}
+
+type logAdminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLogAdminClient(cc grpc.ClientConnInterface) LogAdminClient {
+	return &logAdminClient{cc}
+}
+
+func (c *logAdminClient) Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatsResponse)
+	err := c.cc.Invoke(ctx, LogAdmin_Stats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logAdminClient) ListRetentionPolicies(ctx context.Context, in *ListRetentionPoliciesRequest, opts ...grpc.CallOption) (*RetentionPolicies, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RetentionPolicies)
+	err := c.cc.Invoke(ctx, LogAdmin_ListRetentionPolicies_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logAdminClient) SetRetentionPolicy(ctx context.Context, in *RetentionPolicy, opts ...grpc.CallOption) (*RetentionPolicy, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RetentionPolicy)
+	err := c.cc.Invoke(ctx, LogAdmin_SetRetentionPolicy_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logAdminClient) DeleteRetentionPolicy(ctx context.Context, in *DeleteRetentionPolicyRequest, opts ...grpc.CallOption) (*DeleteRetentionPolicyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteRetentionPolicyResponse)
+	err := c.cc.Invoke(ctx, LogAdmin_DeleteRetentionPolicy_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logAdminClient) EnforceRetention(ctx context.Context, in *EnforceRetentionRequest, opts ...grpc.CallOption) (*EnforceRetentionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EnforceRetentionResponse)
+	err := c.cc.Invoke(ctx, LogAdmin_EnforceRetention_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logAdminClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteResponse)
+	err := c.cc.Invoke(ctx, LogAdmin_Delete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logAdminClient) CreateLegalHold(ctx context.Context, in *LegalHold, opts ...grpc.CallOption) (*LegalHold, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LegalHold)
+	err := c.cc.Invoke(ctx, LogAdmin_CreateLegalHold_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logAdminClient) ListLegalHolds(ctx context.Context, in *ListLegalHoldsRequest, opts ...grpc.CallOption) (*LegalHolds, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LegalHolds)
+	err := c.cc.Invoke(ctx, LogAdmin_ListLegalHolds_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logAdminClient) ReleaseLegalHold(ctx context.Context, in *ReleaseLegalHoldRequest, opts ...grpc.CallOption) (*LegalHold, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LegalHold)
+	err := c.cc.Invoke(ctx, LogAdmin_ReleaseLegalHold_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logAdminClient) RotateEncryptionKey(ctx context.Context, in *RotateEncryptionKeyRequest, opts ...grpc.CallOption) (*RotateEncryptionKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RotateEncryptionKeyResponse)
+	err := c.cc.Invoke(ctx, LogAdmin_RotateEncryptionKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LogAdminServer is the server API for LogAdmin service.
+// All implementations must embed UnimplementedLogAdminServer
+// for forward compatibility.
+//
+// Serviço de administração
+type LogAdminServer interface {
+	// Retorna estatísticas de armazenamento e compressão
+	Stats(context.Context, *StatsRequest) (*StatsResponse, error)
+	// Lista as políticas de retenção
+	ListRetentionPolicies(context.Context, *ListRetentionPoliciesRequest) (*RetentionPolicies, error)
+	// Cria ou substitui uma política de retenção
+	SetRetentionPolicy(context.Context, *RetentionPolicy) (*RetentionPolicy, error)
+	// Remove uma política de retenção
+	DeleteRetentionPolicy(context.Context, *DeleteRetentionPolicyRequest) (*DeleteRetentionPolicyResponse, error)
+	// Aplica as políticas de retenção imediatamente
+	EnforceRetention(context.Context, *EnforceRetentionRequest) (*EnforceRetentionResponse, error)
+	// Remove ou anonimiza logs de um titular (LGPD/GDPR)
+	Delete(context.Context, *DeleteRequest) (*DeleteResponse, error)
+	// Cria uma retenção legal
+	CreateLegalHold(context.Context, *LegalHold) (*LegalHold, error)
+	// Lista as retenções legais
+	ListLegalHolds(context.Context, *ListLegalHoldsRequest) (*LegalHolds, error)
+	// Libera uma retenção legal
+	ReleaseLegalHold(context.Context, *ReleaseLegalHoldRequest) (*LegalHold, error)
+	// Gera uma nova chave de dados; os logs antigos são recriptografados em segundo plano
+	RotateEncryptionKey(context.Context, *RotateEncryptionKeyRequest) (*RotateEncryptionKeyResponse, error)
+	mustEmbedUnimplementedLogAdminServer()
+}
+
+// UnimplementedLogAdminServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLogAdminServer struct{}
+
+func (UnimplementedLogAdminServer) Stats(context.Context, *StatsRequest) (*StatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stats not implemented")
+}
+func (UnimplementedLogAdminServer) ListRetentionPolicies(context.Context, *ListRetentionPoliciesRequest) (*RetentionPolicies, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRetentionPolicies not implemented")
+}
+func (UnimplementedLogAdminServer) SetRetentionPolicy(context.Context, *RetentionPolicy) (*RetentionPolicy, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetRetentionPolicy not implemented")
+}
+func (UnimplementedLogAdminServer) DeleteRetentionPolicy(context.Context, *DeleteRetentionPolicyRequest) (*DeleteRetentionPolicyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteRetentionPolicy not implemented")
+}
+func (UnimplementedLogAdminServer) EnforceRetention(context.Context, *EnforceRetentionRequest) (*EnforceRetentionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnforceRetention not implemented")
+}
+func (UnimplementedLogAdminServer) Delete(context.Context, *DeleteRequest) (*DeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedLogAdminServer) CreateLegalHold(context.Context, *LegalHold) (*LegalHold, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateLegalHold not implemented")
+}
+func (UnimplementedLogAdminServer) ListLegalHolds(context.Context, *ListLegalHoldsRequest) (*LegalHolds, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListLegalHolds not implemented")
+}
+func (UnimplementedLogAdminServer) ReleaseLegalHold(context.Context, *ReleaseLegalHoldRequest) (*LegalHold, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReleaseLegalHold not implemented")
+}
+func (UnimplementedLogAdminServer) RotateEncryptionKey(context.Context, *RotateEncryptionKeyRequest) (*RotateEncryptionKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RotateEncryptionKey not implemented")
+}
+func (UnimplementedLogAdminServer) mustEmbedUnimplementedLogAdminServer() {}
+func (UnimplementedLogAdminServer) testEmbeddedByValue()                  {}
+
+// UnsafeLogAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LogAdminServer will
+// result in compilation errors.
+type UnsafeLogAdminServer interface {
+	mustEmbedUnimplementedLogAdminServer()
+}
+
+func RegisterLogAdminServer(s grpc.ServiceRegistrar, srv LogAdminServer) {
+	// If the following call pancis, it indicates UnimplementedLogAdminServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&LogAdmin_ServiceDesc, srv)
+}
+
+func _LogAdmin_Stats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogAdminServer).Stats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LogAdmin_Stats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogAdminServer).Stats(ctx, req.(*StatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LogAdmin_ListRetentionPolicies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRetentionPoliciesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogAdminServer).ListRetentionPolicies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LogAdmin_ListRetentionPolicies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogAdminServer).ListRetentionPolicies(ctx, req.(*ListRetentionPoliciesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LogAdmin_SetRetentionPolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetentionPolicy)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogAdminServer).SetRetentionPolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LogAdmin_SetRetentionPolicy_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogAdminServer).SetRetentionPolicy(ctx, req.(*RetentionPolicy))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LogAdmin_DeleteRetentionPolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRetentionPolicyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogAdminServer).DeleteRetentionPolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LogAdmin_DeleteRetentionPolicy_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogAdminServer).DeleteRetentionPolicy(ctx, req.(*DeleteRetentionPolicyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LogAdmin_EnforceRetention_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EnforceRetentionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogAdminServer).EnforceRetention(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LogAdmin_EnforceRetention_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogAdminServer).EnforceRetention(ctx, req.(*EnforceRetentionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LogAdmin_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogAdminServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LogAdmin_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogAdminServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LogAdmin_CreateLegalHold_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LegalHold)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogAdminServer).CreateLegalHold(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LogAdmin_CreateLegalHold_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogAdminServer).CreateLegalHold(ctx, req.(*LegalHold))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LogAdmin_ListLegalHolds_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListLegalHoldsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogAdminServer).ListLegalHolds(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LogAdmin_ListLegalHolds_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogAdminServer).ListLegalHolds(ctx, req.(*ListLegalHoldsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LogAdmin_ReleaseLegalHold_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReleaseLegalHoldRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogAdminServer).ReleaseLegalHold(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LogAdmin_ReleaseLegalHold_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogAdminServer).ReleaseLegalHold(ctx, req.(*ReleaseLegalHoldRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LogAdmin_RotateEncryptionKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RotateEncryptionKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogAdminServer).RotateEncryptionKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LogAdmin_RotateEncryptionKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogAdminServer).RotateEncryptionKey(ctx, req.(*RotateEncryptionKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// LogAdmin_ServiceDesc is the grpc.ServiceDesc for LogAdmin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LogAdmin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "logs.LogAdmin",
+	HandlerType: (*LogAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Stats",
+			Handler:    _LogAdmin_Stats_Handler,
+		},
+		{
+			MethodName: "ListRetentionPolicies",
+			Handler:    _LogAdmin_ListRetentionPolicies_Handler,
+		},
+		{
+			MethodName: "SetRetentionPolicy",
+			Handler:    _LogAdmin_SetRetentionPolicy_Handler,
+		},
+		{
+			MethodName: "DeleteRetentionPolicy",
+			Handler:    _LogAdmin_DeleteRetentionPolicy_Handler,
+		},
+		{
+			MethodName: "EnforceRetention",
+			Handler:    _LogAdmin_EnforceRetention_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _LogAdmin_Delete_Handler,
+		},
+		{
+			MethodName: "CreateLegalHold",
+			Handler:    _LogAdmin_CreateLegalHold_Handler,
+		},
+		{
+			MethodName: "ListLegalHolds",
+			Handler:    _LogAdmin_ListLegalHolds_Handler,
+		},
+		{
+			MethodName: "ReleaseLegalHold",
+			Handler:    _LogAdmin_ReleaseLegalHold_Handler,
+		},
+		{
+			MethodName: "RotateEncryptionKey",
+			Handler:    _LogAdmin_RotateEncryptionKey_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "app/sdk/proto/mlog/logs.proto",
+}
+
+const (
+	KeyAdmin_CreateKey_FullMethodName = "/logs.KeyAdmin/CreateKey"
+	KeyAdmin_RotateKey_FullMethodName = "/logs.KeyAdmin/RotateKey"
+	KeyAdmin_RevokeKey_FullMethodName = "/logs.KeyAdmin/RevokeKey"
+	KeyAdmin_ListKeys_FullMethodName  = "/logs.KeyAdmin/ListKeys"
+)
+
+// KeyAdminClient is the client API for KeyAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Serviço de administração das chaves de API
+type KeyAdminClient interface {
+	// Cria uma chave de API e retorna seu segredo
+	CreateKey(ctx context.Context, in *CreateApiKeyRequest, opts ...grpc.CallOption) (*ApiKeySecret, error)
+	// Gera um novo segredo para uma chave de API
+	RotateKey(ctx context.Context, in *RotateApiKeyRequest, opts ...grpc.CallOption) (*ApiKeySecret, error)
+	// Revoga uma chave de API
+	RevokeKey(ctx context.Context, in *RevokeApiKeyRequest, opts ...grpc.CallOption) (*ApiKey, error)
+	// Lista as chaves de API
+	ListKeys(ctx context.Context, in *ListApiKeysRequest, opts ...grpc.CallOption) (*ApiKeys, error)
+}
+
+type keyAdminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewKeyAdminClient(cc grpc.ClientConnInterface) KeyAdminClient {
+	return &keyAdminClient{cc}
+}
+
+func (c *keyAdminClient) CreateKey(ctx context.Context, in *CreateApiKeyRequest, opts ...grpc.CallOption) (*ApiKeySecret, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ApiKeySecret)
+	err := c.cc.Invoke(ctx, KeyAdmin_CreateKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyAdminClient) RotateKey(ctx context.Context, in *RotateApiKeyRequest, opts ...grpc.CallOption) (*ApiKeySecret, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ApiKeySecret)
+	err := c.cc.Invoke(ctx, KeyAdmin_RotateKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyAdminClient) RevokeKey(ctx context.Context, in *RevokeApiKeyRequest, opts ...grpc.CallOption) (*ApiKey, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ApiKey)
+	err := c.cc.Invoke(ctx, KeyAdmin_RevokeKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyAdminClient) ListKeys(ctx context.Context, in *ListApiKeysRequest, opts ...grpc.CallOption) (*ApiKeys, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ApiKeys)
+	err := c.cc.Invoke(ctx, KeyAdmin_ListKeys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// KeyAdminServer is the server API for KeyAdmin service.
+// All implementations must embed UnimplementedKeyAdminServer
+// for forward compatibility.
+//
+// Serviço de administração das chaves de API
+type KeyAdminServer interface {
+	// Cria uma chave de API e retorna seu segredo
+	CreateKey(context.Context, *CreateApiKeyRequest) (*ApiKeySecret, error)
+	// Gera um novo segredo para uma chave de API
+	RotateKey(context.Context, *RotateApiKeyRequest) (*ApiKeySecret, error)
+	// Revoga uma chave de API
+	RevokeKey(context.Context, *RevokeApiKeyRequest) (*ApiKey, error)
+	// Lista as chaves de API
+	ListKeys(context.Context, *ListApiKeysRequest) (*ApiKeys, error)
+	mustEmbedUnimplementedKeyAdminServer()
+}
+
+// UnimplementedKeyAdminServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedKeyAdminServer struct{}
+
+func (UnimplementedKeyAdminServer) CreateKey(context.Context, *CreateApiKeyRequest) (*ApiKeySecret, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateKey not implemented")
+}
+func (UnimplementedKeyAdminServer) RotateKey(context.Context, *RotateApiKeyRequest) (*ApiKeySecret, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RotateKey not implemented")
+}
+func (UnimplementedKeyAdminServer) RevokeKey(context.Context, *RevokeApiKeyRequest) (*ApiKey, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeKey not implemented")
+}
+func (UnimplementedKeyAdminServer) ListKeys(context.Context, *ListApiKeysRequest) (*ApiKeys, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListKeys not implemented")
+}
+func (UnimplementedKeyAdminServer) mustEmbedUnimplementedKeyAdminServer() {}
+func (UnimplementedKeyAdminServer) testEmbeddedByValue()                  {}
+
+// UnsafeKeyAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to KeyAdminServer will
+// result in compilation errors.
+type UnsafeKeyAdminServer interface {
+	mustEmbedUnimplementedKeyAdminServer()
+}
+
+func RegisterKeyAdminServer(s grpc.ServiceRegistrar, srv KeyAdminServer) {
+	// If the following call pancis, it indicates UnimplementedKeyAdminServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&KeyAdmin_ServiceDesc, srv)
+}
+
+func _KeyAdmin_CreateKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateApiKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyAdminServer).CreateKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyAdmin_CreateKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyAdminServer).CreateKey(ctx, req.(*CreateApiKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyAdmin_RotateKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RotateApiKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyAdminServer).RotateKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyAdmin_RotateKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyAdminServer).RotateKey(ctx, req.(*RotateApiKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyAdmin_RevokeKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeApiKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyAdminServer).RevokeKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyAdmin_RevokeKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyAdminServer).RevokeKey(ctx, req.(*RevokeApiKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyAdmin_ListKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListApiKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyAdminServer).ListKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyAdmin_ListKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyAdminServer).ListKeys(ctx, req.(*ListApiKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// KeyAdmin_ServiceDesc is the grpc.ServiceDesc for KeyAdmin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var KeyAdmin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "logs.KeyAdmin",
+	HandlerType: (*KeyAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateKey",
+			Handler:    _KeyAdmin_CreateKey_Handler,
+		},
+		{
+			MethodName: "RotateKey",
+			Handler:    _KeyAdmin_RotateKey_Handler,
+		},
+		{
+			MethodName: "RevokeKey",
+			Handler:    _KeyAdmin_RevokeKey_Handler,
+		},
+		{
+			MethodName: "ListKeys",
+			Handler:    _KeyAdmin_ListKeys_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "app/sdk/proto/mlog/logs.proto",
+}
+
+const (
+	AuditLog_Query_FullMethodName  = "/logs.AuditLog/Query"
+	AuditLog_Verify_FullMethodName = "/logs.AuditLog/Verify"
+)
+
+// AuditLogClient is the client API for AuditLog service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Serviço de consulta da trilha de auditoria
+type AuditLogClient interface {
+	// Busca registros da trilha de auditoria
+	Query(ctx context.Context, in *AuditQuery, opts ...grpc.CallOption) (*AuditEntries, error)
+	// Verifica se a trilha foi alterada
+	Verify(ctx context.Context, in *VerifyAuditRequest, opts ...grpc.CallOption) (*AuditVerification, error)
+}
+
+type auditLogClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAuditLogClient(cc grpc.ClientConnInterface) AuditLogClient {
+	return &auditLogClient{cc}
+}
+
+func (c *auditLogClient) Query(ctx context.Context, in *AuditQuery, opts ...grpc.CallOption) (*AuditEntries, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AuditEntries)
+	err := c.cc.Invoke(ctx, AuditLog_Query_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *auditLogClient) Verify(ctx context.Context, in *VerifyAuditRequest, opts ...grpc.CallOption) (*AuditVerification, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AuditVerification)
+	err := c.cc.Invoke(ctx, AuditLog_Verify_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AuditLogServer is the server API for AuditLog service.
+// All implementations must embed UnimplementedAuditLogServer
+// for forward compatibility.
+//
+// Serviço de consulta da trilha de auditoria
+type AuditLogServer interface {
+	// Busca registros da trilha de auditoria
+	Query(context.Context, *AuditQuery) (*AuditEntries, error)
+	// Verifica se a trilha foi alterada
+	Verify(context.Context, *VerifyAuditRequest) (*AuditVerification, error)
+	mustEmbedUnimplementedAuditLogServer()
+}
+
+// UnimplementedAuditLogServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAuditLogServer struct{}
+
+func (UnimplementedAuditLogServer) Query(context.Context, *AuditQuery) (*AuditEntries, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Query not implemented")
+}
+func (UnimplementedAuditLogServer) Verify(context.Context, *VerifyAuditRequest) (*AuditVerification, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Verify not implemented")
+}
+func (UnimplementedAuditLogServer) mustEmbedUnimplementedAuditLogServer() {}
+func (UnimplementedAuditLogServer) testEmbeddedByValue()                  {}
+
+// UnsafeAuditLogServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AuditLogServer will
+// result in compilation errors.
+type UnsafeAuditLogServer interface {
+	mustEmbedUnimplementedAuditLogServer()
+}
+
+func RegisterAuditLogServer(s grpc.ServiceRegistrar, srv AuditLogServer) {
+	// If the following call pancis, it indicates UnimplementedAuditLogServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AuditLog_ServiceDesc, srv)
+}
+
+func _AuditLog_Query_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuditQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuditLogServer).Query(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuditLog_Query_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuditLogServer).Query(ctx, req.(*AuditQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuditLog_Verify_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyAuditRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuditLogServer).Verify(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuditLog_Verify_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuditLogServer).Verify(ctx, req.(*VerifyAuditRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AuditLog_ServiceDesc is the grpc.ServiceDesc for AuditLog service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AuditLog_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "logs.AuditLog",
+	HandlerType: (*AuditLogServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Query",
+			Handler:    _AuditLog_Query_Handler,
+		},
+		{
+			MethodName: "Verify",
+			Handler:    _AuditLog_Verify_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "app/sdk/proto/mlog/logs.proto",
+}
+
+const (
+	Quota_Usage_FullMethodName = "/logs.Quota/Usage"
+)
+
+// QuotaClient is the client API for Quota service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Serviço de consulta dos limites de taxa e cotas
+type QuotaClient interface {
+	// Retorna os limites e o consumo atual
+	Usage(ctx context.Context, in *UsageRequest, opts ...grpc.CallOption) (*UsageReports, error)
+}
+
+type quotaClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewQuotaClient(cc grpc.ClientConnInterface) QuotaClient {
+	return &quotaClient{cc}
+}
+
+func (c *quotaClient) Usage(ctx context.Context, in *UsageRequest, opts ...grpc.CallOption) (*UsageReports, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UsageReports)
+	err := c.cc.Invoke(ctx, Quota_Usage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// QuotaServer is the server API for Quota service.
+// All implementations must embed UnimplementedQuotaServer
+// for forward compatibility.
+//
+// Serviço de consulta dos limites de taxa e cotas
+type QuotaServer interface {
+	// Retorna os limites e o consumo atual
+	Usage(context.Context, *UsageRequest) (*UsageReports, error)
+	mustEmbedUnimplementedQuotaServer()
+}
+
+// UnimplementedQuotaServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedQuotaServer struct{}
+
+func (UnimplementedQuotaServer) Usage(context.Context, *UsageRequest) (*UsageReports, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Usage not implemented")
+}
+func (UnimplementedQuotaServer) mustEmbedUnimplementedQuotaServer() {}
+func (UnimplementedQuotaServer) testEmbeddedByValue()               {}
+
+// UnsafeQuotaServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to QuotaServer will
+// result in compilation errors.
+type UnsafeQuotaServer interface {
+	mustEmbedUnimplementedQuotaServer()
+}
+
+func RegisterQuotaServer(s grpc.ServiceRegistrar, srv QuotaServer) {
+	// If the following call pancis, it indicates UnimplementedQuotaServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Quota_ServiceDesc, srv)
+}
+
+func _Quota_Usage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UsageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuotaServer).Usage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Quota_Usage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuotaServer).Usage(ctx, req.(*UsageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Quota_ServiceDesc is the grpc.ServiceDesc for Quota service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Quota_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "logs.Quota",
+	HandlerType: (*QuotaServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Usage",
+			Handler:    _Quota_Usage_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "app/sdk/proto/mlog/logs.proto",
+}
+
+const (
+	LogChain_Verify_FullMethodName = "/logs.LogChain/Verify"
+)
+
+// LogChainClient is the client API for LogChain service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Serviço de verificação da cadeia de hashes dos logs
+type LogChainClient interface {
+	// Percorre a cadeia no intervalo e reporta lacunas e alterações
+	Verify(ctx context.Context, in *VerifyChainRequest, opts ...grpc.CallOption) (*ChainVerification, error)
+}
+
+type logChainClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLogChainClient(cc grpc.ClientConnInterface) LogChainClient {
+	return &logChainClient{cc}
+}
+
+func (c *logChainClient) Verify(ctx context.Context, in *VerifyChainRequest, opts ...grpc.CallOption) (*ChainVerification, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ChainVerification)
+	err := c.cc.Invoke(ctx, LogChain_Verify_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LogChainServer is the server API for LogChain service.
+// All implementations must embed UnimplementedLogChainServer
+// for forward compatibility.
+//
+// Serviço de verificação da cadeia de hashes dos logs
+type LogChainServer interface {
+	// Percorre a cadeia no intervalo e reporta lacunas e alterações
+	Verify(context.Context, *VerifyChainRequest) (*ChainVerification, error)
+	mustEmbedUnimplementedLogChainServer()
+}
+
+// UnimplementedLogChainServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLogChainServer struct{}
+
+func (UnimplementedLogChainServer) Verify(context.Context, *VerifyChainRequest) (*ChainVerification, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Verify not implemented")
+}
+func (UnimplementedLogChainServer) mustEmbedUnimplementedLogChainServer() {}
+func (UnimplementedLogChainServer) testEmbeddedByValue()                  {}
+
+// UnsafeLogChainServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LogChainServer will
+// result in compilation errors.
+type UnsafeLogChainServer interface {
+	mustEmbedUnimplementedLogChainServer()
+}
+
+func RegisterLogChainServer(s grpc.ServiceRegistrar, srv LogChainServer) {
+	// If the following call pancis, it indicates UnimplementedLogChainServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&LogChain_ServiceDesc, srv)
+}
+
+func _LogChain_Verify_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyChainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogChainServer).Verify(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LogChain_Verify_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogChainServer).Verify(ctx, req.(*VerifyChainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// LogChain_ServiceDesc is the grpc.ServiceDesc for LogChain service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LogChain_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "logs.LogChain",
+	HandlerType: (*LogChainServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Verify",
+			Handler:    _LogChain_Verify_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "app/sdk/proto/mlog/logs.proto",
+}
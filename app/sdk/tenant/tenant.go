@@ -0,0 +1,91 @@
+// Package tenant resolves the tenant of each gRPC call and carries it in the
+// call's context, where mlog.Business picks it up.
+package tenant
+
+import (
+	"context"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Header is the metadata key clients name their tenant with.
+const Header = "x-tenant-id"
+
+// FromMetadata returns the tenant named in the incoming metadata of ctx, or
+// "" when there is none.
+func FromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	if values := md.Get(Header); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+type claimedKey struct{}
+
+// Claimed reports whether the tenant of ctx was only named in the tenant
+// header, rather than set from the caller's credentials.
+func Claimed(ctx context.Context) bool {
+	claimed, _ := ctx.Value(claimedKey{}).(bool)
+	return claimed
+}
+
+// resolve returns ctx carrying the tenant of the call. A tenant already in
+// ctx, set from the caller's credentials, wins over the header.
+func resolve(ctx context.Context) (context.Context, error) {
+	if mlog.TenantFrom(ctx) != "" {
+		return ctx, nil
+	}
+
+	tenant := FromMetadata(ctx)
+	if tenant == "" {
+		return ctx, nil
+	}
+
+	if err := mlog.ValidateTenant(tenant); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	ctx = context.WithValue(ctx, claimedKey{}, true)
+	return mlog.ContextWithTenant(ctx, tenant), nil
+}
+
+// UnaryServerInterceptor resolves the tenant of unary calls.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := resolve(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor resolves the tenant of streaming calls.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := resolve(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// serverStream replaces the context of a grpc.ServerStream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
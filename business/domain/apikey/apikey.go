@@ -0,0 +1,271 @@
+// Package apikey manages the API keys clients authenticate with. Secrets are
+// shown once, when a key is created or rotated; only their SHA-256 hash is
+// stored.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/foundation/logger"
+	"github.com/oklog/ulid/v2"
+)
+
+var (
+	ErrInvalidKey      = errors.New("invalid api key")
+	ErrKeyNotFound     = errors.New("api key not found")
+	ErrKeyRevoked      = errors.New("api key is revoked")
+	ErrUnauthenticated = errors.New("missing or unknown api key")
+)
+
+// secretPrefix marks secrets so they are easy to spot in leaked text.
+const secretPrefix = "lhk_"
+
+type Storer interface {
+	Create(ctx context.Context, key Key) error
+	Update(ctx context.Context, key Key) error
+	QueryByID(ctx context.Context, id ulid.ULID) (Key, error)
+	// QueryByHash returns the key whose current or previous hash is hash.
+	QueryByHash(ctx context.Context, hash string) (Key, error)
+	Query(ctx context.Context, includeRevoked bool) ([]Key, error)
+}
+
+type Business struct {
+	logger logger.Logger
+	storer Storer
+	roles  []string
+}
+
+type Option func(*Business)
+
+// WithRoles lists the roles keys may be given. Without it, keys cannot have
+// a role.
+func WithRoles(roles []string) Option {
+	return func(b *Business) {
+		b.roles = roles
+	}
+}
+
+func NewBusiness(logger logger.Logger, storer Storer, opts ...Option) *Business {
+	b := Business{
+		logger: logger,
+		storer: storer,
+	}
+	for _, opt := range opts {
+		opt(&b)
+	}
+	return &b
+}
+
+// Hash returns the hash stored for secret.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return secretPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Create stores a new key and returns it with its secret. Callers bound to
+// a tenant or a role can only create keys of that tenant and role.
+func (b *Business) Create(ctx context.Context, nk NewKey) (Key, string, error) {
+	if tenant := mlog.TenantFrom(ctx); tenant != "" {
+		if nk.Tenant != "" && nk.Tenant != tenant {
+			return Key{}, "", fmt.Errorf("create key: %w: cannot create keys of another tenant", ErrInvalidKey)
+		}
+		nk.Tenant = tenant
+	}
+	if r, ok := mlog.RestrictionFrom(ctx); ok {
+		if nk.Role != "" && nk.Role != r.Role {
+			return Key{}, "", fmt.Errorf("create key: %w: cannot create keys of another role", ErrInvalidKey)
+		}
+		nk.Role = r.Role
+	}
+
+	if err := nk.Validate(); err != nil {
+		return Key{}, "", fmt.Errorf("create key: %w", err)
+	}
+	if nk.Tenant != "" {
+		if err := mlog.ValidateTenant(nk.Tenant); err != nil {
+			return Key{}, "", fmt.Errorf("create key: %w: %w", ErrInvalidKey, err)
+		}
+	}
+	if nk.Role != "" && !slices.Contains(b.roles, nk.Role) {
+		return Key{}, "", fmt.Errorf("create key: %w: unknown role %q", ErrInvalidKey, nk.Role)
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return Key{}, "", fmt.Errorf("create key: generating secret: %w", err)
+	}
+
+	key := Key{
+		ID:        ulid.Make(),
+		Name:      nk.Name,
+		Hash:      Hash(secret),
+		Scopes:    nk.Scopes,
+		Tenant:    nk.Tenant,
+		Role:      nk.Role,
+		CreatedBy: nk.CreatedBy,
+		CreatedAt: time.Now(),
+		ExpiresAt: nk.ExpiresAt,
+	}
+
+	if err := b.storer.Create(ctx, key); err != nil {
+		b.logger.Error(ctx, "failed to create api key", "error", err)
+		return Key{}, "", fmt.Errorf("create key: %w", err)
+	}
+
+	b.logger.Info(ctx, "api key created", "id", key.ID, "name", key.Name, "by", key.CreatedBy)
+
+	return key, secret, nil
+}
+
+// Rotate gives a key a new secret. The old secret keeps working for grace,
+// so clients can switch over without downtime.
+func (b *Business) Rotate(ctx context.Context, id ulid.ULID, grace time.Duration, actor string) (Key, string, error) {
+	key, err := b.key(ctx, id)
+	if err != nil {
+		return Key{}, "", fmt.Errorf("rotate key: %w", err)
+	}
+	if !key.RevokedAt.IsZero() {
+		return Key{}, "", fmt.Errorf("rotate key: %w", ErrKeyRevoked)
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return Key{}, "", fmt.Errorf("rotate key: generating secret: %w", err)
+	}
+
+	now := time.Now()
+	key.PreviousHash = key.Hash
+	key.PreviousUntil = now.Add(grace)
+	key.Hash = Hash(secret)
+	key.RotatedAt = now
+
+	if err := b.storer.Update(ctx, key); err != nil {
+		b.logger.Error(ctx, "failed to rotate api key", "error", err)
+		return Key{}, "", fmt.Errorf("rotate key: %w", err)
+	}
+
+	b.logger.Info(ctx, "api key rotated", "id", key.ID, "name", key.Name, "by", actor, "grace", grace)
+
+	return key, secret, nil
+}
+
+// Revoke disables a key for good, along with any secret it had.
+func (b *Business) Revoke(ctx context.Context, id ulid.ULID, actor string) (Key, error) {
+	key, err := b.key(ctx, id)
+	if err != nil {
+		return Key{}, fmt.Errorf("revoke key: %w", err)
+	}
+	if !key.RevokedAt.IsZero() {
+		return key, nil
+	}
+
+	key.RevokedBy = actor
+	key.RevokedAt = time.Now()
+
+	if err := b.storer.Update(ctx, key); err != nil {
+		b.logger.Error(ctx, "failed to revoke api key", "error", err)
+		return Key{}, fmt.Errorf("revoke key: %w", err)
+	}
+
+	b.logger.Info(ctx, "api key revoked", "id", key.ID, "name", key.Name, "by", actor)
+
+	return key, nil
+}
+
+// Keys lists the keys visible to the caller: all of them, or those of its
+// tenant and role.
+func (b *Business) Keys(ctx context.Context, includeRevoked bool) ([]Key, error) {
+	keys, err := b.storer.Query(ctx, includeRevoked)
+	if err != nil {
+		b.logger.Error(ctx, "failed to list api keys", "error", err)
+		return nil, fmt.Errorf("keys: %w", err)
+	}
+
+	visible := keys[:0]
+	for _, k := range keys {
+		if manages(ctx, k) {
+			visible = append(visible, k)
+		}
+	}
+	return visible, nil
+}
+
+// Authenticate returns the active key opened by secret.
+func (b *Business) Authenticate(ctx context.Context, secret string) (Key, error) {
+	if secret == "" {
+		return Key{}, ErrUnauthenticated
+	}
+
+	key, err := b.storer.QueryByHash(ctx, Hash(secret))
+	if err != nil {
+		if errors.Is(err, ErrKeyNotFound) {
+			return Key{}, ErrUnauthenticated
+		}
+		return Key{}, fmt.Errorf("authenticate: %w", err)
+	}
+
+	now := time.Now()
+	if !key.Active(now) || !key.Matches(Hash(secret), now) {
+		return Key{}, ErrUnauthenticated
+	}
+
+	return key, nil
+}
+
+// key loads a key the caller may manage. Keys of other tenants and roles
+// are reported as not found.
+func (b *Business) key(ctx context.Context, id ulid.ULID) (Key, error) {
+	key, err := b.storer.QueryByID(ctx, id)
+	if err != nil {
+		return Key{}, err
+	}
+
+	if !manages(ctx, key) {
+		return Key{}, ErrKeyNotFound
+	}
+
+	return key, nil
+}
+
+// manages reports whether the caller of ctx may see and manage key: callers
+// bound to a tenant or a role only manage keys of that tenant and role.
+func manages(ctx context.Context, key Key) bool {
+	if tenant := mlog.TenantFrom(ctx); tenant != "" && key.Tenant != tenant {
+		return false
+	}
+	if r, ok := mlog.RestrictionFrom(ctx); ok && key.Role != r.Role {
+		return false
+	}
+	return true
+}
+
+type keyCtxKey struct{}
+
+// ContextWithKey returns a copy of ctx carrying the key the call was
+// authenticated with.
+func ContextWithKey(ctx context.Context, key Key) context.Context {
+	return context.WithValue(ctx, keyCtxKey{}, key)
+}
+
+// KeyFrom returns the key carried by ctx.
+func KeyFrom(ctx context.Context) (Key, bool) {
+	key, ok := ctx.Value(keyCtxKey{}).(Key)
+	return key, ok
+}
@@ -0,0 +1,274 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/oklog/ulid/v2"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(context.Context, string, ...interface{})  {}
+func (nopLogger) Error(context.Context, string, ...interface{}) {}
+
+// memStore keeps keys in memory.
+type memStore struct {
+	mu   sync.Mutex
+	keys map[ulid.ULID]Key
+}
+
+func newMemStore() *memStore {
+	return &memStore{keys: make(map[ulid.ULID]Key)}
+}
+
+func (s *memStore) Create(_ context.Context, key Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[key.ID] = key
+	return nil
+}
+
+func (s *memStore) Update(_ context.Context, key Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.keys[key.ID]; !ok {
+		return ErrKeyNotFound
+	}
+	s.keys[key.ID] = key
+	return nil
+}
+
+func (s *memStore) QueryByID(_ context.Context, id ulid.ULID) (Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[id]
+	if !ok {
+		return Key{}, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+func (s *memStore) QueryByHash(_ context.Context, hash string) (Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range s.keys {
+		if key.Hash == hash || key.PreviousHash == hash {
+			return key, nil
+		}
+	}
+	return Key{}, ErrKeyNotFound
+}
+
+func (s *memStore) Query(_ context.Context, includeRevoked bool) ([]Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []Key
+	for _, key := range s.keys {
+		if includeRevoked || key.RevokedAt.IsZero() {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func newTestBusiness() *Business {
+	return NewBusiness(nopLogger{}, newMemStore(), WithRoles([]string{"support", "auditor"}))
+}
+
+func createKey(t *testing.T, ctx context.Context, b *Business, nk NewKey) (Key, string) {
+	t.Helper()
+
+	if nk.Name == "" {
+		nk.Name = "test"
+	}
+	if nk.Scopes == nil {
+		nk.Scopes = []Scope{ScopeRead}
+	}
+
+	key, secret, err := b.Create(ctx, nk)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return key, secret
+}
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []Scope
+		scope  Scope
+		want   bool
+	}{
+		{name: "held", scopes: []Scope{ScopeRead}, scope: ScopeRead, want: true},
+		{name: "missing", scopes: []Scope{ScopeRead}, scope: ScopeWrite, want: false},
+		{name: "export is not read", scopes: []Scope{ScopeExport}, scope: ScopeRead, want: false},
+		{name: "admin grants write", scopes: []Scope{ScopeAdmin}, scope: ScopeWrite, want: true},
+		{name: "admin grants export", scopes: []Scope{ScopeAdmin}, scope: ScopeExport, want: true},
+		{name: "no scopes", scopes: nil, scope: ScopeRead, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Key{Scopes: tt.scopes}).Allows(tt.scope); got != tt.want {
+				t.Errorf("Allows(%s) = %v, want %v", tt.scope, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		nk   NewKey
+	}{
+		{name: "no name", nk: NewKey{Scopes: []Scope{ScopeRead}}},
+		{name: "no scopes", nk: NewKey{Name: "ci"}},
+		{name: "unknown scope", nk: NewKey{Name: "ci", Scopes: []Scope{"logs:delete"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.nk.Validate(); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("Validate = %v, want %v", err, ErrInvalidKey)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	b := newTestBusiness()
+
+	key, secret := createKey(t, ctx, b, NewKey{})
+	got, err := b.Authenticate(ctx, secret)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if got.ID != key.ID {
+		t.Errorf("authenticated key %s, want %s", got.ID, key.ID)
+	}
+
+	_, expiredSecret := createKey(t, ctx, b, NewKey{ExpiresAt: time.Now().Add(-time.Minute)})
+	revoked, revokedSecret := createKey(t, ctx, b, NewKey{})
+	if _, err := b.Revoke(ctx, revoked.ID, "admin"); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+
+	for name, secret := range map[string]string{
+		"empty":   "",
+		"unknown": "lhk_unknown",
+		"expired": expiredSecret,
+		"revoked": revokedSecret,
+	} {
+		if _, err := b.Authenticate(ctx, secret); !errors.Is(err, ErrUnauthenticated) {
+			t.Errorf("Authenticate %s key = %v, want %v", name, err, ErrUnauthenticated)
+		}
+	}
+}
+
+// The secret a rotation replaces keeps working until its grace ends.
+func TestRotate(t *testing.T) {
+	ctx := context.Background()
+	b := newTestBusiness()
+
+	key, old := createKey(t, ctx, b, NewKey{})
+	if _, secret, err := b.Rotate(ctx, key.ID, time.Hour, "admin"); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	} else if _, err := b.Authenticate(ctx, secret); err != nil {
+		t.Errorf("Authenticate with the new secret: %v", err)
+	}
+	if _, err := b.Authenticate(ctx, old); err != nil {
+		t.Errorf("Authenticate with the old secret in its grace: %v", err)
+	}
+
+	key, old = createKey(t, ctx, b, NewKey{})
+	if _, _, err := b.Rotate(ctx, key.ID, 0, "admin"); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if _, err := b.Authenticate(ctx, old); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("Authenticate with the old secret past its grace = %v, want %v", err, ErrUnauthenticated)
+	}
+
+	if _, err := b.Revoke(ctx, key.ID, "admin"); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if _, _, err := b.Rotate(ctx, key.ID, time.Hour, "admin"); !errors.Is(err, ErrKeyRevoked) {
+		t.Errorf("Rotate revoked key = %v, want %v", err, ErrKeyRevoked)
+	}
+}
+
+// Callers bound to a tenant create keys of their tenant only, and neither
+// see nor manage the keys of others.
+func TestTenantBoundCaller(t *testing.T) {
+	b := newTestBusiness()
+	ctx := context.Background()
+	acme := mlog.ContextWithTenant(ctx, "acme")
+
+	global, _ := createKey(t, ctx, b, NewKey{Name: "global"})
+	other, _ := createKey(t, ctx, b, NewKey{Name: "globex", Tenant: "globex"})
+
+	own, _ := createKey(t, acme, b, NewKey{Name: "acme"})
+	if own.Tenant != "acme" {
+		t.Errorf("created key tenant = %q, want acme", own.Tenant)
+	}
+	if _, _, err := b.Create(acme, NewKey{Name: "x", Scopes: []Scope{ScopeRead}, Tenant: "globex"}); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Create key of another tenant = %v, want %v", err, ErrInvalidKey)
+	}
+
+	keys, err := b.Keys(acme, true)
+	if err != nil {
+		t.Fatalf("Keys: %v", err)
+	}
+	if len(keys) != 1 || keys[0].ID != own.ID {
+		t.Errorf("tenant caller sees %d keys, want its own only", len(keys))
+	}
+
+	for _, key := range []Key{global, other} {
+		if _, err := b.Revoke(acme, key.ID, "acme-admin"); !errors.Is(err, ErrKeyNotFound) {
+			t.Errorf("Revoke %s key = %v, want %v", key.Name, err, ErrKeyNotFound)
+		}
+		if _, _, err := b.Rotate(acme, key.ID, 0, "acme-admin"); !errors.Is(err, ErrKeyNotFound) {
+			t.Errorf("Rotate %s key = %v, want %v", key.Name, err, ErrKeyNotFound)
+		}
+	}
+	if _, err := b.Revoke(acme, own.ID, "acme-admin"); err != nil {
+		t.Errorf("Revoke own key: %v", err)
+	}
+
+	keys, err = b.Keys(ctx, true)
+	if err != nil {
+		t.Fatalf("Keys: %v", err)
+	}
+	if len(keys) != 3 {
+		t.Errorf("unbound caller sees %d keys, want 3", len(keys))
+	}
+}
+
+// Callers restricted by a role create and manage keys of that role only,
+// and roles must be known.
+func TestRoleBoundCaller(t *testing.T) {
+	b := newTestBusiness()
+	ctx := context.Background()
+	support := mlog.ContextWithRestriction(ctx, mlog.Restriction{Role: "support"})
+
+	key, _ := createKey(t, support, b, NewKey{})
+	if key.Role != "support" {
+		t.Errorf("created key role = %q, want support", key.Role)
+	}
+	if _, _, err := b.Create(support, NewKey{Name: "x", Scopes: []Scope{ScopeRead}, Role: "auditor"}); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Create key of another role = %v, want %v", err, ErrInvalidKey)
+	}
+	if _, _, err := b.Create(ctx, NewKey{Name: "x", Scopes: []Scope{ScopeRead}, Role: "owner"}); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Create key of an unknown role = %v, want %v", err, ErrInvalidKey)
+	}
+
+	auditor, _ := createKey(t, ctx, b, NewKey{Role: "auditor"})
+	if _, err := b.Revoke(support, auditor.ID, "support"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Revoke key of another role = %v, want %v", err, ErrKeyNotFound)
+	}
+}
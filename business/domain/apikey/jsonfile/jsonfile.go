@@ -0,0 +1,246 @@
+// Package jsonfile implements an apikey.Storer backed by a JSON file, for
+// stores without a database and for keys provisioned by configuration.
+//
+// Operators may write entries by hand with only name, hash, scopes, tenant
+// and role set, where hash is the hex SHA-256 of the secret. Such entries are
+// given an ID and a creation time when the file is opened.
+package jsonfile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/apikey"
+	"github.com/oklog/ulid/v2"
+)
+
+type fileKey struct {
+	ID            string         `json:"id,omitempty"`
+	Name          string         `json:"name"`
+	Hash          string         `json:"hash"`
+	Scopes        []apikey.Scope `json:"scopes"`
+	Tenant        string         `json:"tenant,omitempty"`
+	Role          string         `json:"role,omitempty"`
+	CreatedBy     string         `json:"created_by,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	ExpiresAt     *time.Time     `json:"expires_at,omitempty"`
+	PreviousHash  string         `json:"previous_hash,omitempty"`
+	PreviousUntil *time.Time     `json:"previous_until,omitempty"`
+	RotatedAt     *time.Time     `json:"rotated_at,omitempty"`
+	RevokedBy     string         `json:"revoked_by,omitempty"`
+	RevokedAt     *time.Time     `json:"revoked_at,omitempty"`
+}
+
+type Store struct {
+	path string
+
+	mu   sync.RWMutex
+	keys []apikey.Key
+}
+
+// Open loads the keys of the file at path, which need not exist yet.
+func Open(path string) (*Store, error) {
+	s := Store{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading keys: %w", err)
+	}
+
+	var entries []fileKey
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("decoding keys: %w", err)
+	}
+
+	provisioned := false
+	for _, e := range entries {
+		if e.ID == "" {
+			e.ID = ulid.Make().String()
+			e.CreatedAt = time.Now()
+			provisioned = true
+		}
+
+		key, err := toKey(e)
+		if err != nil {
+			return nil, err
+		}
+		s.keys = append(s.keys, key)
+	}
+
+	if provisioned {
+		if err := s.save(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &s, nil
+}
+
+func (s *Store) Create(ctx context.Context, key apikey.Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys = append(s.keys, key)
+	if err := s.save(); err != nil {
+		s.keys = s.keys[:len(s.keys)-1]
+		return err
+	}
+	return nil
+}
+
+func (s *Store) Update(ctx context.Context, key apikey.Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, k := range s.keys {
+		if k.ID == key.ID {
+			s.keys[i] = key
+			if err := s.save(); err != nil {
+				s.keys[i] = k
+				return err
+			}
+			return nil
+		}
+	}
+	return apikey.ErrKeyNotFound
+}
+
+func (s *Store) QueryByID(ctx context.Context, id ulid.ULID) (apikey.Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, k := range s.keys {
+		if k.ID == id {
+			return k, nil
+		}
+	}
+	return apikey.Key{}, apikey.ErrKeyNotFound
+}
+
+func (s *Store) QueryByHash(ctx context.Context, hash string) (apikey.Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, k := range s.keys {
+		if k.Hash == hash || k.PreviousHash == hash {
+			return k, nil
+		}
+	}
+	return apikey.Key{}, apikey.ErrKeyNotFound
+}
+
+func (s *Store) Query(ctx context.Context, includeRevoked bool) ([]apikey.Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var keys []apikey.Key
+	for _, k := range s.keys {
+		if includeRevoked || k.RevokedAt.IsZero() {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ID.Compare(keys[j].ID) < 0
+	})
+
+	return keys, nil
+}
+
+// save replaces the file atomically. The caller holds mu.
+func (s *Store) save() error {
+	entries := make([]fileKey, len(s.keys))
+	for i, k := range s.keys {
+		entries[i] = toFileKey(k)
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding keys: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("writing keys: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("replacing keys: %w", err)
+	}
+
+	return nil
+}
+
+func toKey(e fileKey) (apikey.Key, error) {
+	id, err := ulid.Parse(e.ID)
+	if err != nil {
+		return apikey.Key{}, fmt.Errorf("parsing id of key %q: %w", e.Name, err)
+	}
+
+	return apikey.Key{
+		ID:            id,
+		Name:          e.Name,
+		Hash:          e.Hash,
+		Scopes:        e.Scopes,
+		Tenant:        e.Tenant,
+		Role:          e.Role,
+		CreatedBy:     e.CreatedBy,
+		CreatedAt:     e.CreatedAt,
+		ExpiresAt:     fromPtr(e.ExpiresAt),
+		PreviousHash:  e.PreviousHash,
+		PreviousUntil: fromPtr(e.PreviousUntil),
+		RotatedAt:     fromPtr(e.RotatedAt),
+		RevokedBy:     e.RevokedBy,
+		RevokedAt:     fromPtr(e.RevokedAt),
+	}, nil
+}
+
+func toFileKey(k apikey.Key) fileKey {
+	return fileKey{
+		ID:            k.ID.String(),
+		Name:          k.Name,
+		Hash:          k.Hash,
+		Scopes:        k.Scopes,
+		Tenant:        k.Tenant,
+		Role:          k.Role,
+		CreatedBy:     k.CreatedBy,
+		CreatedAt:     k.CreatedAt,
+		ExpiresAt:     toPtr(k.ExpiresAt),
+		PreviousHash:  k.PreviousHash,
+		PreviousUntil: toPtr(k.PreviousUntil),
+		RotatedAt:     toPtr(k.RotatedAt),
+		RevokedBy:     k.RevokedBy,
+		RevokedAt:     toPtr(k.RevokedAt),
+	}
+}
+
+// toPtr returns nil for the zero time, so unset times are left out of the
+// file.
+func toPtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func fromPtr(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+var _ apikey.Storer = (*Store)(nil)
@@ -0,0 +1,94 @@
+package apikey
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// Scope grants access to a group of calls.
+type Scope string
+
+const (
+	ScopeWrite  Scope = "logs:write"
+	ScopeRead   Scope = "logs:read"
+	ScopeExport Scope = "logs:export"
+	// ScopeAdmin grants every other scope, along with the admin calls.
+	ScopeAdmin Scope = "admin"
+)
+
+var scopes = []Scope{ScopeWrite, ScopeRead, ScopeExport, ScopeAdmin}
+
+func (s Scope) IsValid() bool {
+	return slices.Contains(scopes, s)
+}
+
+// Key is an API key. Only the SHA-256 hash of its secret is kept.
+type Key struct {
+	ID     ulid.ULID
+	Name   string
+	Hash   string
+	Scopes []Scope
+	// Tenant, when set, binds every call made with the key to that tenant.
+	Tenant string
+	// Role, when set, names the role restricting what the key reads.
+	Role      string
+	CreatedBy string
+	CreatedAt time.Time
+	// ExpiresAt is zero for keys that never expire.
+	ExpiresAt time.Time
+	// PreviousHash is the hash replaced by the last rotation, still
+	// accepted until PreviousUntil.
+	PreviousHash  string
+	PreviousUntil time.Time
+	RotatedAt     time.Time
+	RevokedBy     string
+	RevokedAt     time.Time
+}
+
+func (k Key) Active(now time.Time) bool {
+	if !k.RevokedAt.IsZero() {
+		return false
+	}
+	return k.ExpiresAt.IsZero() || now.Before(k.ExpiresAt)
+}
+
+// Allows reports whether the key grants scope.
+func (k Key) Allows(scope Scope) bool {
+	return slices.Contains(k.Scopes, ScopeAdmin) || slices.Contains(k.Scopes, scope)
+}
+
+// Matches reports whether hash opens the key at now.
+func (k Key) Matches(hash string, now time.Time) bool {
+	if hash == k.Hash {
+		return true
+	}
+	return k.PreviousHash != "" && hash == k.PreviousHash && now.Before(k.PreviousUntil)
+}
+
+// NewKey holds what a caller chooses about a key when creating it.
+type NewKey struct {
+	Name      string
+	Scopes    []Scope
+	Tenant    string
+	Role      string
+	ExpiresAt time.Time
+	CreatedBy string
+}
+
+func (n NewKey) Validate() error {
+	if n.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidKey)
+	}
+	if len(n.Scopes) == 0 {
+		return fmt.Errorf("%w: at least one scope is required", ErrInvalidKey)
+	}
+	for _, s := range n.Scopes {
+		if !s.IsValid() {
+			return fmt.Errorf("%w: unknown scope %q", ErrInvalidKey, s)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+// Package mongodb implements an apikey.Storer backed by a MongoDB
+// collection.
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/apikey"
+	"github.com/oklog/ulid/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type dbKey struct {
+	ID            ulid.ULID      `bson:"id"`
+	Name          string         `bson:"name"`
+	Hash          string         `bson:"hash"`
+	Scopes        []apikey.Scope `bson:"scopes"`
+	Tenant        string         `bson:"tenant,omitempty"`
+	Role          string         `bson:"role,omitempty"`
+	CreatedBy     string         `bson:"createdby,omitempty"`
+	CreatedAt     time.Time      `bson:"createdat"`
+	ExpiresAt     time.Time      `bson:"expiresat,omitempty"`
+	PreviousHash  string         `bson:"previoushash,omitempty"`
+	PreviousUntil time.Time      `bson:"previousuntil,omitempty"`
+	RotatedAt     time.Time      `bson:"rotatedat,omitempty"`
+	RevokedBy     string         `bson:"revokedby,omitempty"`
+	RevokedAt     time.Time      `bson:"revokedat,omitempty"`
+}
+
+type Store struct {
+	collection *mongo.Collection
+}
+
+// NewStore returns a store keeping keys in the named collection of db.
+func NewStore(ctx context.Context, db *mongo.Database, collectionName string) (*Store, error) {
+	collection := db.Collection(collectionName)
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "previoushash", Value: 1}}, Options: options.Index().SetSparse(true)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating api key indexes: %w", err)
+	}
+
+	return &Store{collection: collection}, nil
+}
+
+func (s *Store) Create(ctx context.Context, key apikey.Key) error {
+	_, err := s.collection.InsertOne(ctx, toDBKey(key))
+	return err
+}
+
+func (s *Store) Update(ctx context.Context, key apikey.Key) error {
+	res, err := s.collection.ReplaceOne(ctx, bson.M{"id": key.ID}, toDBKey(key))
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return apikey.ErrKeyNotFound
+	}
+	return nil
+}
+
+func (s *Store) QueryByID(ctx context.Context, id ulid.ULID) (apikey.Key, error) {
+	return s.queryOne(ctx, bson.M{"id": id})
+}
+
+func (s *Store) QueryByHash(ctx context.Context, hash string) (apikey.Key, error) {
+	return s.queryOne(ctx, bson.M{"$or": bson.A{
+		bson.M{"hash": hash},
+		bson.M{"previoushash": hash},
+	}})
+}
+
+func (s *Store) Query(ctx context.Context, includeRevoked bool) ([]apikey.Key, error) {
+	filter := bson.M{}
+	if !includeRevoked {
+		filter["revokedat"] = bson.M{"$exists": false}
+	}
+
+	cursor, err := s.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []dbKey
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	keys := make([]apikey.Key, len(docs))
+	for i, doc := range docs {
+		keys[i] = toCoreKey(doc)
+	}
+
+	return keys, nil
+}
+
+func (s *Store) queryOne(ctx context.Context, filter bson.M) (apikey.Key, error) {
+	var doc dbKey
+	if err := s.collection.FindOne(ctx, filter).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apikey.Key{}, apikey.ErrKeyNotFound
+		}
+		return apikey.Key{}, err
+	}
+	return toCoreKey(doc), nil
+}
+
+func toDBKey(k apikey.Key) dbKey {
+	return dbKey{
+		ID:            k.ID,
+		Name:          k.Name,
+		Hash:          k.Hash,
+		Scopes:        k.Scopes,
+		Tenant:        k.Tenant,
+		Role:          k.Role,
+		CreatedBy:     k.CreatedBy,
+		CreatedAt:     k.CreatedAt,
+		ExpiresAt:     k.ExpiresAt,
+		PreviousHash:  k.PreviousHash,
+		PreviousUntil: k.PreviousUntil,
+		RotatedAt:     k.RotatedAt,
+		RevokedBy:     k.RevokedBy,
+		RevokedAt:     k.RevokedAt,
+	}
+}
+
+func toCoreKey(doc dbKey) apikey.Key {
+	return apikey.Key{
+		ID:            doc.ID,
+		Name:          doc.Name,
+		Hash:          doc.Hash,
+		Scopes:        doc.Scopes,
+		Tenant:        doc.Tenant,
+		Role:          doc.Role,
+		CreatedBy:     doc.CreatedBy,
+		CreatedAt:     doc.CreatedAt,
+		ExpiresAt:     doc.ExpiresAt,
+		PreviousHash:  doc.PreviousHash,
+		PreviousUntil: doc.PreviousUntil,
+		RotatedAt:     doc.RotatedAt,
+		RevokedBy:     doc.RevokedBy,
+		RevokedAt:     doc.RevokedAt,
+	}
+}
+
+var _ apikey.Storer = (*Store)(nil)
@@ -0,0 +1,146 @@
+// Package postgres implements an apikey.Storer on the api_keys table of the
+// PostgreSQL log store, which creates the table in its migrations.
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/apikey"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+)
+
+const keyColumns = `id, name, hash, scopes, tenant, role, created_by, created_at, expires_at,
+	previous_hash, previous_until, rotated_at, revoked_by, revoked_at`
+
+type Store struct {
+	pool *pgxpool.Pool
+}
+
+func NewStore(pool *pgxpool.Pool) *Store {
+	return &Store{pool: pool}
+}
+
+func (s *Store) Create(ctx context.Context, key apikey.Key) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO api_keys (`+keyColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
+		key.ID.String(), key.Name, key.Hash, scopeStrings(key.Scopes), key.Tenant, key.Role, key.CreatedBy, key.CreatedAt, nullTime(key.ExpiresAt),
+		key.PreviousHash, nullTime(key.PreviousUntil), nullTime(key.RotatedAt), key.RevokedBy, nullTime(key.RevokedAt),
+	)
+	return err
+}
+
+func (s *Store) Update(ctx context.Context, key apikey.Key) error {
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE api_keys SET name = $1, hash = $2, scopes = $3, tenant = $4, role = $5, expires_at = $6,
+			previous_hash = $7, previous_until = $8, rotated_at = $9, revoked_by = $10, revoked_at = $11
+		WHERE id = $12`,
+		key.Name, key.Hash, scopeStrings(key.Scopes), key.Tenant, key.Role, nullTime(key.ExpiresAt),
+		key.PreviousHash, nullTime(key.PreviousUntil), nullTime(key.RotatedAt), key.RevokedBy, nullTime(key.RevokedAt),
+		key.ID.String(),
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return apikey.ErrKeyNotFound
+	}
+	return nil
+}
+
+func (s *Store) QueryByID(ctx context.Context, id ulid.ULID) (apikey.Key, error) {
+	row := s.pool.QueryRow(ctx, `SELECT `+keyColumns+` FROM api_keys WHERE id = $1`, id.String())
+	return scanKey(row)
+}
+
+func (s *Store) QueryByHash(ctx context.Context, hash string) (apikey.Key, error) {
+	row := s.pool.QueryRow(ctx, `SELECT `+keyColumns+` FROM api_keys WHERE hash = $1 OR previous_hash = $1 LIMIT 1`, hash)
+	return scanKey(row)
+}
+
+func (s *Store) Query(ctx context.Context, includeRevoked bool) ([]apikey.Key, error) {
+	query := `SELECT ` + keyColumns + ` FROM api_keys`
+	if !includeRevoked {
+		query += ` WHERE revoked_at IS NULL`
+	}
+	query += ` ORDER BY id`
+
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []apikey.Key
+	for rows.Next() {
+		key, err := scanKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func scanKey(row pgx.Row) (apikey.Key, error) {
+	var (
+		key                                            apikey.Key
+		id                                             string
+		scopes                                         []string
+		expiresAt, previousUntil, rotatedAt, revokedAt *time.Time
+	)
+	err := row.Scan(&id, &key.Name, &key.Hash, &scopes, &key.Tenant, &key.Role, &key.CreatedBy, &key.CreatedAt, &expiresAt,
+		&key.PreviousHash, &previousUntil, &rotatedAt, &key.RevokedBy, &revokedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return apikey.Key{}, apikey.ErrKeyNotFound
+	}
+	if err != nil {
+		return apikey.Key{}, err
+	}
+
+	key.ID, err = ulid.Parse(id)
+	if err != nil {
+		return apikey.Key{}, fmt.Errorf("parsing id %q: %w", id, err)
+	}
+
+	for _, s := range scopes {
+		key.Scopes = append(key.Scopes, apikey.Scope(s))
+	}
+
+	key.CreatedAt = key.CreatedAt.UTC()
+	key.ExpiresAt = fromNull(expiresAt)
+	key.PreviousUntil = fromNull(previousUntil)
+	key.RotatedAt = fromNull(rotatedAt)
+	key.RevokedAt = fromNull(revokedAt)
+
+	return key, nil
+}
+
+func scopeStrings(scopes []apikey.Scope) []string {
+	s := make([]string, len(scopes))
+	for i, scope := range scopes {
+		s[i] = string(scope)
+	}
+	return s
+}
+
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func fromNull(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.UTC()
+}
+
+var _ apikey.Storer = (*Store)(nil)
@@ -0,0 +1,156 @@
+// Package sqlite implements an apikey.Storer on the api_keys table of the
+// SQLite log store, which creates the table in its migrations.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/apikey"
+	"github.com/oklog/ulid/v2"
+)
+
+const keyColumns = `id, name, hash, scopes, tenant, role, created_by, created_at, expires_at,
+	previous_hash, previous_until, rotated_at, revoked_by, revoked_at`
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) Create(ctx context.Context, key apikey.Key) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO api_keys (`+keyColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		key.ID.String(), key.Name, key.Hash, joinScopes(key.Scopes), key.Tenant, key.Role, key.CreatedBy, nanos(key.CreatedAt), nanos(key.ExpiresAt),
+		key.PreviousHash, nanos(key.PreviousUntil), nanos(key.RotatedAt), key.RevokedBy, nanos(key.RevokedAt),
+	)
+	return err
+}
+
+func (s *Store) Update(ctx context.Context, key apikey.Key) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE api_keys SET name = ?, hash = ?, scopes = ?, tenant = ?, role = ?, expires_at = ?,
+			previous_hash = ?, previous_until = ?, rotated_at = ?, revoked_by = ?, revoked_at = ?
+		WHERE id = ?`,
+		key.Name, key.Hash, joinScopes(key.Scopes), key.Tenant, key.Role, nanos(key.ExpiresAt),
+		key.PreviousHash, nanos(key.PreviousUntil), nanos(key.RotatedAt), key.RevokedBy, nanos(key.RevokedAt),
+		key.ID.String(),
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return apikey.ErrKeyNotFound
+	}
+	return nil
+}
+
+func (s *Store) QueryByID(ctx context.Context, id ulid.ULID) (apikey.Key, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+keyColumns+` FROM api_keys WHERE id = ?`, id.String())
+	return scanKey(row)
+}
+
+func (s *Store) QueryByHash(ctx context.Context, hash string) (apikey.Key, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+keyColumns+` FROM api_keys WHERE hash = ? OR previous_hash = ? LIMIT 1`, hash, hash)
+	return scanKey(row)
+}
+
+func (s *Store) Query(ctx context.Context, includeRevoked bool) ([]apikey.Key, error) {
+	query := `SELECT ` + keyColumns + ` FROM api_keys`
+	if !includeRevoked {
+		query += ` WHERE revoked_at = 0`
+	}
+	query += ` ORDER BY id`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []apikey.Key
+	for rows.Next() {
+		key, err := scanKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanKey(row scanner) (apikey.Key, error) {
+	var (
+		key                                                     apikey.Key
+		id, scopes                                              string
+		createdAt, expiresAt, previousUntil, rotatedAt, revoked int64
+	)
+	err := row.Scan(&id, &key.Name, &key.Hash, &scopes, &key.Tenant, &key.Role, &key.CreatedBy, &createdAt, &expiresAt,
+		&key.PreviousHash, &previousUntil, &rotatedAt, &key.RevokedBy, &revoked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return apikey.Key{}, apikey.ErrKeyNotFound
+	}
+	if err != nil {
+		return apikey.Key{}, err
+	}
+
+	key.ID, err = ulid.Parse(id)
+	if err != nil {
+		return apikey.Key{}, fmt.Errorf("parsing id %q: %w", id, err)
+	}
+
+	for _, s := range strings.Split(scopes, ",") {
+		if s != "" {
+			key.Scopes = append(key.Scopes, apikey.Scope(s))
+		}
+	}
+
+	key.CreatedAt = fromNanos(createdAt)
+	key.ExpiresAt = fromNanos(expiresAt)
+	key.PreviousUntil = fromNanos(previousUntil)
+	key.RotatedAt = fromNanos(rotatedAt)
+	key.RevokedAt = fromNanos(revoked)
+
+	return key, nil
+}
+
+func joinScopes(scopes []apikey.Scope) string {
+	s := make([]string, len(scopes))
+	for i, scope := range scopes {
+		s[i] = string(scope)
+	}
+	return strings.Join(s, ",")
+}
+
+func nanos(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func fromNanos(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n).UTC()
+}
+
+var _ apikey.Storer = (*Store)(nil)
@@ -0,0 +1,157 @@
+// Package audit keeps the trail of who read, exported or administered logs.
+// Entries are only ever appended, and each one carries the hash of the one
+// before it, so an entry altered or removed afterwards breaks the chain and
+// is reported by Verify. Servers may share a store: an entry links to the
+// newest one stored, and the store refuses a second entry linking to the
+// same one.
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/foundation/logger"
+	"github.com/oklog/ulid/v2"
+)
+
+var (
+	ErrNotFound = errors.New("audit entry not found")
+	// ErrConflict is returned by Append when the entry linked to already
+	// has a successor, recorded meanwhile by another server.
+	ErrConflict = errors.New("audit entry already has a successor")
+)
+
+const (
+	defaultPageSize = 100
+
+	// maxAttempts bounds how often Record links an entry to a newer head
+	// after losing it to another server.
+	maxAttempts = 5
+)
+
+type Storer interface {
+	// Append stores e, or returns ErrConflict when an entry with the same
+	// PrevHash is already stored.
+	Append(ctx context.Context, e Entry) error
+	// Last returns the newest entry, or ErrNotFound when there is none.
+	Last(ctx context.Context) (Entry, error)
+	// Query returns a page of the entries matching filter, newest first,
+	// and whether more follow.
+	Query(ctx context.Context, filter Filter, page, pageSize int) ([]Entry, bool, error)
+	// Scan calls fn with every entry, oldest first.
+	Scan(ctx context.Context, fn func(Entry) error) error
+}
+
+type Business struct {
+	logger logger.Logger
+	storer Storer
+
+	// mu serializes Record so the entries of this server do not race each
+	// other for the head of the trail.
+	mu sync.Mutex
+}
+
+func NewBusiness(logger logger.Logger, storer Storer) *Business {
+	return &Business{
+		logger: logger,
+		storer: storer,
+	}
+}
+
+// Record appends e to the trail, setting its ID, time and hashes. The head
+// of the trail is read for every entry, so entries recorded by other
+// servers sharing the store are linked to as well.
+func (b *Business) Record(ctx context.Context, e Entry) (Entry, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var err error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		var prevHash string
+		last, lastErr := b.storer.Last(ctx)
+		switch {
+		case lastErr == nil:
+			prevHash = last.Hash
+		case !errors.Is(lastErr, ErrNotFound):
+			return Entry{}, fmt.Errorf("record: reading last entry: %w", lastErr)
+		}
+
+		e.ID = ulid.Make()
+		e.Time = time.Now().UTC().Truncate(time.Millisecond)
+		e.PrevHash = prevHash
+		e.Hash = e.ComputeHash()
+
+		err = b.storer.Append(ctx, e)
+		if !errors.Is(err, ErrConflict) {
+			break
+		}
+	}
+	if err != nil {
+		b.logger.Error(ctx, "failed to record audit entry", "error", err, "method", e.Method)
+		return Entry{}, fmt.Errorf("record: %w", err)
+	}
+
+	return e, nil
+}
+
+// Query returns a page of the entries matching filter, newest first.
+// Callers bound to a tenant only see the entries of that tenant.
+func (b *Business) Query(ctx context.Context, filter Filter, page, pageSize int) ([]Entry, bool, error) {
+	if tenant := mlog.TenantFrom(ctx); tenant != "" {
+		filter.Tenant = tenant
+	}
+	if page < 0 {
+		page = 0
+	}
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	entries, hasMore, err := b.storer.Query(ctx, filter, page, pageSize)
+	if err != nil {
+		b.logger.Error(ctx, "failed to query audit entries", "error", err)
+		return nil, false, fmt.Errorf("query: %w", err)
+	}
+
+	return entries, hasMore, nil
+}
+
+// Verify walks the whole trail and checks every entry against its hash and
+// the hash of the entry before it.
+func (b *Business) Verify(ctx context.Context) (Verification, error) {
+	var (
+		v    = Verification{Intact: true}
+		prev string
+	)
+
+	err := b.storer.Scan(ctx, func(e Entry) error {
+		v.Checked++
+
+		switch {
+		case e.PrevHash != prev:
+			v.Reason = "entry does not link to the entry before it"
+		case e.ComputeHash() != e.Hash:
+			v.Reason = "entry does not match its hash"
+		default:
+			prev = e.Hash
+			return nil
+		}
+
+		v.Intact = false
+		v.BrokenAt = e.ID
+		return errStop
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		b.logger.Error(ctx, "failed to verify audit trail", "error", err)
+		return Verification{}, fmt.Errorf("verify: %w", err)
+	}
+
+	return v, nil
+}
+
+// errStop ends a Scan once the chain is found broken.
+var errStop = errors.New("stop")
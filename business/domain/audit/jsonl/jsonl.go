@@ -0,0 +1,241 @@
+// Package jsonl implements an audit.Storer on an append-only file holding
+// one JSON entry per line. The file is only ever opened for appending, and
+// every entry is synced to disk before Append returns.
+package jsonl
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/audit"
+	"github.com/oklog/ulid/v2"
+)
+
+type fileEntry struct {
+	ID        string `json:"id"`
+	Time      int64  `json:"time_ms"`
+	Actor     string `json:"actor,omitempty"`
+	Tenant    string `json:"tenant,omitempty"`
+	Method    string `json:"method"`
+	Request   string `json:"request,omitempty"`
+	Results   int64  `json:"results,omitempty"`
+	Bytes     int64  `json:"bytes,omitempty"`
+	Duration  int64  `json:"duration_ns"`
+	Code      string `json:"code"`
+	PendingID string `json:"pending_id,omitempty"`
+	PrevHash  string `json:"prev_hash,omitempty"`
+	Hash      string `json:"hash"`
+}
+
+type Store struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+	// head is the newest entry, read from the file once; found reports
+	// whether there is one.
+	head   audit.Entry
+	found  bool
+	loaded bool
+}
+
+// Open opens the trail at path, creating it if needed.
+func Open(path string) (*Store, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening audit trail: %w", err)
+	}
+
+	return &Store{path: path, file: file}, nil
+}
+
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
+
+func (s *Store) Append(ctx context.Context, e audit.Entry) error {
+	line, err := json.Marshal(toFileEntry(e))
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadHead(ctx); err != nil {
+		return err
+	}
+	if e.PrevHash != s.head.Hash {
+		return audit.ErrConflict
+	}
+
+	if _, err := s.file.Write(line); err != nil {
+		return fmt.Errorf("writing entry: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+	s.head, s.found = e, true
+
+	return nil
+}
+
+// Last returns the newest entry. The file has a single writer, so it is
+// only read for the first call.
+func (s *Store) Last(ctx context.Context) (audit.Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadHead(ctx); err != nil {
+		return audit.Entry{}, err
+	}
+	if !s.found {
+		return audit.Entry{}, audit.ErrNotFound
+	}
+	return s.head, nil
+}
+
+func (s *Store) loadHead(ctx context.Context) error {
+	if s.loaded {
+		return nil
+	}
+
+	err := s.Scan(ctx, func(e audit.Entry) error {
+		s.head, s.found = e, true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.loaded = true
+
+	return nil
+}
+
+// Query reads the whole file, which suits the volume of an audit trail.
+func (s *Store) Query(ctx context.Context, filter audit.Filter, page, pageSize int) ([]audit.Entry, bool, error) {
+	var matched []audit.Entry
+	err := s.Scan(ctx, func(e audit.Entry) error {
+		if filter.Match(e) {
+			matched = append(matched, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	skip := page * pageSize
+	if skip >= len(matched) {
+		return nil, false, nil
+	}
+
+	var entries []audit.Entry
+	for i := len(matched) - 1 - skip; i >= 0 && len(entries) < pageSize; i-- {
+		entries = append(entries, matched[i])
+	}
+
+	return entries, skip+len(entries) < len(matched), nil
+}
+
+func (s *Store) Scan(ctx context.Context, fn func(audit.Entry) error) error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64<<10), 16<<20)
+
+	for n := 1; scanner.Scan(); n++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var fe fileEntry
+		if err := json.Unmarshal(scanner.Bytes(), &fe); err != nil {
+			return fmt.Errorf("decoding line %d: %w", n, err)
+		}
+
+		e, err := toEntry(fe)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+func toFileEntry(e audit.Entry) fileEntry {
+	var pendingID string
+	if e.PendingID != (ulid.ULID{}) {
+		pendingID = e.PendingID.String()
+	}
+
+	return fileEntry{
+		ID:        e.ID.String(),
+		Time:      e.Time.UnixMilli(),
+		Actor:     e.Actor,
+		Tenant:    e.Tenant,
+		Method:    e.Method,
+		Request:   e.Request,
+		Results:   e.Results,
+		Bytes:     e.Bytes,
+		Duration:  int64(e.Duration),
+		Code:      e.Code,
+		PendingID: pendingID,
+		PrevHash:  e.PrevHash,
+		Hash:      e.Hash,
+	}
+}
+
+func toEntry(fe fileEntry) (audit.Entry, error) {
+	id, err := ulid.Parse(fe.ID)
+	if err != nil {
+		return audit.Entry{}, fmt.Errorf("parsing id %q: %w", fe.ID, err)
+	}
+
+	var pendingID ulid.ULID
+	if fe.PendingID != "" {
+		pendingID, err = ulid.Parse(fe.PendingID)
+		if err != nil {
+			return audit.Entry{}, fmt.Errorf("parsing pending id %q: %w", fe.PendingID, err)
+		}
+	}
+
+	return audit.Entry{
+		ID:        id,
+		Time:      time.UnixMilli(fe.Time).UTC(),
+		Actor:     fe.Actor,
+		Tenant:    fe.Tenant,
+		Method:    fe.Method,
+		Request:   fe.Request,
+		Results:   fe.Results,
+		Bytes:     fe.Bytes,
+		Duration:  time.Duration(fe.Duration),
+		Code:      fe.Code,
+		PendingID: pendingID,
+		PrevHash:  fe.PrevHash,
+		Hash:      fe.Hash,
+	}, nil
+}
+
+var _ audit.Storer = (*Store)(nil)
@@ -0,0 +1,125 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// CodePending is the Code of the entry recorded when a call starts, before
+// its handler runs.
+const CodePending = "Pending"
+
+// Entry records one call made to the server. Every call is recorded twice:
+// once when it starts, with Code CodePending, and once when it ends, with
+// PendingID naming the first entry.
+type Entry struct {
+	ID   ulid.ULID
+	Time time.Time
+	// Actor names the caller, as reported by its API key, client
+	// certificate or, prefixed with "header:", its unverified x-actor
+	// header.
+	Actor  string
+	Tenant string
+	Method string
+	// Request is the request of the call as JSON, holding its criteria.
+	Request string
+	// Results counts the logs returned or affected by the call.
+	Results int64
+	// Bytes is the size of the file written by an export.
+	Bytes    int64
+	Duration time.Duration
+	// Code is the gRPC status code the call ended with, or CodePending.
+	Code string
+	// PendingID is the ID of the entry recorded when the call started, for
+	// the entry recording how it ended.
+	PendingID ulid.ULID
+	// PrevHash is the Hash of the entry recorded before this one, "" for
+	// the first entry.
+	PrevHash string
+	Hash     string
+}
+
+// ComputeHash returns the hash of e, covering every field but Hash itself.
+// PendingID is left out when zero, so entries recorded before it existed
+// keep their hash.
+func (e Entry) ComputeHash() string {
+	var pendingID string
+	if e.PendingID != (ulid.ULID{}) {
+		pendingID = e.PendingID.String()
+	}
+
+	data, _ := json.Marshal(struct {
+		ID        string
+		Time      int64
+		Actor     string
+		Tenant    string
+		Method    string
+		Request   string
+		Results   int64
+		Bytes     int64
+		Duration  int64
+		Code      string
+		PendingID string `json:",omitempty"`
+		PrevHash  string
+	}{
+		ID:        e.ID.String(),
+		Time:      e.Time.UnixMilli(),
+		Actor:     e.Actor,
+		Tenant:    e.Tenant,
+		Method:    e.Method,
+		Request:   e.Request,
+		Results:   e.Results,
+		Bytes:     e.Bytes,
+		Duration:  int64(e.Duration),
+		Code:      e.Code,
+		PendingID: pendingID,
+		PrevHash:  e.PrevHash,
+	})
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Filter selects entries. Zero fields match every entry.
+type Filter struct {
+	StartTime time.Time
+	EndTime   time.Time
+	Actor     string
+	Tenant    string
+	Method    string
+}
+
+// Match reports whether e satisfies the filter, for stores filtering in
+// memory.
+func (f Filter) Match(e Entry) bool {
+	if !f.StartTime.IsZero() && e.Time.Before(f.StartTime) {
+		return false
+	}
+	if !f.EndTime.IsZero() && e.Time.After(f.EndTime) {
+		return false
+	}
+	if f.Actor != "" && e.Actor != f.Actor {
+		return false
+	}
+	if f.Tenant != "" && e.Tenant != f.Tenant {
+		return false
+	}
+	if f.Method != "" && e.Method != f.Method {
+		return false
+	}
+	return true
+}
+
+// Verification reports the state of the hash chain.
+type Verification struct {
+	Checked int64
+	Intact  bool
+	// BrokenAt is the first entry whose hash or link does not match, when
+	// the chain is not intact.
+	BrokenAt ulid.ULID
+	Reason   string
+}
@@ -0,0 +1,237 @@
+// Package mongodb implements an audit.Storer backed by a MongoDB
+// collection.
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/audit"
+	"github.com/oklog/ulid/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type dbEntry struct {
+	ID        ulid.ULID `bson:"id"`
+	Time      time.Time `bson:"time"`
+	Actor     string    `bson:"actor,omitempty"`
+	Tenant    string    `bson:"tenant,omitempty"`
+	Method    string    `bson:"method"`
+	Request   string    `bson:"request,omitempty"`
+	Results   int64     `bson:"results,omitempty"`
+	Bytes     int64     `bson:"bytes,omitempty"`
+	Duration  int64     `bson:"duration"`
+	Code      string    `bson:"code"`
+	PendingID ulid.ULID `bson:"pendingid,omitempty"`
+	PrevHash  string    `bson:"prevhash,omitempty"`
+	Hash      string    `bson:"hash"`
+}
+
+type Store struct {
+	collection *mongo.Collection
+}
+
+// prevHashIndex names the unique index refusing a second entry linking to
+// the same one.
+const prevHashIndex = "prevhash_unique"
+
+// NewStore returns a store keeping the trail in the named collection of db.
+// Entries are ordered by ID, which grows with every entry recorded.
+func NewStore(ctx context.Context, db *mongo.Database, collectionName string) (*Store, error) {
+	collection := db.Collection(collectionName)
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "time", Value: 1}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating audit indexes: %w", err)
+	}
+
+	s := Store{collection: collection}
+	if err := s.ensurePrevHashIndex(ctx); err != nil {
+		return nil, fmt.Errorf("creating audit prevhash index: %w", err)
+	}
+
+	return &s, nil
+}
+
+// ensurePrevHashIndex creates the unique prevhash index once. Entries
+// already stored are left out, as servers sharing the trail may have forked
+// it before; the first entry, without prevhash, is covered when the trail
+// is empty.
+func (s *Store) ensurePrevHashIndex(ctx context.Context) error {
+	cursor, err := s.collection.Indexes().List(ctx)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var indexes []struct {
+		Name string `bson:"name"`
+	}
+	if err := cursor.All(ctx, &indexes); err != nil {
+		return err
+	}
+	for _, idx := range indexes {
+		if idx.Name == prevHashIndex {
+			return nil
+		}
+	}
+
+	opts := options.Index().SetName(prevHashIndex).SetUnique(true)
+	last, err := s.Last(ctx)
+	switch {
+	case err == nil:
+		opts.SetPartialFilterExpression(bson.M{"time": bson.M{"$gt": last.Time}})
+	case !errors.Is(err, audit.ErrNotFound):
+		return err
+	}
+
+	_, err = s.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "prevhash", Value: 1}},
+		Options: opts,
+	})
+	return err
+}
+
+// Append stores e. The unique prevhash index turns a second entry linking
+// to the same one into audit.ErrConflict.
+func (s *Store) Append(ctx context.Context, e audit.Entry) error {
+	_, err := s.collection.InsertOne(ctx, toDBEntry(e))
+	if mongo.IsDuplicateKeyError(err) {
+		return audit.ErrConflict
+	}
+	return err
+}
+
+func (s *Store) Last(ctx context.Context) (audit.Entry, error) {
+	var doc dbEntry
+	opts := options.FindOne().SetSort(bson.D{{Key: "id", Value: -1}})
+	if err := s.collection.FindOne(ctx, bson.M{}, opts).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return audit.Entry{}, audit.ErrNotFound
+		}
+		return audit.Entry{}, err
+	}
+	return toCoreEntry(doc), nil
+}
+
+func (s *Store) Query(ctx context.Context, filter audit.Filter, page, pageSize int) ([]audit.Entry, bool, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "id", Value: -1}}).
+		SetSkip(int64(page * pageSize)).
+		SetLimit(int64(pageSize + 1))
+
+	cursor, err := s.collection.Find(ctx, buildFilter(filter), opts)
+	if err != nil {
+		return nil, false, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []dbEntry
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(docs) > pageSize
+	if hasMore {
+		docs = docs[:pageSize]
+	}
+
+	entries := make([]audit.Entry, len(docs))
+	for i, doc := range docs {
+		entries[i] = toCoreEntry(doc)
+	}
+
+	return entries, hasMore, nil
+}
+
+func (s *Store) Scan(ctx context.Context, fn func(audit.Entry) error) error {
+	cursor, err := s.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "id", Value: 1}}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc dbEntry
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		if err := fn(toCoreEntry(doc)); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+func buildFilter(filter audit.Filter) bson.M {
+	f := bson.M{}
+
+	timeRange := bson.M{}
+	if !filter.StartTime.IsZero() {
+		timeRange["$gte"] = filter.StartTime
+	}
+	if !filter.EndTime.IsZero() {
+		timeRange["$lte"] = filter.EndTime
+	}
+	if len(timeRange) > 0 {
+		f["time"] = timeRange
+	}
+
+	if filter.Actor != "" {
+		f["actor"] = filter.Actor
+	}
+	if filter.Tenant != "" {
+		f["tenant"] = filter.Tenant
+	}
+	if filter.Method != "" {
+		f["method"] = filter.Method
+	}
+
+	return f
+}
+
+func toDBEntry(e audit.Entry) dbEntry {
+	return dbEntry{
+		ID:        e.ID,
+		Time:      e.Time,
+		Actor:     e.Actor,
+		Tenant:    e.Tenant,
+		Method:    e.Method,
+		Request:   e.Request,
+		Results:   e.Results,
+		Bytes:     e.Bytes,
+		Duration:  int64(e.Duration),
+		Code:      e.Code,
+		PendingID: e.PendingID,
+		PrevHash:  e.PrevHash,
+		Hash:      e.Hash,
+	}
+}
+
+func toCoreEntry(doc dbEntry) audit.Entry {
+	return audit.Entry{
+		ID:        doc.ID,
+		Time:      doc.Time.UTC(),
+		Actor:     doc.Actor,
+		Tenant:    doc.Tenant,
+		Method:    doc.Method,
+		Request:   doc.Request,
+		Results:   doc.Results,
+		Bytes:     doc.Bytes,
+		Duration:  time.Duration(doc.Duration),
+		Code:      doc.Code,
+		PendingID: doc.PendingID,
+		PrevHash:  doc.PrevHash,
+		Hash:      doc.Hash,
+	}
+}
+
+var _ audit.Storer = (*Store)(nil)
@@ -0,0 +1,174 @@
+// Package postgres implements an audit.Storer on the audit_log table of the
+// PostgreSQL log store, which creates the table in its migrations.
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/audit"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+)
+
+const uniqueViolation = "23505"
+
+const entryColumns = `id, time, actor, tenant, method, request, results, bytes, duration, code, pending_id, prev_hash, hash`
+
+type Store struct {
+	pool *pgxpool.Pool
+}
+
+func NewStore(pool *pgxpool.Pool) *Store {
+	return &Store{pool: pool}
+}
+
+// Append stores e. The unique index on prev_hash turns a second entry
+// linking to the same one into audit.ErrConflict.
+func (s *Store) Append(ctx context.Context, e audit.Entry) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO audit_log (`+entryColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		e.ID.String(), e.Time, e.Actor, e.Tenant, e.Method, e.Request,
+		e.Results, e.Bytes, int64(e.Duration), e.Code, pendingID(e), e.PrevHash, e.Hash,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return audit.ErrConflict
+	}
+	return err
+}
+
+func (s *Store) Last(ctx context.Context) (audit.Entry, error) {
+	row := s.pool.QueryRow(ctx, `SELECT `+entryColumns+` FROM audit_log ORDER BY seq DESC LIMIT 1`)
+	e, err := scanEntry(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return audit.Entry{}, audit.ErrNotFound
+	}
+	return e, err
+}
+
+func (s *Store) Query(ctx context.Context, filter audit.Filter, page, pageSize int) ([]audit.Entry, bool, error) {
+	where, args := buildWhere(filter)
+	n := len(args)
+	args = append(args, pageSize+1, page*pageSize)
+
+	rows, err := s.pool.Query(ctx,
+		fmt.Sprintf(`SELECT `+entryColumns+` FROM audit_log%s ORDER BY seq DESC LIMIT $%d OFFSET $%d`, where, n+1, n+2),
+		args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var entries []audit.Entry
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return nil, false, err
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	if len(entries) > pageSize {
+		return entries[:pageSize], true, nil
+	}
+	return entries, false, nil
+}
+
+func (s *Store) Scan(ctx context.Context, fn func(audit.Entry) error) error {
+	rows, err := s.pool.Query(ctx, `SELECT `+entryColumns+` FROM audit_log ORDER BY seq`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func buildWhere(filter audit.Filter) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if !filter.StartTime.IsZero() {
+		add("time >= $%d", filter.StartTime)
+	}
+	if !filter.EndTime.IsZero() {
+		add("time <= $%d", filter.EndTime)
+	}
+	if filter.Actor != "" {
+		add("actor = $%d", filter.Actor)
+	}
+	if filter.Tenant != "" {
+		add("tenant = $%d", filter.Tenant)
+	}
+	if filter.Method != "" {
+		add("method = $%d", filter.Method)
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+func scanEntry(row pgx.Row) (audit.Entry, error) {
+	var (
+		e           audit.Entry
+		id, pending string
+		durationNs  int64
+	)
+	err := row.Scan(&id, &e.Time, &e.Actor, &e.Tenant, &e.Method, &e.Request,
+		&e.Results, &e.Bytes, &durationNs, &e.Code, &pending, &e.PrevHash, &e.Hash)
+	if err != nil {
+		return audit.Entry{}, err
+	}
+
+	e.ID, err = ulid.Parse(id)
+	if err != nil {
+		return audit.Entry{}, fmt.Errorf("parsing id %q: %w", id, err)
+	}
+	e.Time = e.Time.UTC()
+	e.Duration = time.Duration(durationNs)
+	if pending != "" {
+		e.PendingID, err = ulid.Parse(pending)
+		if err != nil {
+			return audit.Entry{}, fmt.Errorf("parsing pending id %q: %w", pending, err)
+		}
+	}
+
+	return e, nil
+}
+
+// pendingID returns the PendingID of e as stored, "" when it has none.
+func pendingID(e audit.Entry) string {
+	if e.PendingID == (ulid.ULID{}) {
+		return ""
+	}
+	return e.PendingID.String()
+}
+
+var _ audit.Storer = (*Store)(nil)
@@ -0,0 +1,175 @@
+// Package sqlite implements an audit.Storer on the audit_log table of the
+// SQLite log store, which creates the table in its migrations.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/audit"
+	"github.com/oklog/ulid/v2"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+const entryColumns = `id, time, actor, tenant, method, request, results, bytes, duration, code, pending_id, prev_hash, hash`
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Append stores e. The unique index on prev_hash turns a second entry
+// linking to the same one into audit.ErrConflict.
+func (s *Store) Append(ctx context.Context, e audit.Entry) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO audit_log (`+entryColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.ID.String(), e.Time.UnixMilli(), e.Actor, e.Tenant, e.Method, e.Request,
+		e.Results, e.Bytes, int64(e.Duration), e.Code, pendingID(e), e.PrevHash, e.Hash,
+	)
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+		return audit.ErrConflict
+	}
+	return err
+}
+
+func (s *Store) Last(ctx context.Context) (audit.Entry, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+entryColumns+` FROM audit_log ORDER BY seq DESC LIMIT 1`)
+	e, err := scanEntry(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return audit.Entry{}, audit.ErrNotFound
+	}
+	return e, err
+}
+
+func (s *Store) Query(ctx context.Context, filter audit.Filter, page, pageSize int) ([]audit.Entry, bool, error) {
+	where, args := buildWhere(filter)
+	args = append(args, pageSize+1, page*pageSize)
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+entryColumns+` FROM audit_log`+where+` ORDER BY seq DESC LIMIT ? OFFSET ?`, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var entries []audit.Entry
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return nil, false, err
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	if len(entries) > pageSize {
+		return entries[:pageSize], true, nil
+	}
+	return entries, false, nil
+}
+
+func (s *Store) Scan(ctx context.Context, fn func(audit.Entry) error) error {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+entryColumns+` FROM audit_log ORDER BY seq`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func buildWhere(filter audit.Filter) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+
+	if !filter.StartTime.IsZero() {
+		conds = append(conds, "time >= ?")
+		args = append(args, filter.StartTime.UnixMilli())
+	}
+	if !filter.EndTime.IsZero() {
+		conds = append(conds, "time <= ?")
+		args = append(args, filter.EndTime.UnixMilli())
+	}
+	if filter.Actor != "" {
+		conds = append(conds, "actor = ?")
+		args = append(args, filter.Actor)
+	}
+	if filter.Tenant != "" {
+		conds = append(conds, "tenant = ?")
+		args = append(args, filter.Tenant)
+	}
+	if filter.Method != "" {
+		conds = append(conds, "method = ?")
+		args = append(args, filter.Method)
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEntry(row scanner) (audit.Entry, error) {
+	var (
+		e              audit.Entry
+		id, pending    string
+		ms, durationNs int64
+	)
+	err := row.Scan(&id, &ms, &e.Actor, &e.Tenant, &e.Method, &e.Request,
+		&e.Results, &e.Bytes, &durationNs, &e.Code, &pending, &e.PrevHash, &e.Hash)
+	if err != nil {
+		return audit.Entry{}, err
+	}
+
+	e.ID, err = ulid.Parse(id)
+	if err != nil {
+		return audit.Entry{}, fmt.Errorf("parsing id %q: %w", id, err)
+	}
+	e.Time = time.UnixMilli(ms).UTC()
+	e.Duration = time.Duration(durationNs)
+	if pending != "" {
+		e.PendingID, err = ulid.Parse(pending)
+		if err != nil {
+			return audit.Entry{}, fmt.Errorf("parsing pending id %q: %w", pending, err)
+		}
+	}
+
+	return e, nil
+}
+
+// pendingID returns the PendingID of e as stored, "" when it has none.
+func pendingID(e audit.Entry) string {
+	if e.PendingID == (ulid.ULID{}) {
+		return ""
+	}
+	return e.PendingID.String()
+}
+
+var _ audit.Storer = (*Store)(nil)
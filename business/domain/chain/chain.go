@@ -0,0 +1,531 @@
+// Package chain makes stored logs tamper-evident. Every log written is
+// linked into a hash chain of its tenant, each link carrying the digest of
+// the log and the hash of the link before it, and the heads of the chains
+// are signed in periodic checkpoints. Verify walks a chain and reports logs
+// and links altered, removed or added behind its back.
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/foundation/logger"
+	"github.com/oklog/ulid/v2"
+)
+
+var (
+	ErrNotFound  = errors.New("chain link not found")
+	ErrNoSigner  = errors.New("no checkpoint signing key")
+	errLinkStore = errors.New("storing chain link")
+)
+
+const (
+	// maxIssues bounds the issues a Verification lists; the rest are
+	// counted.
+	maxIssues = 1000
+
+	// maxPending bounds the stored logs waiting for their link. Past it,
+	// writes are refused until the links can be stored again.
+	maxPending = 10000
+
+	// maxTombstoneIDs bounds the logs a tombstone lists, so a large
+	// deletion is recorded in several.
+	maxTombstoneIDs = 10000
+)
+
+type Storer interface {
+	AppendLink(ctx context.Context, l Link) error
+	// Heads returns the newest link of every partition.
+	Heads(ctx context.Context) ([]Link, error)
+	// SeqRange returns the lowest and highest Seq of the links of partition
+	// whose log falls in tr, or ErrNotFound when there is none.
+	SeqRange(ctx context.Context, partition string, tr mlog.TimeRange) (int64, int64, error)
+	// Links calls fn with the links of partition from Seq from to to, in
+	// ascending order.
+	Links(ctx context.Context, partition string, from, to int64, fn func(Link) error) error
+	SaveCheckpoint(ctx context.Context, c Checkpoint) error
+	// Checkpoints returns the checkpoints of partition with a Seq from from
+	// to to, in ascending order.
+	Checkpoints(ctx context.Context, partition string, from, to int64) ([]Checkpoint, error)
+	SaveTombstone(ctx context.Context, t Tombstone) error
+	// Tombstones returns every tombstone of partition.
+	Tombstones(ctx context.Context, partition string) ([]Tombstone, error)
+}
+
+type Business struct {
+	logger logger.Logger
+	storer Storer
+	logs   mlog.Store
+	signer *Signer
+
+	// mu serializes writes through the Writer, so every link points to the
+	// one written before it.
+	mu     sync.Mutex
+	heads  map[string]Link
+	loaded bool
+	// pending holds the logs stored whose link could not be, in the order
+	// they were stored. They are linked before any later log.
+	pending []*mlog.Log
+}
+
+// NewBusiness returns the chain of the logs in logs. Checkpoints need
+// signer; without it Verify only checks the checkpoints against the links.
+func NewBusiness(logger logger.Logger, storer Storer, logs mlog.Store, signer *Signer) *Business {
+	return &Business{
+		logger: logger,
+		storer: storer,
+		logs:   logs,
+		signer: signer,
+		heads:  make(map[string]Link),
+	}
+}
+
+// PublicKey returns the key checkpoints are signed with, nil without one.
+func (b *Business) PublicKey() []byte {
+	if b.signer == nil {
+		return nil
+	}
+	return b.signer.PublicKey()
+}
+
+// link chains logs, which were just stored, after the pending ones. Logs
+// whose link cannot be stored now are kept pending, since they are stored
+// all the same. The caller holds b.mu.
+func (b *Business) link(ctx context.Context, logs []*mlog.Log) {
+	b.pending = append(b.pending, logs...)
+	b.linkPending(ctx)
+}
+
+// linkPending chains the pending logs, in order, until one fails. The caller
+// holds b.mu.
+func (b *Business) linkPending(ctx context.Context) error {
+	n, err := b.append(ctx, b.pending)
+	b.pending = b.pending[n:]
+	if len(b.pending) == 0 {
+		b.pending = nil
+	}
+	return err
+}
+
+// full reports whether no more logs may be stored until the pending ones are
+// linked. The caller holds b.mu.
+func (b *Business) full(ctx context.Context) error {
+	if len(b.pending) < maxPending {
+		return nil
+	}
+	if err := b.linkPending(ctx); err != nil {
+		return fmt.Errorf("%d logs wait for their chain link: %w", len(b.pending), err)
+	}
+	return nil
+}
+
+// append stores the links of logs in order and returns how many it stored.
+// The caller holds b.mu.
+func (b *Business) append(ctx context.Context, logs []*mlog.Log) (int, error) {
+	if len(logs) == 0 {
+		return 0, nil
+	}
+
+	if !b.loaded {
+		heads, err := b.storer.Heads(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("reading chain heads: %w", err)
+		}
+		for _, h := range heads {
+			b.heads[h.Partition] = h
+		}
+		b.loaded = true
+	}
+
+	for i, log := range logs {
+		prev := b.heads[log.Tenant]
+
+		l := Link{
+			Partition: log.Tenant,
+			Seq:       prev.Seq + 1,
+			LogID:     log.ID,
+			Timestamp: log.Timestamp.UTC().Truncate(time.Millisecond),
+			Digest:    Digest(*log),
+			PrevHash:  prev.Hash,
+		}
+		l.Hash = l.ComputeHash()
+
+		if err := b.storer.AppendLink(ctx, l); err != nil {
+			// Another writer may have moved the chain on, so the heads are
+			// read again before the next link.
+			b.heads = make(map[string]Link)
+			b.loaded = false
+
+			b.logger.Error(ctx, "failed to store chain link", "error", err, "id", log.ID.String())
+			return i, fmt.Errorf("%w: %w", errLinkStore, err)
+		}
+		b.heads[l.Partition] = l
+	}
+
+	return len(logs), nil
+}
+
+// Checkpoint links the logs still waiting for their link, then signs the
+// head of every chain that moved since its last checkpoint and returns the
+// checkpoints made.
+func (b *Business) Checkpoint(ctx context.Context) ([]Checkpoint, error) {
+	if b.signer == nil {
+		return nil, fmt.Errorf("checkpoint: %w", ErrNoSigner)
+	}
+
+	b.mu.Lock()
+	err := b.linkPending(ctx)
+	b.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint: %w", err)
+	}
+
+	heads, err := b.storer.Heads(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint: reading heads: %w", err)
+	}
+
+	var made []Checkpoint
+	for _, h := range heads {
+		existing, err := b.storer.Checkpoints(ctx, h.Partition, h.Seq, h.Seq)
+		if err != nil {
+			return made, fmt.Errorf("checkpoint: %w", err)
+		}
+		if len(existing) > 0 {
+			continue
+		}
+
+		c := Checkpoint{
+			Partition: h.Partition,
+			Seq:       h.Seq,
+			Hash:      h.Hash,
+			Time:      time.Now().UTC().Truncate(time.Millisecond),
+		}
+		b.signer.Sign(&c)
+
+		if err := b.storer.SaveCheckpoint(ctx, c); err != nil {
+			b.logger.Error(ctx, "failed to save chain checkpoint", "error", err, "partition", c.Partition)
+			return made, fmt.Errorf("checkpoint: %w", err)
+		}
+		made = append(made, c)
+	}
+
+	return made, nil
+}
+
+// RecordDeletion signs tombstones in the chain of d.Tenant for the logs d
+// removed or rewrote, holding up to maxTombstoneIDs logs each.
+func (b *Business) RecordDeletion(ctx context.Context, d mlog.Deletion) error {
+	if b.signer == nil {
+		return fmt.Errorf("record deletion: %w", ErrNoSigner)
+	}
+
+	for start := 0; start < len(d.IDs); start += maxTombstoneIDs {
+		end := min(start+maxTombstoneIDs, len(d.IDs))
+
+		t := Tombstone{
+			Partition: d.Tenant,
+			Reason:    d.Reason,
+			LogIDs:    d.IDs[start:end],
+			Time:      time.Now().UTC().Truncate(time.Millisecond),
+		}
+		b.signer.SignTombstone(&t)
+
+		if err := b.storer.SaveTombstone(ctx, t); err != nil {
+			b.logger.Error(ctx, "failed to save chain tombstone", "error", err, "partition", d.Tenant)
+			return fmt.Errorf("record deletion: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Verify walks the chain of partition over the logs in tr. It checks every
+// link against its hash and the link before it, every log against the
+// digest its link holds, and every checkpoint against its signature and
+// the link it signed. Callers bound to a tenant only verify their own.
+func (b *Business) Verify(ctx context.Context, partition string, tr mlog.TimeRange) (Verification, error) {
+	if !tr.StartTime.IsZero() && !tr.EndTime.IsZero() && tr.EndTime.Before(tr.StartTime) {
+		return Verification{}, fmt.Errorf("verify: %w", mlog.ErrInvalidTimeRange)
+	}
+	if tenant := mlog.TenantFrom(ctx); tenant != "" {
+		partition = tenant
+	}
+
+	scanner, ok := b.logs.(mlog.Scanner)
+	if !ok {
+		return Verification{}, fmt.Errorf("verify: %w", mlog.ErrNotSupported)
+	}
+
+	// Links keep milliseconds, so the range is widened to whole ones for
+	// the stores that keep more to select the same logs.
+	tr.StartTime = tr.StartTime.Truncate(time.Millisecond)
+	tr.EndTime = tr.EndTime.Truncate(time.Millisecond)
+	scan := tr
+	if !scan.EndTime.IsZero() {
+		scan.EndTime = scan.EndTime.Add(time.Millisecond - 1)
+	}
+
+	v := Verification{Partition: partition}
+
+	tombs, err := b.tombstones(ctx, &v, partition)
+	if err != nil {
+		return Verification{}, err
+	}
+
+	// chained holds the links of the logs in tr, by log.
+	chained := make(map[ulid.ULID]Link)
+
+	from, to, err := b.storer.SeqRange(ctx, partition, tr)
+	switch {
+	case err == nil:
+		if err := b.verifyLinks(ctx, &v, partition, tr, from, to, chained); err != nil {
+			return Verification{}, err
+		}
+	case !errors.Is(err, ErrNotFound):
+		return Verification{}, fmt.Errorf("verify: %w", err)
+	}
+
+	if err := b.verifyTail(ctx, &v, partition); err != nil {
+		return Verification{}, err
+	}
+
+	err = scanner.Scan(ctx, mlog.SearchCriteria{Tenant: partition, TimeRange: scan}, func(log mlog.Log) error {
+		if log.Tenant != partition {
+			return nil
+		}
+		v.Logs++
+
+		l, ok := chained[log.ID]
+		if !ok {
+			v.add(Issue{Kind: UnchainedLog, LogID: log.ID, Detail: "log is not in the chain"})
+			return nil
+		}
+		delete(chained, log.ID)
+
+		if Digest(log) != l.Digest {
+			if tombs.redacted[log.ID] {
+				v.Redacted++
+				return nil
+			}
+			v.add(Issue{Kind: ModifiedLog, Seq: l.Seq, LogID: log.ID, Detail: "log does not match the digest of its link"})
+		}
+		return nil
+	})
+	if err != nil {
+		b.logger.Error(ctx, "failed to scan logs to verify", "error", err)
+		return Verification{}, fmt.Errorf("verify: %w", err)
+	}
+
+	missing := make([]Link, 0, len(chained))
+	for _, l := range chained {
+		missing = append(missing, l)
+	}
+	sort.Slice(missing, func(i, j int) bool { return missing[i].Seq < missing[j].Seq })
+	for _, l := range missing {
+		if tombs.removed(l) {
+			v.Removed++
+			continue
+		}
+		v.add(Issue{Kind: MissingLog, Seq: l.Seq, LogID: l.LogID, Detail: "chained log is no longer stored"})
+	}
+
+	v.Intact = len(v.Issues) == 0
+	return v, nil
+}
+
+// verifyLinks walks the links from Seq from to to, starting one link early
+// so the first one in range is checked against the link before it.
+func (b *Business) verifyLinks(ctx context.Context, v *Verification, partition string, tr mlog.TimeRange, from, to int64, chained map[ulid.ULID]Link) error {
+	start := max(from-1, 1)
+
+	var (
+		prev   Link
+		hashes = make(map[int64]string)
+	)
+	err := b.storer.Links(ctx, partition, start, to, func(l Link) error {
+		v.Links++
+
+		expected := start
+		if prev.Seq != 0 {
+			expected = prev.Seq + 1
+		}
+
+		switch {
+		case l.Seq != expected:
+			v.add(Issue{Kind: MissingLink, Seq: expected, Detail: missingLinks(expected, l.Seq-1)})
+		case prev.Seq != 0 && l.PrevHash != prev.Hash, l.Seq == 1 && l.PrevHash != "":
+			v.add(Issue{Kind: BrokenLink, Seq: l.Seq, LogID: l.LogID, Detail: "link does not point to the link before it"})
+		}
+		if l.ComputeHash() != l.Hash {
+			v.add(Issue{Kind: ModifiedLink, Seq: l.Seq, LogID: l.LogID, Detail: "link does not match its hash"})
+		}
+
+		if inRange(l.Timestamp, tr) {
+			chained[l.LogID] = l
+		}
+		hashes[l.Seq] = l.Hash
+		prev = l
+		return nil
+	})
+	if err != nil {
+		b.logger.Error(ctx, "failed to read chain links", "error", err)
+		return fmt.Errorf("verify: %w", err)
+	}
+
+	if prev.Seq < to {
+		first := max(prev.Seq+1, start)
+		v.add(Issue{Kind: MissingLink, Seq: first, Detail: missingLinks(first, to)})
+	}
+
+	checkpoints, err := b.storer.Checkpoints(ctx, partition, start, to)
+	if err != nil {
+		return fmt.Errorf("verify: %w", err)
+	}
+	for _, c := range checkpoints {
+		v.Checkpoints++
+		b.checkCheckpoint(v, c, hashes)
+	}
+
+	return nil
+}
+
+// verifyTail reports checkpoints past the head of the chain, which signed
+// links removed from its end since.
+func (b *Business) verifyTail(ctx context.Context, v *Verification, partition string) error {
+	heads, err := b.storer.Heads(ctx)
+	if err != nil {
+		return fmt.Errorf("verify: %w", err)
+	}
+
+	var head int64
+	for _, h := range heads {
+		if h.Partition == partition {
+			head = h.Seq
+		}
+	}
+
+	checkpoints, err := b.storer.Checkpoints(ctx, partition, head+1, math.MaxInt64)
+	if err != nil {
+		return fmt.Errorf("verify: %w", err)
+	}
+
+	var signed int64
+	for _, c := range checkpoints {
+		v.Checkpoints++
+		if b.signer != nil && !b.signer.Verify(c) {
+			v.add(Issue{Kind: BadSignature, Seq: c.Seq, Detail: "checkpoint is not signed by the signing key"})
+			continue
+		}
+		signed = c.Seq
+	}
+	if signed > 0 {
+		v.add(Issue{Kind: MissingLink, Seq: head + 1, Detail: missingLinks(head+1, signed) + " from the end of the chain"})
+	}
+
+	return nil
+}
+
+// checkCheckpoint checks c against its signature and the hash of the link
+// it signed, looked up in hashes.
+func (b *Business) checkCheckpoint(v *Verification, c Checkpoint, hashes map[int64]string) {
+	if b.signer != nil && !b.signer.Verify(c) {
+		v.add(Issue{Kind: BadSignature, Seq: c.Seq, Detail: "checkpoint is not signed by the signing key"})
+		return
+	}
+
+	hash, ok := hashes[c.Seq]
+	switch {
+	case !ok:
+		v.add(Issue{Kind: CheckpointMismatch, Seq: c.Seq, Detail: "checkpointed link is missing"})
+	case hash != c.Hash:
+		v.add(Issue{Kind: CheckpointMismatch, Seq: c.Seq, Detail: "link differs from its checkpoint"})
+	}
+}
+
+func missingLinks(from, to int64) string {
+	if from == to {
+		return fmt.Sprintf("link %d is missing", from)
+	}
+	return fmt.Sprintf("links %d to %d are missing", from, to)
+}
+
+func (v *Verification) add(issue Issue) {
+	if len(v.Issues) >= maxIssues {
+		v.OmittedIssues++
+		return
+	}
+	v.Issues = append(v.Issues, issue)
+}
+
+func inRange(t time.Time, tr mlog.TimeRange) bool {
+	if !tr.StartTime.IsZero() && t.Before(tr.StartTime) {
+		return false
+	}
+	if !tr.EndTime.IsZero() && t.After(tr.EndTime) {
+		return false
+	}
+	return true
+}
+
+// tombstoned gathers the logs the tombstones of a partition account for.
+type tombstoned struct {
+	erased   map[ulid.ULID]bool
+	redacted map[ulid.ULID]bool
+	// before is the newest end of the logs expired by retention, from
+	// tombstones recorded before retention named the logs it deleted.
+	before time.Time
+}
+
+// removed reports whether l links a log erased or expired by retention.
+func (t tombstoned) removed(l Link) bool {
+	return t.erased[l.LogID] || l.Timestamp.Before(t.before)
+}
+
+// tombstones reads the tombstones of partition, reporting the ones not
+// signed by the signing key, which account for nothing.
+func (b *Business) tombstones(ctx context.Context, v *Verification, partition string) (tombstoned, error) {
+	tombs := tombstoned{
+		erased:   make(map[ulid.ULID]bool),
+		redacted: make(map[ulid.ULID]bool),
+	}
+
+	stored, err := b.storer.Tombstones(ctx, partition)
+	if err != nil {
+		b.logger.Error(ctx, "failed to read chain tombstones", "error", err)
+		return tombs, fmt.Errorf("verify: %w", err)
+	}
+
+	for _, t := range stored {
+		if b.signer != nil && !b.signer.VerifyTombstone(t) {
+			v.add(Issue{Kind: BadSignature, Detail: fmt.Sprintf("%s tombstone of %s is not signed by the signing key", t.Reason, t.Time.Format(time.RFC3339))})
+			continue
+		}
+
+		switch t.Reason {
+		case mlog.DeletionErase:
+			for _, id := range t.LogIDs {
+				tombs.erased[id] = true
+			}
+		case mlog.DeletionRedact:
+			for _, id := range t.LogIDs {
+				tombs.redacted[id] = true
+			}
+		case mlog.DeletionRetention:
+			for _, id := range t.LogIDs {
+				tombs.erased[id] = true
+			}
+			if t.Before.After(tombs.before) {
+				tombs.before = t.Before
+			}
+		}
+	}
+
+	return tombs, nil
+}
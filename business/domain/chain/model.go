@@ -0,0 +1,210 @@
+package chain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/oklog/ulid/v2"
+)
+
+// Link ties a stored log into the chain of its partition.
+type Link struct {
+	// Partition is the tenant of the log; each one has a chain of its own.
+	Partition string
+	// Seq is the position of the link in its partition, starting at 1.
+	Seq       int64
+	LogID     ulid.ULID
+	Timestamp time.Time
+	// Digest is the Digest of the log when it was written.
+	Digest string
+	// PrevHash is the Hash of the link before this one, "" for the first.
+	PrevHash string
+	Hash     string
+}
+
+// ComputeHash returns the hash of l, covering every field but Hash itself.
+func (l Link) ComputeHash() string {
+	data, _ := json.Marshal(struct {
+		Partition string
+		Seq       int64
+		LogID     string
+		Timestamp int64
+		Digest    string
+		PrevHash  string
+	}{
+		Partition: l.Partition,
+		Seq:       l.Seq,
+		LogID:     l.LogID.String(),
+		Timestamp: l.Timestamp.UnixMilli(),
+		Digest:    l.Digest,
+		PrevHash:  l.PrevHash,
+	})
+
+	return hash(data)
+}
+
+// Digest returns the hash of the content of log. Timestamps count in
+// milliseconds, the precision every store keeps, and compression is left
+// out, since compaction changes it.
+func Digest(log mlog.Log) string {
+	data, _ := json.Marshal(struct {
+		ID        string
+		Tenant    string
+		Timestamp int64
+		Level     string
+		Message   string
+		Metadata  map[string]string
+	}{
+		ID:        log.ID.String(),
+		Tenant:    log.Tenant,
+		Timestamp: log.Timestamp.UnixMilli(),
+		Level:     string(log.Level),
+		Message:   log.Message,
+		Metadata:  nonEmpty(log.Metadata),
+	})
+
+	return hash(data)
+}
+
+// nonEmpty returns nil for empty metadata, which some stores read back as
+// nil.
+func nonEmpty(metadata map[string]string) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+	return metadata
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Checkpoint is a signed statement of the head of a partition's chain. A
+// chain rewritten after a checkpoint no longer matches it, and the
+// signature cannot be made again without the signing key.
+type Checkpoint struct {
+	Partition string
+	Seq       int64
+	Hash      string
+	Time      time.Time
+	// KeyID names the key that signed the checkpoint.
+	KeyID     string
+	Signature []byte
+}
+
+// payload returns what the signature of c covers.
+func (c Checkpoint) payload() []byte {
+	data, _ := json.Marshal(struct {
+		Partition string
+		Seq       int64
+		Hash      string
+		Time      int64
+	}{
+		Partition: c.Partition,
+		Seq:       c.Seq,
+		Hash:      c.Hash,
+		Time:      c.Time.UnixMilli(),
+	})
+	return data
+}
+
+// Tombstone is a signed record of chained logs removed or rewritten on
+// purpose, by an erase or by retention, so Verify does not report them as
+// tampered with.
+type Tombstone struct {
+	Partition string
+	Reason    mlog.DeletionReason
+	// LogIDs lists the logs erased, redacted or expired.
+	LogIDs []ulid.ULID
+	// Before covers every log older than it. Only tombstones of retention
+	// written before they listed LogIDs have it.
+	Before time.Time
+	Time   time.Time
+	// KeyID names the key that signed the tombstone.
+	KeyID     string
+	Signature []byte
+}
+
+// payload returns what the signature of t covers.
+func (t Tombstone) payload() []byte {
+	ids := make([]string, len(t.LogIDs))
+	for i, id := range t.LogIDs {
+		ids[i] = id.String()
+	}
+
+	var before int64
+	if !t.Before.IsZero() {
+		before = t.Before.UnixMilli()
+	}
+
+	data, _ := json.Marshal(struct {
+		Partition string
+		Reason    string
+		LogIDs    []string
+		Before    int64
+		Time      int64
+	}{
+		Partition: t.Partition,
+		Reason:    string(t.Reason),
+		LogIDs:    ids,
+		Before:    before,
+		Time:      t.Time.UnixMilli(),
+	})
+	return data
+}
+
+// IssueKind names what Verify found wrong.
+type IssueKind string
+
+const (
+	// MissingLink reports links removed from the middle or the end of the
+	// chain.
+	MissingLink IssueKind = "missing_link"
+	// BrokenLink reports a link that does not point to the one before it.
+	BrokenLink IssueKind = "broken_link"
+	// ModifiedLink reports a link that does not match its hash.
+	ModifiedLink IssueKind = "modified_link"
+	// MissingLog reports a chained log that is no longer stored.
+	MissingLog IssueKind = "missing_log"
+	// ModifiedLog reports a log that no longer matches its digest.
+	ModifiedLog IssueKind = "modified_log"
+	// UnchainedLog reports a stored log that was never chained.
+	UnchainedLog IssueKind = "unchained_log"
+	// CheckpointMismatch reports a link that differs from what a checkpoint
+	// signed for it.
+	CheckpointMismatch IssueKind = "checkpoint_mismatch"
+	// BadSignature reports a checkpoint or tombstone not signed by the
+	// signing key.
+	BadSignature IssueKind = "bad_signature"
+)
+
+// Issue is a problem Verify found in a chain.
+type Issue struct {
+	Kind IssueKind
+	// Seq is the link the issue is about, 0 for unchained logs.
+	Seq int64
+	// LogID is the log the issue is about, when there is one.
+	LogID  ulid.ULID
+	Detail string
+}
+
+// Verification reports the state of the chain of a partition over a time
+// range.
+type Verification struct {
+	Partition   string
+	Links       int64
+	Logs        int64
+	Checkpoints int64
+	// Removed counts the chained logs erased or expired by retention, and
+	// Redacted the ones redacted, as their tombstones record.
+	Removed  int64
+	Redacted int64
+	Intact   bool
+	Issues   []Issue
+	// OmittedIssues counts the issues found past the ones reported.
+	OmittedIssues int64
+}
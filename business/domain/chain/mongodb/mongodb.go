@@ -0,0 +1,283 @@
+// Package mongodb implements a chain.Storer backed by two MongoDB
+// collections, one for links and one for checkpoints.
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/chain"
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/oklog/ulid/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type dbLink struct {
+	Partition string    `bson:"partition"`
+	Seq       int64     `bson:"seq"`
+	LogID     ulid.ULID `bson:"log_id"`
+	Timestamp time.Time `bson:"timestamp"`
+	Digest    string    `bson:"digest"`
+	PrevHash  string    `bson:"prevhash,omitempty"`
+	Hash      string    `bson:"hash"`
+}
+
+type dbCheckpoint struct {
+	Partition string    `bson:"partition"`
+	Seq       int64     `bson:"seq"`
+	Hash      string    `bson:"hash"`
+	Time      time.Time `bson:"time"`
+	KeyID     string    `bson:"key_id"`
+	Signature []byte    `bson:"signature"`
+}
+
+type dbTombstone struct {
+	Partition string      `bson:"partition"`
+	Reason    string      `bson:"reason"`
+	LogIDs    []ulid.ULID `bson:"log_ids,omitempty"`
+	Before    time.Time   `bson:"before,omitempty"`
+	Time      time.Time   `bson:"time"`
+	KeyID     string      `bson:"key_id"`
+	Signature []byte      `bson:"signature"`
+}
+
+type Store struct {
+	links       *mongo.Collection
+	checkpoints *mongo.Collection
+	tombstones  *mongo.Collection
+}
+
+// NewStore returns a store keeping the links in the collection named
+// collectionName in db, the checkpoints in collectionName+"_checkpoints" and
+// the tombstones in collectionName+"_tombstones".
+func NewStore(ctx context.Context, db *mongo.Database, collectionName string) (*Store, error) {
+	links := db.Collection(collectionName)
+	checkpoints := db.Collection(collectionName + "_checkpoints")
+	tombstones := db.Collection(collectionName + "_tombstones")
+
+	_, err := links.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "partition", Value: 1}, {Key: "seq", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "partition", Value: 1}, {Key: "timestamp", Value: 1}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating chain indexes: %w", err)
+	}
+
+	_, err = checkpoints.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "partition", Value: 1}, {Key: "seq", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating checkpoint indexes: %w", err)
+	}
+
+	_, err = tombstones.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "partition", Value: 1}, {Key: "time", Value: 1}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating tombstone indexes: %w", err)
+	}
+
+	return &Store{links: links, checkpoints: checkpoints, tombstones: tombstones}, nil
+}
+
+func (s *Store) AppendLink(ctx context.Context, l chain.Link) error {
+	_, err := s.links.InsertOne(ctx, dbLink{
+		Partition: l.Partition,
+		Seq:       l.Seq,
+		LogID:     l.LogID,
+		Timestamp: l.Timestamp,
+		Digest:    l.Digest,
+		PrevHash:  l.PrevHash,
+		Hash:      l.Hash,
+	})
+	return err
+}
+
+func (s *Store) Heads(ctx context.Context) ([]chain.Link, error) {
+	cursor, err := s.links.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$sort", Value: bson.D{{Key: "partition", Value: 1}, {Key: "seq", Value: -1}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$partition"},
+			{Key: "head", Value: bson.D{{Key: "$first", Value: "$$ROOT"}}},
+		}}},
+		{{Key: "$replaceRoot", Value: bson.D{{Key: "newRoot", Value: "$head"}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "partition", Value: 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []dbLink
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	heads := make([]chain.Link, len(docs))
+	for i, doc := range docs {
+		heads[i] = toCoreLink(doc)
+	}
+	return heads, nil
+}
+
+func (s *Store) SeqRange(ctx context.Context, partition string, tr mlog.TimeRange) (int64, int64, error) {
+	match := bson.M{"partition": partition}
+
+	timeRange := bson.M{}
+	if !tr.StartTime.IsZero() {
+		timeRange["$gte"] = tr.StartTime
+	}
+	if !tr.EndTime.IsZero() {
+		timeRange["$lte"] = tr.EndTime
+	}
+	if len(timeRange) > 0 {
+		match["timestamp"] = timeRange
+	}
+
+	cursor, err := s.links.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: nil},
+			{Key: "from", Value: bson.D{{Key: "$min", Value: "$seq"}}},
+			{Key: "to", Value: bson.D{{Key: "$max", Value: "$seq"}}},
+		}}},
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		From int64 `bson:"from"`
+		To   int64 `bson:"to"`
+	}
+	if err := cursor.All(ctx, &result); err != nil {
+		return 0, 0, err
+	}
+	if len(result) == 0 {
+		return 0, 0, chain.ErrNotFound
+	}
+
+	return result[0].From, result[0].To, nil
+}
+
+func (s *Store) Links(ctx context.Context, partition string, from, to int64, fn func(chain.Link) error) error {
+	filter := bson.M{"partition": partition, "seq": bson.M{"$gte": from, "$lte": to}}
+	cursor, err := s.links.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "seq", Value: 1}}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc dbLink
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		if err := fn(toCoreLink(doc)); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+func (s *Store) SaveCheckpoint(ctx context.Context, c chain.Checkpoint) error {
+	_, err := s.checkpoints.InsertOne(ctx, dbCheckpoint{
+		Partition: c.Partition,
+		Seq:       c.Seq,
+		Hash:      c.Hash,
+		Time:      c.Time,
+		KeyID:     c.KeyID,
+		Signature: c.Signature,
+	})
+	return err
+}
+
+func (s *Store) Checkpoints(ctx context.Context, partition string, from, to int64) ([]chain.Checkpoint, error) {
+	filter := bson.M{"partition": partition, "seq": bson.M{"$gte": from, "$lte": to}}
+	cursor, err := s.checkpoints.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "seq", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []dbCheckpoint
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	checkpoints := make([]chain.Checkpoint, len(docs))
+	for i, doc := range docs {
+		checkpoints[i] = chain.Checkpoint{
+			Partition: doc.Partition,
+			Seq:       doc.Seq,
+			Hash:      doc.Hash,
+			Time:      doc.Time.UTC(),
+			KeyID:     doc.KeyID,
+			Signature: doc.Signature,
+		}
+	}
+	return checkpoints, nil
+}
+
+func (s *Store) SaveTombstone(ctx context.Context, t chain.Tombstone) error {
+	_, err := s.tombstones.InsertOne(ctx, dbTombstone{
+		Partition: t.Partition,
+		Reason:    string(t.Reason),
+		LogIDs:    t.LogIDs,
+		Before:    t.Before,
+		Time:      t.Time,
+		KeyID:     t.KeyID,
+		Signature: t.Signature,
+	})
+	return err
+}
+
+func (s *Store) Tombstones(ctx context.Context, partition string) ([]chain.Tombstone, error) {
+	cursor, err := s.tombstones.Find(ctx, bson.M{"partition": partition}, options.Find().SetSort(bson.D{{Key: "time", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []dbTombstone
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	tombstones := make([]chain.Tombstone, len(docs))
+	for i, doc := range docs {
+		t := chain.Tombstone{
+			Partition: doc.Partition,
+			Reason:    mlog.DeletionReason(doc.Reason),
+			LogIDs:    doc.LogIDs,
+			Time:      doc.Time.UTC(),
+			KeyID:     doc.KeyID,
+			Signature: doc.Signature,
+		}
+		if !doc.Before.IsZero() {
+			t.Before = doc.Before.UTC()
+		}
+		tombstones[i] = t
+	}
+	return tombstones, nil
+}
+
+func toCoreLink(doc dbLink) chain.Link {
+	return chain.Link{
+		Partition: doc.Partition,
+		Seq:       doc.Seq,
+		LogID:     doc.LogID,
+		Timestamp: doc.Timestamp.UTC(),
+		Digest:    doc.Digest,
+		PrevHash:  doc.PrevHash,
+		Hash:      doc.Hash,
+	}
+}
+
+var _ chain.Storer = (*Store)(nil)
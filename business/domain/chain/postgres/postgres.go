@@ -0,0 +1,212 @@
+// Package postgres implements a chain.Storer on the log_chain and
+// chain_checkpoints tables of the PostgreSQL log store, which creates them
+// in its migrations.
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/chain"
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	linkColumns       = `tenant, seq, log_id, timestamp, digest, prev_hash, hash`
+	checkpointColumns = `tenant, seq, hash, time, key_id, signature`
+	tombstoneColumns  = `tenant, reason, log_ids, expired_before, time, key_id, signature`
+)
+
+type Store struct {
+	pool *pgxpool.Pool
+}
+
+func NewStore(pool *pgxpool.Pool) *Store {
+	return &Store{pool: pool}
+}
+
+func (s *Store) AppendLink(ctx context.Context, l chain.Link) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO log_chain (`+linkColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		l.Partition, l.Seq, l.LogID.String(), l.Timestamp, l.Digest, l.PrevHash, l.Hash,
+	)
+	return err
+}
+
+func (s *Store) Heads(ctx context.Context) ([]chain.Link, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT DISTINCT ON (tenant) `+linkColumns+` FROM log_chain ORDER BY tenant, seq DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var heads []chain.Link
+	for rows.Next() {
+		l, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		heads = append(heads, l)
+	}
+
+	return heads, rows.Err()
+}
+
+func (s *Store) SeqRange(ctx context.Context, partition string, tr mlog.TimeRange) (int64, int64, error) {
+	query := `SELECT MIN(seq), MAX(seq) FROM log_chain WHERE tenant = $1`
+	args := []any{partition}
+
+	if !tr.StartTime.IsZero() {
+		args = append(args, tr.StartTime)
+		query += fmt.Sprintf(` AND timestamp >= $%d`, len(args))
+	}
+	if !tr.EndTime.IsZero() {
+		args = append(args, tr.EndTime)
+		query += fmt.Sprintf(` AND timestamp <= $%d`, len(args))
+	}
+
+	var from, to *int64
+	if err := s.pool.QueryRow(ctx, query, args...).Scan(&from, &to); err != nil {
+		return 0, 0, err
+	}
+	if from == nil {
+		return 0, 0, chain.ErrNotFound
+	}
+
+	return *from, *to, nil
+}
+
+func (s *Store) Links(ctx context.Context, partition string, from, to int64, fn func(chain.Link) error) error {
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+linkColumns+` FROM log_chain WHERE tenant = $1 AND seq >= $2 AND seq <= $3 ORDER BY seq`,
+		partition, from, to)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		l, err := scanLink(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(l); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *Store) SaveCheckpoint(ctx context.Context, c chain.Checkpoint) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO chain_checkpoints (`+checkpointColumns+`) VALUES ($1, $2, $3, $4, $5, $6)`,
+		c.Partition, c.Seq, c.Hash, c.Time, c.KeyID, c.Signature,
+	)
+	return err
+}
+
+func (s *Store) Checkpoints(ctx context.Context, partition string, from, to int64) ([]chain.Checkpoint, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+checkpointColumns+` FROM chain_checkpoints WHERE tenant = $1 AND seq >= $2 AND seq <= $3 ORDER BY seq`,
+		partition, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checkpoints []chain.Checkpoint
+	for rows.Next() {
+		var c chain.Checkpoint
+		if err := rows.Scan(&c.Partition, &c.Seq, &c.Hash, &c.Time, &c.KeyID, &c.Signature); err != nil {
+			return nil, err
+		}
+		c.Time = c.Time.UTC()
+		checkpoints = append(checkpoints, c)
+	}
+
+	return checkpoints, rows.Err()
+}
+
+func (s *Store) SaveTombstone(ctx context.Context, t chain.Tombstone) error {
+	ids := make([]string, len(t.LogIDs))
+	for i, id := range t.LogIDs {
+		ids[i] = id.String()
+	}
+
+	var before *time.Time
+	if !t.Before.IsZero() {
+		before = &t.Before
+	}
+
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO chain_tombstones (`+tombstoneColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		t.Partition, string(t.Reason), ids, before, t.Time, t.KeyID, t.Signature,
+	)
+	return err
+}
+
+func (s *Store) Tombstones(ctx context.Context, partition string) ([]chain.Tombstone, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+tombstoneColumns+` FROM chain_tombstones WHERE tenant = $1 ORDER BY time`, partition)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tombstones []chain.Tombstone
+	for rows.Next() {
+		var (
+			t      chain.Tombstone
+			reason string
+			ids    []string
+			before *time.Time
+		)
+		if err := rows.Scan(&t.Partition, &reason, &ids, &before, &t.Time, &t.KeyID, &t.Signature); err != nil {
+			return nil, err
+		}
+		t.Reason = mlog.DeletionReason(reason)
+		t.Time = t.Time.UTC()
+		if before != nil {
+			t.Before = before.UTC()
+		}
+
+		for _, id := range ids {
+			parsed, err := ulid.Parse(id)
+			if err != nil {
+				return nil, fmt.Errorf("parsing id %q: %w", id, err)
+			}
+			t.LogIDs = append(t.LogIDs, parsed)
+		}
+
+		tombstones = append(tombstones, t)
+	}
+
+	return tombstones, rows.Err()
+}
+
+func scanLink(row pgx.Row) (chain.Link, error) {
+	var (
+		l  chain.Link
+		id string
+	)
+	if err := row.Scan(&l.Partition, &l.Seq, &id, &l.Timestamp, &l.Digest, &l.PrevHash, &l.Hash); err != nil {
+		return chain.Link{}, err
+	}
+
+	var err error
+	l.LogID, err = ulid.Parse(id)
+	if err != nil {
+		return chain.Link{}, fmt.Errorf("parsing id %q: %w", id, err)
+	}
+	l.Timestamp = l.Timestamp.UTC()
+
+	return l, nil
+}
+
+var _ chain.Storer = (*Store)(nil)
@@ -0,0 +1,74 @@
+package chain
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Signer signs checkpoints with an Ed25519 key.
+type Signer struct {
+	key ed25519.PrivateKey
+	id  string
+}
+
+// LoadSigner reads the signing key from the file at path, holding a 32 byte
+// Ed25519 seed in base64 or hex.
+func LoadSigner(path string) (*Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading signing key: %w", err)
+	}
+
+	text := strings.TrimSpace(string(data))
+	if seed, err := base64.StdEncoding.DecodeString(text); err == nil && len(seed) == ed25519.SeedSize {
+		return NewSigner(seed), nil
+	}
+	if seed, err := hex.DecodeString(text); err == nil && len(seed) == ed25519.SeedSize {
+		return NewSigner(seed), nil
+	}
+
+	return nil, fmt.Errorf("signing key must hold a %d byte seed in base64 or hex", ed25519.SeedSize)
+}
+
+// NewSigner returns a signer for the Ed25519 key derived from seed.
+func NewSigner(seed []byte) *Signer {
+	key := ed25519.NewKeyFromSeed(seed)
+
+	sum := sha256.Sum256(key.Public().(ed25519.PublicKey))
+	return &Signer{
+		key: key,
+		id:  hex.EncodeToString(sum[:8]),
+	}
+}
+
+// PublicKey returns the key checkpoints are checked with.
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+// Sign sets the key ID and signature of c.
+func (s *Signer) Sign(c *Checkpoint) {
+	c.KeyID = s.id
+	c.Signature = ed25519.Sign(s.key, c.payload())
+}
+
+// Verify reports whether c was signed by the key of s.
+func (s *Signer) Verify(c Checkpoint) bool {
+	return c.KeyID == s.id && ed25519.Verify(s.PublicKey(), c.payload(), c.Signature)
+}
+
+// SignTombstone sets the key ID and signature of t.
+func (s *Signer) SignTombstone(t *Tombstone) {
+	t.KeyID = s.id
+	t.Signature = ed25519.Sign(s.key, t.payload())
+}
+
+// VerifyTombstone reports whether t was signed by the key of s.
+func (s *Signer) VerifyTombstone(t Tombstone) bool {
+	return t.KeyID == s.id && ed25519.Verify(s.PublicKey(), t.payload(), t.Signature)
+}
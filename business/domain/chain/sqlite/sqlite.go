@@ -0,0 +1,226 @@
+// Package sqlite implements a chain.Storer on the log_chain and
+// chain_checkpoints tables of the SQLite log store, which creates them in
+// its migrations.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/chain"
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	linkColumns       = `tenant, seq, log_id, timestamp, digest, prev_hash, hash`
+	checkpointColumns = `tenant, seq, hash, time, key_id, signature`
+	tombstoneColumns  = `tenant, reason, log_ids, expired_before, time, key_id, signature`
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) AppendLink(ctx context.Context, l chain.Link) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO log_chain (`+linkColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		l.Partition, l.Seq, l.LogID.String(), l.Timestamp.UnixMilli(), l.Digest, l.PrevHash, l.Hash,
+	)
+	return err
+}
+
+func (s *Store) Heads(ctx context.Context) ([]chain.Link, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+linkColumns+` FROM log_chain
+		WHERE (tenant, seq) IN (SELECT tenant, MAX(seq) FROM log_chain GROUP BY tenant)
+		ORDER BY tenant`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var heads []chain.Link
+	for rows.Next() {
+		l, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		heads = append(heads, l)
+	}
+
+	return heads, rows.Err()
+}
+
+func (s *Store) SeqRange(ctx context.Context, partition string, tr mlog.TimeRange) (int64, int64, error) {
+	query := `SELECT MIN(seq), MAX(seq) FROM log_chain WHERE tenant = ?`
+	args := []any{partition}
+
+	if !tr.StartTime.IsZero() {
+		query += ` AND timestamp >= ?`
+		args = append(args, tr.StartTime.UnixMilli())
+	}
+	if !tr.EndTime.IsZero() {
+		query += ` AND timestamp <= ?`
+		args = append(args, tr.EndTime.UnixMilli())
+	}
+
+	var from, to sql.NullInt64
+	if err := s.db.QueryRowContext(ctx, query, args...).Scan(&from, &to); err != nil {
+		return 0, 0, err
+	}
+	if !from.Valid {
+		return 0, 0, chain.ErrNotFound
+	}
+
+	return from.Int64, to.Int64, nil
+}
+
+func (s *Store) Links(ctx context.Context, partition string, from, to int64, fn func(chain.Link) error) error {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+linkColumns+` FROM log_chain WHERE tenant = ? AND seq >= ? AND seq <= ? ORDER BY seq`,
+		partition, from, to)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		l, err := scanLink(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(l); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *Store) SaveCheckpoint(ctx context.Context, c chain.Checkpoint) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO chain_checkpoints (`+checkpointColumns+`) VALUES (?, ?, ?, ?, ?, ?)`,
+		c.Partition, c.Seq, c.Hash, c.Time.UnixMilli(), c.KeyID, c.Signature,
+	)
+	return err
+}
+
+func (s *Store) Checkpoints(ctx context.Context, partition string, from, to int64) ([]chain.Checkpoint, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+checkpointColumns+` FROM chain_checkpoints WHERE tenant = ? AND seq >= ? AND seq <= ? ORDER BY seq`,
+		partition, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checkpoints []chain.Checkpoint
+	for rows.Next() {
+		var (
+			c  chain.Checkpoint
+			ms int64
+		)
+		if err := rows.Scan(&c.Partition, &c.Seq, &c.Hash, &ms, &c.KeyID, &c.Signature); err != nil {
+			return nil, err
+		}
+		c.Time = time.UnixMilli(ms).UTC()
+		checkpoints = append(checkpoints, c)
+	}
+
+	return checkpoints, rows.Err()
+}
+
+func (s *Store) SaveTombstone(ctx context.Context, t chain.Tombstone) error {
+	ids := make([]string, len(t.LogIDs))
+	for i, id := range t.LogIDs {
+		ids[i] = id.String()
+	}
+	logIDs, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+
+	var before int64
+	if !t.Before.IsZero() {
+		before = t.Before.UnixMilli()
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO chain_tombstones (`+tombstoneColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		t.Partition, string(t.Reason), string(logIDs), before, t.Time.UnixMilli(), t.KeyID, t.Signature,
+	)
+	return err
+}
+
+func (s *Store) Tombstones(ctx context.Context, partition string) ([]chain.Tombstone, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+tombstoneColumns+` FROM chain_tombstones WHERE tenant = ? ORDER BY time`, partition)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tombstones []chain.Tombstone
+	for rows.Next() {
+		var (
+			t          chain.Tombstone
+			reason     string
+			logIDs     string
+			before, ms int64
+		)
+		if err := rows.Scan(&t.Partition, &reason, &logIDs, &before, &ms, &t.KeyID, &t.Signature); err != nil {
+			return nil, err
+		}
+		t.Reason = mlog.DeletionReason(reason)
+		t.Time = time.UnixMilli(ms).UTC()
+		if before != 0 {
+			t.Before = time.UnixMilli(before).UTC()
+		}
+
+		var ids []string
+		if err := json.Unmarshal([]byte(logIDs), &ids); err != nil {
+			return nil, fmt.Errorf("parsing tombstone ids: %w", err)
+		}
+		for _, id := range ids {
+			parsed, err := ulid.Parse(id)
+			if err != nil {
+				return nil, fmt.Errorf("parsing id %q: %w", id, err)
+			}
+			t.LogIDs = append(t.LogIDs, parsed)
+		}
+
+		tombstones = append(tombstones, t)
+	}
+
+	return tombstones, rows.Err()
+}
+
+func scanLink(rows *sql.Rows) (chain.Link, error) {
+	var (
+		l      chain.Link
+		id     string
+		millis int64
+	)
+	if err := rows.Scan(&l.Partition, &l.Seq, &id, &millis, &l.Digest, &l.PrevHash, &l.Hash); err != nil {
+		return chain.Link{}, err
+	}
+
+	var err error
+	l.LogID, err = ulid.Parse(id)
+	if err != nil {
+		return chain.Link{}, fmt.Errorf("parsing id %q: %w", id, err)
+	}
+	l.Timestamp = time.UnixMilli(millis).UTC()
+
+	return l, nil
+}
+
+var _ chain.Storer = (*Store)(nil)
@@ -0,0 +1,134 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+)
+
+// Writer stores logs through the writer it wraps and links each log stored
+// into its chain. Writes are serialized, so logs are chained in the order
+// they are stored.
+type Writer struct {
+	next  mlog.Writer
+	chain *Business
+}
+
+// NewWriter returns a writer chaining the logs written through next.
+func NewWriter(next mlog.Writer, chain *Business) *Writer {
+	return &Writer{
+		next:  next,
+		chain: chain,
+	}
+}
+
+// Write stores log and links it. A log stored whose link cannot be stored
+// is still reported as written, since a retry would store it twice; it is
+// linked before the next log instead. Once too many logs wait for their
+// link, writes fail without storing anything.
+func (w *Writer) Write(ctx context.Context, log *mlog.Log) error {
+	w.chain.mu.Lock()
+	defer w.chain.mu.Unlock()
+
+	if err := w.chain.full(ctx); err != nil {
+		return err
+	}
+
+	if err := w.next.Write(ctx, log); err != nil {
+		return err
+	}
+	w.chain.link(ctx, []*mlog.Log{log})
+	return nil
+}
+
+// WriteBatch stores logs and links the ones stored, as Write does.
+func (w *Writer) WriteBatch(ctx context.Context, logs []*mlog.Log) error {
+	w.chain.mu.Lock()
+	defer w.chain.mu.Unlock()
+
+	if err := w.chain.full(ctx); err != nil {
+		return err
+	}
+
+	var failed map[int]error
+
+	err := mlog.WriteBatch(ctx, w.next, logs)
+	if err != nil {
+		var batchErr *mlog.BatchError
+		if !errors.As(err, &batchErr) {
+			return err
+		}
+		failed = batchErr.Failed
+	}
+
+	stored := make([]*mlog.Log, 0, len(logs))
+	for i, log := range logs {
+		if _, ok := failed[i]; !ok {
+			stored = append(stored, log)
+		}
+	}
+	w.chain.link(ctx, stored)
+
+	return err
+}
+
+// WithTx returns a writer storing logs in store, which runs inside a
+// transaction, and linking them once it commits, so a rolled back log is
+// never chained.
+func (w *Writer) WithTx(store mlog.Store) mlog.TxBoundWriter {
+	return &txWriter{
+		store: store,
+		chain: w.chain,
+	}
+}
+
+// txWriter stores logs inside a transaction and links them when it commits.
+type txWriter struct {
+	store mlog.Store
+	chain *Business
+
+	mu     sync.Mutex
+	stored []*mlog.Log
+}
+
+func (w *txWriter) Write(ctx context.Context, log *mlog.Log) error {
+	w.chain.mu.Lock()
+	err := w.chain.full(ctx)
+	w.chain.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := w.store.Write(ctx, log); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	w.stored = append(w.stored, log)
+	w.mu.Unlock()
+	return nil
+}
+
+// Committed links the logs stored inside the transaction, after the logs
+// linked meanwhile.
+func (w *txWriter) Committed(ctx context.Context) {
+	w.mu.Lock()
+	stored := w.stored
+	w.stored = nil
+	w.mu.Unlock()
+
+	if len(stored) == 0 {
+		return
+	}
+
+	w.chain.mu.Lock()
+	defer w.chain.mu.Unlock()
+	w.chain.link(ctx, stored)
+}
+
+var (
+	_ mlog.BatchWriter = (*Writer)(nil)
+	_ mlog.TxWriter    = (*Writer)(nil)
+)
@@ -0,0 +1,45 @@
+package mlog
+
+import (
+	"context"
+	"fmt"
+)
+
+// BatchError reports the logs of a batch that were not stored, by their
+// index in the batch. Every other log was stored.
+type BatchError struct {
+	Failed map[int]error
+}
+
+func (e *BatchError) Error() string {
+	for _, err := range e.Failed {
+		return fmt.Sprintf("%d logs of the batch failed, first: %v", len(e.Failed), err)
+	}
+	return "batch failed"
+}
+
+// WriteBatch stores logs through w in a single round trip when w is a
+// BatchWriter, and one by one otherwise. Logs written one by one that fail
+// are reported as a *BatchError.
+func WriteBatch(ctx context.Context, w Writer, logs []*Log) error {
+	if bw, ok := w.(BatchWriter); ok {
+		return bw.WriteBatch(ctx, logs)
+	}
+
+	failed := map[int]error{}
+	for i, log := range logs {
+		if err := w.Write(ctx, log); err != nil {
+			failed[i] = err
+		}
+	}
+
+	switch len(failed) {
+	case 0:
+		return nil
+	case len(logs):
+		for _, err := range failed {
+			return err
+		}
+	}
+	return &BatchError{Failed: failed}
+}
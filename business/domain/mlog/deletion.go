@@ -0,0 +1,50 @@
+package mlog
+
+import (
+	"context"
+
+	"github.com/oklog/ulid/v2"
+)
+
+type DeletionReason string
+
+const (
+	DeletionErase     DeletionReason = "erase"
+	DeletionRedact    DeletionReason = "redact"
+	DeletionRetention DeletionReason = "retention"
+)
+
+// Deletion describes logs Business removed or rewrote on purpose.
+type Deletion struct {
+	Reason DeletionReason
+	// Tenant and IDs name the logs erased, redacted or expired.
+	Tenant string
+	IDs    []ulid.ULID
+}
+
+// DeletionRecorder is told of every Deletion, so records kept over the logs,
+// such as the log chain, can tell them from tampering.
+type DeletionRecorder interface {
+	RecordDeletion(ctx context.Context, d Deletion) error
+}
+
+// WithDeletionRecorder reports the logs erased, redacted and expired by
+// retention to r.
+func WithDeletionRecorder(r DeletionRecorder) Option {
+	return func(b *Business) {
+		b.deletions = r
+	}
+}
+
+// recordDeletion reports d to the recorder, if there is one.
+func (b *Business) recordDeletion(ctx context.Context, d Deletion) error {
+	if b.deletions == nil {
+		return nil
+	}
+
+	if err := b.deletions.RecordDeletion(ctx, d); err != nil {
+		b.logger.Error(ctx, "failed to record deletion", "error", err, "reason", d.Reason)
+		return err
+	}
+	return nil
+}
@@ -2,6 +2,10 @@ package mlog
 
 import (
 	"context"
+	"time"
+
+	"github.com/felipecooper/log-horizon/foundation/transaction"
+	"github.com/oklog/ulid/v2"
 )
 
 type Writer interface {
@@ -22,3 +26,100 @@ type Store interface {
 	Reader
 	Exporter
 }
+
+type Compactor interface {
+	Compact(ctx context.Context, olderThan time.Time) (CompactionReport, error)
+}
+
+type StatsReporter interface {
+	Stats(ctx context.Context) (Stats, error)
+}
+
+type RetentionStore interface {
+	RetentionPolicies(ctx context.Context) ([]RetentionPolicy, error)
+	SaveRetentionPolicy(ctx context.Context, policy RetentionPolicy) error
+	DeleteRetentionPolicy(ctx context.Context, name string) error
+	ApplyRetention(ctx context.Context, policies []RetentionPolicy, holds []LegalHold, now time.Time, dryRun bool) ([]RetentionResult, error)
+}
+
+type Eraser interface {
+	Erase(ctx context.Context, criteria DeleteCriteria, holds []LegalHold) (DeleteResult, error)
+	SaveDeletionAudit(ctx context.Context, audit DeletionAudit) error
+}
+
+type HoldStore interface {
+	CreateLegalHold(ctx context.Context, hold LegalHold) error
+	LegalHolds(ctx context.Context, includeReleased bool) ([]LegalHold, error)
+	ReleaseLegalHold(ctx context.Context, id ulid.ULID, by string, at time.Time) (LegalHold, error)
+}
+
+// Scanner streams every log matching criteria in ascending timestamp order,
+// ignoring paging.
+type Scanner interface {
+	Scan(ctx context.Context, criteria SearchCriteria, fn func(Log) error) error
+}
+
+// Streamer walks every log matching criteria newest first, the order of
+// Search, ignoring paging. Stores implement it to serve a whole stream from
+// a single cursor instead of one query per page.
+type Streamer interface {
+	Stream(ctx context.Context, criteria SearchCriteria, fn func(Log) error) error
+}
+
+// BatchWriter stores several logs in a single round trip. When only some
+// logs fail it returns a *BatchError.
+type BatchWriter interface {
+	WriteBatch(ctx context.Context, logs []*Log) error
+}
+
+// IdempotencyStore remembers which log each idempotency key produced, so
+// retries are recognized by every instance sharing the store.
+type IdempotencyStore interface {
+	// ClaimIdempotencyKey binds key to id, unless it is bound since since,
+	// in which case it returns the ID bound and false.
+	ClaimIdempotencyKey(ctx context.Context, key string, id ulid.ULID, at, since time.Time) (ulid.ULID, bool, error)
+	ReleaseIdempotencyKey(ctx context.Context, key string, id ulid.ULID) error
+	PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int64, error)
+}
+
+// BacklogReporter is implemented by writers that hold logs before they reach
+// the store.
+type BacklogReporter interface {
+	Backlog() Backlog
+}
+
+// Pruner removes logs without auditing. It is meant for data that has
+// already been copied elsewhere, such as an archive tier: only the logs of
+// ids inside tr are removed, so logs that reached tr after the copy stay.
+type Pruner interface {
+	Prune(ctx context.Context, tr TimeRange, ids []ulid.ULID) (int64, error)
+}
+
+// MessageSearcher is implemented by stores that filter on
+// SearchCriteria.Message.
+type MessageSearcher interface {
+	SearchMessages() bool
+}
+
+// Transactor is implemented by stores that group calls into transactions.
+// NewWithTx returns a store whose calls run inside tx, which must have been
+// started by Begin on the same store.
+type Transactor interface {
+	transaction.Beginner
+	NewWithTx(tx transaction.CommitRollbacker) (Store, error)
+}
+
+// TxWriter is implemented by writers that do more than store logs, such as
+// the log chain, so logs written inside a transaction go through them too.
+// WithTx returns a writer storing logs in store, which runs inside a
+// transaction.
+type TxWriter interface {
+	WithTx(store Store) TxBoundWriter
+}
+
+// TxBoundWriter writes inside a transaction. Committed is called once the
+// transaction commits, and never when it is rolled back.
+type TxBoundWriter interface {
+	Writer
+	Committed(ctx context.Context)
+}
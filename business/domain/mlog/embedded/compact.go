@@ -0,0 +1,183 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/business/domain/mlog/segment"
+	"github.com/oklog/ulid/v2"
+)
+
+// Compact merges the segments of every partition that ended before olderThan
+// into a single segment. Each flush adds a segment per partition it touches,
+// so without compaction reads of a busy day open many small files.
+func (s *Store) Compact(ctx context.Context, olderThan time.Time) (mlog.CompactionReport, error) {
+	if err := s.Flush(ctx); err != nil {
+		return mlog.CompactionReport{}, err
+	}
+
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	var report mlog.CompactionReport
+
+	for p, files := range s.partitions() {
+		if p.Add(day).After(olderThan) || len(files) < 2 {
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		merged, err := s.rewrite(p, files, nil)
+		if err != nil {
+			return report, fmt.Errorf("compacting %s: %w", p.Format(dayLayout), err)
+		}
+
+		for _, f := range files {
+			report.Logs += int(f.index.Count)
+			report.BytesBefore += f.size
+		}
+		if merged != nil {
+			report.Blocks += len(merged.index.Blocks)
+			report.BytesAfter += merged.size
+		}
+	}
+
+	return report, nil
+}
+
+// Prune removes the logs of ids inside tr. The segments holding them are
+// rewritten without those logs, or deleted when nothing is left.
+func (s *Store) Prune(ctx context.Context, tr mlog.TimeRange, ids []ulid.ULID) (int64, error) {
+	if err := s.Flush(ctx); err != nil {
+		return 0, err
+	}
+
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	inRange := mlog.SearchCriteria{AllTenants: true, TimeRange: tr}
+	pruned := make(map[ulid.ULID]bool, len(ids))
+	for _, id := range ids {
+		pruned[id] = true
+	}
+
+	var deleted int64
+	for p, files := range s.partitions() {
+		var touched []*segmentFile
+		for _, f := range files {
+			if f.index.Overlaps(tr) {
+				touched = append(touched, f)
+			}
+		}
+		if len(touched) == 0 {
+			continue
+		}
+
+		var before int64
+		for _, f := range touched {
+			before += f.index.Count
+		}
+
+		merged, err := s.rewrite(p, touched, func(log mlog.Log) bool {
+			return !pruned[log.ID] || !segment.Match(log, inRange)
+		})
+		if err != nil {
+			return deleted, fmt.Errorf("pruning %s: %w", p.Format(dayLayout), err)
+		}
+
+		deleted += before
+		if merged != nil {
+			deleted -= merged.index.Count
+		}
+	}
+
+	return deleted, nil
+}
+
+// partitions groups the current segments by partition.
+func (s *Store) partitions() map[time.Time][]*segmentFile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make(map[time.Time][]*segmentFile)
+	for _, f := range s.segments {
+		out[f.partition] = append(out[f.partition], f)
+	}
+	return out
+}
+
+// rewrite replaces files, all of partition p, by one segment with their logs
+// accepted by keep, or by nothing when no log is kept. The new segment lists
+// the files it replaces so a crash before they are removed is repaired on
+// the next start. Callers hold flushMu.
+func (s *Store) rewrite(p time.Time, files []*segmentFile, keep func(mlog.Log) bool) (*segmentFile, error) {
+	var (
+		logs []mlog.Log
+		seq  uint64
+	)
+
+	for _, f := range files {
+		if f.seq > seq {
+			seq = f.seq
+		}
+
+		r, closer, err := s.open(f)
+		if err != nil {
+			return nil, err
+		}
+
+		err = r.Scan(mlog.SearchCriteria{AllTenants: true}, func(log mlog.Log) error {
+			if keep == nil || keep(log) {
+				logs = append(logs, log)
+			}
+			return nil
+		})
+		closer.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].Timestamp.Before(logs[j].Timestamp)
+	})
+
+	var merged *segmentFile
+	if len(logs) > 0 {
+		names := make([]string, len(files))
+		for i, f := range files {
+			names[i] = f.name
+		}
+
+		var err error
+		merged, err = s.writeSegment(p, seq, func(w *segment.Writer) error {
+			w.Supersede(names...)
+			for _, log := range logs {
+				if err := w.Append(log); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var added []*segmentFile
+	if merged != nil {
+		added = append(added, merged)
+	}
+
+	s.mu.Lock()
+	s.install(added, files)
+	s.mu.Unlock()
+
+	return merged, s.retire(files)
+}
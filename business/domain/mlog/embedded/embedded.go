@@ -0,0 +1,631 @@
+// Package embedded implements mlog.Store as an append-only storage engine on
+// local disk, for deployments without MongoDB.
+//
+// Writes are appended to a write-ahead log and kept in a memtable. Flush turns
+// the memtable into immutable segment files partitioned by UTC day, whose
+// indexes carry the time range and level counts used to prune reads. On
+// startup the segments are loaded and the write-ahead log replayed, skipping
+// records whose partition was already flushed. Compact merges the segments of
+// a partition into one.
+//
+//	<dir>/wal/<seq>.wal
+//	<dir>/segments/<day>/<seq>-<ulid>.seg
+package embedded
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/business/domain/mlog/segment"
+	"github.com/felipecooper/log-horizon/foundation/blob"
+	"github.com/felipecooper/log-horizon/foundation/compress"
+	"github.com/felipecooper/log-horizon/foundation/logger"
+	"github.com/oklog/ulid/v2"
+)
+
+var ErrClosed = errors.New("store closed")
+
+const (
+	defaultMemtableSize = 10000
+	defaultPageSize     = 50
+	segmentExt          = ".seg"
+	tmpExt              = ".tmp"
+	dayLayout           = "2006-01-02"
+	day                 = 24 * time.Hour
+)
+
+type Config struct {
+	Dir              string
+	Compression      compress.Algorithm
+	CompressionLevel int
+	// BlockSize is the uncompressed size at which segment blocks are cut.
+	BlockSize int
+	// MemtableSize is the number of logs buffered before a flush starts.
+	MemtableSize int
+	// NoSync skips the fsync after every write-ahead log append, trading the
+	// last writes before a power loss for throughput.
+	NoSync       bool
+	ExportPath   string
+	ExportBucket blob.Bucket
+}
+
+type Store struct {
+	log          logger.Logger
+	walDir       string
+	segmentDir   string
+	compressor   compress.Compressor
+	blockSize    int
+	memtableSize int
+	sync         bool
+	exports      blob.Bucket
+
+	// mu guards everything below. flushMu serializes Flush, Compact and
+	// Prune, which replace segments. Queries hold a reference on the
+	// segments they read, so replaced files are only removed once no query
+	// uses them.
+	mu       sync.RWMutex
+	flushMu  sync.Mutex
+	wal      *wal
+	pending  []uint64
+	memtable []mlog.Log
+	frozen   []mlog.Log
+	segments []*segmentFile
+	closed   bool
+}
+
+// segmentFile is the manifest entry of one segment on disk.
+type segmentFile struct {
+	name      string
+	partition time.Time
+	seq       uint64
+	size      int64
+	index     segment.Index
+
+	// refs counts the queries reading the segment. Once replaced is set the
+	// file is removed by whoever sees no query left, see retire.
+	refs     atomic.Int64
+	replaced atomic.Bool
+}
+
+func NewStore(ctx context.Context, log logger.Logger, cfg Config) (*Store, error) {
+	algorithm := cfg.Compression
+	if algorithm == "" {
+		algorithm = compress.Zstd
+	}
+
+	compressor, err := compress.New(algorithm, cfg.CompressionLevel)
+	if err != nil {
+		return nil, fmt.Errorf("creating compressor: %w", err)
+	}
+
+	memtableSize := cfg.MemtableSize
+	if memtableSize <= 0 {
+		memtableSize = defaultMemtableSize
+	}
+
+	exports := cfg.ExportBucket
+	if exports == nil {
+		exports = blob.NewLocal(cfg.ExportPath)
+	}
+
+	s := Store{
+		log:          log,
+		walDir:       filepath.Join(cfg.Dir, "wal"),
+		segmentDir:   filepath.Join(cfg.Dir, "segments"),
+		compressor:   compressor,
+		blockSize:    cfg.BlockSize,
+		memtableSize: memtableSize,
+		sync:         !cfg.NoSync,
+		exports:      exports,
+	}
+
+	for _, dir := range []string{s.walDir, s.segmentDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("creating %s: %w", dir, err)
+		}
+	}
+
+	if err := s.loadSegments(ctx); err != nil {
+		return nil, fmt.Errorf("loading segments: %w", err)
+	}
+
+	if err := s.recover(ctx); err != nil {
+		return nil, fmt.Errorf("replaying wal: %w", err)
+	}
+
+	if err := s.Flush(ctx); err != nil {
+		return nil, fmt.Errorf("flushing recovered logs: %w", err)
+	}
+
+	return &s, nil
+}
+
+func (s *Store) Write(ctx context.Context, log *mlog.Log) error {
+	return s.write(ctx, *log)
+}
+
+// WriteBatch appends logs to the write-ahead log with a single sync, which
+// is what makes batching worth it with SyncWrites enabled.
+func (s *Store) WriteBatch(ctx context.Context, logs []*mlog.Log) error {
+	batch := make([]mlog.Log, len(logs))
+	for i, log := range logs {
+		batch[i] = *log
+	}
+	return s.write(ctx, batch...)
+}
+
+func (s *Store) write(ctx context.Context, logs ...mlog.Log) error {
+	s.mu.Lock()
+
+	if s.closed {
+		s.mu.Unlock()
+		return ErrClosed
+	}
+
+	if err := s.wal.append(logs...); err != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("appending to wal: %w", err)
+	}
+
+	s.memtable = append(s.memtable, logs...)
+	full := len(s.memtable) >= s.memtableSize
+
+	s.mu.Unlock()
+
+	if full && s.flushMu.TryLock() {
+		go func() {
+			defer s.flushMu.Unlock()
+			if err := s.flush(context.Background()); err != nil {
+				s.log.Error(ctx, "failed to flush memtable", "error", err)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// Flush writes the memtable to segments and drops the write-ahead log files
+// it covers.
+func (s *Store) Flush(ctx context.Context) error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	return s.flush(ctx)
+}
+
+func (s *Store) flush(ctx context.Context) error {
+	s.mu.Lock()
+	if len(s.memtable) == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+
+	// New writes go to a fresh wal and memtable while the frozen one is
+	// written out; reads keep seeing the frozen logs until then.
+	seq := s.wal.seq
+	next, err := s.rotate()
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	s.wal = next
+
+	s.frozen = s.memtable
+	s.memtable = nil
+	pending := s.pending
+	s.pending = []uint64{next.seq}
+	frozen := s.frozen
+	s.mu.Unlock()
+
+	files, err := s.writePartitions(frozen, seq)
+	if err != nil {
+		s.mu.Lock()
+		s.memtable = append(s.frozen, s.memtable...)
+		s.frozen = nil
+		s.pending = append(pending, s.pending...)
+		s.mu.Unlock()
+		return err
+	}
+
+	s.mu.Lock()
+	s.install(files, nil)
+	s.frozen = nil
+	s.mu.Unlock()
+
+	for _, seq := range pending {
+		if err := os.Remove(filepath.Join(s.walDir, walName(seq))); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			s.log.Error(ctx, "failed to remove flushed wal", "seq", seq, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// rotate closes the current wal and opens the next one.
+func (s *Store) rotate() (*wal, error) {
+	if err := s.wal.close(); err != nil {
+		return nil, fmt.Errorf("closing wal: %w", err)
+	}
+
+	next, err := openWAL(s.walDir, s.wal.seq+1, s.sync)
+	if err != nil {
+		return nil, err
+	}
+
+	return next, nil
+}
+
+// writePartitions writes one segment per day of logs, named after seq, the
+// newest wal the logs came from.
+func (s *Store) writePartitions(logs []mlog.Log, seq uint64) ([]*segmentFile, error) {
+	partitions := make(map[time.Time][]mlog.Log)
+	for _, log := range logs {
+		p := partitionOf(log.Timestamp)
+		partitions[p] = append(partitions[p], log)
+	}
+
+	var files []*segmentFile
+	for p, logs := range partitions {
+		sort.SliceStable(logs, func(i, j int) bool {
+			return logs[i].Timestamp.Before(logs[j].Timestamp)
+		})
+
+		f, err := s.writeSegment(p, seq, func(w *segment.Writer) error {
+			for _, log := range logs {
+				if err := w.Append(log); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			for _, f := range files {
+				os.Remove(filepath.Join(s.segmentDir, f.name))
+			}
+			return nil, err
+		}
+
+		files = append(files, f)
+	}
+
+	return files, nil
+}
+
+// writeSegment builds a segment of partition p in a temporary file and
+// renames it into place once it is durable.
+func (s *Store) writeSegment(p time.Time, seq uint64, fill func(w *segment.Writer) error) (*segmentFile, error) {
+	name := filepath.ToSlash(filepath.Join(p.Format(dayLayout), fmt.Sprintf("%0*d-%s%s", walSeqDigits, seq, ulid.Make(), segmentExt)))
+	path := filepath.Join(s.segmentDir, name)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating partition: %w", err)
+	}
+
+	file, err := os.Create(path + tmpExt)
+	if err != nil {
+		return nil, fmt.Errorf("creating segment: %w", err)
+	}
+	defer os.Remove(path + tmpExt)
+	defer file.Close()
+
+	w, err := segment.NewWriter(file, s.compressor, s.blockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fill(w); err != nil {
+		return nil, err
+	}
+
+	index, err := w.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := file.Sync(); err != nil {
+		return nil, fmt.Errorf("syncing segment: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stating segment: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("closing segment: %w", err)
+	}
+
+	if err := os.Rename(path+tmpExt, path); err != nil {
+		return nil, fmt.Errorf("renaming segment: %w", err)
+	}
+
+	return &segmentFile{
+		name:      name,
+		partition: p,
+		seq:       seq,
+		size:      info.Size(),
+		index:     index,
+	}, nil
+}
+
+// install adds files to the manifest and drops the replaced ones. Callers
+// hold mu.
+func (s *Store) install(files []*segmentFile, replaced []*segmentFile) {
+	drop := make(map[*segmentFile]bool, len(replaced))
+	for _, f := range replaced {
+		drop[f] = true
+	}
+
+	segments := make([]*segmentFile, 0, len(s.segments)+len(files))
+	for _, f := range s.segments {
+		if !drop[f] {
+			segments = append(segments, f)
+		}
+	}
+	segments = append(segments, files...)
+
+	sort.Slice(segments, func(i, j int) bool {
+		if !segments[i].partition.Equal(segments[j].partition) {
+			return segments[i].partition.Before(segments[j].partition)
+		}
+		return segments[i].name < segments[j].name
+	})
+
+	s.segments = segments
+}
+
+// retire removes the files of segments dropped from the manifest, leaving
+// those still read by a query to the query. It reports the first failure.
+func (s *Store) retire(files []*segmentFile) error {
+	var first error
+	for _, f := range files {
+		f.replaced.Store(true)
+		if f.refs.Load() > 0 {
+			continue
+		}
+		if err := s.removeSegment(f); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// release drops the references a query took on files, removing those that
+// were replaced meanwhile.
+func (s *Store) release(ctx context.Context, files []*segmentFile) {
+	for _, f := range files {
+		if f.refs.Add(-1) > 0 || !f.replaced.Load() {
+			continue
+		}
+		if err := s.removeSegment(f); err != nil {
+			s.log.Error(ctx, "failed to remove replaced segment", "segment", f.name, "error", err)
+		}
+	}
+}
+
+// removeSegment removes the file of f. Both retire and release may get to
+// it, so a file already gone is not an error.
+func (s *Store) removeSegment(f *segmentFile) error {
+	err := os.Remove(filepath.Join(s.segmentDir, f.name))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("removing %s: %w", f.name, err)
+	}
+	return nil
+}
+
+// Close flushes the memtable and closes the write-ahead log.
+func (s *Store) Close(ctx context.Context) error {
+	if err := s.Flush(ctx); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	return s.wal.close()
+}
+
+// loadSegments builds the manifest from the segment directory. Temporary
+// files of interrupted writes are removed, and so are segments replaced by a
+// compaction that stopped before deleting its inputs.
+func (s *Store) loadSegments(ctx context.Context) error {
+	var files []*segmentFile
+
+	err := filepath.WalkDir(s.segmentDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		if strings.HasSuffix(path, tmpExt) {
+			return os.Remove(path)
+		}
+
+		rel, err := filepath.Rel(s.segmentDir, path)
+		if err != nil {
+			return err
+		}
+
+		f, ok := parseSegmentName(filepath.ToSlash(rel))
+		if !ok {
+			return nil
+		}
+
+		if err := s.readIndex(f); err != nil {
+			return err
+		}
+
+		files = append(files, f)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	replaced := make(map[string]bool)
+	for _, f := range files {
+		for _, name := range f.index.Replaces {
+			replaced[name] = true
+		}
+	}
+
+	var stale []*segmentFile
+	for _, f := range files {
+		if replaced[f.name] {
+			s.log.Info(ctx, "removing replaced segment", "segment", f.name)
+			if err := os.Remove(filepath.Join(s.segmentDir, f.name)); err != nil {
+				return err
+			}
+			stale = append(stale, f)
+		}
+	}
+
+	s.mu.Lock()
+	s.segments = nil
+	s.install(files, stale)
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *Store) readIndex(f *segmentFile) error {
+	file, err := os.Open(filepath.Join(s.segmentDir, f.name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	r, err := segment.NewReader(file, info.Size())
+	if err != nil {
+		return fmt.Errorf("segment %s: %w", f.name, err)
+	}
+
+	f.size = info.Size()
+	f.index = r.Index()
+
+	return nil
+}
+
+// recover replays the write-ahead log into the memtable. Logs of partitions
+// that already have a segment from the same or a later wal were flushed
+// before the crash and are skipped.
+func (s *Store) recover(ctx context.Context) error {
+	seqs, err := walFiles(s.walDir)
+	if err != nil {
+		return err
+	}
+
+	flushed := make(map[time.Time]uint64)
+	for _, f := range s.segments {
+		if f.seq > flushed[f.partition] {
+			flushed[f.partition] = f.seq
+		}
+	}
+
+	var last uint64
+	for _, seq := range seqs {
+		var replayed, skipped int
+
+		err := replayWAL(filepath.Join(s.walDir, walName(seq)), func(log mlog.Log) {
+			if flushed[partitionOf(log.Timestamp)] >= seq {
+				skipped++
+				return
+			}
+			s.memtable = append(s.memtable, log)
+			replayed++
+		})
+		if errors.Is(err, errTornRecord) {
+			s.log.Error(ctx, "wal ends with a torn record", "seq", seq)
+		} else if err != nil {
+			return err
+		}
+
+		s.log.Info(ctx, "replayed wal", "seq", seq, "logs", replayed, "skipped", skipped)
+
+		s.pending = append(s.pending, seq)
+		last = seq
+	}
+
+	for _, f := range s.segments {
+		if f.seq > last {
+			last = f.seq
+		}
+	}
+
+	w, err := openWAL(s.walDir, last+1, s.sync)
+	if err != nil {
+		return err
+	}
+	s.wal = w
+	s.pending = append(s.pending, w.seq)
+
+	return nil
+}
+
+func parseSegmentName(name string) (*segmentFile, bool) {
+	dir, file, ok := strings.Cut(name, "/")
+	if !ok || !strings.HasSuffix(file, segmentExt) {
+		return nil, false
+	}
+
+	p, err := time.Parse(dayLayout, dir)
+	if err != nil {
+		return nil, false
+	}
+
+	seqPart, _, ok := strings.Cut(file, "-")
+	if !ok {
+		return nil, false
+	}
+
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+
+	return &segmentFile{
+		name:      name,
+		partition: p,
+		seq:       seq,
+	}, true
+}
+
+func partitionOf(t time.Time) time.Time {
+	return t.UTC().Truncate(day)
+}
+
+func (s *Store) open(f *segmentFile) (*segment.Reader, io.Closer, error) {
+	file, err := os.Open(filepath.Join(s.segmentDir, f.name))
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening segment %s: %w", f.name, err)
+	}
+
+	r, err := segment.NewReader(file, f.size)
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("reading segment %s: %w", f.name, err)
+	}
+
+	return r, file, nil
+}
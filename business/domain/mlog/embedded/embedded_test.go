@@ -0,0 +1,313 @@
+package embedded
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/oklog/ulid/v2"
+)
+
+// These tests hold the embedded store to the behavior of the MongoDB store:
+// searches return the newest logs first with the same paging, filters
+// select the same logs, and writes survive a restart.
+
+type nopLogger struct{}
+
+func (nopLogger) Info(context.Context, string, ...interface{})  {}
+func (nopLogger) Error(context.Context, string, ...interface{}) {}
+
+// base is the time of the logs written by the tests, a day old so Compact
+// may merge their partition.
+var base = time.Now().UTC().Truncate(24 * time.Hour).Add(-36 * time.Hour)
+
+func newTestStore(t *testing.T, dir string) *Store {
+	t.Helper()
+
+	s, err := NewStore(context.Background(), nopLogger{}, Config{Dir: dir, ExportPath: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	return s
+}
+
+func testLog(minute int, tenant string, level mlog.Level, service string) *mlog.Log {
+	return &mlog.Log{
+		ID:        ulid.Make(),
+		Tenant:    tenant,
+		Message:   fmt.Sprintf("log %d", minute),
+		Timestamp: base.Add(time.Duration(minute) * time.Minute),
+		Level:     level,
+		Metadata:  map[string]string{"service": service},
+	}
+}
+
+// writeTestLogs writes logs at minutes 0 to 5, flushing some to a segment
+// so searches read both segments and the memtable.
+func writeTestLogs(t *testing.T, s *Store) []*mlog.Log {
+	t.Helper()
+	ctx := context.Background()
+
+	logs := []*mlog.Log{
+		testLog(0, "", mlog.Info, "api"),
+		testLog(1, "", mlog.Error, "api"),
+		testLog(2, "acme", mlog.Info, "api"),
+		testLog(3, "", mlog.Warn, "billing"),
+		testLog(4, "", mlog.Info, "billing"),
+		testLog(5, "", mlog.Error, "api"),
+	}
+
+	if err := s.WriteBatch(ctx, logs[:3]); err != nil {
+		t.Fatalf("WriteBatch: %v", err)
+	}
+	if err := s.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	for _, log := range logs[3:] {
+		if err := s.Write(ctx, log); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	return logs
+}
+
+func messages(logs []mlog.Log) []string {
+	out := make([]string, len(logs))
+	for i, log := range logs {
+		out[i] = log.Message
+	}
+	return out
+}
+
+func TestSearchPagesNewestFirst(t *testing.T) {
+	s := newTestStore(t, t.TempDir())
+	writeTestLogs(t, s)
+	ctx := context.Background()
+
+	tests := []struct {
+		page     int
+		want     []string
+		hasMore  bool
+		nextPage int
+	}{
+		{page: 0, want: []string{"log 5", "log 4"}, hasMore: true, nextPage: 1},
+		{page: 1, want: []string{"log 3", "log 1"}, hasMore: true, nextPage: 2},
+		{page: 2, want: []string{"log 0"}, hasMore: false, nextPage: 2},
+	}
+
+	for _, tt := range tests {
+		res, err := s.Search(ctx, mlog.SearchCriteria{PageSize: 2, Page: tt.page})
+		if err != nil {
+			t.Fatalf("Search page %d: %v", tt.page, err)
+		}
+		if got := messages(res.Logs); !slices.Equal(got, tt.want) {
+			t.Errorf("page %d = %v, want %v", tt.page, got, tt.want)
+		}
+		if res.Total != 5 || res.HasMore != tt.hasMore || res.NextPage != tt.nextPage {
+			t.Errorf("page %d: total %d, has more %v, next page %d; want 5, %v, %d",
+				tt.page, res.Total, res.HasMore, res.NextPage, tt.hasMore, tt.nextPage)
+		}
+	}
+}
+
+func TestSearchFilters(t *testing.T) {
+	s := newTestStore(t, t.TempDir())
+	writeTestLogs(t, s)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		criteria mlog.SearchCriteria
+		want     []string
+	}{
+		{
+			name:     "tenant",
+			criteria: mlog.SearchCriteria{Tenant: "acme"},
+			want:     []string{"log 2"},
+		},
+		{
+			name:     "all tenants",
+			criteria: mlog.SearchCriteria{AllTenants: true},
+			want:     []string{"log 5", "log 4", "log 3", "log 2", "log 1", "log 0"},
+		},
+		{
+			name:     "level",
+			criteria: mlog.SearchCriteria{Level: mlog.Error},
+			want:     []string{"log 5", "log 1"},
+		},
+		{
+			name:     "levels",
+			criteria: mlog.SearchCriteria{Levels: []mlog.Level{mlog.Warn, mlog.Error}},
+			want:     []string{"log 5", "log 3", "log 1"},
+		},
+		{
+			name:     "metadata",
+			criteria: mlog.SearchCriteria{Metadata: map[string]string{"service": "billing"}},
+			want:     []string{"log 4", "log 3"},
+		},
+		{
+			// Both ends are included, as with $gte and $lte.
+			name: "time range",
+			criteria: mlog.SearchCriteria{TimeRange: mlog.TimeRange{
+				StartTime: base.Add(time.Minute),
+				EndTime:   base.Add(4 * time.Minute),
+			}},
+			want: []string{"log 4", "log 3", "log 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := s.Search(ctx, tt.criteria)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if got := messages(res.Logs); !slices.Equal(got, tt.want) {
+				t.Errorf("logs = %v, want %v", got, tt.want)
+			}
+
+			n, err := s.Count(ctx, tt.criteria)
+			if err != nil {
+				t.Fatalf("Count: %v", err)
+			}
+			if n != len(tt.want) {
+				t.Errorf("count = %d, want %d", n, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestScanOldestFirst(t *testing.T) {
+	s := newTestStore(t, t.TempDir())
+	writeTestLogs(t, s)
+
+	var got []string
+	err := s.Scan(context.Background(), mlog.SearchCriteria{}, func(log mlog.Log) error {
+		got = append(got, log.Message)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+
+	if want := []string{"log 0", "log 1", "log 3", "log 4", "log 5"}; !slices.Equal(got, want) {
+		t.Fatalf("logs = %v, want %v", got, want)
+	}
+}
+
+func TestWritesSurviveRestart(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	// The first store is never closed, as after a crash: the logs left in
+	// its memtable are only in the write-ahead log.
+	first := newTestStore(t, dir)
+	writeTestLogs(t, first)
+
+	second := newTestStore(t, dir)
+	defer second.Close(ctx)
+
+	n, err := second.Count(ctx, mlog.SearchCriteria{AllTenants: true})
+	if err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	if n != 6 {
+		t.Fatalf("count after restart = %d, want 6", n)
+	}
+}
+
+func TestCompactKeepsLogs(t *testing.T) {
+	s := newTestStore(t, t.TempDir())
+	ctx := context.Background()
+	defer s.Close(ctx)
+
+	writeTestLogs(t, s)
+	if err := s.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	before, err := s.Search(ctx, mlog.SearchCriteria{AllTenants: true})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+
+	report, err := s.Compact(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	if report.Logs != 6 {
+		t.Fatalf("compacted logs = %d, want 6", report.Logs)
+	}
+
+	after, err := s.Search(ctx, mlog.SearchCriteria{AllTenants: true})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if got, want := messages(after.Logs), messages(before.Logs); !slices.Equal(got, want) {
+		t.Fatalf("logs after compaction = %v, want %v", got, want)
+	}
+
+	segments, err := filepath.Glob(filepath.Join(s.segmentDir, "*", "*"+segmentExt))
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	if len(segments) != 1 {
+		t.Fatalf("segments after compaction = %d, want 1", len(segments))
+	}
+}
+
+// A query callback may use the store, even to replace the segments being
+// read; those are removed once the query is done.
+func TestCompactDuringScan(t *testing.T) {
+	s := newTestStore(t, t.TempDir())
+	ctx := context.Background()
+	defer s.Close(ctx)
+
+	writeTestLogs(t, s)
+	if err := s.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	var replaced []string
+	done := make(chan error, 1)
+	go func() {
+		compacted := false
+		done <- s.Scan(ctx, mlog.SearchCriteria{AllTenants: true}, func(mlog.Log) error {
+			if compacted {
+				return nil
+			}
+			compacted = true
+
+			for _, f := range s.partitions()[partitionOf(base)] {
+				replaced = append(replaced, filepath.Join(s.segmentDir, f.name))
+			}
+			_, err := s.Compact(ctx, time.Now())
+			return err
+		})
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Compact inside a Scan callback did not return")
+	}
+
+	if len(replaced) < 2 {
+		t.Fatalf("segments before compaction = %d, want at least 2", len(replaced))
+	}
+	for _, name := range replaced {
+		if _, err := os.Stat(name); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("replaced segment %s still exists: %v", name, err)
+		}
+	}
+}
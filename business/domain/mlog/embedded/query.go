@@ -0,0 +1,430 @@
+package embedded
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/business/domain/mlog/segment"
+	"github.com/felipecooper/log-horizon/foundation/blob"
+)
+
+var errStop = errors.New("stop")
+
+// source is a segment or the memtable logs of one partition.
+type source struct {
+	file     *segmentFile
+	logs     []mlog.Log
+	min, max time.Time
+}
+
+// count returns how many logs of the source match criteria when the index
+// can answer it.
+func (src source) count(criteria mlog.SearchCriteria) (int64, bool) {
+	if src.file != nil {
+		return src.file.index.Matches(criteria)
+	}
+	return int64(len(src.logs)), true
+}
+
+// view is a consistent snapshot of the data visible to a query. It holds a
+// reference on its segments until the query releases them.
+type view struct {
+	partitions []time.Time
+	sources    map[time.Time][]source
+	files      []*segmentFile
+}
+
+// snapshot returns the data matching criteria. Callers release the files of
+// the view once done reading them.
+func (s *Store) snapshot(criteria mlog.SearchCriteria) view {
+	s.mu.RLock()
+	var segments []*segmentFile
+	for _, f := range s.segments {
+		if f.index.Overlaps(criteria.TimeRange) {
+			f.refs.Add(1)
+			segments = append(segments, f)
+		}
+	}
+	var mem []mlog.Log
+	for _, logs := range [][]mlog.Log{s.frozen, s.memtable} {
+		for _, log := range logs {
+			if segment.Match(log, criteria) {
+				mem = append(mem, log)
+			}
+		}
+	}
+	s.mu.RUnlock()
+
+	v := view{sources: make(map[time.Time][]source), files: segments}
+
+	for _, f := range segments {
+		v.sources[f.partition] = append(v.sources[f.partition], source{
+			file: f,
+			min:  f.index.MinTime,
+			max:  f.index.MaxTime,
+		})
+	}
+
+	sort.SliceStable(mem, func(i, j int) bool {
+		return mem[i].Timestamp.Before(mem[j].Timestamp)
+	})
+
+	for len(mem) > 0 {
+		p := partitionOf(mem[0].Timestamp)
+		n := sort.Search(len(mem), func(i int) bool {
+			return !partitionOf(mem[i].Timestamp).Equal(p)
+		})
+		v.sources[p] = append(v.sources[p], source{
+			logs: mem[:n],
+			min:  mem[0].Timestamp,
+			max:  mem[n-1].Timestamp,
+		})
+		mem = mem[n:]
+	}
+
+	for p := range v.sources {
+		v.partitions = append(v.partitions, p)
+	}
+	sort.Slice(v.partitions, func(i, j int) bool {
+		return v.partitions[i].Before(v.partitions[j])
+	})
+
+	return v
+}
+
+// clusters groups the sources of a partition into runs whose time ranges
+// overlap, ordered oldest first. Sources of different clusters never
+// interleave, so each cluster can be read on its own.
+func clusters(sources []source) [][]source {
+	sort.Slice(sources, func(i, j int) bool {
+		return sources[i].min.Before(sources[j].min)
+	})
+
+	var (
+		out [][]source
+		end time.Time
+	)
+	for _, src := range sources {
+		if len(out) > 0 && !src.min.After(end) {
+			out[len(out)-1] = append(out[len(out)-1], src)
+			if src.max.After(end) {
+				end = src.max
+			}
+			continue
+		}
+		out = append(out, []source{src})
+		end = src.max
+	}
+
+	return out
+}
+
+// walk calls fn for every log matching criteria, newest first when desc is
+// set. When skip is not nil, clusters and blocks that fall entirely inside it
+// are skipped using the indexes instead of being decoded.
+func (s *Store) walk(ctx context.Context, criteria mlog.SearchCriteria, desc bool, skip *int, fn func(mlog.Log) error) error {
+	v := s.snapshot(criteria)
+	defer s.release(ctx, v.files)
+
+	partitions := v.partitions
+	if desc {
+		partitions = reversed(partitions)
+	}
+
+	for _, p := range partitions {
+		groups := clusters(v.sources[p])
+		if desc {
+			groups = reversed(groups)
+		}
+
+		for _, group := range groups {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			if skip != nil {
+				if n, ok := groupCount(group, criteria); ok && int64(*skip) >= n {
+					*skip -= int(n)
+					continue
+				}
+			}
+
+			var err error
+			if len(group) == 1 {
+				err = s.walkSource(group[0], criteria, desc, skip, fn)
+			} else {
+				err = s.walkMerged(group, criteria, desc, fn)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func groupCount(group []source, criteria mlog.SearchCriteria) (int64, bool) {
+	var total int64
+	for _, src := range group {
+		n, ok := src.count(criteria)
+		if !ok {
+			return 0, false
+		}
+		total += n
+	}
+	return total, true
+}
+
+func (s *Store) walkSource(src source, criteria mlog.SearchCriteria, desc bool, skip *int, fn func(mlog.Log) error) error {
+	if src.file == nil {
+		return emit(src.logs, desc, fn)
+	}
+
+	r, closer, err := s.open(src.file)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	blocks := src.file.index.Blocks
+	for k := range blocks {
+		i := k
+		if desc {
+			i = len(blocks) - 1 - k
+		}
+
+		block := blocks[i]
+		if !block.Overlaps(criteria.TimeRange) {
+			continue
+		}
+
+		if skip != nil {
+			if n, ok := block.Matches(criteria); ok && int64(*skip) >= n {
+				*skip -= int(n)
+				continue
+			}
+		}
+
+		logs, err := r.Block(i)
+		if err != nil {
+			return err
+		}
+
+		matched := logs[:0]
+		for _, log := range logs {
+			if segment.Match(log, criteria) {
+				matched = append(matched, log)
+			}
+		}
+
+		if err := emit(matched, desc, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// walkMerged reads overlapping sources together. Overlaps only happen for
+// logs replayed or written with old timestamps, so clusters stay small.
+func (s *Store) walkMerged(group []source, criteria mlog.SearchCriteria, desc bool, fn func(mlog.Log) error) error {
+	var logs []mlog.Log
+
+	for _, src := range group {
+		if src.file == nil {
+			logs = append(logs, src.logs...)
+			continue
+		}
+
+		r, closer, err := s.open(src.file)
+		if err != nil {
+			return err
+		}
+
+		err = r.Scan(criteria, func(log mlog.Log) error {
+			logs = append(logs, log)
+			return nil
+		})
+		closer.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].Timestamp.Before(logs[j].Timestamp)
+	})
+
+	return emit(logs, desc, fn)
+}
+
+func emit(logs []mlog.Log, desc bool, fn func(mlog.Log) error) error {
+	for k := range logs {
+		i := k
+		if desc {
+			i = len(logs) - 1 - k
+		}
+		if err := fn(logs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func reversed[T any](s []T) []T {
+	out := make([]T, len(s))
+	for i, v := range s {
+		out[len(s)-1-i] = v
+	}
+	return out
+}
+
+func (s *Store) Search(ctx context.Context, criteria mlog.SearchCriteria) (mlog.SearchResult, error) {
+	pageSize := criteria.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	skip := criteria.Page * pageSize
+
+	var logs []mlog.Log
+	err := s.walk(ctx, criteria, true, &skip, func(log mlog.Log) error {
+		if skip > 0 {
+			skip--
+			return nil
+		}
+		logs = append(logs, log)
+		if len(logs) == pageSize {
+			return errStop
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		return mlog.SearchResult{}, err
+	}
+
+	totalCount, err := s.Count(ctx, criteria)
+	if err != nil {
+		s.log.Error(ctx, "failed to count logs", "error", err)
+	}
+
+	hasMore := (criteria.Page+1)*pageSize < totalCount
+	nextPage := criteria.Page + 1
+	if !hasMore {
+		nextPage = criteria.Page
+	}
+
+	return mlog.SearchResult{
+		Logs:     logs,
+		Total:    totalCount,
+		HasMore:  hasMore,
+		NextPage: nextPage,
+	}, nil
+}
+
+func (s *Store) Count(ctx context.Context, criteria mlog.SearchCriteria) (int, error) {
+	v := s.snapshot(criteria)
+	defer s.release(ctx, v.files)
+
+	var total int64
+	for _, p := range v.partitions {
+		for _, src := range v.sources[p] {
+			if n, ok := src.count(criteria); ok {
+				total += n
+				continue
+			}
+
+			n, err := s.countFile(src.file, criteria)
+			if err != nil {
+				return 0, err
+			}
+			total += n
+		}
+	}
+
+	return int(total), nil
+}
+
+func (s *Store) countFile(f *segmentFile, criteria mlog.SearchCriteria) (int64, error) {
+	r, closer, err := s.open(f)
+	if err != nil {
+		return 0, err
+	}
+	defer closer.Close()
+
+	var total int64
+	for i, block := range f.index.Blocks {
+		if n, ok := block.Matches(criteria); ok {
+			total += n
+			continue
+		}
+
+		logs, err := r.Block(i)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, log := range logs {
+			if segment.Match(log, criteria) {
+				total++
+			}
+		}
+	}
+
+	return total, nil
+}
+
+// SearchMessages reports that message filters are supported; they are
+// evaluated on the decoded logs.
+func (s *Store) SearchMessages() bool {
+	return true
+}
+
+// Scan walks the logs matching criteria oldest first.
+func (s *Store) Scan(ctx context.Context, criteria mlog.SearchCriteria, fn func(mlog.Log) error) error {
+	return s.walk(ctx, criteria, false, nil, fn)
+}
+
+func (s *Store) ExportToFile(ctx context.Context, criteria mlog.SearchCriteria) (string, int64, error) {
+	filename := mlog.ExportFilename(criteria, time.Now())
+
+	file, err := s.exports.Create(ctx, filename)
+	if err != nil {
+		return "", 0, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			blob.Abort(file)
+		}
+	}()
+
+	var size int64
+	err = s.walk(ctx, criteria, true, nil, func(log mlog.Log) error {
+		line := fmt.Sprintf("[%s] [%s] %s\n", log.Timestamp.Format(time.RFC3339), log.Level, log.Message)
+		n, err := io.WriteString(file, line)
+		size += int64(n)
+		return err
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	if err := file.Close(); err != nil {
+		return "", 0, err
+	}
+	committed = true
+
+	fileURL, err := s.exports.URL(ctx, filename)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return fileURL, size, nil
+}
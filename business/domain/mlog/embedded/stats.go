@@ -0,0 +1,128 @@
+package embedded
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/business/domain/mlog/segment"
+)
+
+// Stats is computed from the segment indexes and the memtable, without
+// reading any block. Raw sizes are those of the encoded logs.
+func (s *Store) Stats(ctx context.Context) (mlog.Stats, error) {
+	s.mu.RLock()
+	segments := s.segments
+	var memtable []mlog.Log
+	memtable = append(memtable, s.frozen...)
+	memtable = append(memtable, s.memtable...)
+	s.mu.RUnlock()
+
+	var (
+		stats      mlog.Stats
+		levels     = make(map[mlog.Level]int64)
+		tenants    = make(map[string]int64)
+		days       = make(map[time.Time]int64)
+		algorithms = make(map[string]*mlog.AlgorithmStats)
+		footers    int64
+	)
+
+	segmentStats := mlog.CollectionStats{Name: "segments", IndexSizes: map[string]int64{}}
+
+	for _, f := range segments {
+		idx := f.index
+
+		var stored int64
+		for _, b := range idx.Blocks {
+			stored += b.Length
+		}
+
+		stats.Total += idx.Count
+		stats.Compressed += idx.Count
+		stats.RawBytes += idx.RawBytes
+		stats.StoredBytes += stored
+
+		for i, level := range segment.Levels {
+			levels[level] += idx.Levels[i]
+		}
+		days[f.partition] += idx.Count
+		for tenant, count := range idx.Tenants {
+			tenants[tenant] += count
+		}
+		tenants[""] += idx.Tenants.Untenanted(idx.Count)
+
+		a, ok := algorithms[string(idx.Algorithm)]
+		if !ok {
+			a = &mlog.AlgorithmStats{Algorithm: string(idx.Algorithm)}
+			algorithms[a.Algorithm] = a
+		}
+		a.Count += idx.Count
+		a.RawBytes += idx.RawBytes
+		a.StoredBytes += stored
+
+		segmentStats.Count++
+		segmentStats.Size += idx.RawBytes
+		segmentStats.StorageSize += f.size
+		footers += f.size - stored
+	}
+	segmentStats.IndexSize = footers
+	segmentStats.IndexSizes["footer"] = footers
+
+	memtableStats := mlog.CollectionStats{Name: "memtable"}
+	for _, log := range memtable {
+		size := int64(len(log.Message))
+
+		stats.Total++
+		stats.Uncompressed++
+		stats.RawBytes += size
+		stats.StoredBytes += size
+
+		levels[log.Level]++
+		tenants[log.Tenant]++
+		days[partitionOf(log.Timestamp)]++
+
+		memtableStats.Count++
+		memtableStats.Size += size
+	}
+
+	for level, count := range levels {
+		if count > 0 {
+			stats.Levels = append(stats.Levels, mlog.LevelStats{Level: level, Count: count})
+		}
+	}
+	sort.Slice(stats.Levels, func(i, j int) bool {
+		return stats.Levels[i].Level < stats.Levels[j].Level
+	})
+
+	stats.Tenants = mlog.SortTenantStats(tenants)
+
+	for d, count := range days {
+		stats.Days = append(stats.Days, mlog.DayStats{Day: d, Count: count})
+	}
+	sort.Slice(stats.Days, func(i, j int) bool {
+		return stats.Days[i].Day.Before(stats.Days[j].Day)
+	})
+
+	for _, a := range algorithms {
+		stats.Algorithms = append(stats.Algorithms, *a)
+	}
+	sort.Slice(stats.Algorithms, func(i, j int) bool {
+		return stats.Algorithms[i].Algorithm < stats.Algorithms[j].Algorithm
+	})
+
+	stats.Collections = []mlog.CollectionStats{segmentStats, memtableStats}
+	stats.ComputedAt = time.Now()
+
+	return stats, nil
+}
+
+var (
+	_ mlog.Store           = (*Store)(nil)
+	_ mlog.BatchWriter     = (*Store)(nil)
+	_ mlog.Scanner         = (*Store)(nil)
+	_ mlog.Pruner          = (*Store)(nil)
+	_ mlog.Compactor       = (*Store)(nil)
+	_ mlog.StatsReporter   = (*Store)(nil)
+	_ mlog.MessageSearcher = (*Store)(nil)
+)
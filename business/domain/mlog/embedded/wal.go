@@ -0,0 +1,169 @@
+package embedded
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/business/domain/mlog/segment"
+)
+
+// The write-ahead log is a sequence of files named after an increasing
+// sequence number. Each record is crc32 | length | encoded log.
+
+const (
+	walExt          = ".wal"
+	walHeaderSize   = 8
+	maxWALRecord    = 64 << 20
+	walSeqDigits    = 20
+	walBufferedSize = 64 << 10
+)
+
+var (
+	crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+	errTornRecord = errors.New("torn wal record")
+)
+
+type wal struct {
+	seq  uint64
+	file *os.File
+	buf  *bufio.Writer
+	sync bool
+}
+
+func walName(seq uint64) string {
+	return fmt.Sprintf("%0*d%s", walSeqDigits, seq, walExt)
+}
+
+func openWAL(dir string, seq uint64, sync bool) (*wal, error) {
+	file, err := os.OpenFile(filepath.Join(dir, walName(seq)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening wal: %w", err)
+	}
+
+	return &wal{
+		seq:  seq,
+		file: file,
+		buf:  bufio.NewWriterSize(file, walBufferedSize),
+		sync: sync,
+	}, nil
+}
+
+func (w *wal) append(logs ...mlog.Log) error {
+	for _, log := range logs {
+		var payload bytes.Buffer
+		segment.EncodeLog(&payload, log)
+
+		var header [walHeaderSize]byte
+		binary.LittleEndian.PutUint32(header[0:4], crc32.Checksum(payload.Bytes(), crcTable))
+		binary.LittleEndian.PutUint32(header[4:8], uint32(payload.Len()))
+
+		if _, err := w.buf.Write(header[:]); err != nil {
+			return err
+		}
+		if _, err := w.buf.Write(payload.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+
+	if w.sync {
+		return w.file.Sync()
+	}
+	return nil
+}
+
+func (w *wal) close() error {
+	if err := w.buf.Flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+	if err := w.file.Sync(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// walFiles returns the sequence numbers of the wal files in dir, ascending.
+func walFiles(dir string) ([]uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var seqs []uint64
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), walExt)
+		if !ok {
+			continue
+		}
+		seq, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+
+	return seqs, nil
+}
+
+// replayWAL calls fn for every intact record of a wal file. A torn record,
+// left by a crash in the middle of a write, ends the replay with
+// errTornRecord after every record before it was delivered.
+func replayWAL(path string, fn func(mlog.Log)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	header := make([]byte, walHeaderSize)
+
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return errTornRecord
+		}
+
+		length := binary.LittleEndian.Uint32(header[4:8])
+		if length > maxWALRecord {
+			return errTornRecord
+		}
+
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return errTornRecord
+		}
+
+		if crc32.Checksum(payload, crcTable) != binary.LittleEndian.Uint32(header[0:4]) {
+			return errTornRecord
+		}
+
+		log, err := segment.DecodeLog(bytes.NewReader(payload))
+		if err != nil {
+			return errTornRecord
+		}
+
+		fn(log)
+	}
+}
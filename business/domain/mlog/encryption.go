@@ -0,0 +1,34 @@
+package mlog
+
+import (
+	"context"
+	"fmt"
+)
+
+// KeyRotator is implemented by stores encrypting logs at rest.
+type KeyRotator interface {
+	// RotateKey makes a new data key current and returns its ID. Logs
+	// sealed with older keys stay readable until Reencrypt reaches them.
+	RotateKey(ctx context.Context) (string, error)
+	// Reencrypt seals up to limit logs that are not sealed with the current
+	// data key with it, and returns how many it sealed.
+	Reencrypt(ctx context.Context, limit int) (int64, error)
+}
+
+// RotateEncryptionKey makes a new data key current for the logs written
+// from now on, and returns its ID.
+func (b *Business) RotateEncryptionKey(ctx context.Context) (string, error) {
+	store, ok := b.store.(KeyRotator)
+	if !ok {
+		return "", fmt.Errorf("rotate encryption key: %w", ErrNotSupported)
+	}
+
+	id, err := store.RotateKey(ctx)
+	if err != nil {
+		b.logger.Error(ctx, "failed to rotate encryption key", "error", err)
+		return "", fmt.Errorf("rotate encryption key: %w", err)
+	}
+
+	b.logger.Info(ctx, "encryption key rotated", "keyID", id)
+	return id, nil
+}
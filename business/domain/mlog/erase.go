@@ -0,0 +1,261 @@
+package mlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+var (
+	ErrUnboundedCriteria = errors.New("delete criteria must select a subject by metadata or message")
+	ErrDryRunMismatch    = errors.New("expected count does not match the dry run")
+	ErrTooManyMatches    = errors.New("delete criteria match too many logs")
+	ErrInvalidEraseMode  = errors.New("invalid erase mode")
+)
+
+// Redacted replaces erased content when logs are rewritten instead of deleted.
+const Redacted = "[REDACTED]"
+
+const (
+	minEraseMessage        = 3
+	defaultMaxEraseMatches = 100000
+)
+
+type EraseMode string
+
+const (
+	// EraseDelete removes every matching log.
+	EraseDelete EraseMode = "delete"
+
+	// EraseRedact keeps matching logs but replaces each occurrence of the
+	// message fragment and the values of the selected metadata keys.
+	EraseRedact EraseMode = "redact"
+)
+
+// DeleteCriteria selects logs about one subject, such as a user ID found in
+// metadata or inside the message.
+type DeleteCriteria struct {
+	// Tenant is set by Business from the context; "" selects the logs of
+	// no tenant.
+	Tenant        string
+	TimeRange     TimeRange
+	Level         Level
+	Metadata      map[string]string
+	Message       string
+	Mode          EraseMode
+	DryRun        bool
+	ExpectedCount int64
+	Actor         string
+	Reason        string
+}
+
+// DeleteResult counts matching logs. Held logs match but are left untouched
+// because a legal hold covers them.
+type DeleteResult struct {
+	Matched  int64
+	Deleted  int64
+	Redacted int64
+	Held     int64
+	DryRun   bool
+	AuditID  ulid.ULID
+	// IDs lists the logs deleted or redacted. It is not reported to clients.
+	IDs []ulid.ULID
+}
+
+type DeletionAudit struct {
+	ID       ulid.ULID
+	Actor    string
+	Reason   string
+	Criteria DeleteCriteria
+	Matched  int64
+	Deleted  int64
+	Redacted int64
+	Held     int64
+	At       time.Time
+}
+
+func (c DeleteCriteria) Validate() error {
+	if len(c.Metadata) == 0 && len(c.Message) < minEraseMessage {
+		return ErrUnboundedCriteria
+	}
+
+	for k, v := range c.Metadata {
+		if k == "" || v == "" {
+			return ErrUnboundedCriteria
+		}
+	}
+
+	if c.Message != "" && len(c.Message) < minEraseMessage {
+		return fmt.Errorf("%w: message must have at least %d characters", ErrUnboundedCriteria, minEraseMessage)
+	}
+
+	if c.Level != "" && !c.Level.IsValid() {
+		return ErrInvalidLevel
+	}
+
+	if !c.TimeRange.StartTime.IsZero() && !c.TimeRange.EndTime.IsZero() && c.TimeRange.EndTime.Before(c.TimeRange.StartTime) {
+		return ErrInvalidTimeRange
+	}
+
+	switch c.Mode {
+	case EraseDelete, EraseRedact:
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidEraseMode, c.Mode)
+	}
+
+	return nil
+}
+
+// Matches reports whether a decoded log is selected by the criteria.
+func (c DeleteCriteria) Matches(log Log) bool {
+	if log.Tenant != c.Tenant {
+		return false
+	}
+	if c.Level != "" && log.Level != c.Level {
+		return false
+	}
+	if !c.TimeRange.StartTime.IsZero() && log.Timestamp.Before(c.TimeRange.StartTime) {
+		return false
+	}
+	if !c.TimeRange.EndTime.IsZero() && log.Timestamp.After(c.TimeRange.EndTime) {
+		return false
+	}
+	for k, v := range c.Metadata {
+		if log.Metadata[k] != v {
+			return false
+		}
+	}
+	return c.Message == "" || strings.Contains(log.Message, c.Message)
+}
+
+// Redact returns the log with the erased content replaced.
+func (c DeleteCriteria) Redact(log Log) Log {
+	if c.Message != "" {
+		log.Message = strings.ReplaceAll(log.Message, c.Message, Redacted)
+	}
+
+	if len(c.Metadata) > 0 {
+		metadata := make(map[string]string, len(log.Metadata))
+		for k, v := range log.Metadata {
+			if _, ok := c.Metadata[k]; ok {
+				v = Redacted
+			}
+			metadata[k] = v
+		}
+		log.Metadata = metadata
+	}
+
+	return log
+}
+
+// Delete erases the logs selected by criteria. A dry run only counts them; a
+// real run must carry the count returned by the dry run in ExpectedCount so a
+// mistyped criteria cannot silently remove more than was reviewed.
+func (b *Business) Delete(ctx context.Context, criteria DeleteCriteria) (DeleteResult, error) {
+	if criteria.Mode == "" {
+		criteria.Mode = EraseDelete
+	}
+
+	tenant, err := b.tenant(ctx)
+	if err != nil {
+		return DeleteResult{}, fmt.Errorf("delete: %w", err)
+	}
+	criteria.Tenant = tenant
+
+	if err := criteria.Validate(); err != nil {
+		b.logger.Error(ctx, "invalid delete criteria", "error", err)
+		return DeleteResult{}, fmt.Errorf("delete: %w", err)
+	}
+
+	store, ok := b.store.(Eraser)
+	if !ok {
+		return DeleteResult{}, fmt.Errorf("delete: %w", ErrNotSupported)
+	}
+
+	holds, err := b.activeHolds(ctx)
+	if err != nil {
+		b.logger.Error(ctx, "failed to list legal holds", "error", err)
+		return DeleteResult{}, fmt.Errorf("delete: %w", err)
+	}
+
+	count := criteria
+	count.DryRun = true
+
+	preview, err := store.Erase(ctx, count, holds)
+	if err != nil {
+		b.logger.Error(ctx, "failed to count logs to delete", "error", err)
+		return DeleteResult{}, fmt.Errorf("delete: %w", err)
+	}
+
+	if preview.Matched > int64(b.maxEraseMatches) {
+		return DeleteResult{}, fmt.Errorf("delete: %w: %d matches, limit is %d", ErrTooManyMatches, preview.Matched, b.maxEraseMatches)
+	}
+
+	if criteria.DryRun {
+		return preview, nil
+	}
+
+	if criteria.ExpectedCount != preview.Matched {
+		return DeleteResult{}, fmt.Errorf("delete: %w: expected %d, found %d", ErrDryRunMismatch, criteria.ExpectedCount, preview.Matched)
+	}
+
+	result, err := store.Erase(ctx, criteria, holds)
+	if err != nil {
+		b.logger.Error(ctx, "failed to delete logs", "error", err)
+
+		// Logs erased before the failure are gone all the same.
+		b.recordErase(ctx, criteria, result)
+		return DeleteResult{}, fmt.Errorf("delete: %w", err)
+	}
+
+	audit := DeletionAudit{
+		ID:       ulid.Make(),
+		Actor:    criteria.Actor,
+		Reason:   criteria.Reason,
+		Criteria: criteria,
+		Matched:  result.Matched,
+		Deleted:  result.Deleted,
+		Redacted: result.Redacted,
+		Held:     result.Held,
+		At:       time.Now(),
+	}
+
+	if err := store.SaveDeletionAudit(ctx, audit); err != nil {
+		b.logger.Error(ctx, "failed to save deletion audit", "error", err, "actor", audit.Actor)
+		return result, fmt.Errorf("delete: %w", err)
+	}
+
+	b.logger.Info(ctx, "logs erased",
+		"audit", audit.ID,
+		"actor", audit.Actor,
+		"mode", criteria.Mode,
+		"deleted", result.Deleted,
+		"redacted", result.Redacted,
+		"held", result.Held,
+	)
+
+	result.AuditID = audit.ID
+
+	if err := b.recordErase(ctx, criteria, result); err != nil {
+		return result, fmt.Errorf("delete: %w", err)
+	}
+
+	return result, nil
+}
+
+// recordErase records the logs erased or redacted by criteria.
+func (b *Business) recordErase(ctx context.Context, criteria DeleteCriteria, result DeleteResult) error {
+	if len(result.IDs) == 0 {
+		return nil
+	}
+
+	reason := DeletionErase
+	if criteria.Mode == EraseRedact {
+		reason = DeletionRedact
+	}
+	return b.recordDeletion(ctx, Deletion{Reason: reason, Tenant: criteria.Tenant, IDs: result.IDs})
+}
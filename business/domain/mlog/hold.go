@@ -0,0 +1,142 @@
+package mlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+var (
+	ErrInvalidLegalHold  = errors.New("invalid legal hold")
+	ErrLegalHoldNotFound = errors.New("legal hold not found")
+)
+
+// LegalHold freezes the logs it selects: while active, neither retention nor
+// Delete removes or rewrites them. Empty fields match everything.
+type LegalHold struct {
+	ID         ulid.ULID
+	Name       string
+	Reason     string
+	TimeRange  TimeRange
+	Level      Level
+	Metadata   map[string]string
+	CreatedBy  string
+	CreatedAt  time.Time
+	ReleasedBy string
+	ReleasedAt time.Time
+}
+
+func (h LegalHold) Active() bool {
+	return h.ReleasedAt.IsZero()
+}
+
+func (h LegalHold) Validate() error {
+	if h.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidLegalHold)
+	}
+	if h.Level != "" && !h.Level.IsValid() {
+		return fmt.Errorf("%w: %w", ErrInvalidLegalHold, ErrInvalidLevel)
+	}
+	if !h.TimeRange.StartTime.IsZero() && !h.TimeRange.EndTime.IsZero() && h.TimeRange.EndTime.Before(h.TimeRange.StartTime) {
+		return fmt.Errorf("%w: %w", ErrInvalidLegalHold, ErrInvalidTimeRange)
+	}
+	return nil
+}
+
+// Covers reports whether the hold applies to a log.
+func (h LegalHold) Covers(log Log) bool {
+	if h.Level != "" && log.Level != h.Level {
+		return false
+	}
+	if !h.TimeRange.StartTime.IsZero() && log.Timestamp.Before(h.TimeRange.StartTime) {
+		return false
+	}
+	if !h.TimeRange.EndTime.IsZero() && log.Timestamp.After(h.TimeRange.EndTime) {
+		return false
+	}
+	for k, v := range h.Metadata {
+		if log.Metadata[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *Business) holdStore() (HoldStore, error) {
+	store, ok := b.store.(HoldStore)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return store, nil
+}
+
+// activeHolds returns the holds that must be honored. Stores without hold
+// support simply have none.
+func (b *Business) activeHolds(ctx context.Context) ([]LegalHold, error) {
+	store, err := b.holdStore()
+	if err != nil {
+		return nil, nil
+	}
+	return store.LegalHolds(ctx, false)
+}
+
+func (b *Business) CreateLegalHold(ctx context.Context, hold LegalHold) (LegalHold, error) {
+	if err := hold.Validate(); err != nil {
+		b.logger.Error(ctx, "invalid legal hold", "error", err)
+		return LegalHold{}, fmt.Errorf("create legal hold: %w", err)
+	}
+
+	store, err := b.holdStore()
+	if err != nil {
+		return LegalHold{}, fmt.Errorf("create legal hold: %w", err)
+	}
+
+	hold.ID = ulid.Make()
+	hold.CreatedAt = time.Now()
+	hold.ReleasedBy = ""
+	hold.ReleasedAt = time.Time{}
+
+	if err := store.CreateLegalHold(ctx, hold); err != nil {
+		b.logger.Error(ctx, "failed to create legal hold", "error", err)
+		return LegalHold{}, fmt.Errorf("create legal hold: %w", err)
+	}
+
+	b.logger.Info(ctx, "legal hold created", "id", hold.ID, "name", hold.Name, "by", hold.CreatedBy)
+
+	return hold, nil
+}
+
+func (b *Business) LegalHolds(ctx context.Context, includeReleased bool) ([]LegalHold, error) {
+	store, err := b.holdStore()
+	if err != nil {
+		return nil, fmt.Errorf("legal holds: %w", err)
+	}
+
+	holds, err := store.LegalHolds(ctx, includeReleased)
+	if err != nil {
+		b.logger.Error(ctx, "failed to list legal holds", "error", err)
+		return nil, fmt.Errorf("legal holds: %w", err)
+	}
+
+	return holds, nil
+}
+
+func (b *Business) ReleaseLegalHold(ctx context.Context, id ulid.ULID, actor string) (LegalHold, error) {
+	store, err := b.holdStore()
+	if err != nil {
+		return LegalHold{}, fmt.Errorf("release legal hold: %w", err)
+	}
+
+	hold, err := store.ReleaseLegalHold(ctx, id, actor, time.Now())
+	if err != nil {
+		b.logger.Error(ctx, "failed to release legal hold", "error", err)
+		return LegalHold{}, fmt.Errorf("release legal hold: %w", err)
+	}
+
+	b.logger.Info(ctx, "legal hold released", "id", hold.ID, "name", hold.Name, "by", actor)
+
+	return hold, nil
+}
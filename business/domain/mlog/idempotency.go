@@ -0,0 +1,179 @@
+package mlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/oklog/ulid/v2"
+)
+
+var ErrInvalidIdempotencyKey = errors.New("invalid idempotency key")
+
+const (
+	defaultIdempotencyWindow = 24 * time.Hour
+	maxIdempotencyKeyLength  = 256
+
+	// idempotencyKeySeparator joins the tenant and the key of a tenant's
+	// idempotency key. Keys cannot hold control characters, so the key of
+	// one tenant never reads as the key of another or of no tenant.
+	idempotencyKeySeparator = "\x1f"
+)
+
+// idempotencyState remembers idempotency keys in memory for stores that do
+// not implement IdempotencyStore. It only deduplicates retries reaching the
+// same process.
+type idempotencyState struct {
+	mu   sync.Mutex
+	keys map[string]idempotencyEntry
+}
+
+type idempotencyEntry struct {
+	id ulid.ULID
+	at time.Time
+}
+
+// WithIdempotencyWindow defines for how long a repeated idempotency key
+// returns the log it first produced.
+func WithIdempotencyWindow(window time.Duration) Option {
+	return func(b *Business) {
+		if window > 0 {
+			b.idempotencyWindow = window
+		}
+	}
+}
+
+// RegisterWithKey registers a log like Register. When key is not empty and
+// already produced a log within the idempotency window, nothing is stored
+// and the returned log carries the ID of the original.
+func (b *Business) RegisterWithKey(ctx context.Context, key, message string, level Level, metadata map[string]string) (Log, error) {
+	if len(key) > maxIdempotencyKeyLength {
+		return Log{}, fmt.Errorf("register: %w: longer than %d bytes", ErrInvalidIdempotencyKey, maxIdempotencyKeyLength)
+	}
+	if strings.ContainsFunc(key, unicode.IsControl) {
+		return Log{}, fmt.Errorf("register: %w: holds control characters", ErrInvalidIdempotencyKey)
+	}
+
+	if !level.IsValid() {
+		b.logger.Error(ctx, fmt.Sprintf("unrecognized level: %s", level), "error", ErrInvalidLevel)
+		return Log{}, fmt.Errorf("register: %w", ErrInvalidLevel)
+	}
+
+	tenant, err := b.tenant(ctx)
+	if err != nil {
+		return Log{}, fmt.Errorf("register: %w", err)
+	}
+
+	log := Log{
+		ID:        ulid.Make(),
+		Tenant:    tenant,
+		Message:   message,
+		Timestamp: time.Now(),
+		Level:     level,
+		Metadata:  metadata,
+	}
+
+	if b.redactor != nil {
+		b.redactor.Redact(&log)
+	}
+
+	// Keys are scoped by tenant, so tenants cannot learn each other's IDs.
+	if key != "" && tenant != "" {
+		key = tenant + idempotencyKeySeparator + key
+	}
+
+	if key != "" {
+		owner, claimed := b.claimIdempotencyKey(ctx, key, log.ID, log.Timestamp)
+		if !claimed {
+			b.logger.Info(ctx, "repeated idempotency key", "key", key, "id", owner)
+			log.ID = owner
+			return log, nil
+		}
+	}
+
+	if err := b.writer.Write(ctx, &log); err != nil {
+		b.logger.Error(ctx, "failed to register log", "error", err)
+		if key != "" {
+			b.releaseIdempotencyKey(ctx, key, log.ID)
+		}
+		if errors.Is(err, ErrOverloaded) {
+			return Log{}, fmt.Errorf("register: %w", ErrOverloaded)
+		}
+		return Log{}, fmt.Errorf("register: %w", ErrOnRegisterLog)
+	}
+
+	return log, nil
+}
+
+// claimIdempotencyKey binds key to id and reports whether it did. Otherwise
+// it returns the log the key is bound to. When the store cannot be asked the
+// key is claimed in memory, favouring a possible duplicate over a lost log.
+func (b *Business) claimIdempotencyKey(ctx context.Context, key string, id ulid.ULID, now time.Time) (ulid.ULID, bool) {
+	since := now.Add(-b.idempotencyWindow)
+
+	if store, ok := b.store.(IdempotencyStore); ok {
+		owner, claimed, err := store.ClaimIdempotencyKey(ctx, key, id, now, since)
+		if err == nil {
+			return owner, claimed
+		}
+		if !errors.Is(err, ErrNotSupported) {
+			b.logger.Error(ctx, "failed to claim idempotency key", "error", err, "key", key)
+		}
+	}
+
+	b.idempotency.mu.Lock()
+	defer b.idempotency.mu.Unlock()
+
+	if e, ok := b.idempotency.keys[key]; ok && e.at.After(since) {
+		return e.id, false
+	}
+	b.idempotency.keys[key] = idempotencyEntry{id: id, at: now}
+
+	return id, true
+}
+
+// releaseIdempotencyKey unbinds key from id after its log failed to be
+// stored, so a retry can store it.
+func (b *Business) releaseIdempotencyKey(ctx context.Context, key string, id ulid.ULID) {
+	b.idempotency.mu.Lock()
+	if e, ok := b.idempotency.keys[key]; ok && e.id == id {
+		delete(b.idempotency.keys, key)
+	}
+	b.idempotency.mu.Unlock()
+
+	if store, ok := b.store.(IdempotencyStore); ok {
+		if err := store.ReleaseIdempotencyKey(ctx, key, id); err != nil && !errors.Is(err, ErrNotSupported) {
+			b.logger.Error(ctx, "failed to release idempotency key", "error", err, "key", key)
+		}
+	}
+}
+
+// PurgeIdempotencyKeys forgets the idempotency keys older than the window
+// and returns how many were removed.
+func (b *Business) PurgeIdempotencyKeys(ctx context.Context) (int64, error) {
+	before := time.Now().Add(-b.idempotencyWindow)
+
+	var purged int64
+	b.idempotency.mu.Lock()
+	for key, e := range b.idempotency.keys {
+		if !e.at.After(before) {
+			delete(b.idempotency.keys, key)
+			purged++
+		}
+	}
+	b.idempotency.mu.Unlock()
+
+	if store, ok := b.store.(IdempotencyStore); ok {
+		n, err := store.PurgeIdempotencyKeys(ctx, before)
+		purged += n
+		if err != nil && !errors.Is(err, ErrNotSupported) {
+			return purged, fmt.Errorf("purge idempotency keys: %w", err)
+		}
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,286 @@
+// Package ingest implements a write-behind buffer between mlog.Business and
+// the store.
+//
+// Logs are queued and written by a single flusher in batches, cut when
+// BatchSize logs are waiting or FlushInterval has passed since the first of
+// them. When the queue is full writes fail with mlog.ErrOverloaded instead
+// of piling up, so callers can back off.
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/foundation/logger"
+)
+
+var ErrClosed = errors.New("ingest buffer is closed")
+
+// AckMode defines when a write is acknowledged.
+type AckMode string
+
+const (
+	// AckDurable acknowledges a write once its batch is in the store.
+	AckDurable AckMode = "durable"
+	// AckBuffered acknowledges a write once it is queued. Logs still queued
+	// are lost if the process dies before Close.
+	AckBuffered AckMode = "buffered"
+)
+
+const (
+	defaultBatchSize     = 500
+	defaultFlushInterval = 100 * time.Millisecond
+	defaultQueueSize     = 10000
+	defaultWriteTimeout  = 30 * time.Second
+)
+
+type Config struct {
+	BatchSize     int
+	FlushInterval time.Duration
+	// QueueSize is how many logs may wait to be written before writes are
+	// rejected.
+	QueueSize int
+	Ack       AckMode
+	// WriteTimeout bounds the write of each batch to the store.
+	WriteTimeout time.Duration
+}
+
+// entry is a queued log. result is nil in buffered mode.
+type entry struct {
+	log    *mlog.Log
+	result chan error
+}
+
+// Buffer is an mlog.Writer that batches writes to a store.
+type Buffer struct {
+	log           logger.Logger
+	store         mlog.Writer
+	ack           AckMode
+	batchSize     int
+	flushInterval time.Duration
+	writeTimeout  time.Duration
+	queue         chan entry
+	done          chan struct{}
+
+	// mu guards closed and keeps the queue open while writers send to it.
+	mu     sync.RWMutex
+	closed bool
+}
+
+// New returns a buffer writing to store and starts its flusher. Close must
+// be called to write the logs still queued.
+func New(log logger.Logger, store mlog.Writer, cfg Config) (*Buffer, error) {
+	ack := cfg.Ack
+	switch ack {
+	case "":
+		ack = AckDurable
+	case AckDurable, AckBuffered:
+	default:
+		return nil, fmt.Errorf("unknown ack mode %q", ack)
+	}
+
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	flushInterval := cfg.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = defaultFlushInterval
+	}
+
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+
+	writeTimeout := cfg.WriteTimeout
+	if writeTimeout <= 0 {
+		writeTimeout = defaultWriteTimeout
+	}
+
+	b := Buffer{
+		log:           log,
+		store:         store,
+		ack:           ack,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		writeTimeout:  writeTimeout,
+		queue:         make(chan entry, queueSize),
+		done:          make(chan struct{}),
+	}
+
+	go b.run()
+
+	return &b, nil
+}
+
+// Write queues log. In durable mode it waits until the batch holding log is
+// stored and reports its compression back on log. If ctx ends first it
+// returns ctx.Err(), though the log stays queued and may still be stored, so
+// callers retrying should reuse an idempotency key.
+func (b *Buffer) Write(ctx context.Context, log *mlog.Log) error {
+	queued := *log
+	e := entry{log: &queued}
+	if b.ack == AckDurable {
+		e.result = make(chan error, 1)
+	}
+
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return ErrClosed
+	}
+	select {
+	case b.queue <- e:
+	default:
+		b.mu.RUnlock()
+		return mlog.ErrOverloaded
+	}
+	b.mu.RUnlock()
+
+	if e.result == nil {
+		return nil
+	}
+
+	var err error
+	select {
+	case err = <-e.result:
+	case <-ctx.Done():
+		// The batch may have been written as ctx ended.
+		select {
+		case err = <-e.result:
+		default:
+			return ctx.Err()
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Compressed = queued.Compressed
+	log.CompressedAt = queued.CompressedAt
+	log.Compression = queued.Compression
+	return nil
+}
+
+// Pending returns how many logs are waiting to be written.
+func (b *Buffer) Pending() int {
+	return len(b.queue)
+}
+
+// Backlog reports the logs queued in the buffer, along with the backlog of
+// the writer behind it.
+func (b *Buffer) Backlog() mlog.Backlog {
+	var backlog mlog.Backlog
+	if r, ok := b.store.(mlog.BacklogReporter); ok {
+		backlog = r.Backlog()
+	}
+	backlog.Queued = int64(len(b.queue))
+	return backlog
+}
+
+// Close stops accepting logs and waits until every queued log is written or
+// ctx ends.
+func (b *Buffer) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.queue)
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d logs still queued: %w", len(b.queue), ctx.Err())
+	}
+}
+
+// run collects batches from the queue and writes them until the queue is
+// closed and drained.
+func (b *Buffer) run() {
+	defer close(b.done)
+
+	timer := time.NewTimer(b.flushInterval)
+	timer.Stop()
+
+	batch := make([]entry, 0, b.batchSize)
+	for {
+		select {
+		case e, ok := <-b.queue:
+			if !ok {
+				b.flush(batch)
+				return
+			}
+
+			if len(batch) == 0 {
+				timer.Reset(b.flushInterval)
+			}
+			batch = append(batch, e)
+			if len(batch) < b.batchSize {
+				continue
+			}
+
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+
+		case <-timer.C:
+		}
+
+		b.flush(batch)
+		batch = batch[:0]
+	}
+}
+
+// flush writes batch and reports every log's outcome to its writer.
+func (b *Buffer) flush(batch []entry) {
+	if len(batch) == 0 {
+		return
+	}
+
+	logs := make([]*mlog.Log, len(batch))
+	for i, e := range batch {
+		logs[i] = e.log
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), b.writeTimeout)
+	defer cancel()
+
+	err := mlog.WriteBatch(ctx, b.store, logs)
+
+	var batchErr *mlog.BatchError
+	errors.As(err, &batchErr)
+
+	failed := 0
+	for i, e := range batch {
+		result := err
+		if batchErr != nil {
+			result = batchErr.Failed[i]
+		}
+		if result != nil {
+			failed++
+		}
+		if e.result != nil {
+			e.result <- result
+		}
+	}
+
+	if failed > 0 {
+		b.log.Error(ctx, "failed to write buffered logs", "error", err, "logs", len(batch), "failed", failed)
+	}
+}
+
+var (
+	_ mlog.Writer          = (*Buffer)(nil)
+	_ mlog.BacklogReporter = (*Buffer)(nil)
+)
@@ -4,59 +4,214 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/felipecooper/log-horizon/foundation/logger"
 	"github.com/felipecooper/log-horizon/foundation/transaction"
-	"github.com/oklog/ulid/v2"
 )
 
 var (
 	ErrOnRegisterLog    = errors.New("failed on save log in writer")
 	ErrInvalidLevel     = errors.New("unrecognized level")
 	ErrInvalidTimeRange = errors.New("invalid time range")
+	ErrNotSupported     = errors.New("operation not supported by store")
+	ErrOverloaded       = errors.New("too many logs waiting to be stored")
+	ErrForeignTx        = errors.New("transaction was not started by Begin")
+)
+
+const (
+	defaultStatsCacheTTL  = 30 * time.Second
+	defaultStreamPageSize = 100
 )
 
 type Business struct {
 	logger logger.Logger
 	store  Store
+	writer Writer
+
+	statsTTL   time.Duration
+	statsMu    sync.Mutex
+	statsCache Stats
+
+	retention *retentionState
+
+	maxEraseMatches int
+
+	idempotencyWindow time.Duration
+	idempotency       *idempotencyState
+
+	tenantRequired bool
+
+	redactor Redactor
+
+	deletions DeletionRecorder
+
+	txWriter TxWriter
 }
 
-func NewMlog(logger logger.Logger, store Store) *Business {
-	return &Business{
-		logger: logger,
-		store:  store,
+// Option configures optional behavior of the Business.
+type Option func(*Business)
+
+// WithMaxEraseMatches limits how many logs a single Delete may touch.
+func WithMaxEraseMatches(n int) Option {
+	return func(b *Business) {
+		b.maxEraseMatches = n
 	}
 }
 
-func (b *Business) NewWithTx(tx transaction.CommitRollbacker) (*Business, error) {
-	return b, nil
+// WithWriter makes Register store logs through w, such as an ingest buffer
+// in front of the store, instead of writing to the store directly.
+func WithWriter(w Writer) Option {
+	return func(b *Business) {
+		b.writer = w
+	}
 }
 
-func (b *Business) Register(ctx context.Context, message string, level Level, metadata map[string]string) (Log, error) {
-	if !level.IsValid() {
-		b.logger.Error(ctx, fmt.Sprintf("unrecognized level: %s", level), "error", ErrInvalidLevel)
-		return Log{}, fmt.Errorf("register: %w", ErrInvalidLevel)
+// WithTxWriter makes logs registered inside a transaction go through w,
+// bound to the transaction, rather than straight to the store.
+func WithTxWriter(w TxWriter) Option {
+	return func(b *Business) {
+		b.txWriter = w
+	}
+}
+
+// WithStatsCacheTTL defines for how long Stats results are reused.
+func WithStatsCacheTTL(ttl time.Duration) Option {
+	return func(b *Business) {
+		b.statsTTL = ttl
+	}
+}
+
+func NewMlog(logger logger.Logger, store Store, opts ...Option) *Business {
+	b := Business{
+		logger:            logger,
+		store:             store,
+		writer:            store,
+		statsTTL:          defaultStatsCacheTTL,
+		retention:         &retentionState{},
+		maxEraseMatches:   defaultMaxEraseMatches,
+		idempotencyWindow: defaultIdempotencyWindow,
+		idempotency:       &idempotencyState{keys: make(map[string]idempotencyEntry)},
+	}
+	for _, opt := range opts {
+		opt(&b)
+	}
+	return &b
+}
+
+// Begin starts a transaction on the store. Calls made through the Business
+// returned by NewWithTx take effect when it is committed.
+func (b *Business) Begin(ctx context.Context) (transaction.CommitRollbacker, error) {
+	store, ok := b.store.(Transactor)
+	if !ok {
+		return nil, fmt.Errorf("begin: %w", ErrNotSupported)
+	}
+
+	tx, err := store.Begin(ctx)
+	if err != nil {
+		b.logger.Error(ctx, "failed to begin transaction", "error", err)
+		return nil, fmt.Errorf("begin: %w", err)
+	}
+
+	return &storeTx{CommitRollbacker: tx}, nil
+}
+
+// storeTx is a transaction of the store that tells the writers bound to it
+// when it commits.
+type storeTx struct {
+	transaction.CommitRollbacker
+	writers []TxBoundWriter
+}
+
+func (t *storeTx) Commit(ctx context.Context) error {
+	if err := t.CommitRollbacker.Commit(ctx); err != nil {
+		return err
+	}
+	for _, w := range t.writers {
+		w.Committed(ctx)
 	}
+	return nil
+}
 
-	log := Log{
-		ID:        ulid.Make(),
-		Message:   message,
-		Timestamp: time.Now(),
-		Level:     level,
-		Metadata:  metadata,
+// NewWithTx returns a Business whose calls run inside tx, which must have
+// been started by Begin. It shares the configuration of b but not its
+// caches. Logs it registers go through the writer set by WithTxWriter, if
+// any, but never through the writer set by WithWriter, which stores them
+// outside the transaction.
+func (b *Business) NewWithTx(tx transaction.CommitRollbacker) (*Business, error) {
+	store, ok := b.store.(Transactor)
+	if !ok {
+		return nil, fmt.Errorf("new with tx: %w", ErrNotSupported)
 	}
 
-	err := b.store.Write(ctx, &log)
+	t, ok := tx.(*storeTx)
+	if !ok {
+		return nil, fmt.Errorf("new with tx: %w", ErrForeignTx)
+	}
+
+	txStore, err := store.NewWithTx(t.CommitRollbacker)
 	if err != nil {
-		b.logger.Error(ctx, "failed to register log", "error", err)
-		return Log{}, fmt.Errorf("register: %w", ErrOnRegisterLog)
+		return nil, fmt.Errorf("new with tx: %w", err)
 	}
 
-	return log, nil
+	var writer Writer = txStore
+	if b.txWriter != nil {
+		w := b.txWriter.WithTx(txStore)
+		t.writers = append(t.writers, w)
+		writer = w
+	}
+
+	return &Business{
+		logger:            b.logger,
+		store:             txStore,
+		writer:            writer,
+		statsTTL:          b.statsTTL,
+		retention:         b.retention,
+		maxEraseMatches:   b.maxEraseMatches,
+		idempotencyWindow: b.idempotencyWindow,
+		idempotency:       b.idempotency,
+		tenantRequired:    b.tenantRequired,
+		redactor:          b.redactor,
+		deletions:         b.deletions,
+		txWriter:          b.txWriter,
+	}, nil
 }
 
-func (b *Business) Query(ctx context.Context, startTime, endTime time.Time, level Level, page, pageSize int) (SearchResult, error) {
+// InTx runs fn inside a transaction, committing it when fn succeeds and
+// rolling it back otherwise.
+func (b *Business) InTx(ctx context.Context, fn func(*Business) error) error {
+	tx, err := b.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	txBus, err := b.NewWithTx(tx)
+	if err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+
+	if err := fn(txBus); err != nil {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			b.logger.Error(ctx, "failed to roll back transaction", "error", rbErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		b.logger.Error(ctx, "failed to commit transaction", "error", err)
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	return nil
+}
+
+func (b *Business) Register(ctx context.Context, message string, level Level, metadata map[string]string) (Log, error) {
+	return b.RegisterWithKey(ctx, "", message, level, metadata)
+}
+
+func (b *Business) Query(ctx context.Context, startTime, endTime time.Time, level Level, message string, page, pageSize int) (SearchResult, error) {
 	if !startTime.IsZero() && !endTime.IsZero() && endTime.Before(startTime) {
 		b.logger.Error(ctx, "end time before start time", "error", ErrInvalidTimeRange)
 		return SearchResult{}, fmt.Errorf("query: %w", ErrInvalidTimeRange)
@@ -67,16 +222,31 @@ func (b *Business) Query(ctx context.Context, startTime, endTime time.Time, leve
 		return SearchResult{}, fmt.Errorf("query: %w", ErrInvalidLevel)
 	}
 
+	tenant, err := b.tenant(ctx)
+	if err != nil {
+		return SearchResult{}, fmt.Errorf("query: %w", err)
+	}
+
+	if message != "" && !b.searchesMessages() {
+		return SearchResult{}, fmt.Errorf("query: message search: %w", ErrNotSupported)
+	}
+
 	criteria := SearchCriteria{
+		Tenant: tenant,
 		TimeRange: TimeRange{
 			StartTime: startTime,
 			EndTime:   endTime,
 		},
 		Level:    level,
+		Message:  message,
 		Page:     page,
 		PageSize: pageSize,
 	}
 
+	if err := restrict(ctx, &criteria, time.Now()); err != nil {
+		return SearchResult{}, fmt.Errorf("query: %w", err)
+	}
+
 	result, err := b.store.Search(ctx, criteria)
 	if err != nil {
 		b.logger.Error(ctx, "failed to search logs", "error", err)
@@ -86,7 +256,7 @@ func (b *Business) Query(ctx context.Context, startTime, endTime time.Time, leve
 	return result, nil
 }
 
-func (b *Business) ExportToFile(ctx context.Context, startTime, endTime time.Time, level Level) (string, int64, error) {
+func (b *Business) ExportToFile(ctx context.Context, startTime, endTime time.Time, level Level, message string) (string, int64, error) {
 	if !startTime.IsZero() && !endTime.IsZero() && endTime.Before(startTime) {
 		b.logger.Error(ctx, "end time before start time", "error", ErrInvalidTimeRange)
 		return "", 0, fmt.Errorf("export: %w", ErrInvalidTimeRange)
@@ -97,12 +267,27 @@ func (b *Business) ExportToFile(ctx context.Context, startTime, endTime time.Tim
 		return "", 0, fmt.Errorf("export: %w", ErrInvalidLevel)
 	}
 
+	tenant, err := b.tenant(ctx)
+	if err != nil {
+		return "", 0, fmt.Errorf("export: %w", err)
+	}
+
+	if message != "" && !b.searchesMessages() {
+		return "", 0, fmt.Errorf("export: message search: %w", ErrNotSupported)
+	}
+
 	criteria := SearchCriteria{
+		Tenant: tenant,
 		TimeRange: TimeRange{
 			StartTime: startTime,
 			EndTime:   endTime,
 		},
-		Level: level,
+		Level:   level,
+		Message: message,
+	}
+
+	if err := restrict(ctx, &criteria, time.Now()); err != nil {
+		return "", 0, fmt.Errorf("export: %w", err)
 	}
 
 	fileURL, fileSize, err := b.store.ExportToFile(ctx, criteria)
@@ -114,6 +299,109 @@ func (b *Business) ExportToFile(ctx context.Context, startTime, endTime time.Tim
 	return fileURL, fileSize, nil
 }
 
+// Stream delivers the logs matching the filters newest first, in pages of
+// pageSize, to fn. Stores implementing Streamer serve the whole stream from
+// one cursor; the others are paged through Search.
+func (b *Business) Stream(ctx context.Context, startTime, endTime time.Time, level Level, message string, pageSize int, fn func(SearchResult) error) error {
+	if !startTime.IsZero() && !endTime.IsZero() && endTime.Before(startTime) {
+		b.logger.Error(ctx, "end time before start time", "error", ErrInvalidTimeRange)
+		return fmt.Errorf("stream: %w", ErrInvalidTimeRange)
+	}
+
+	if level != "" && !level.IsValid() {
+		b.logger.Error(ctx, fmt.Sprintf("invalid level: %s", level), "error", ErrInvalidLevel)
+		return fmt.Errorf("stream: %w", ErrInvalidLevel)
+	}
+
+	tenant, err := b.tenant(ctx)
+	if err != nil {
+		return fmt.Errorf("stream: %w", err)
+	}
+
+	if message != "" && !b.searchesMessages() {
+		return fmt.Errorf("stream: message search: %w", ErrNotSupported)
+	}
+
+	if pageSize <= 0 {
+		pageSize = defaultStreamPageSize
+	}
+
+	criteria := SearchCriteria{
+		Tenant: tenant,
+		TimeRange: TimeRange{
+			StartTime: startTime,
+			EndTime:   endTime,
+		},
+		Level:    level,
+		Message:  message,
+		PageSize: pageSize,
+	}
+
+	if err := restrict(ctx, &criteria, time.Now()); err != nil {
+		return fmt.Errorf("stream: %w", err)
+	}
+
+	streamer, ok := b.store.(Streamer)
+	if !ok {
+		for {
+			result, err := b.store.Search(ctx, criteria)
+			if err != nil {
+				b.logger.Error(ctx, "failed to search logs", "error", err, "page", criteria.Page)
+				return fmt.Errorf("stream: %w", err)
+			}
+
+			if err := fn(result); err != nil {
+				return err
+			}
+
+			if !result.HasMore {
+				return nil
+			}
+			criteria.Page = result.NextPage
+		}
+	}
+
+	total, err := b.store.Count(ctx, criteria)
+	if err != nil {
+		b.logger.Error(ctx, "failed to count logs", "error", err)
+		return fmt.Errorf("stream: %w", err)
+	}
+
+	var (
+		logs []Log
+		page int
+	)
+	send := func(hasMore bool) error {
+		result := SearchResult{Logs: logs, Total: total, HasMore: hasMore, NextPage: page}
+		if hasMore {
+			result.NextPage = page + 1
+		}
+		logs = nil
+		page++
+		return fn(result)
+	}
+
+	var sendErr error
+	err = streamer.Stream(ctx, criteria, func(log Log) error {
+		if len(logs) == pageSize {
+			if sendErr = send(true); sendErr != nil {
+				return sendErr
+			}
+		}
+		logs = append(logs, log)
+		return nil
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil {
+		b.logger.Error(ctx, "failed to stream logs", "error", err)
+		return fmt.Errorf("stream: %w", err)
+	}
+
+	return send(false)
+}
+
 func (b *Business) Count(ctx context.Context, startTime, endTime time.Time, level Level) (int, error) {
 	if !startTime.IsZero() && !endTime.IsZero() && endTime.Before(startTime) {
 		b.logger.Error(ctx, "end time before start time", "error", ErrInvalidTimeRange)
@@ -125,7 +413,13 @@ func (b *Business) Count(ctx context.Context, startTime, endTime time.Time, leve
 		return 0, fmt.Errorf("count: %w", ErrInvalidLevel)
 	}
 
+	tenant, err := b.tenant(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("count: %w", err)
+	}
+
 	criteria := SearchCriteria{
+		Tenant: tenant,
 		TimeRange: TimeRange{
 			StartTime: startTime,
 			EndTime:   endTime,
@@ -133,6 +427,10 @@ func (b *Business) Count(ctx context.Context, startTime, endTime time.Time, leve
 		Level: level,
 	}
 
+	if err := restrict(ctx, &criteria, time.Now()); err != nil {
+		return 0, fmt.Errorf("count: %w", err)
+	}
+
 	count, err := b.store.Count(ctx, criteria)
 	if err != nil {
 		b.logger.Error(ctx, "failed to count logs", "error", err)
@@ -141,3 +439,50 @@ func (b *Business) Count(ctx context.Context, startTime, endTime time.Time, leve
 
 	return count, nil
 }
+
+func (b *Business) searchesMessages() bool {
+	searcher, ok := b.store.(MessageSearcher)
+	return ok && searcher.SearchMessages()
+}
+
+func (b *Business) Stats(ctx context.Context, refresh bool) (Stats, error) {
+	reporter, ok := b.store.(StatsReporter)
+	if !ok {
+		return Stats{}, fmt.Errorf("stats: %w", ErrNotSupported)
+	}
+
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	if refresh || b.statsCache.ComputedAt.IsZero() || time.Since(b.statsCache.ComputedAt) >= b.statsTTL {
+		stats, err := reporter.Stats(ctx)
+		if err != nil {
+			b.logger.Error(ctx, "failed to compute stats", "error", err)
+			return Stats{}, fmt.Errorf("stats: %w", err)
+		}
+		b.statsCache = stats
+	}
+
+	// The rest of the stats spans every tenant, so a tenant only learns how
+	// many logs it holds itself.
+	if tenant := TenantFrom(ctx); tenant != "" {
+		stats := Stats{ComputedAt: b.statsCache.ComputedAt}
+		for _, t := range b.statsCache.Tenants {
+			if t.Tenant == tenant {
+				stats.Total = t.Count
+				stats.Tenants = []TenantStats{t}
+			}
+		}
+		return stats, nil
+	}
+
+	stats := b.statsCache
+	if backlog, ok := b.writer.(BacklogReporter); ok {
+		stats.Backlog = backlog.Backlog()
+	}
+	if b.redactor != nil {
+		stats.Redactions = b.redactor.Redactions()
+	}
+
+	return stats, nil
+}
@@ -1,9 +1,14 @@
 package mlog
 
 import (
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/oklog/ulid/v2"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
 )
 
 type Level string
@@ -17,12 +22,14 @@ const (
 
 type Log struct {
 	ID           ulid.ULID
+	Tenant       string
 	Message      string
 	Timestamp    time.Time
 	Level        Level
 	Metadata     map[string]string
 	Compressed   bool
 	CompressedAt time.Time
+	Compression  string
 }
 
 type TimeRange struct {
@@ -31,10 +38,87 @@ type TimeRange struct {
 }
 
 type SearchCriteria struct {
-	TimeRange TimeRange
-	Level     Level
-	PageSize  int
-	Page      int
+	// Tenant restricts the search to the logs of one tenant. Business
+	// always sets it from the context; "" matches the logs of no tenant.
+	Tenant string
+	// AllTenants lifts the tenant restriction, for the jobs of the stores
+	// themselves that work over every log. Business never sets it.
+	AllTenants bool
+	TimeRange  TimeRange
+	Level      Level
+	// Levels, when set, restricts the search to logs of any of these
+	// levels. Business only sets it when Level is empty.
+	Levels   []Level
+	Metadata map[string]string
+	// Message lists words that must all appear in the message. Only stores
+	// implementing MessageSearcher honor it.
+	Message  string
+	PageSize int
+	Page     int
+}
+
+// MatchesTenant reports whether a log of tenant is selected by the criteria.
+func (c SearchCriteria) MatchesTenant(tenant string) bool {
+	return c.AllTenants || tenant == c.Tenant
+}
+
+// MatchesMessage reports whether every word of Message appears in message.
+// Stores without a text index use it to filter logs, so it matches as the
+// SQLite full-text index does: words match whole tokens, runs of letters
+// and digits compared without case or diacritics, and a word of several
+// tokens, such as "user-42", matches them in a row. Words without tokens
+// are ignored, but a search made only of them matches nothing.
+func (c SearchCriteria) MatchesMessage(message string) bool {
+	if c.Message == "" {
+		return true
+	}
+
+	tokens := messageTokens(message)
+	searched := false
+	for _, word := range strings.Fields(c.Message) {
+		run := messageTokens(word)
+		if len(run) == 0 {
+			continue
+		}
+		if !containsRun(tokens, run) {
+			return false
+		}
+		searched = true
+	}
+	return searched
+}
+
+// messageTokens splits s into lowercase tokens without diacritics, the way
+// the unicode61 tokenizer of SQLite does.
+func messageTokens(s string) []string {
+	fold := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	if folded, _, err := transform.String(fold, s); err == nil {
+		s = folded
+	}
+
+	// Final sigma folds like any other.
+	s = strings.ReplaceAll(strings.ToLower(s), "ς", "σ")
+
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && !unicode.Is(unicode.Co, r)
+	})
+}
+
+// containsRun reports whether run appears in tokens in a row.
+func containsRun(tokens, run []string) bool {
+	for i := 0; i+len(run) <= len(tokens); i++ {
+		match := true
+		for j, token := range run {
+			if tokens[i+j] != token {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
 }
 
 type SearchResult struct {
@@ -44,6 +128,102 @@ type SearchResult struct {
 	NextPage int
 }
 
+type CompactionReport struct {
+	Logs        int
+	Blocks      int
+	BytesBefore int64
+	BytesAfter  int64
+}
+
+func (r CompactionReport) Saved() int64 {
+	return r.BytesBefore - r.BytesAfter
+}
+
+type Stats struct {
+	Total        int64
+	Compressed   int64
+	Uncompressed int64
+	RawBytes     int64
+	StoredBytes  int64
+	Levels       []LevelStats
+	Days         []DayStats
+	Algorithms   []AlgorithmStats
+	Collections  []CollectionStats
+	Tenants      []TenantStats
+	ComputedAt   time.Time
+	// Backlog and Redactions are read live on every call, even when the
+	// rest is cached.
+	Backlog    Backlog
+	Redactions []RedactionStats
+}
+
+// Backlog describes the logs accepted by Register that are not in the store
+// yet.
+type Backlog struct {
+	// Queued logs wait in memory for their batch.
+	Queued int64
+	// Spooled logs wait on disk for the store to come back.
+	Spooled       int64
+	SpoolBytes    int64
+	SpoolSegments int
+	// OldestSpooled is the timestamp of the next log to be replayed.
+	OldestSpooled time.Time
+	// Replayed counts the logs moved from the spool to the store since the
+	// process started, and DeadLettered the ones the store kept rejecting.
+	Replayed     int64
+	DeadLettered int64
+	// Spooling is set while the store is failing or the spool is replayed.
+	Spooling bool
+}
+
+func (s Stats) Ratio() float64 {
+	return ratio(s.RawBytes, s.StoredBytes)
+}
+
+// TenantStats counts the logs of a tenant. Tenant is "" for logs of no
+// tenant.
+type TenantStats struct {
+	Tenant string
+	Count  int64
+}
+
+type LevelStats struct {
+	Level Level
+	Count int64
+}
+
+type DayStats struct {
+	Day   time.Time
+	Count int64
+}
+
+type AlgorithmStats struct {
+	Algorithm   string
+	Count       int64
+	RawBytes    int64
+	StoredBytes int64
+}
+
+func (a AlgorithmStats) Ratio() float64 {
+	return ratio(a.RawBytes, a.StoredBytes)
+}
+
+type CollectionStats struct {
+	Name        string
+	Count       int64
+	Size        int64
+	StorageSize int64
+	IndexSize   int64
+	IndexSizes  map[string]int64
+}
+
+func ratio(raw, stored int64) float64 {
+	if stored == 0 {
+		return 0
+	}
+	return float64(raw) / float64(stored)
+}
+
 func (l Level) IsValid() bool {
 	switch l {
 	case Error, Warn, Debug, Info:
@@ -0,0 +1,301 @@
+package mongodb
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/foundation/compress"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultBlockSize  = 1000
+	blockCacheEntries = 16
+)
+
+// dbBlock stores the messages of many logs compressed together. Each log keeps
+// its own document with every queryable field, and points into the block
+// through an offset and length over the decompressed data. With a keyring
+// the compressed data is sealed with the data key KeyID.
+type dbBlock struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	Algorithm compress.Algorithm `bson:"algorithm"`
+	Data      []byte             `bson:"data"`
+	KeyID     string             `bson:"keyid,omitempty"`
+	Count     int                `bson:"count"`
+	RawSize   int64              `bson:"rawsize"`
+	Start     time.Time          `bson:"start"`
+	End       time.Time          `bson:"end"`
+	CreatedAt time.Time          `bson:"createdat"`
+}
+
+// Compact rewrites the messages of logs older than olderThan into compressed
+// blocks. Logs already stored in a block are skipped, so the job can be run
+// repeatedly.
+func (s *Store) Compact(ctx context.Context, olderThan time.Time) (mlog.CompactionReport, error) {
+	filter := bson.M{
+		"timestamp": bson.M{"$lt": olderThan},
+		"block":     bson.M{"$exists": false},
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: 1}}).
+		SetBatchSize(int32(s.blockSize))
+
+	cursor, err := s.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return mlog.CompactionReport{}, fmt.Errorf("finding logs to compact: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var (
+		report mlog.CompactionReport
+		batch  []dbLog
+	)
+
+	for cursor.Next(ctx) {
+		var doc dbLog
+		if err := cursor.Decode(&doc); err != nil {
+			continue
+		}
+
+		batch = append(batch, doc)
+		if len(batch) < s.blockSize {
+			continue
+		}
+
+		if err := s.writeBlock(ctx, batch, &report); err != nil {
+			return report, err
+		}
+		batch = batch[:0]
+	}
+
+	if err := cursor.Err(); err != nil {
+		return report, fmt.Errorf("iterating logs to compact: %w", err)
+	}
+
+	if len(batch) > 0 {
+		if err := s.writeBlock(ctx, batch, &report); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// writeBlock packs the messages of docs into one block. A log whose message
+// cannot be decompressed is left out and keeps its message, so it is never
+// pointed at bytes that are not its own.
+func (s *Store) writeBlock(ctx context.Context, batch []dbLog, report *mlog.CompactionReport) error {
+	var (
+		raw     []byte
+		docs    = make([]dbLog, 0, len(batch))
+		offsets = make([]int, 0, len(batch))
+		lengths = make([]int, 0, len(batch))
+		before  int64
+	)
+
+	for _, doc := range batch {
+		stored := len(doc.Message)
+		if err := s.unseal(ctx, &doc); err != nil {
+			s.log.Error(ctx, "failed to open log message, leaving it out of the block", "error", err, "id", doc.ID)
+			continue
+		}
+
+		message := []byte(doc.Message)
+		if doc.Compressed {
+			decompressed, err := s.decompress(ctx, doc)
+			if err != nil {
+				s.log.Error(ctx, "failed to decompress log message, leaving it out of the block", "error", err, "id", doc.ID)
+				continue
+			}
+			message = decompressed
+		}
+
+		docs = append(docs, doc)
+		offsets = append(offsets, len(raw))
+		lengths = append(lengths, len(message))
+		raw = append(raw, message...)
+		before += int64(stored)
+	}
+
+	if len(docs) == 0 {
+		return nil
+	}
+
+	data, err := s.blockCompressor.Compress(raw)
+	if err != nil {
+		return fmt.Errorf("compressing block: %w", err)
+	}
+
+	id := primitive.NewObjectID()
+	keyID, data, err := s.sealBlock(id, data)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	block := dbBlock{
+		ID:        id,
+		Algorithm: s.blockCompressor.Algorithm(),
+		Data:      data,
+		KeyID:     keyID,
+		Count:     len(docs),
+		RawSize:   int64(len(raw)),
+		Start:     docs[0].Timestamp,
+		End:       docs[len(docs)-1].Timestamp,
+		CreatedAt: now,
+	}
+
+	if _, err := s.blocks.InsertOne(ctx, block); err != nil {
+		return fmt.Errorf("inserting block: %w", err)
+	}
+
+	models := make([]mongo.WriteModel, len(docs))
+	for i, doc := range docs {
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"id": doc.ID}).
+			SetUpdate(bson.M{
+				"$set": bson.M{
+					"message":      "",
+					"compressed":   true,
+					"compressedat": now,
+					"algorithm":    block.Algorithm,
+					"block":        block.ID,
+					"blockoffset":  offsets[i],
+					"blocklength":  lengths[i],
+					"rawsize":      lengths[i],
+				},
+				"$unset": bson.M{"dictionary": ""},
+			})
+	}
+
+	if _, err := s.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+		return fmt.Errorf("pointing logs to block: %w", err)
+	}
+
+	report.Logs += len(docs)
+	report.Blocks++
+	report.BytesBefore += before
+	report.BytesAfter += int64(len(data))
+
+	return nil
+}
+
+func (s *Store) blockMessage(ctx context.Context, doc dbLog) ([]byte, error) {
+	data, err := s.blockData(ctx, doc.Block)
+	if err != nil {
+		return nil, err
+	}
+
+	end := doc.BlockOffset + doc.BlockLength
+	if doc.BlockOffset < 0 || end > len(data) {
+		return nil, fmt.Errorf("log %s is out of range of block %s", doc.ID, doc.Block.Hex())
+	}
+
+	return data[doc.BlockOffset:end], nil
+}
+
+func (s *Store) blockData(ctx context.Context, id primitive.ObjectID) ([]byte, error) {
+	if data, ok := s.blockCache.get(id); ok {
+		return data, nil
+	}
+
+	var block dbBlock
+	if err := s.blocks.FindOne(ctx, bson.M{"_id": id}).Decode(&block); err != nil {
+		return nil, fmt.Errorf("finding block %s: %w", id.Hex(), err)
+	}
+
+	data := block.Data
+	if block.KeyID != "" {
+		opened, err := s.open(ctx, block.KeyID, data, blockAAD(id))
+		if err != nil {
+			return nil, fmt.Errorf("opening block %s: %w", id.Hex(), err)
+		}
+		data = opened
+	}
+
+	data, err := compress.Decompress(block.Algorithm, data)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing block %s: %w", id.Hex(), err)
+	}
+
+	s.blockCache.put(id, data)
+
+	return data, nil
+}
+
+// blockCache keeps the most recently used decompressed blocks. Queries sort by
+// timestamp, so consecutive logs usually share a block.
+type blockCache struct {
+	mu      sync.Mutex
+	entries map[primitive.ObjectID]*list.Element
+	order   *list.List
+}
+
+type blockCacheEntry struct {
+	id   primitive.ObjectID
+	data []byte
+}
+
+func newBlockCache() *blockCache {
+	return &blockCache{
+		entries: make(map[primitive.ObjectID]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (c *blockCache) get(id primitive.ObjectID) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*blockCacheEntry).data, true
+}
+
+func (c *blockCache) put(id primitive.ObjectID, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[id]; ok {
+		elem.Value.(*blockCacheEntry).data = data
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[id] = c.order.PushFront(&blockCacheEntry{id: id, data: data})
+
+	if c.order.Len() > blockCacheEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*blockCacheEntry).id)
+	}
+}
+
+func (c *blockCache) invalidate(id primitive.ObjectID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[id]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, id)
+	}
+}
+
+func (c *blockCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[primitive.ObjectID]*list.Element)
+	c.order.Init()
+}
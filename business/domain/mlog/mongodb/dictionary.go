@@ -0,0 +1,158 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/felipecooper/log-horizon/foundation/compress"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultDictionarySize    = 64 << 10
+	defaultDictionarySamples = 2000
+	minDictionaryMessage     = 8
+)
+
+type dbDictionary struct {
+	Version    uint32    `bson:"version"`
+	Dictionary []byte    `bson:"dictionary"`
+	Samples    int       `bson:"samples"`
+	CreatedAt  time.Time `bson:"createdat"`
+}
+
+// dictionarySet holds the dictionary used for new writes and a decoder that
+// knows every dictionary ever trained, so historical records stay readable.
+type dictionarySet struct {
+	mu        sync.RWMutex
+	version   uint32
+	trainedAt time.Time
+	active    *compress.ZstdDictCompressor
+	decoder   *compress.ZstdDictDecoder
+}
+
+func (d *dictionarySet) current() *compress.ZstdDictCompressor {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.active
+}
+
+func (d *dictionarySet) decompress(data []byte) ([]byte, error) {
+	d.mu.RLock()
+	decoder := d.decoder
+	d.mu.RUnlock()
+
+	if decoder == nil {
+		return nil, fmt.Errorf("no dictionaries loaded")
+	}
+	return decoder.Decompress(data)
+}
+
+func (s *Store) loadDictionaries(ctx context.Context) error {
+	cursor, err := s.dictionaries.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "version", Value: 1}}))
+	if err != nil {
+		return fmt.Errorf("finding dictionaries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []dbDictionary
+	if err := cursor.All(ctx, &docs); err != nil {
+		return fmt.Errorf("decoding dictionaries: %w", err)
+	}
+
+	if len(docs) == 0 {
+		return nil
+	}
+
+	dicts := make([][]byte, len(docs))
+	for i, doc := range docs {
+		dicts[i] = doc.Dictionary
+	}
+
+	decoder, err := compress.NewZstdDictDecoder(dicts...)
+	if err != nil {
+		return fmt.Errorf("creating dictionary decoder: %w", err)
+	}
+
+	latest := docs[len(docs)-1]
+	active, err := compress.NewZstdDictCompressor(latest.Dictionary, s.compressionLevel)
+	if err != nil {
+		return fmt.Errorf("creating dictionary compressor: %w", err)
+	}
+
+	s.dicts.mu.Lock()
+	s.dicts.version = latest.Version
+	s.dicts.trainedAt = latest.CreatedAt
+	s.dicts.active = active
+	s.dicts.decoder = decoder
+	s.dicts.mu.Unlock()
+
+	return nil
+}
+
+// DictionaryTrainedAt returns when the active dictionary was trained, or the
+// zero time when there is none yet.
+func (s *Store) DictionaryTrainedAt() time.Time {
+	s.dicts.mu.RLock()
+	defer s.dicts.mu.RUnlock()
+	return s.dicts.trainedAt
+}
+
+// TrainDictionary builds a new zstd dictionary from a random sample of stored
+// messages, persists it under the next version and makes it the active one.
+func (s *Store) TrainDictionary(ctx context.Context) (uint32, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$sample", Value: bson.M{"size": s.dictionarySamples}}},
+	}
+
+	cursor, err := s.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, fmt.Errorf("sampling logs: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var samples [][]byte
+	for cursor.Next(ctx) {
+		var doc dbLog
+		if err := cursor.Decode(&doc); err != nil {
+			continue
+		}
+
+		log := s.decode(ctx, doc)
+		if log.Message == "" {
+			continue
+		}
+		samples = append(samples, []byte(log.Message))
+	}
+
+	s.dicts.mu.RLock()
+	version := s.dicts.version + 1
+	s.dicts.mu.RUnlock()
+
+	dict, err := compress.TrainZstdDictionary(version, samples, s.dictionarySize)
+	if err != nil {
+		return 0, fmt.Errorf("training dictionary: %w", err)
+	}
+
+	_, err = s.dictionaries.InsertOne(ctx, dbDictionary{
+		Version:    version,
+		Dictionary: dict,
+		Samples:    len(samples),
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("saving dictionary: %w", err)
+	}
+
+	if err := s.loadDictionaries(ctx); err != nil {
+		return 0, err
+	}
+
+	s.log.Info(ctx, "dictionary trained", "version", version, "samples", len(samples), "size", len(dict))
+
+	return version, nil
+}
@@ -0,0 +1,319 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/foundation/envelope"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type dbDataKey struct {
+	ID        string    `bson:"id"`
+	Wrapped   []byte    `bson:"wrapped"`
+	CreatedAt time.Time `bson:"createdat"`
+}
+
+// messageAAD binds a sealed message to its log, so it cannot be swapped
+// with the message of another one.
+func messageAAD(doc dbLog) []byte {
+	return []byte(doc.ID.String())
+}
+
+// metadataAAD binds a sealed metadata value to its log and key.
+func metadataAAD(doc dbLog, key string) []byte {
+	return []byte(doc.ID.String() + "\x00" + key)
+}
+
+// blockAAD binds the sealed data of a block to the block.
+func blockAAD(id primitive.ObjectID) []byte {
+	return []byte(id.Hex())
+}
+
+// loadKeys adds the stored data keys to the keyring, creating the first one
+// on a new database. It runs again when a log sealed with a key created by
+// another server is read, and before reencrypting, so every server
+// converges on the newest key.
+func (s *Store) loadKeys(ctx context.Context) error {
+	cursor, err := s.dataKeys.Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []dbDataKey
+	if err := cursor.All(ctx, &docs); err != nil {
+		return err
+	}
+
+	for _, doc := range docs {
+		dk := envelope.DataKey{ID: doc.ID, Wrapped: doc.Wrapped, CreatedAt: doc.CreatedAt}
+		if err := s.keyring.Add(dk); err != nil {
+			return err
+		}
+	}
+
+	if _, ok := s.keyring.Current(); ok {
+		return nil
+	}
+
+	_, err = s.RotateKey(ctx)
+	return err
+}
+
+// RotateKey makes a new data key current. Logs sealed with older keys stay
+// readable, since keys are never deleted, until Reencrypt moves them over.
+func (s *Store) RotateKey(ctx context.Context) (string, error) {
+	if s.keyring == nil {
+		return "", mlog.ErrNotSupported
+	}
+
+	dk, err := s.keyring.NewDataKey()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.dataKeys.InsertOne(ctx, dbDataKey{ID: dk.ID, Wrapped: dk.Wrapped, CreatedAt: dk.CreatedAt})
+	if err != nil {
+		return "", fmt.Errorf("storing data key: %w", err)
+	}
+
+	return dk.ID, nil
+}
+
+// seal encrypts the message and metadata values of doc with the current
+// data key. Metadata keeps the keyed hash of each value for filters, the
+// value itself going to Sealed. A message held in a block is sealed with
+// the block.
+func (s *Store) seal(doc *dbLog) error {
+	if s.keyring == nil {
+		return nil
+	}
+
+	current, ok := s.keyring.Current()
+	if !ok {
+		return envelope.ErrNoCurrentKey
+	}
+
+	if doc.Block.IsZero() {
+		sealed, err := s.keyring.SealWith(current.ID, []byte(doc.Message), messageAAD(*doc))
+		if err != nil {
+			return fmt.Errorf("sealing message: %w", err)
+		}
+		doc.Message = string(sealed)
+	}
+
+	metadata := make(map[string]string, len(doc.Metadata))
+	sealed := make(map[string][]byte, len(doc.Metadata))
+	for k, v := range doc.Metadata {
+		value, err := s.keyring.SealWith(current.ID, []byte(v), metadataAAD(*doc, k))
+		if err != nil {
+			return fmt.Errorf("sealing metadata: %w", err)
+		}
+		metadata[k] = s.keyring.Index(v)
+		sealed[k] = value
+	}
+
+	doc.Metadata = metadata
+	doc.Sealed = sealed
+	doc.KeyID = current.ID
+
+	return nil
+}
+
+// unseal decrypts the message and metadata values of doc in place. Logs
+// written before encryption was turned on are left as they are.
+func (s *Store) unseal(ctx context.Context, doc *dbLog) error {
+	if doc.KeyID == "" {
+		return nil
+	}
+
+	if doc.Block.IsZero() {
+		message, err := s.open(ctx, doc.KeyID, []byte(doc.Message), messageAAD(*doc))
+		if err != nil {
+			return fmt.Errorf("opening message: %w", err)
+		}
+		doc.Message = string(message)
+	}
+
+	metadata := make(map[string]string, len(doc.Sealed))
+	for k, sealed := range doc.Sealed {
+		value, err := s.open(ctx, doc.KeyID, sealed, metadataAAD(*doc, k))
+		if err != nil {
+			return fmt.Errorf("opening metadata: %w", err)
+		}
+		metadata[k] = string(value)
+	}
+
+	doc.Metadata = metadata
+	doc.Sealed = nil
+	doc.KeyID = ""
+
+	return nil
+}
+
+// open decrypts data sealed with the data key keyID, loading the stored
+// keys again when another server created keyID.
+func (s *Store) open(ctx context.Context, keyID string, sealed, aad []byte) ([]byte, error) {
+	if s.keyring == nil {
+		return nil, fmt.Errorf("%w: %s", envelope.ErrUnknownKey, keyID)
+	}
+
+	data, err := s.keyring.Open(keyID, sealed, aad)
+	if !errors.Is(err, envelope.ErrUnknownKey) {
+		return data, err
+	}
+
+	if err := s.loadKeys(ctx); err != nil {
+		return nil, fmt.Errorf("loading data keys: %w", err)
+	}
+	return s.keyring.Open(keyID, sealed, aad)
+}
+
+// metadataValue returns what a metadata filter compares against: sealed
+// logs hold the keyed hash of the value, logs not yet reencrypted the value
+// itself.
+func (s *Store) metadataValue(v string) any {
+	if s.keyring == nil {
+		return v
+	}
+	return bson.M{"$in": bson.A{s.keyring.Index(v), v}}
+}
+
+// Reencrypt seals up to limit logs and blocks not sealed with the current
+// key with it, including those written before encryption was turned on, and
+// returns how many it rewrote.
+func (s *Store) Reencrypt(ctx context.Context, limit int) (int64, error) {
+	if s.keyring == nil {
+		return 0, mlog.ErrNotSupported
+	}
+
+	if err := s.loadKeys(ctx); err != nil {
+		return 0, fmt.Errorf("loading data keys: %w", err)
+	}
+
+	current, ok := s.keyring.Current()
+	if !ok {
+		return 0, envelope.ErrNoCurrentKey
+	}
+
+	n, err := s.reencryptLogs(ctx, current.ID, limit)
+	if err != nil || n == int64(limit) {
+		return n, err
+	}
+
+	blocks, err := s.reencryptBlocks(ctx, current.ID, limit-int(n))
+	return n + blocks, err
+}
+
+func (s *Store) reencryptLogs(ctx context.Context, keyID string, limit int) (int64, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{"keyid": bson.M{"$ne": keyID}}, options.Find().SetLimit(int64(limit)))
+	if err != nil {
+		return 0, fmt.Errorf("finding logs to reencrypt: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var n int64
+	for cursor.Next(ctx) {
+		var doc dbLog
+		if err := cursor.Decode(&doc); err != nil {
+			return n, fmt.Errorf("decoding log: %w", err)
+		}
+
+		// The log is only replaced as it was read: one redacted, compacted
+		// or reencrypted meanwhile is left to the next run.
+		filter := bson.M{"id": doc.ID, "keyid": doc.KeyID}
+		if doc.KeyID == "" {
+			filter["keyid"] = bson.M{"$exists": false}
+		}
+		if doc.Block.IsZero() {
+			filter["block"] = bson.M{"$exists": false}
+		}
+
+		if err := s.unseal(ctx, &doc); err != nil {
+			return n, fmt.Errorf("log %s: %w", doc.ID, err)
+		}
+		if err := s.seal(&doc); err != nil {
+			return n, fmt.Errorf("log %s: %w", doc.ID, err)
+		}
+
+		set := bson.M{
+			"metadata": doc.Metadata,
+			"sealed":   doc.Sealed,
+			"keyid":    doc.KeyID,
+		}
+		if doc.Block.IsZero() {
+			set["message"] = doc.Message
+		}
+
+		res, err := s.collection.UpdateOne(ctx, filter, bson.M{"$set": set})
+		if err != nil {
+			return n, fmt.Errorf("updating log %s: %w", doc.ID, err)
+		}
+		n += res.ModifiedCount
+	}
+
+	return n, cursor.Err()
+}
+
+func (s *Store) reencryptBlocks(ctx context.Context, keyID string, limit int) (int64, error) {
+	cursor, err := s.blocks.Find(ctx, bson.M{"keyid": bson.M{"$ne": keyID}}, options.Find().SetLimit(int64(limit)))
+	if err != nil {
+		return 0, fmt.Errorf("finding blocks to reencrypt: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var n int64
+	for cursor.Next(ctx) {
+		var block dbBlock
+		if err := cursor.Decode(&block); err != nil {
+			return n, fmt.Errorf("decoding block: %w", err)
+		}
+
+		data := block.Data
+		if block.KeyID != "" {
+			if data, err = s.open(ctx, block.KeyID, data, blockAAD(block.ID)); err != nil {
+				return n, fmt.Errorf("opening block %s: %w", block.ID.Hex(), err)
+			}
+		}
+
+		sealed, err := s.keyring.SealWith(keyID, data, blockAAD(block.ID))
+		if err != nil {
+			return n, fmt.Errorf("sealing block %s: %w", block.ID.Hex(), err)
+		}
+
+		// A block wiped meanwhile is left to the next run.
+		res, err := s.blocks.UpdateOne(ctx,
+			bson.M{"_id": block.ID, "data": block.Data},
+			bson.M{"$set": bson.M{"data": sealed, "keyid": keyID}},
+		)
+		if err != nil {
+			return n, fmt.Errorf("updating block %s: %w", block.ID.Hex(), err)
+		}
+		s.blockCache.invalidate(block.ID)
+		n += res.ModifiedCount
+	}
+
+	return n, cursor.Err()
+}
+
+// sealBlock encrypts the compressed data of a block with the current data
+// key, and returns the ID of the key used, "" without a keyring.
+func (s *Store) sealBlock(id primitive.ObjectID, data []byte) (string, []byte, error) {
+	if s.keyring == nil {
+		return "", data, nil
+	}
+
+	keyID, sealed, err := s.keyring.Seal(data, blockAAD(id))
+	if err != nil {
+		return "", nil, fmt.Errorf("sealing block %s: %w", id.Hex(), err)
+	}
+	return keyID, sealed, nil
+}
+
+var _ mlog.KeyRotator = (*Store)(nil)
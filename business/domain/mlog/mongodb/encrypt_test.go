@@ -0,0 +1,204 @@
+package mongodb
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"maps"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/foundation/envelope"
+	"github.com/oklog/ulid/v2"
+)
+
+// These tests cover sealing and unsealing documents, which need no
+// database: a Store holding only a keyring.
+
+func newSealingStore(t *testing.T) *Store {
+	t.Helper()
+
+	master := make([]byte, 32)
+	if _, err := rand.Read(master); err != nil {
+		t.Fatalf("generating master key: %v", err)
+	}
+
+	k, err := envelope.NewKeyring(master)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	if _, err := k.NewDataKey(); err != nil {
+		t.Fatalf("NewDataKey: %v", err)
+	}
+
+	return &Store{keyring: k}
+}
+
+func testDoc() dbLog {
+	return toDBLog(mlog.Log{
+		ID:        ulid.Make(),
+		Message:   "card charged",
+		Timestamp: time.Now().UTC(),
+		Level:     mlog.Info,
+		Metadata:  map[string]string{"service": "billing", "user": "alice"},
+	})
+}
+
+func TestSealUnseal(t *testing.T) {
+	s := newSealingStore(t)
+	current, _ := s.keyring.Current()
+
+	doc := testDoc()
+	want := doc
+	want.Metadata = maps.Clone(doc.Metadata)
+
+	if err := s.seal(&doc); err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+
+	if doc.KeyID != current.ID {
+		t.Errorf("key id = %q, want %q", doc.KeyID, current.ID)
+	}
+	if strings.Contains(doc.Message, want.Message) {
+		t.Errorf("sealed message is plaintext")
+	}
+	for k, v := range want.Metadata {
+		if got := doc.Metadata[k]; got != s.keyring.Index(v) {
+			t.Errorf("metadata %s = %q, want the keyed hash of %q", k, got, v)
+		}
+		if strings.Contains(string(doc.Sealed[k]), v) {
+			t.Errorf("sealed metadata %s is plaintext", k)
+		}
+	}
+
+	if err := s.unseal(context.Background(), &doc); err != nil {
+		t.Fatalf("unseal: %v", err)
+	}
+	if doc.Message != want.Message {
+		t.Errorf("message = %q, want %q", doc.Message, want.Message)
+	}
+	if !maps.Equal(doc.Metadata, want.Metadata) {
+		t.Errorf("metadata = %v, want %v", doc.Metadata, want.Metadata)
+	}
+	if doc.KeyID != "" || doc.Sealed != nil {
+		t.Errorf("unsealed log keeps key %q and %d sealed values", doc.KeyID, len(doc.Sealed))
+	}
+}
+
+// A message held in a block is sealed with the block, so only the metadata
+// of its log is.
+func TestSealBlockLog(t *testing.T) {
+	s := newSealingStore(t)
+
+	doc := testDoc()
+	doc.Message = ""
+	doc.Block = [12]byte{1}
+
+	if err := s.seal(&doc); err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if doc.Message != "" {
+		t.Errorf("message = %q, want it left empty", doc.Message)
+	}
+	if err := s.unseal(context.Background(), &doc); err != nil {
+		t.Fatalf("unseal: %v", err)
+	}
+	if doc.Metadata["service"] != "billing" {
+		t.Errorf("metadata = %v, want service billing", doc.Metadata)
+	}
+}
+
+// Sealed data is bound to its log, and metadata values to their key, so
+// moving either fails to open.
+func TestUnsealTampered(t *testing.T) {
+	s := newSealingStore(t)
+
+	tests := []struct {
+		name   string
+		tamper func(doc *dbLog, other dbLog)
+	}{
+		{
+			name:   "message of another log",
+			tamper: func(doc *dbLog, other dbLog) { doc.Message = other.Message },
+		},
+		{
+			name:   "metadata of another log",
+			tamper: func(doc *dbLog, other dbLog) { doc.Sealed["service"] = other.Sealed["service"] },
+		},
+		{
+			name: "metadata of another key",
+			tamper: func(doc *dbLog, _ dbLog) {
+				doc.Sealed["service"], doc.Sealed["user"] = doc.Sealed["user"], doc.Sealed["service"]
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, other := testDoc(), testDoc()
+			for _, d := range []*dbLog{&doc, &other} {
+				if err := s.seal(d); err != nil {
+					t.Fatalf("seal: %v", err)
+				}
+			}
+
+			tt.tamper(&doc, other)
+			if err := s.unseal(context.Background(), &doc); !errors.Is(err, envelope.ErrDecrypt) {
+				t.Fatalf("unseal = %v, want %v", err, envelope.ErrDecrypt)
+			}
+		})
+	}
+}
+
+// Logs sealed with a rotated key stay readable, and sealing them again
+// moves them to the current key.
+func TestSealAfterRotation(t *testing.T) {
+	s := newSealingStore(t)
+	first, _ := s.keyring.Current()
+
+	doc := testDoc()
+	if err := s.seal(&doc); err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+
+	second, err := s.keyring.NewDataKey()
+	if err != nil {
+		t.Fatalf("NewDataKey: %v", err)
+	}
+	if doc.KeyID != first.ID {
+		t.Fatalf("key id = %q, want %q", doc.KeyID, first.ID)
+	}
+
+	ctx := context.Background()
+	if err := s.unseal(ctx, &doc); err != nil {
+		t.Fatalf("unseal: %v", err)
+	}
+	if err := s.seal(&doc); err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if doc.KeyID != second.ID {
+		t.Errorf("key id after rotation = %q, want %q", doc.KeyID, second.ID)
+	}
+
+	if err := s.unseal(ctx, &doc); err != nil {
+		t.Fatalf("unseal: %v", err)
+	}
+	if doc.Message != "card charged" {
+		t.Errorf("message = %q, want %q", doc.Message, "card charged")
+	}
+}
+
+// Logs written before encryption was turned on are read as they are.
+func TestUnsealPlaintext(t *testing.T) {
+	s := newSealingStore(t)
+
+	doc := testDoc()
+	if err := s.unseal(context.Background(), &doc); err != nil {
+		t.Fatalf("unseal: %v", err)
+	}
+	if doc.Message != "card charged" || doc.Metadata["service"] != "billing" {
+		t.Errorf("log = %+v, want it unchanged", doc)
+	}
+}
@@ -0,0 +1,262 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/oklog/ulid/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type dbDeletionAudit struct {
+	ID        ulid.ULID         `bson:"id"`
+	Actor     string            `bson:"actor"`
+	Reason    string            `bson:"reason,omitempty"`
+	Mode      mlog.EraseMode    `bson:"mode"`
+	StartTime time.Time         `bson:"starttime,omitempty"`
+	EndTime   time.Time         `bson:"endtime,omitempty"`
+	Level     mlog.Level        `bson:"level,omitempty"`
+	Metadata  map[string]string `bson:"metadata,omitempty"`
+	Message   string            `bson:"message,omitempty"`
+	Matched   int64             `bson:"matched"`
+	Deleted   int64             `bson:"deleted"`
+	Redacted  int64             `bson:"redacted"`
+	Held      int64             `bson:"held"`
+	At        time.Time         `bson:"at"`
+}
+
+type blockSpan struct {
+	offset int
+	length int
+}
+
+// Erase deletes or redacts the logs selected by criteria. Compressed messages
+// cannot be matched by the database, so they are decoded and checked here.
+// Messages held in compacted blocks are also wiped from the block itself.
+// Logs covered by a legal hold are counted but left untouched.
+func (s *Store) Erase(ctx context.Context, criteria mlog.DeleteCriteria, holds []mlog.LegalHold) (mlog.DeleteResult, error) {
+	filter := s.buildFilter(mlog.SearchCriteria{
+		Tenant:    criteria.Tenant,
+		TimeRange: criteria.TimeRange,
+		Level:     criteria.Level,
+		Metadata:  criteria.Metadata,
+	})
+
+	if criteria.Message != "" {
+		filter["$or"] = bson.A{
+			bson.M{"compressed": true},
+			bson.M{"keyid": bson.M{"$exists": true}},
+			bson.M{"message": bson.M{"$regex": regexp.QuoteMeta(criteria.Message)}},
+		}
+	}
+
+	cursor, err := s.collection.Find(ctx, filter)
+	if err != nil {
+		return mlog.DeleteResult{}, fmt.Errorf("finding logs to erase: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	result := mlog.DeleteResult{
+		DryRun: criteria.DryRun,
+	}
+
+	var (
+		ids    bson.A
+		blocks = make(map[primitive.ObjectID][]blockSpan)
+	)
+
+	for cursor.Next(ctx) {
+		var doc dbLog
+		if err := cursor.Decode(&doc); err != nil {
+			continue
+		}
+
+		log := s.decode(ctx, doc)
+		if !criteria.Matches(log) {
+			continue
+		}
+
+		result.Matched++
+
+		if coveredByHold(log, holds) {
+			result.Held++
+			continue
+		}
+
+		if criteria.DryRun {
+			continue
+		}
+
+		if !doc.Block.IsZero() {
+			blocks[doc.Block] = append(blocks[doc.Block], blockSpan{offset: doc.BlockOffset, length: doc.BlockLength})
+		}
+
+		if criteria.Mode == mlog.EraseDelete {
+			ids = append(ids, doc.ID)
+			continue
+		}
+
+		if err := s.rewrite(ctx, criteria.Redact(log)); err != nil {
+			return result, fmt.Errorf("redacting log %s: %w", doc.ID, err)
+		}
+		result.Redacted++
+		result.IDs = append(result.IDs, doc.ID)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return result, fmt.Errorf("iterating logs to erase: %w", err)
+	}
+
+	for id, spans := range blocks {
+		if err := s.wipeBlock(ctx, id, spans); err != nil {
+			return result, err
+		}
+	}
+
+	for start := 0; start < len(ids); start += retentionBatchSize {
+		end := min(start+retentionBatchSize, len(ids))
+
+		deleted, err := s.collection.DeleteMany(ctx, bson.M{"id": bson.M{"$in": ids[start:end]}})
+		if err != nil {
+			return result, fmt.Errorf("deleting logs: %w", err)
+		}
+		result.Deleted += deleted.DeletedCount
+		for _, id := range ids[start:end] {
+			result.IDs = append(result.IDs, id.(ulid.ULID))
+		}
+	}
+
+	if len(blocks) > 0 {
+		unused := make(map[primitive.ObjectID]bool, len(blocks))
+		for id := range blocks {
+			unused[id] = true
+		}
+		if _, err := s.dropUnusedBlocks(ctx, unused); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// rewrite stores a redacted log inline, compressing it again if worthwhile.
+// A log that lived in a block leaves it, since the block cannot change size.
+func (s *Store) rewrite(ctx context.Context, log mlog.Log) error {
+	doc := toDBLog(log)
+	s.compress(ctx, &doc)
+	if err := s.seal(&doc); err != nil {
+		return err
+	}
+
+	set := bson.M{
+		"message":    doc.Message,
+		"metadata":   doc.Metadata,
+		"rawsize":    doc.RawSize,
+		"compressed": doc.Compressed,
+	}
+	unset := bson.M{
+		"block":       "",
+		"blockoffset": "",
+		"blocklength": "",
+	}
+
+	if doc.KeyID != "" {
+		set["keyid"] = doc.KeyID
+		set["sealed"] = doc.Sealed
+	} else {
+		unset["keyid"] = ""
+		unset["sealed"] = ""
+	}
+
+	if doc.Compressed {
+		set["compressedat"] = doc.CompressedAt
+		set["algorithm"] = doc.Algorithm
+		if doc.Dictionary != 0 {
+			set["dictionary"] = doc.Dictionary
+		} else {
+			unset["dictionary"] = ""
+		}
+	} else {
+		unset["compressedat"] = ""
+		unset["algorithm"] = ""
+		unset["dictionary"] = ""
+	}
+
+	_, err := s.collection.UpdateOne(ctx, bson.M{"id": doc.ID}, bson.M{"$set": set, "$unset": unset})
+	return err
+}
+
+// wipeBlock overwrites the given spans of a block with zeros and stores it
+// compressed again, so erased messages do not survive inside blocks.
+func (s *Store) wipeBlock(ctx context.Context, id primitive.ObjectID, spans []blockSpan) error {
+	var block dbBlock
+	if err := s.blocks.FindOne(ctx, bson.M{"_id": id}).Decode(&block); err != nil {
+		return fmt.Errorf("finding block %s: %w", id.Hex(), err)
+	}
+
+	data, err := s.blockData(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	wiped := make([]byte, len(data))
+	copy(wiped, data)
+
+	for _, span := range spans {
+		end := min(span.offset+span.length, len(wiped))
+		for i := span.offset; i < end; i++ {
+			wiped[i] = 0
+		}
+	}
+
+	compressed, err := s.blockCompressor.Compress(wiped)
+	if err != nil {
+		return fmt.Errorf("compressing block %s: %w", id.Hex(), err)
+	}
+
+	keyID, compressed, err := s.sealBlock(id, compressed)
+	if err != nil {
+		return err
+	}
+
+	set := bson.M{"data": compressed, "algorithm": s.blockCompressor.Algorithm()}
+	update := bson.M{"$set": set}
+	if keyID != "" {
+		set["keyid"] = keyID
+	} else {
+		update["$unset"] = bson.M{"keyid": ""}
+	}
+
+	_, err = s.blocks.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("updating block %s: %w", id.Hex(), err)
+	}
+
+	s.blockCache.invalidate(id)
+
+	return nil
+}
+
+func (s *Store) SaveDeletionAudit(ctx context.Context, audit mlog.DeletionAudit) error {
+	_, err := s.deletions.InsertOne(ctx, dbDeletionAudit{
+		ID:        audit.ID,
+		Actor:     audit.Actor,
+		Reason:    audit.Reason,
+		Mode:      audit.Criteria.Mode,
+		StartTime: audit.Criteria.TimeRange.StartTime,
+		EndTime:   audit.Criteria.TimeRange.EndTime,
+		Level:     audit.Criteria.Level,
+		Metadata:  audit.Criteria.Metadata,
+		Message:   audit.Criteria.Message,
+		Matched:   audit.Matched,
+		Deleted:   audit.Deleted,
+		Redacted:  audit.Redacted,
+		Held:      audit.Held,
+		At:        audit.At,
+	})
+	return err
+}
@@ -0,0 +1,158 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/oklog/ulid/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type dbLegalHold struct {
+	ID         ulid.ULID         `bson:"id"`
+	Name       string            `bson:"name"`
+	Reason     string            `bson:"reason,omitempty"`
+	StartTime  time.Time         `bson:"starttime,omitempty"`
+	EndTime    time.Time         `bson:"endtime,omitempty"`
+	Level      mlog.Level        `bson:"level,omitempty"`
+	Metadata   map[string]string `bson:"metadata,omitempty"`
+	CreatedBy  string            `bson:"createdby,omitempty"`
+	CreatedAt  time.Time         `bson:"createdat"`
+	ReleasedBy string            `bson:"releasedby,omitempty"`
+	ReleasedAt time.Time         `bson:"releasedat,omitempty"`
+}
+
+func toDBLegalHold(h mlog.LegalHold) dbLegalHold {
+	return dbLegalHold{
+		ID:         h.ID,
+		Name:       h.Name,
+		Reason:     h.Reason,
+		StartTime:  h.TimeRange.StartTime,
+		EndTime:    h.TimeRange.EndTime,
+		Level:      h.Level,
+		Metadata:   h.Metadata,
+		CreatedBy:  h.CreatedBy,
+		CreatedAt:  h.CreatedAt,
+		ReleasedBy: h.ReleasedBy,
+		ReleasedAt: h.ReleasedAt,
+	}
+}
+
+func toCoreLegalHold(doc dbLegalHold) mlog.LegalHold {
+	return mlog.LegalHold{
+		ID:     doc.ID,
+		Name:   doc.Name,
+		Reason: doc.Reason,
+		TimeRange: mlog.TimeRange{
+			StartTime: doc.StartTime,
+			EndTime:   doc.EndTime,
+		},
+		Level:      doc.Level,
+		Metadata:   doc.Metadata,
+		CreatedBy:  doc.CreatedBy,
+		CreatedAt:  doc.CreatedAt,
+		ReleasedBy: doc.ReleasedBy,
+		ReleasedAt: doc.ReleasedAt,
+	}
+}
+
+// CreateLegalHold stores hold once the TTL indexes of retention policies are
+// gone, since MongoDB would keep expiring the held logs until the enforcer
+// next runs.
+func (s *Store) CreateLegalHold(ctx context.Context, hold mlog.LegalHold) error {
+	if err := s.dropTTLIndexes(ctx, nil); err != nil {
+		return err
+	}
+
+	_, err := s.holds.InsertOne(ctx, toDBLegalHold(hold))
+	return err
+}
+
+func (s *Store) LegalHolds(ctx context.Context, includeReleased bool) ([]mlog.LegalHold, error) {
+	filter := bson.M{}
+	if !includeReleased {
+		filter["releasedat"] = bson.M{"$exists": false}
+	}
+
+	cursor, err := s.holds.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdat", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []dbLegalHold
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	holds := make([]mlog.LegalHold, len(docs))
+	for i, doc := range docs {
+		holds[i] = toCoreLegalHold(doc)
+	}
+
+	return holds, nil
+}
+
+func (s *Store) ReleaseLegalHold(ctx context.Context, id ulid.ULID, by string, at time.Time) (mlog.LegalHold, error) {
+	var doc dbLegalHold
+	err := s.holds.FindOneAndUpdate(ctx,
+		bson.M{"id": id, "releasedat": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"releasedby": by, "releasedat": at}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&doc)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return mlog.LegalHold{}, fmt.Errorf("%s: %w", id, mlog.ErrLegalHoldNotFound)
+		}
+		return mlog.LegalHold{}, err
+	}
+
+	return toCoreLegalHold(doc), nil
+}
+
+// holdFilter matches the logs covered by a hold.
+func (s *Store) holdFilter(h mlog.LegalHold) bson.D {
+	filter := bson.D{}
+
+	timeFilter := bson.D{}
+	if !h.TimeRange.StartTime.IsZero() {
+		timeFilter = append(timeFilter, bson.E{Key: "$gte", Value: h.TimeRange.StartTime})
+	}
+	if !h.TimeRange.EndTime.IsZero() {
+		timeFilter = append(timeFilter, bson.E{Key: "$lte", Value: h.TimeRange.EndTime})
+	}
+	if len(timeFilter) > 0 {
+		filter = append(filter, bson.E{Key: "timestamp", Value: timeFilter})
+	}
+
+	if h.Level != "" {
+		filter = append(filter, bson.E{Key: "level", Value: h.Level})
+	}
+
+	keys := make([]string, 0, len(h.Metadata))
+	for k := range h.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		filter = append(filter, bson.E{Key: "metadata." + k, Value: s.metadataValue(h.Metadata[k])})
+	}
+
+	return filter
+}
+
+func coveredByHold(log mlog.Log, holds []mlog.LegalHold) bool {
+	for _, h := range holds {
+		if h.Covers(log) {
+			return true
+		}
+	}
+	return false
+}
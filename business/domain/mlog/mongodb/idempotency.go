@@ -0,0 +1,61 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// dbIdempotencyKey binds a key to the log it produced. The key is the _id,
+// so its unique index settles concurrent claims.
+type dbIdempotencyKey struct {
+	Key       string    `bson:"_id"`
+	LogID     ulid.ULID `bson:"logid"`
+	CreatedAt time.Time `bson:"createdat"`
+}
+
+// ClaimIdempotencyKey upserts the binding of key only when it is missing or
+// older than since. A live binding makes the upsert collide on _id, and the
+// bound ID is read back.
+func (s *Store) ClaimIdempotencyKey(ctx context.Context, key string, id ulid.ULID, at, since time.Time) (ulid.ULID, bool, error) {
+	filter := bson.M{"_id": key, "createdat": bson.M{"$lt": since}}
+	update := bson.M{"$set": bson.M{"logid": id, "createdat": at}}
+
+	_, err := s.idempotency.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err == nil {
+		return id, true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return ulid.ULID{}, false, err
+	}
+
+	var doc dbIdempotencyKey
+	err = s.idempotency.FindOne(ctx, bson.M{"_id": key}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ulid.ULID{}, false, fmt.Errorf("idempotency key %q released while claimed", key)
+	}
+	if err != nil {
+		return ulid.ULID{}, false, err
+	}
+
+	return doc.LogID, false, nil
+}
+
+func (s *Store) ReleaseIdempotencyKey(ctx context.Context, key string, id ulid.ULID) error {
+	_, err := s.idempotency.DeleteOne(ctx, bson.M{"_id": key, "logid": id})
+	return err
+}
+
+func (s *Store) PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.idempotency.DeleteMany(ctx, bson.M{"createdat": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
@@ -0,0 +1,70 @@
+package mongodb
+
+import (
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/foundation/compress"
+	"github.com/oklog/ulid/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type dbLog struct {
+	ID           ulid.ULID          `bson:"id"`
+	Tenant       string             `bson:"tenant,omitempty"`
+	Message      string             `bson:"message"`
+	Timestamp    time.Time          `bson:"timestamp"`
+	Level        mlog.Level         `bson:"level"`
+	Metadata     map[string]string  `bson:"metadata"`
+	RawSize      int                `bson:"rawsize,omitempty"`
+	Compressed   bool               `bson:"compressed"`
+	CompressedAt time.Time          `bson:"compressedat"`
+	Algorithm    compress.Algorithm `bson:"algorithm,omitempty"`
+	Dictionary   uint32             `bson:"dictionary,omitempty"`
+	Block        primitive.ObjectID `bson:"block,omitempty"`
+	BlockOffset  int                `bson:"blockoffset,omitempty"`
+	BlockLength  int                `bson:"blocklength,omitempty"`
+	// KeyID names the data key sealing the message and metadata values,
+	// "" for plaintext. Metadata then holds the keyed hash of each value,
+	// and Sealed the value itself.
+	KeyID  string            `bson:"keyid,omitempty"`
+	Sealed map[string][]byte `bson:"sealed,omitempty"`
+}
+
+func toDBLog(log mlog.Log) dbLog {
+	return dbLog{
+		ID:        log.ID,
+		Tenant:    log.Tenant,
+		Message:   log.Message,
+		Timestamp: log.Timestamp,
+		Level:     log.Level,
+		Metadata:  log.Metadata,
+		RawSize:   len(log.Message),
+	}
+}
+
+func toCoreLog(doc dbLog) mlog.Log {
+	log := mlog.Log{
+		ID:           doc.ID,
+		Tenant:       doc.Tenant,
+		Message:      doc.Message,
+		Timestamp:    doc.Timestamp,
+		Level:        doc.Level,
+		Metadata:     doc.Metadata,
+		Compressed:   doc.Compressed,
+		CompressedAt: doc.CompressedAt,
+	}
+	if doc.Compressed {
+		log.Compression = string(doc.algorithm())
+	}
+	return log
+}
+
+// algorithm reports how a compressed message was encoded. Records written
+// before the algorithm was stored per log were always gzip.
+func (doc dbLog) algorithm() compress.Algorithm {
+	if doc.Algorithm == "" {
+		return compress.Gzip
+	}
+	return doc.Algorithm
+}
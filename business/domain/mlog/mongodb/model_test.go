@@ -0,0 +1,93 @@
+package mongodb
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/foundation/compress"
+	"github.com/oklog/ulid/v2"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Logs compressed before the algorithm was stored per log have no
+// algorithm field, and were always gzip.
+func TestLegacyCompressedLog(t *testing.T) {
+	message := strings.Repeat("user alice logged in from the api service; ", 10)
+
+	gzip, err := compress.NewGzipCompressor(compress.DefaultLevel)
+	if err != nil {
+		t.Fatalf("NewGzipCompressor: %v", err)
+	}
+	compressed, err := gzip.Compress([]byte(message))
+	if err != nil {
+		t.Fatalf("Compress: %v", err)
+	}
+
+	raw, err := bson.Marshal(bson.M{
+		"id":           ulid.Make(),
+		"message":      string(compressed),
+		"timestamp":    time.Now().UTC(),
+		"level":        mlog.Info,
+		"compressed":   true,
+		"compressedat": time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var doc dbLog
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if doc.Algorithm != "" {
+		t.Fatalf("algorithm = %q, want none stored", doc.Algorithm)
+	}
+
+	if log := toCoreLog(doc); log.Compression != string(compress.Gzip) {
+		t.Errorf("compression = %q, want %q", log.Compression, compress.Gzip)
+	}
+
+	var s Store
+	got, err := s.decompress(context.Background(), doc)
+	if err != nil {
+		t.Fatalf("decompress: %v", err)
+	}
+	if string(got) != message {
+		t.Errorf("message = %q, want %q", got, message)
+	}
+}
+
+// Logs compressed with another algorithm are read back with it.
+func TestCompressedLogAlgorithm(t *testing.T) {
+	message := strings.Repeat("user alice logged in from the api service; ", 10)
+
+	for _, alg := range []compress.Algorithm{compress.Gzip, compress.Zstd, compress.Snappy, compress.LZ4} {
+		t.Run(string(alg), func(t *testing.T) {
+			c, err := compress.New(alg, compress.DefaultLevel)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			compressed, err := c.Compress([]byte(message))
+			if err != nil {
+				t.Fatalf("Compress: %v", err)
+			}
+
+			doc := dbLog{Message: string(compressed), Compressed: true, Algorithm: alg}
+			if log := toCoreLog(doc); log.Compression != string(alg) {
+				t.Errorf("compression = %q, want %q", log.Compression, alg)
+			}
+
+			var s Store
+			got, err := s.decompress(context.Background(), doc)
+			if err != nil {
+				t.Fatalf("decompress: %v", err)
+			}
+			if string(got) != message {
+				t.Errorf("message = %q, want %q", got, message)
+			}
+		})
+	}
+}
@@ -2,40 +2,123 @@ package mongodb
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io"
 	"time"
 
 	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/foundation/blob"
 	"github.com/felipecooper/log-horizon/foundation/compress"
+	"github.com/felipecooper/log-horizon/foundation/envelope"
 	"github.com/felipecooper/log-horizon/foundation/logger"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+const (
+	compressionThreshold = 100
+
+	// duplicateKeyCode is the error code of a write refused by a unique
+	// index.
+	duplicateKeyCode = 11000
+)
+
 type Store struct {
-	log         logger.Logger
-	db          *mongo.Database
-	collection  *mongo.Collection
-	compressor  compress.Compressor
-	compression bool
-	exportPath  string
+	log               logger.Logger
+	db                *mongo.Database
+	collection        *mongo.Collection
+	dictionaries      *mongo.Collection
+	blocks            *mongo.Collection
+	retention         *mongo.Collection
+	deletions         *mongo.Collection
+	holds             *mongo.Collection
+	idempotency       *mongo.Collection
+	dataKeys          *mongo.Collection
+	retentionTTL      bool
+	compressor        compress.Compressor
+	blockCompressor   compress.Compressor
+	blockSize         int
+	blockCache        *blockCache
+	compressionLevel  int
+	dictionaryMode    bool
+	dictionarySize    int
+	dictionarySamples int
+	dicts             *dictionarySet
+	exports           blob.Bucket
+	keyring           *envelope.Keyring
 }
 
 type Config struct {
-	DatabaseName     string
-	CollectionName   string
-	URI              string
+	DatabaseName   string
+	CollectionName string
+	URI            string
+	// Client, when set, is used instead of connecting to URI, so stores of
+	// several tenants share one connection pool.
+	Client           *mongo.Client
+	Compression      compress.Algorithm
 	CompressionLevel int
-	ExportPath       string
+	// DictionarySize and DictionarySamples tune TrainDictionary when
+	// Compression is compress.ZstdDict.
+	DictionarySize    int
+	DictionarySamples int
+	// BlockCompression, BlockCompressionLevel and BlockSize control how Compact
+	// packs old logs into blocks.
+	BlockCompression      compress.Algorithm
+	BlockCompressionLevel int
+	BlockSize             int
+	// RetentionTTL lets ApplyRetention delegate policies to TTL indexes.
+	RetentionTTL bool
+	ExportPath   string
+	ExportBucket blob.Bucket
+	// Keyring, when set, encrypts messages, metadata values and blocks at
+	// rest. Dictionary compression is refused with it, as dictionaries are
+	// trained on plaintext, and retention is never delegated to TTL indexes,
+	// which would match metadata by its plaintext.
+	Keyring *envelope.Keyring
 }
 
 func NewStore(ctx context.Context, log logger.Logger, cfg Config) (*Store, error) {
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.URI))
+	if cfg.Keyring != nil && cfg.Compression == compress.ZstdDict {
+		return nil, fmt.Errorf("encryption at rest does not support %s compression", compress.ZstdDict)
+	}
+
+	var compressor compress.Compressor
+	switch cfg.Compression {
+	case compress.None:
+	case "":
+		compressor, _ = compress.NewGzipCompressor(compress.DefaultLevel)
+	case compress.ZstdDict:
+		c, err := compress.NewZstdCompressor(cfg.CompressionLevel)
+		if err != nil {
+			return nil, fmt.Errorf("creating compressor: %w", err)
+		}
+		compressor = c
+	default:
+		c, err := compress.New(cfg.Compression, cfg.CompressionLevel)
+		if err != nil {
+			return nil, fmt.Errorf("creating compressor: %w", err)
+		}
+		compressor = c
+	}
+
+	blockAlgorithm := cfg.BlockCompression
+	if blockAlgorithm == "" {
+		blockAlgorithm = compress.Zstd
+	}
+
+	blockCompressor, err := compress.New(blockAlgorithm, cfg.BlockCompressionLevel)
 	if err != nil {
-		return nil, fmt.Errorf("connecting to MongoDB: %w", err)
+		return nil, fmt.Errorf("creating block compressor: %w", err)
+	}
+
+	client := cfg.Client
+	if client == nil {
+		client, err = mongo.Connect(ctx, options.Client().ApplyURI(cfg.URI))
+		if err != nil {
+			return nil, fmt.Errorf("connecting to MongoDB: %w", err)
+		}
 	}
 
 	err = client.Ping(ctx, nil)
@@ -58,29 +141,132 @@ func NewStore(ctx context.Context, log logger.Logger, cfg Config) (*Store, error
 		log.Error(ctx, "failed to create index", "error", err)
 	}
 
-	return &Store{
-		log:         log,
-		db:          client.Database(cfg.DatabaseName),
-		collection:  collection,
-		compressor:  compress.NewGzipCompressor(),
-		compression: true,
-		exportPath:  cfg.ExportPath,
-	}, nil
+	// A log written again, such as by a spool replay cut short, is refused
+	// instead of stored twice.
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Error(ctx, "failed to create id index", "error", err)
+	}
+
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "tenant", Value: 1},
+			{Key: "timestamp", Value: 1},
+		},
+		Options: options.Index().SetBackground(true).SetPartialFilterExpression(bson.M{"tenant": bson.M{"$exists": true}}),
+	})
+	if err != nil {
+		log.Error(ctx, "failed to create tenant index", "error", err)
+	}
+
+	dictionaries := client.Database(cfg.DatabaseName).Collection(cfg.CollectionName + "_dictionaries")
+
+	_, err = dictionaries.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "version", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Error(ctx, "failed to create dictionary index", "error", err)
+	}
+
+	retention := client.Database(cfg.DatabaseName).Collection(cfg.CollectionName + "_retention")
+
+	_, err = retention.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Error(ctx, "failed to create retention index", "error", err)
+	}
+
+	idempotency := client.Database(cfg.DatabaseName).Collection(cfg.CollectionName + "_idempotency")
+
+	_, err = idempotency.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "createdat", Value: 1}},
+	})
+	if err != nil {
+		log.Error(ctx, "failed to create idempotency index", "error", err)
+	}
+
+	exports := cfg.ExportBucket
+	if exports == nil {
+		exports = blob.NewLocal(cfg.ExportPath)
+	}
+
+	dictionarySize := cfg.DictionarySize
+	if dictionarySize <= 0 {
+		dictionarySize = defaultDictionarySize
+	}
+
+	dictionarySamples := cfg.DictionarySamples
+	if dictionarySamples <= 0 {
+		dictionarySamples = defaultDictionarySamples
+	}
+
+	blockSize := cfg.BlockSize
+	if blockSize <= 0 {
+		blockSize = defaultBlockSize
+	}
+
+	s := Store{
+		log:               log,
+		db:                client.Database(cfg.DatabaseName),
+		collection:        collection,
+		dictionaries:      dictionaries,
+		blocks:            client.Database(cfg.DatabaseName).Collection(cfg.CollectionName + "_blocks"),
+		retention:         retention,
+		deletions:         client.Database(cfg.DatabaseName).Collection(cfg.CollectionName + "_deletions"),
+		holds:             client.Database(cfg.DatabaseName).Collection(cfg.CollectionName + "_holds"),
+		idempotency:       idempotency,
+		dataKeys:          client.Database(cfg.DatabaseName).Collection(cfg.CollectionName + "_datakeys"),
+		retentionTTL:      cfg.RetentionTTL && cfg.Keyring == nil,
+		compressor:        compressor,
+		blockCompressor:   blockCompressor,
+		blockSize:         blockSize,
+		blockCache:        newBlockCache(),
+		compressionLevel:  cfg.CompressionLevel,
+		dictionaryMode:    cfg.Compression == compress.ZstdDict,
+		dictionarySize:    dictionarySize,
+		dictionarySamples: dictionarySamples,
+		dicts:             &dictionarySet{},
+		exports:           exports,
+		keyring:           cfg.Keyring,
+	}
+
+	if err := s.loadDictionaries(ctx); err != nil {
+		return nil, fmt.Errorf("loading dictionaries: %w", err)
+	}
+
+	if s.keyring != nil {
+		if err := s.loadKeys(ctx); err != nil {
+			return nil, fmt.Errorf("loading data keys: %w", err)
+		}
+	}
+
+	return &s, nil
 }
 
 func (s *Store) Write(ctx context.Context, log *mlog.Log) error {
-	if s.compression && len(log.Message) > 100 {
-		compressed, err := s.compressor.Compress([]byte(log.Message))
-		if err != nil {
-			s.log.Error(ctx, "failed to compress log message", "error", err)
-		} else {
-			log.Message = string(compressed)
-			log.Compressed = true
-			log.CompressedAt = time.Now()
-		}
+	doc := toDBLog(*log)
+
+	s.compress(ctx, &doc)
+	if err := s.seal(&doc); err != nil {
+		return err
+	}
+	if doc.Compressed {
+		log.Compressed = true
+		log.CompressedAt = doc.CompressedAt
+		log.Compression = string(doc.Algorithm)
 	}
 
-	_, err := s.collection.InsertOne(ctx, log)
+	_, err := s.collection.InsertOne(ctx, doc)
+	if mongo.IsDuplicateKeyError(err) {
+		// An earlier attempt stored it.
+		return nil
+	}
 	if err != nil {
 		s.log.Error(ctx, "failed to insert log in MongoDB", "error", err)
 		return err
@@ -88,6 +274,58 @@ func (s *Store) Write(ctx context.Context, log *mlog.Log) error {
 	return nil
 }
 
+// WriteBatch inserts logs with a single unordered InsertMany, so one
+// rejected document does not stop the others. Partial failures are reported
+// as a *mlog.BatchError.
+func (s *Store) WriteBatch(ctx context.Context, logs []*mlog.Log) error {
+	docs := make([]any, len(logs))
+	for i, log := range logs {
+		doc := toDBLog(*log)
+		s.compress(ctx, &doc)
+		if err := s.seal(&doc); err != nil {
+			return err
+		}
+		docs[i] = doc
+	}
+
+	_, err := s.collection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err != nil {
+		s.log.Error(ctx, "failed to insert logs in MongoDB", "error", err, "logs", len(logs))
+	}
+
+	failed := map[int]error{}
+	var bulkErr mongo.BulkWriteException
+	switch {
+	case err == nil:
+	case errors.As(err, &bulkErr) && len(bulkErr.WriteErrors) > 0 && bulkErr.WriteConcernError == nil:
+		for _, we := range bulkErr.WriteErrors {
+			// Logs refused as duplicates were stored by an earlier attempt.
+			if we.HasErrorCode(duplicateKeyCode) {
+				continue
+			}
+			failed[we.Index] = we
+		}
+		err = nil
+		if len(failed) > 0 {
+			err = &mlog.BatchError{Failed: failed}
+		}
+	default:
+		return err
+	}
+
+	for i, log := range logs {
+		doc := docs[i].(dbLog)
+		if _, ok := failed[i]; ok || !doc.Compressed {
+			continue
+		}
+		log.Compressed = true
+		log.CompressedAt = doc.CompressedAt
+		log.Compression = string(doc.Algorithm)
+	}
+
+	return err
+}
+
 func (s *Store) Search(ctx context.Context, criteria mlog.SearchCriteria) (mlog.SearchResult, error) {
 	filter := s.buildFilter(criteria)
 
@@ -109,17 +347,12 @@ func (s *Store) Search(ctx context.Context, criteria mlog.SearchCriteria) (mlog.
 
 	var logs []mlog.Log
 	for cursor.Next(ctx) {
-		var log mlog.Log
-		if err := cursor.Decode(&log); err != nil {
+		var doc dbLog
+		if err := cursor.Decode(&doc); err != nil {
 			continue
 		}
 
-		if log.Compressed {
-			decompressed, err := s.compressor.Decompress([]byte(log.Message))
-			if err == nil {
-				log.Message = string(decompressed)
-			}
-		}
+		log := s.decode(ctx, doc)
 
 		logs = append(logs, log)
 	}
@@ -153,39 +386,51 @@ func (s *Store) ExportToFile(ctx context.Context, criteria mlog.SearchCriteria)
 	}
 	defer cursor.Close(ctx)
 
-	filename := fmt.Sprintf("logs_export_%d.txt", time.Now().Unix())
-	filepath := filepath.Join(s.exportPath, filename)
+	filename := mlog.ExportFilename(criteria, time.Now())
 
-	file, err := os.Create(filepath)
+	file, err := s.exports.Create(ctx, filename)
 	if err != nil {
 		return "", 0, err
 	}
-	defer file.Close()
+	committed := false
+	defer func() {
+		if !committed {
+			blob.Abort(file)
+		}
+	}()
 
 	var size int64
 	for cursor.Next(ctx) {
-		var log mlog.Log
-		if err := cursor.Decode(&log); err != nil {
-			continue
+		var doc dbLog
+		if err := cursor.Decode(&doc); err != nil {
+			return "", 0, fmt.Errorf("decoding log: %w", err)
 		}
 
-		if log.Compressed {
-			decompressed, err := s.compressor.Decompress([]byte(log.Message))
-			if err == nil {
-				log.Message = string(decompressed)
-			}
-		}
+		log := s.decode(ctx, doc)
 
 		line := fmt.Sprintf("[%s] [%s] %s\n", log.Timestamp.Format(time.RFC3339), log.Level, log.Message)
-		n, err := file.WriteString(line)
+		n, err := io.WriteString(file, line)
 		if err != nil {
 			s.log.Error(ctx, "error writing to export file", "error", err)
-			continue
+			return "", 0, fmt.Errorf("writing export: %w", err)
 		}
 		size += int64(n)
 	}
+	if err := cursor.Err(); err != nil {
+		return "", 0, err
+	}
+
+	if err := file.Close(); err != nil {
+		return "", 0, err
+	}
+	committed = true
+
+	fileURL, err := s.exports.URL(ctx, filename)
+	if err != nil {
+		return "", 0, err
+	}
 
-	return filename, size, nil
+	return fileURL, size, nil
 }
 
 func (s *Store) Count(ctx context.Context, criteria mlog.SearchCriteria) (int, error) {
@@ -197,9 +442,98 @@ func (s *Store) Count(ctx context.Context, criteria mlog.SearchCriteria) (int, e
 	return int(count), nil
 }
 
+// compress replaces the message of doc with its compressed form when that
+// actually saves space. With a trained dictionary even short messages qualify.
+func (s *Store) compress(ctx context.Context, doc *dbLog) {
+	var (
+		compressor compress.Compressor
+		dictionary uint32
+	)
+
+	switch {
+	case s.dictionaryMode && s.dicts.current() != nil && len(doc.Message) >= minDictionaryMessage:
+		active := s.dicts.current()
+		compressor = active
+		dictionary = active.ID()
+	case s.compressor != nil && len(doc.Message) > compressionThreshold:
+		compressor = s.compressor
+	default:
+		return
+	}
+
+	compressed, err := compressor.Compress([]byte(doc.Message))
+	if err != nil {
+		s.log.Error(ctx, "failed to compress log message", "error", err)
+		return
+	}
+
+	if len(compressed) >= len(doc.Message) {
+		return
+	}
+
+	doc.Message = string(compressed)
+	doc.Compressed = true
+	doc.CompressedAt = time.Now()
+	doc.Algorithm = compressor.Algorithm()
+	doc.Dictionary = dictionary
+}
+
+func (s *Store) decompress(ctx context.Context, doc dbLog) ([]byte, error) {
+	if !doc.Block.IsZero() {
+		return s.blockMessage(ctx, doc)
+	}
+
+	alg := doc.algorithm()
+	if alg != compress.ZstdDict {
+		return compress.Decompress(alg, []byte(doc.Message))
+	}
+
+	// Another instance may have trained a dictionary we have not seen yet.
+	s.dicts.mu.RLock()
+	known := s.dicts.version
+	s.dicts.mu.RUnlock()
+
+	if doc.Dictionary > known {
+		if err := s.loadDictionaries(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.dicts.decompress([]byte(doc.Message))
+}
+
+func (s *Store) decode(ctx context.Context, doc dbLog) mlog.Log {
+	if err := s.unseal(ctx, &doc); err != nil {
+		s.log.Error(ctx, "failed to open sealed log", "error", err, "id", doc.ID)
+		doc.Message, doc.Metadata, doc.Compressed = "", nil, false
+	}
+
+	log := toCoreLog(doc)
+
+	if doc.Compressed {
+		decompressed, err := s.decompress(ctx, doc)
+		if err != nil {
+			s.log.Error(ctx, "failed to decompress log message", "error", err, "id", doc.ID)
+		} else {
+			log.Message = string(decompressed)
+		}
+	}
+
+	return log
+}
+
 func (s *Store) buildFilter(criteria mlog.SearchCriteria) bson.M {
 	filter := bson.M{}
 
+	// Logs of no tenant are stored without the field.
+	switch {
+	case criteria.AllTenants:
+	case criteria.Tenant == "":
+		filter["tenant"] = bson.M{"$exists": false}
+	default:
+		filter["tenant"] = criteria.Tenant
+	}
+
 	timeFilter := bson.M{}
 	if !criteria.TimeRange.StartTime.IsZero() {
 		timeFilter["$gte"] = criteria.TimeRange.StartTime
@@ -213,9 +547,26 @@ func (s *Store) buildFilter(criteria mlog.SearchCriteria) bson.M {
 
 	if criteria.Level != "" {
 		filter["level"] = criteria.Level
+	} else if len(criteria.Levels) > 0 {
+		filter["level"] = bson.M{"$in": criteria.Levels}
+	}
+
+	for k, v := range criteria.Metadata {
+		filter["metadata."+k] = s.metadataValue(v)
 	}
 
 	return filter
 }
 
-var _ mlog.Store = (*Store)(nil)
+var (
+	_ mlog.Store            = (*Store)(nil)
+	_ mlog.BatchWriter      = (*Store)(nil)
+	_ mlog.Compactor        = (*Store)(nil)
+	_ mlog.StatsReporter    = (*Store)(nil)
+	_ mlog.RetentionStore   = (*Store)(nil)
+	_ mlog.Eraser           = (*Store)(nil)
+	_ mlog.HoldStore        = (*Store)(nil)
+	_ mlog.Scanner          = (*Store)(nil)
+	_ mlog.Pruner           = (*Store)(nil)
+	_ mlog.IdempotencyStore = (*Store)(nil)
+)
@@ -0,0 +1,496 @@
+package mongodb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/oklog/ulid/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	retentionBatchSize   = 1000
+	retentionIndexPrefix = "retention_"
+)
+
+type dbRetentionPolicy struct {
+	Name      string            `bson:"name"`
+	Level     mlog.Level        `bson:"level,omitempty"`
+	Metadata  map[string]string `bson:"metadata,omitempty"`
+	MaxAge    int64             `bson:"maxage"`
+	UpdatedAt time.Time         `bson:"updatedat"`
+	// CountedTo is the timestamp the logs of a TTL policy were counted up
+	// to, and CountedAt when, so each log is counted once before it
+	// expires. See countTTL.
+	CountedTo time.Time `bson:"countedto,omitempty"`
+	CountedAt time.Time `bson:"countedat,omitempty"`
+}
+
+func toDBRetentionPolicy(p mlog.RetentionPolicy) dbRetentionPolicy {
+	return dbRetentionPolicy{
+		Name:      p.Name,
+		Level:     p.Level,
+		Metadata:  p.Metadata,
+		MaxAge:    int64(p.MaxAge / time.Second),
+		UpdatedAt: p.UpdatedAt,
+	}
+}
+
+func toCoreRetentionPolicy(doc dbRetentionPolicy) mlog.RetentionPolicy {
+	return mlog.RetentionPolicy{
+		Name:      doc.Name,
+		Level:     doc.Level,
+		Metadata:  doc.Metadata,
+		MaxAge:    time.Duration(doc.MaxAge) * time.Second,
+		UpdatedAt: doc.UpdatedAt,
+	}
+}
+
+func (s *Store) RetentionPolicies(ctx context.Context) ([]mlog.RetentionPolicy, error) {
+	cursor, err := s.retention.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []dbRetentionPolicy
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	policies := make([]mlog.RetentionPolicy, len(docs))
+	for i, doc := range docs {
+		policies[i] = toCoreRetentionPolicy(doc)
+	}
+
+	return policies, nil
+}
+
+func (s *Store) SaveRetentionPolicy(ctx context.Context, policy mlog.RetentionPolicy) error {
+	// Replacing the policy also starts its TTL count over, since it may
+	// select other logs.
+	_, err := s.retention.ReplaceOne(ctx,
+		bson.M{"name": policy.Name},
+		toDBRetentionPolicy(policy),
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
+
+func (s *Store) DeleteRetentionPolicy(ctx context.Context, name string) error {
+	result, err := s.retention.DeleteOne(ctx, bson.M{"name": name})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("%s: %w", name, mlog.ErrRetentionPolicyNotFound)
+	}
+
+	return nil
+}
+
+// ApplyRetention enforces the policies. A policy that no longer-lived policy
+// overlaps is delegated to a partial TTL index so MongoDB expires its logs on
+// its own; every other policy is enforced with batched deletes. TTL indexes
+// cannot skip held logs, so they are all dropped while any legal hold is
+// active.
+func (s *Store) ApplyRetention(ctx context.Context, policies []mlog.RetentionPolicy, holds []mlog.LegalHold, now time.Time, dryRun bool) ([]mlog.RetentionResult, error) {
+	results := make([]mlog.RetentionResult, 0, len(policies))
+	ttl := make(map[string]bool)
+	useTTL := s.retentionTTL && len(holds) == 0
+
+	for _, p := range policies {
+		filter := s.retentionFilter(p, policies, holds, now)
+
+		if dryRun {
+			count, size, err := s.measure(ctx, filter)
+			if err != nil {
+				return results, fmt.Errorf("measuring policy %s: %w", p.Name, err)
+			}
+			results = append(results, mlog.RetentionResult{Policy: p.Name, Deleted: count, Bytes: size, DryRun: true})
+			continue
+		}
+
+		if useTTL && len(p.Protected(policies)) == 0 {
+			err := s.ensureTTLIndex(ctx, p)
+			if err == nil {
+				ttl[retentionIndexPrefix+p.Name] = true
+				deleted, size, err := s.countTTL(ctx, p, filter, now)
+				if err != nil {
+					s.log.Error(ctx, "failed to count logs expired by TTL index", "policy", p.Name, "error", err)
+				}
+				results = append(results, mlog.RetentionResult{Policy: p.Name, Deleted: deleted, Bytes: size, TTL: true, Estimated: true})
+				continue
+			}
+			s.log.Error(ctx, "failed to create TTL index, falling back to batched deletes", "policy", p.Name, "error", err)
+		}
+
+		ids := make(map[string][]ulid.ULID)
+		deleted, size, err := s.deleteBatched(ctx, filter, ids)
+		results = append(results, mlog.RetentionResult{Policy: p.Name, Deleted: deleted, Bytes: size, IDs: ids})
+		if err != nil {
+			return results, fmt.Errorf("enforcing policy %s: %w", p.Name, err)
+		}
+	}
+
+	if dryRun {
+		return results, nil
+	}
+
+	if err := s.dropTTLIndexes(ctx, ttl); err != nil {
+		return results, err
+	}
+
+	// A hold created while the indexes were made did not see them, so they
+	// go again.
+	if len(ttl) > 0 {
+		n, err := s.holds.CountDocuments(ctx, bson.M{"releasedat": bson.M{"$exists": false}})
+		if err != nil {
+			return results, fmt.Errorf("counting legal holds: %w", err)
+		}
+		if n > 0 {
+			return results, s.dropTTLIndexes(ctx, nil)
+		}
+	}
+
+	if len(ttl) > 0 {
+		if err := s.dropExpiredBlocks(ctx, policies, now); err != nil {
+			return results, err
+		}
+	}
+
+	return results, nil
+}
+
+// countTTL estimates what the TTL index of p removes, since MongoDB does not
+// report it. Logs are counted while they still exist: each run counts the
+// logs of p from where the last run stopped up to the age they will have
+// reached by the next run, taken to come as long after this one as this one
+// came after the last. Logs written with older timestamps than those
+// counted, or expired by a run later than expected, are missed. The policy
+// document records where counting stopped, so servers sharing the database
+// count each log once.
+func (s *Store) countTTL(ctx context.Context, p mlog.RetentionPolicy, filter bson.D, now time.Time) (int64, int64, error) {
+	var doc dbRetentionPolicy
+	if err := s.retention.FindOne(ctx, bson.M{"name": p.Name}).Decode(&doc); err != nil {
+		return 0, 0, fmt.Errorf("reading policy: %w", err)
+	}
+
+	to := now.Add(-p.MaxAge)
+	if !doc.CountedAt.IsZero() && now.After(doc.CountedAt) {
+		to = to.Add(now.Sub(doc.CountedAt))
+	}
+	if !to.After(doc.CountedTo) {
+		return 0, 0, nil
+	}
+
+	claim := bson.M{"name": p.Name, "countedto": doc.CountedTo}
+	if doc.CountedTo.IsZero() {
+		claim["countedto"] = bson.M{"$exists": false}
+	}
+	res, err := s.retention.UpdateOne(ctx, claim, bson.M{"$set": bson.M{"countedto": to, "countedat": now}})
+	if err != nil {
+		return 0, 0, fmt.Errorf("recording count: %w", err)
+	}
+	if res.ModifiedCount == 0 {
+		// Another server counted meanwhile.
+		return 0, 0, nil
+	}
+
+	// The age bound of filter is replaced by the range being counted.
+	window := make(bson.D, 0, len(filter))
+	for _, e := range filter {
+		if e.Key != "timestamp" {
+			window = append(window, e)
+		}
+	}
+	timestamp := bson.M{"$lt": to}
+	if !doc.CountedTo.IsZero() {
+		timestamp["$gte"] = doc.CountedTo
+	}
+	window = append(window, bson.E{Key: "timestamp", Value: timestamp})
+
+	return s.measure(ctx, window)
+}
+
+// dropExpiredBlocks removes blocks whose logs were all expired by TTL indexes,
+// since MongoDB only deletes the log documents themselves.
+func (s *Store) dropExpiredBlocks(ctx context.Context, policies []mlog.RetentionPolicy, now time.Time) error {
+	shortest := policies[0].MaxAge
+	for _, p := range policies[1:] {
+		shortest = min(shortest, p.MaxAge)
+	}
+
+	cursor, err := s.blocks.Find(ctx,
+		bson.M{"end": bson.M{"$lt": now.Add(-shortest)}},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return fmt.Errorf("finding expired blocks: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	blocks := make(map[primitive.ObjectID]bool)
+	for cursor.Next(ctx) {
+		var block dbBlock
+		if err := cursor.Decode(&block); err != nil {
+			continue
+		}
+		blocks[block.ID] = true
+	}
+
+	_, err = s.dropUnusedBlocks(ctx, blocks)
+	return err
+}
+
+func (s *Store) retentionFilter(p mlog.RetentionPolicy, policies []mlog.RetentionPolicy, holds []mlog.LegalHold, now time.Time) bson.D {
+	filter := s.policyFilter(p)
+	filter = append(filter, bson.E{Key: "timestamp", Value: bson.M{"$lt": now.Add(-p.MaxAge)}})
+
+	var nor bson.A
+	for _, q := range p.Protected(policies) {
+		nor = append(nor, s.policyFilter(q))
+	}
+	for _, h := range holds {
+		nor = append(nor, s.holdFilter(h))
+	}
+
+	if len(nor) > 0 {
+		filter = append(filter, bson.E{Key: "$nor", Value: nor})
+	}
+
+	return filter
+}
+
+// policyFilter matches the logs selected by a policy regardless of age. Keys
+// are sorted so the same policy always yields the same document.
+func (s *Store) policyFilter(p mlog.RetentionPolicy) bson.D {
+	filter := bson.D{}
+	if p.Level != "" {
+		filter = append(filter, bson.E{Key: "level", Value: p.Level})
+	}
+
+	keys := make([]string, 0, len(p.Metadata))
+	for k := range p.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		filter = append(filter, bson.E{Key: "metadata." + k, Value: s.metadataValue(p.Metadata[k])})
+	}
+
+	return filter
+}
+
+func (s *Store) measure(ctx context.Context, filter any) (int64, int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    nil,
+			"count":  bson.M{"$sum": 1},
+			"stored": bson.M{"$sum": bson.M{"$strLenBytes": "$message"}},
+		}}},
+	}
+
+	var groups []statsGroup
+	if err := s.aggregate(ctx, s.collection, pipeline, &groups); err != nil {
+		return 0, 0, err
+	}
+
+	if len(groups) == 0 {
+		return 0, 0, nil
+	}
+	return groups[0].Count, groups[0].Stored, nil
+}
+
+type deleteCandidate struct {
+	ID     primitive.ObjectID `bson:"_id"`
+	LogID  ulid.ULID          `bson:"id"`
+	Tenant string             `bson:"tenant,omitempty"`
+	Block  primitive.ObjectID `bson:"block,omitempty"`
+	Size   int64              `bson:"size"`
+}
+
+// deleteBatched removes the logs matching filter in batches so a large backlog
+// never holds a single long-running delete, then drops blocks left without
+// any log pointing to them. The IDs of the logs deleted are added to removed
+// by tenant unless it is nil.
+func (s *Store) deleteBatched(ctx context.Context, filter any, removed map[string][]ulid.ULID) (int64, int64, error) {
+	var (
+		deleted int64
+		size    int64
+		blocks  = make(map[primitive.ObjectID]bool)
+	)
+
+	for {
+		pipeline := mongo.Pipeline{
+			{{Key: "$match", Value: filter}},
+			{{Key: "$limit", Value: retentionBatchSize}},
+			{{Key: "$project", Value: bson.M{
+				"id":     1,
+				"tenant": 1,
+				"block":  1,
+				"size":   bson.M{"$strLenBytes": "$message"},
+			}}},
+		}
+
+		var candidates []deleteCandidate
+		if err := s.aggregate(ctx, s.collection, pipeline, &candidates); err != nil {
+			return deleted, size, err
+		}
+
+		if len(candidates) == 0 {
+			break
+		}
+
+		ids := make(bson.A, len(candidates))
+		for i, c := range candidates {
+			ids[i] = c.ID
+			size += c.Size
+			if !c.Block.IsZero() {
+				blocks[c.Block] = true
+			}
+		}
+
+		result, err := s.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+		if err != nil {
+			return deleted, size, err
+		}
+		deleted += result.DeletedCount
+
+		if removed != nil {
+			for _, c := range candidates {
+				removed[c.Tenant] = append(removed[c.Tenant], c.LogID)
+			}
+		}
+
+		if len(candidates) < retentionBatchSize {
+			break
+		}
+	}
+
+	freed, err := s.dropUnusedBlocks(ctx, blocks)
+	size += freed
+
+	return deleted, size, err
+}
+
+func (s *Store) dropUnusedBlocks(ctx context.Context, blocks map[primitive.ObjectID]bool) (int64, error) {
+	var freed int64
+
+	for id := range blocks {
+		n, err := s.collection.CountDocuments(ctx, bson.M{"block": id}, options.Count().SetLimit(1))
+		if err != nil {
+			return freed, err
+		}
+		if n > 0 {
+			continue
+		}
+
+		var block dbBlock
+		err = s.blocks.FindOneAndDelete(ctx, bson.M{"_id": id}).Decode(&block)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return freed, err
+		}
+		freed += int64(len(block.Data))
+	}
+
+	return freed, nil
+}
+
+type dbIndex struct {
+	Name                    string   `bson:"name"`
+	ExpireAfterSeconds      *int32   `bson:"expireAfterSeconds"`
+	PartialFilterExpression bson.Raw `bson:"partialFilterExpression"`
+}
+
+func (s *Store) retentionIndexes(ctx context.Context) (map[string]dbIndex, error) {
+	cursor, err := s.collection.Indexes().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var indexes []dbIndex
+	if err := cursor.All(ctx, &indexes); err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]dbIndex)
+	for _, idx := range indexes {
+		if strings.HasPrefix(idx.Name, retentionIndexPrefix) {
+			byName[idx.Name] = idx
+		}
+	}
+
+	return byName, nil
+}
+
+func (s *Store) ensureTTLIndex(ctx context.Context, p mlog.RetentionPolicy) error {
+	name := retentionIndexPrefix + p.Name
+	expire := int32(p.MaxAge / time.Second)
+	filter := s.policyFilter(p)
+
+	var partial bson.Raw
+	if len(filter) > 0 {
+		raw, err := bson.Marshal(filter)
+		if err != nil {
+			return err
+		}
+		partial = raw
+	}
+
+	existing, err := s.retentionIndexes(ctx)
+	if err != nil {
+		return err
+	}
+
+	if idx, ok := existing[name]; ok {
+		if idx.ExpireAfterSeconds != nil && *idx.ExpireAfterSeconds == expire && bytes.Equal(idx.PartialFilterExpression, partial) {
+			return nil
+		}
+		if _, err := s.collection.Indexes().DropOne(ctx, name); err != nil {
+			return err
+		}
+	}
+
+	opts := options.Index().SetName(name).SetExpireAfterSeconds(expire)
+	if len(filter) > 0 {
+		opts.SetPartialFilterExpression(filter)
+	}
+
+	_, err = s.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "timestamp", Value: 1}},
+		Options: opts,
+	})
+	return err
+}
+
+func (s *Store) dropTTLIndexes(ctx context.Context, keep map[string]bool) error {
+	existing, err := s.retentionIndexes(ctx)
+	if err != nil {
+		return err
+	}
+
+	for name := range existing {
+		if keep[name] {
+			continue
+		}
+		if _, err := s.collection.Indexes().DropOne(ctx, name); err != nil {
+			return fmt.Errorf("dropping TTL index %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/oklog/ulid/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Scan walks the logs matching criteria oldest first with a single cursor.
+func (s *Store) Scan(ctx context.Context, criteria mlog.SearchCriteria, fn func(mlog.Log) error) error {
+	filter := s.buildFilter(criteria)
+	findOptions := options.Find().SetSort(bson.D{
+		{Key: "timestamp", Value: 1},
+		{Key: "_id", Value: 1},
+	})
+
+	cursor, err := s.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return fmt.Errorf("finding logs: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc dbLog
+		if err := cursor.Decode(&doc); err != nil {
+			return fmt.Errorf("decoding log: %w", err)
+		}
+
+		if err := fn(s.decode(ctx, doc)); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// Prune deletes the logs of ids inside tr, dropping blocks left without
+// members.
+func (s *Store) Prune(ctx context.Context, tr mlog.TimeRange, ids []ulid.ULID) (int64, error) {
+	var deleted int64
+	for start := 0; start < len(ids); start += retentionBatchSize {
+		end := min(start+retentionBatchSize, len(ids))
+
+		filter := s.buildFilter(mlog.SearchCriteria{AllTenants: true, TimeRange: tr})
+		filter["id"] = bson.M{"$in": ids[start:end]}
+
+		n, _, err := s.deleteBatched(ctx, filter, nil)
+		deleted += n
+		if err != nil {
+			return deleted, fmt.Errorf("pruning logs: %w", err)
+		}
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,189 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type statsGroup struct {
+	ID         string `bson:"_id"`
+	Count      int64  `bson:"count"`
+	Compressed int64  `bson:"compressed"`
+	Raw        int64  `bson:"raw"`
+	Stored     int64  `bson:"stored"`
+}
+
+type statsFacets struct {
+	Totals     []statsGroup `bson:"totals"`
+	Levels     []statsGroup `bson:"levels"`
+	Days       []statsGroup `bson:"days"`
+	Algorithms []statsGroup `bson:"algorithms"`
+	Tenants    []statsGroup `bson:"tenants"`
+}
+
+type collStats struct {
+	Count          int64            `bson:"count"`
+	Size           int64            `bson:"size"`
+	StorageSize    int64            `bson:"storageSize"`
+	TotalIndexSize int64            `bson:"totalIndexSize"`
+	IndexSizes     map[string]int64 `bson:"indexSizes"`
+}
+
+// Stats aggregates counts and sizes over the logs and blocks collections.
+// Sizes of logs written before raw sizes were recorded fall back to the
+// stored size, so their ratio reads as 1.
+func (s *Store) Stats(ctx context.Context) (mlog.Stats, error) {
+	stored := bson.M{"$strLenBytes": "$message"}
+	raw := bson.M{"$ifNull": bson.A{"$rawsize", stored}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$facet", Value: bson.M{
+			"totals": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":        nil,
+					"count":      bson.M{"$sum": 1},
+					"compressed": bson.M{"$sum": bson.M{"$cond": bson.A{"$compressed", 1, 0}}},
+					"raw":        bson.M{"$sum": raw},
+					"stored":     bson.M{"$sum": stored},
+				}},
+			},
+			"levels": bson.A{
+				bson.M{"$group": bson.M{"_id": "$level", "count": bson.M{"$sum": 1}}},
+				bson.M{"$sort": bson.M{"_id": 1}},
+			},
+			"tenants": bson.A{
+				bson.M{"$group": bson.M{"_id": bson.M{"$ifNull": bson.A{"$tenant", ""}}, "count": bson.M{"$sum": 1}}},
+				bson.M{"$sort": bson.M{"_id": 1}},
+			},
+			"days": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":   bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$timestamp"}},
+					"count": bson.M{"$sum": 1},
+				}},
+				bson.M{"$sort": bson.M{"_id": 1}},
+			},
+			"algorithms": bson.A{
+				bson.M{"$match": bson.M{"compressed": true, "block": bson.M{"$exists": false}}},
+				bson.M{"$group": bson.M{
+					"_id":    bson.M{"$ifNull": bson.A{"$algorithm", "gzip"}},
+					"count":  bson.M{"$sum": 1},
+					"raw":    bson.M{"$sum": raw},
+					"stored": bson.M{"$sum": stored},
+				}},
+				bson.M{"$sort": bson.M{"_id": 1}},
+			},
+		}}},
+	}
+
+	var facets []statsFacets
+	if err := s.aggregate(ctx, s.collection, pipeline, &facets); err != nil {
+		return mlog.Stats{}, fmt.Errorf("aggregating logs: %w", err)
+	}
+
+	var blocks []statsGroup
+	blockPipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":    "$algorithm",
+			"count":  bson.M{"$sum": "$count"},
+			"raw":    bson.M{"$sum": "$rawsize"},
+			"stored": bson.M{"$sum": bson.M{"$binarySize": "$data"}},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+	if err := s.aggregate(ctx, s.blocks, blockPipeline, &blocks); err != nil {
+		return mlog.Stats{}, fmt.Errorf("aggregating blocks: %w", err)
+	}
+
+	stats := mlog.Stats{
+		ComputedAt: time.Now(),
+	}
+
+	if len(facets) > 0 {
+		f := facets[0]
+
+		if len(f.Totals) > 0 {
+			stats.Total = f.Totals[0].Count
+			stats.Compressed = f.Totals[0].Compressed
+			stats.Uncompressed = stats.Total - stats.Compressed
+			stats.RawBytes = f.Totals[0].Raw
+			stats.StoredBytes = f.Totals[0].Stored
+		}
+
+		for _, g := range f.Levels {
+			stats.Levels = append(stats.Levels, mlog.LevelStats{Level: mlog.Level(g.ID), Count: g.Count})
+		}
+
+		for _, g := range f.Tenants {
+			stats.Tenants = append(stats.Tenants, mlog.TenantStats{Tenant: g.ID, Count: g.Count})
+		}
+
+		for _, g := range f.Days {
+			day, err := time.Parse(time.DateOnly, g.ID)
+			if err != nil {
+				continue
+			}
+			stats.Days = append(stats.Days, mlog.DayStats{Day: day, Count: g.Count})
+		}
+
+		for _, g := range f.Algorithms {
+			stats.Algorithms = append(stats.Algorithms, mlog.AlgorithmStats{
+				Algorithm:   g.ID,
+				Count:       g.Count,
+				RawBytes:    g.Raw,
+				StoredBytes: g.Stored,
+			})
+		}
+	}
+
+	for _, g := range blocks {
+		stats.StoredBytes += g.Stored
+		stats.Algorithms = append(stats.Algorithms, mlog.AlgorithmStats{
+			Algorithm:   g.ID + "-block",
+			Count:       g.Count,
+			RawBytes:    g.Raw,
+			StoredBytes: g.Stored,
+		})
+	}
+
+	for _, c := range []*mongo.Collection{s.collection, s.blocks, s.dictionaries} {
+		cs, err := s.collStats(ctx, c)
+		if err != nil {
+			return mlog.Stats{}, err
+		}
+		stats.Collections = append(stats.Collections, cs)
+	}
+
+	return stats, nil
+}
+
+func (s *Store) aggregate(ctx context.Context, c *mongo.Collection, pipeline mongo.Pipeline, results any) error {
+	cursor, err := c.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	return cursor.All(ctx, results)
+}
+
+func (s *Store) collStats(ctx context.Context, c *mongo.Collection) (mlog.CollectionStats, error) {
+	var result collStats
+	err := s.db.RunCommand(ctx, bson.D{{Key: "collStats", Value: c.Name()}}).Decode(&result)
+	if err != nil {
+		return mlog.CollectionStats{}, fmt.Errorf("collStats %s: %w", c.Name(), err)
+	}
+
+	return mlog.CollectionStats{
+		Name:        c.Name(),
+		Count:       result.Count,
+		Size:        result.Size,
+		StorageSize: result.StorageSize,
+		IndexSize:   result.TotalIndexSize,
+		IndexSizes:  result.IndexSizes,
+	}, nil
+}
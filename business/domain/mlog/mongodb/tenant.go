@@ -0,0 +1,79 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Isolation selects where the logs of each tenant are kept.
+type Isolation string
+
+const (
+	// IsolationShared keeps every tenant in one collection, told apart by
+	// the tenant field.
+	IsolationShared Isolation = "shared"
+	// IsolationCollection gives each tenant its own collections, named
+	// <tenant>.<collection>, in the configured database.
+	IsolationCollection Isolation = "collection"
+	// IsolationDatabase gives each tenant its own database, named
+	// <database>_<tenant>.
+	IsolationDatabase Isolation = "database"
+)
+
+// Client returns the client of the store, so stores of other tenants can
+// share it through Config.Client.
+func (s *Store) Client() *mongo.Client {
+	return s.db.Client()
+}
+
+// ForTenant returns the config of the store holding the logs of tenant under
+// isolation. Tenant names cannot hold a dot, so the collection prefix never
+// clashes with the suffixes of the companion collections.
+func (cfg Config) ForTenant(isolation Isolation, tenant string) Config {
+	switch isolation {
+	case IsolationCollection:
+		cfg.CollectionName = tenant + "." + cfg.CollectionName
+	case IsolationDatabase:
+		cfg.DatabaseName = cfg.DatabaseName + "_" + tenant
+	}
+	return cfg
+}
+
+// Tenants lists the tenants that have logs of their own under isolation.
+func Tenants(ctx context.Context, client *mongo.Client, cfg Config, isolation Isolation) ([]string, error) {
+	switch isolation {
+	case IsolationCollection:
+		names, err := client.Database(cfg.DatabaseName).ListCollectionNames(ctx, bson.M{})
+		if err != nil {
+			return nil, fmt.Errorf("listing collections: %w", err)
+		}
+
+		var tenants []string
+		for _, name := range names {
+			if tenant, ok := strings.CutSuffix(name, "."+cfg.CollectionName); ok {
+				tenants = append(tenants, tenant)
+			}
+		}
+		return tenants, nil
+
+	case IsolationDatabase:
+		names, err := client.ListDatabaseNames(ctx, bson.M{})
+		if err != nil {
+			return nil, fmt.Errorf("listing databases: %w", err)
+		}
+
+		var tenants []string
+		for _, name := range names {
+			if tenant, ok := strings.CutPrefix(name, cfg.DatabaseName+"_"); ok {
+				tenants = append(tenants, tenant)
+			}
+		}
+		return tenants, nil
+	}
+
+	return nil, nil
+}
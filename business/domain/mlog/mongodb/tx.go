@@ -0,0 +1,224 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/foundation/transaction"
+	"github.com/oklog/ulid/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+var (
+	ErrTxDone    = errors.New("transaction already committed or rolled back")
+	ErrForeignTx = errors.New("transaction was not started by this store")
+)
+
+// unknownCommitResult labels commit errors after which the transaction may
+// or may not have been applied; committing again is safe.
+const unknownCommitResult = "UnknownTransactionCommitResult"
+
+// A commit with an unknown result is tried up to commitAttempts times,
+// waiting commitBackoff before the second try and twice as long before each
+// one after it.
+const (
+	commitAttempts = 5
+	commitBackoff  = 50 * time.Millisecond
+)
+
+// Tx is a MongoDB transaction backed by a client session. Transactions need
+// a replica set or a sharded cluster. A Tx must not be used from several
+// goroutines at once.
+type Tx struct {
+	store   *Store
+	session mongo.Session
+	done    bool
+}
+
+// Begin starts a transaction with snapshot reads and majority writes.
+func (s *Store) Begin(ctx context.Context) (transaction.CommitRollbacker, error) {
+	session, err := s.db.Client().StartSession()
+	if err != nil {
+		return nil, fmt.Errorf("starting session: %w", err)
+	}
+
+	opts := options.Transaction().
+		SetReadConcern(readconcern.Snapshot()).
+		SetWriteConcern(writeconcern.Majority())
+
+	if err := session.StartTransaction(opts); err != nil {
+		session.EndSession(ctx)
+		return nil, fmt.Errorf("starting transaction: %w", err)
+	}
+
+	return &Tx{store: s, session: session}, nil
+}
+
+func (t *Tx) Commit(ctx context.Context) error {
+	if t.done {
+		return ErrTxDone
+	}
+	t.done = true
+	defer t.session.EndSession(ctx)
+
+	backoff := commitBackoff
+	for attempt := 1; ; attempt++ {
+		err := t.session.CommitTransaction(ctx)
+		if err == nil {
+			break
+		}
+
+		var serverErr mongo.ServerError
+		if !errors.As(err, &serverErr) || !serverErr.HasErrorLabel(unknownCommitResult) || attempt == commitAttempts {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
+		}
+		backoff *= 2
+	}
+
+	// Blocks rewritten by the transaction may be cached with their old
+	// contents.
+	t.store.blockCache.reset()
+
+	return nil
+}
+
+// Rollback aborts the transaction. After Commit it does nothing, so it can
+// always be deferred.
+func (t *Tx) Rollback(ctx context.Context) error {
+	if t.done {
+		return nil
+	}
+	t.done = true
+	defer t.session.EndSession(ctx)
+
+	return t.session.AbortTransaction(ctx)
+}
+
+// NewWithTx returns a store whose calls run inside tx. Maintenance
+// operations such as compaction and retention are not available through it.
+func (s *Store) NewWithTx(tx transaction.CommitRollbacker) (mlog.Store, error) {
+	t, ok := tx.(*Tx)
+	if !ok || t.store != s {
+		return nil, ErrForeignTx
+	}
+
+	// Blocks decoded inside the transaction may not be committed yet, so the
+	// copy keeps its own cache.
+	bound := *s
+	bound.blockCache = newBlockCache()
+
+	return &txStore{store: &bound, tx: t}, nil
+}
+
+// txStore runs every call in the session of its transaction.
+type txStore struct {
+	store *Store
+	tx    *Tx
+}
+
+func (t *txStore) bind(ctx context.Context) (context.Context, error) {
+	if t.tx.done {
+		return nil, ErrTxDone
+	}
+	return mongo.NewSessionContext(ctx, t.tx.session), nil
+}
+
+func (t *txStore) Write(ctx context.Context, log *mlog.Log) error {
+	ctx, err := t.bind(ctx)
+	if err != nil {
+		return err
+	}
+	return t.store.Write(ctx, log)
+}
+
+func (t *txStore) Search(ctx context.Context, criteria mlog.SearchCriteria) (mlog.SearchResult, error) {
+	ctx, err := t.bind(ctx)
+	if err != nil {
+		return mlog.SearchResult{}, err
+	}
+	return t.store.Search(ctx, criteria)
+}
+
+func (t *txStore) Count(ctx context.Context, criteria mlog.SearchCriteria) (int, error) {
+	ctx, err := t.bind(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return t.store.Count(ctx, criteria)
+}
+
+func (t *txStore) ExportToFile(ctx context.Context, criteria mlog.SearchCriteria) (string, int64, error) {
+	ctx, err := t.bind(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	return t.store.ExportToFile(ctx, criteria)
+}
+
+func (t *txStore) Erase(ctx context.Context, criteria mlog.DeleteCriteria, holds []mlog.LegalHold) (mlog.DeleteResult, error) {
+	ctx, err := t.bind(ctx)
+	if err != nil {
+		return mlog.DeleteResult{}, err
+	}
+	return t.store.Erase(ctx, criteria, holds)
+}
+
+func (t *txStore) SaveDeletionAudit(ctx context.Context, audit mlog.DeletionAudit) error {
+	ctx, err := t.bind(ctx)
+	if err != nil {
+		return err
+	}
+	return t.store.SaveDeletionAudit(ctx, audit)
+}
+
+// CreateLegalHold drops the TTL indexes outside the transaction, which
+// cannot drop indexes, and stores the hold inside it.
+func (t *txStore) CreateLegalHold(ctx context.Context, hold mlog.LegalHold) error {
+	if err := t.store.dropTTLIndexes(ctx, nil); err != nil {
+		return err
+	}
+
+	ctx, err := t.bind(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = t.store.holds.InsertOne(ctx, toDBLegalHold(hold))
+	return err
+}
+
+func (t *txStore) LegalHolds(ctx context.Context, includeReleased bool) ([]mlog.LegalHold, error) {
+	ctx, err := t.bind(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return t.store.LegalHolds(ctx, includeReleased)
+}
+
+func (t *txStore) ReleaseLegalHold(ctx context.Context, id ulid.ULID, by string, at time.Time) (mlog.LegalHold, error) {
+	ctx, err := t.bind(ctx)
+	if err != nil {
+		return mlog.LegalHold{}, err
+	}
+	return t.store.ReleaseLegalHold(ctx, id, by, at)
+}
+
+var (
+	_ mlog.Transactor = (*Store)(nil)
+	_ mlog.Store      = (*txStore)(nil)
+	_ mlog.Eraser     = (*txStore)(nil)
+	_ mlog.HoldStore  = (*txStore)(nil)
+)
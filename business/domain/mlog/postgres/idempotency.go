@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/oklog/ulid/v2"
+)
+
+// ClaimIdempotencyKey inserts the binding of key, replacing it only when it
+// is older than since. A live binding makes the upsert return no row, and
+// the bound ID is read back.
+func (s *Store) ClaimIdempotencyKey(ctx context.Context, key string, id ulid.ULID, at, since time.Time) (ulid.ULID, bool, error) {
+	var bound string
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO idempotency_keys (key, log_id, created_at) VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE SET log_id = EXCLUDED.log_id, created_at = EXCLUDED.created_at
+		WHERE idempotency_keys.created_at < $4
+		RETURNING log_id`,
+		key, id.String(), at, since,
+	).Scan(&bound)
+
+	claimed := err == nil
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = s.pool.QueryRow(ctx, `SELECT log_id FROM idempotency_keys WHERE key = $1`, key).Scan(&bound)
+	}
+	if err != nil {
+		return ulid.ULID{}, false, err
+	}
+
+	owner, err := ulid.Parse(bound)
+	if err != nil {
+		return ulid.ULID{}, false, fmt.Errorf("parsing id %q: %w", bound, err)
+	}
+
+	return owner, claimed, nil
+}
+
+func (s *Store) ReleaseIdempotencyKey(ctx context.Context, key string, id ulid.ULID) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE key = $1 AND log_id = $2`, key, id.String())
+	return err
+}
+
+func (s *Store) PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
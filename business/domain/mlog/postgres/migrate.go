@@ -0,0 +1,232 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationLock is the advisory lock key held while migrating, so instances
+// starting together do not apply the same migration twice.
+const migrationLock = 0x6c6f6768
+
+// migrations are applied in order and recorded in schema_migrations. Never
+// edit an entry that has shipped; append a new one instead.
+var migrations = []string{
+	// 1: logs, partitioned by day on timestamp. Partitions are created on
+	// demand as logs arrive. The primary key must include the partition key.
+	`CREATE TABLE logs (
+		id            TEXT        NOT NULL,
+		timestamp     TIMESTAMPTZ NOT NULL,
+		level         TEXT        NOT NULL,
+		message       BYTEA       NOT NULL,
+		raw_size      INTEGER     NOT NULL,
+		algorithm     TEXT        NOT NULL DEFAULT '',
+		compressed_at TIMESTAMPTZ,
+		metadata      JSONB       NOT NULL DEFAULT '{}',
+		PRIMARY KEY (timestamp, id)
+	) PARTITION BY RANGE (timestamp);
+	CREATE INDEX logs_level_timestamp ON logs (level, timestamp);
+	CREATE INDEX logs_metadata ON logs USING GIN (metadata jsonb_path_ops);`,
+
+	// 2: retention policies.
+	`CREATE TABLE log_retention_policies (
+		name       TEXT        PRIMARY KEY,
+		level      TEXT        NOT NULL DEFAULT '',
+		metadata   JSONB       NOT NULL DEFAULT '{}',
+		max_age    BIGINT      NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL
+	);`,
+
+	// 3: idempotency keys and the log each one produced.
+	`CREATE TABLE idempotency_keys (
+		key        TEXT        PRIMARY KEY,
+		log_id     TEXT        NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL
+	);
+	CREATE INDEX idempotency_keys_created_at ON idempotency_keys (created_at);`,
+
+	// 4: the tenant owning each log, '' for none.
+	`ALTER TABLE logs ADD COLUMN tenant TEXT NOT NULL DEFAULT '';
+	CREATE INDEX logs_tenant_timestamp ON logs (tenant, timestamp);`,
+
+	// 5: API keys, read by the apikey/postgres store.
+	`CREATE TABLE api_keys (
+		id             TEXT        PRIMARY KEY,
+		name           TEXT        NOT NULL,
+		hash           TEXT        NOT NULL UNIQUE,
+		scopes         TEXT[]      NOT NULL,
+		tenant         TEXT        NOT NULL DEFAULT '',
+		created_by     TEXT        NOT NULL DEFAULT '',
+		created_at     TIMESTAMPTZ NOT NULL,
+		expires_at     TIMESTAMPTZ,
+		previous_hash  TEXT        NOT NULL DEFAULT '',
+		previous_until TIMESTAMPTZ,
+		rotated_at     TIMESTAMPTZ,
+		revoked_by     TEXT        NOT NULL DEFAULT '',
+		revoked_at     TIMESTAMPTZ
+	);
+	CREATE INDEX api_keys_previous_hash ON api_keys (previous_hash);`,
+
+	// 6: the role restricting what each API key reads, '' for none.
+	`ALTER TABLE api_keys ADD COLUMN role TEXT NOT NULL DEFAULT '';`,
+
+	// 7: the audit trail, read by the audit/postgres store. A trigger keeps
+	// it append-only; duration is in nanoseconds.
+	`CREATE TABLE audit_log (
+		seq       BIGSERIAL   PRIMARY KEY,
+		id        TEXT        NOT NULL UNIQUE,
+		time      TIMESTAMPTZ NOT NULL,
+		actor     TEXT        NOT NULL DEFAULT '',
+		tenant    TEXT        NOT NULL DEFAULT '',
+		method    TEXT        NOT NULL,
+		request   TEXT        NOT NULL DEFAULT '',
+		results   BIGINT      NOT NULL DEFAULT 0,
+		bytes     BIGINT      NOT NULL DEFAULT 0,
+		duration  BIGINT      NOT NULL DEFAULT 0,
+		code      TEXT        NOT NULL,
+		prev_hash TEXT        NOT NULL DEFAULT '',
+		hash      TEXT        NOT NULL
+	);
+	CREATE INDEX audit_log_time ON audit_log (time);
+	CREATE FUNCTION audit_log_append_only() RETURNS trigger LANGUAGE plpgsql AS $$
+	BEGIN
+		RAISE EXCEPTION 'audit_log is append-only';
+	END;
+	$$;
+	CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log
+		FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();`,
+
+	// 8: the hash chains of the logs and their signed checkpoints, read by
+	// the chain/postgres store. Triggers keep both append-only.
+	`CREATE TABLE log_chain (
+		tenant    TEXT        NOT NULL,
+		seq       BIGINT      NOT NULL,
+		log_id    TEXT        NOT NULL,
+		timestamp TIMESTAMPTZ NOT NULL,
+		digest    TEXT        NOT NULL,
+		prev_hash TEXT        NOT NULL DEFAULT '',
+		hash      TEXT        NOT NULL,
+		PRIMARY KEY (tenant, seq)
+	);
+	CREATE INDEX log_chain_timestamp ON log_chain (tenant, timestamp);
+	CREATE TABLE chain_checkpoints (
+		tenant    TEXT        NOT NULL,
+		seq       BIGINT      NOT NULL,
+		hash      TEXT        NOT NULL,
+		time      TIMESTAMPTZ NOT NULL,
+		key_id    TEXT        NOT NULL,
+		signature BYTEA       NOT NULL,
+		PRIMARY KEY (tenant, seq)
+	);
+	CREATE FUNCTION chain_append_only() RETURNS trigger LANGUAGE plpgsql AS $$
+	BEGIN
+		RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+	END;
+	$$;
+	CREATE TRIGGER log_chain_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON log_chain
+		FOR EACH STATEMENT EXECUTE FUNCTION chain_append_only();
+	CREATE TRIGGER chain_checkpoints_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON chain_checkpoints
+		FOR EACH STATEMENT EXECUTE FUNCTION chain_append_only();`,
+
+	// 9: signed tombstones of the chained logs erased, redacted or expired
+	// on purpose; expired_before is only set for retention.
+	`CREATE TABLE chain_tombstones (
+		tenant         TEXT        NOT NULL,
+		reason         TEXT        NOT NULL,
+		log_ids        TEXT[]      NOT NULL DEFAULT '{}',
+		expired_before TIMESTAMPTZ,
+		time           TIMESTAMPTZ NOT NULL,
+		key_id         TEXT        NOT NULL,
+		signature      BYTEA       NOT NULL
+	);
+	CREATE INDEX chain_tombstones_tenant ON chain_tombstones (tenant);
+	CREATE TRIGGER chain_tombstones_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON chain_tombstones
+		FOR EACH STATEMENT EXECUTE FUNCTION chain_append_only();`,
+
+	// 10: the audit entry recorded when a call started, on the entry
+	// recording how it ended. The unique prev_hash refuses a second entry
+	// linking to the same one; entries already stored are left out, as
+	// servers sharing the trail may have forked it before.
+	`ALTER TABLE audit_log ADD COLUMN pending_id TEXT NOT NULL DEFAULT '';
+	DO $$
+	BEGIN
+		EXECUTE format('CREATE UNIQUE INDEX audit_log_prev_hash ON audit_log (prev_hash) WHERE seq > %s',
+			(SELECT COALESCE(MAX(seq), 0) FROM audit_log));
+	END;
+	$$;`,
+
+	// 11: a unique index on id in every partition of logs. Unique indexes
+	// of logs itself must include the partition key, as its primary key
+	// does, which would take a log written again with another timestamp of
+	// the same day. Partitions made later get theirs from createPartition.
+	`DO $$
+	DECLARE
+		name TEXT;
+	BEGIN
+		FOR name IN
+			SELECT c.relname FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid
+			WHERE i.inhparent = 'logs'::regclass
+		LOOP
+			EXECUTE format('CREATE UNIQUE INDEX IF NOT EXISTS %I ON %I (id)', name || '_id', name);
+		END LOOP;
+	END;
+	$$;`,
+}
+
+func migrate(ctx context.Context, pool *pgxpool.Pool) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLock); err != nil {
+		return fmt.Errorf("locking schema: %w", err)
+	}
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLock)
+
+	_, err = conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER     PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return fmt.Errorf("creating schema_migrations: %w", err)
+	}
+
+	var current int
+	err = conn.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current)
+	if err != nil {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+
+	if current > len(migrations) {
+		return fmt.Errorf("schema version %d is newer than this binary (%d)", current, len(migrations))
+	}
+
+	for i := current; i < len(migrations); i++ {
+		version := i + 1
+
+		tx, err := conn.Begin(ctx)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, migrations[i]); err != nil {
+			tx.Rollback(ctx)
+			return fmt.Errorf("applying migration %d: %w", version, err)
+		}
+
+		if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, version); err != nil {
+			tx.Rollback(ctx)
+			return fmt.Errorf("recording migration %d: %w", version, err)
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("committing migration %d: %w", version, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,216 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	partitionPrefix = "logs_p"
+	partitionLayout = "20060102"
+	day             = 24 * time.Hour
+
+	// SQLSTATE codes.
+	duplicateTable  = "42P07"
+	checkViolation  = "23514"
+	uniqueViolation = "23505"
+	undefinedTable  = "42P01"
+)
+
+// partition is one daily partition of logs, holding [day, day+24h).
+type partition struct {
+	name string
+	day  time.Time
+}
+
+func (p partition) end() time.Time {
+	return p.day.Add(day)
+}
+
+func partitionOf(t time.Time) partition {
+	d := t.UTC().Truncate(day)
+	return partition{name: partitionPrefix + d.Format(partitionLayout), day: d}
+}
+
+// ensurePartitions creates the partitions holding times that are not known
+// to exist yet.
+func (s *Store) ensurePartitions(ctx context.Context, times ...time.Time) error {
+	for _, t := range times {
+		p := partitionOf(t)
+
+		s.mu.RLock()
+		known := s.partitions[p.day]
+		s.mu.RUnlock()
+		if known {
+			continue
+		}
+
+		if err := s.createPartition(ctx, p); err != nil {
+			return fmt.Errorf("creating partition %s: %w", p.name, err)
+		}
+
+		s.mu.Lock()
+		s.partitions[p.day] = true
+		s.mu.Unlock()
+	}
+
+	return nil
+}
+
+// createPartition creates the partition p along with its unique index on
+// id. PostgreSQL only enforces unique indexes of a partitioned table that
+// include the partition key, so each partition holds its own.
+func (s *Store) createPartition(ctx context.Context, p partition) error {
+	sql := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s PARTITION OF logs FOR VALUES FROM ('%s') TO ('%s')`,
+		pgx.Identifier{p.name}.Sanitize(),
+		p.day.Format(time.RFC3339),
+		p.end().Format(time.RFC3339),
+	)
+
+	// Another instance may win the race between IF NOT EXISTS and the create.
+	if _, err := s.pool.Exec(ctx, sql); err != nil && pgCode(err) != duplicateTable {
+		return err
+	}
+
+	sql = fmt.Sprintf(`CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s (id)`,
+		pgx.Identifier{p.name + "_id"}.Sanitize(),
+		pgx.Identifier{p.name}.Sanitize(),
+	)
+	if _, err := s.pool.Exec(ctx, sql); err != nil && pgCode(err) != duplicateTable {
+		return err
+	}
+	return nil
+}
+
+// loadPartitions fills the partition cache from the catalog.
+func (s *Store) loadPartitions(ctx context.Context) error {
+	partitions, err := s.listPartitions(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.partitions = make(map[time.Time]bool, len(partitions))
+	for _, p := range partitions {
+		s.partitions[p.day] = true
+	}
+
+	return nil
+}
+
+// forgetPartitions clears the partition cache, after another instance
+// dropped a partition this one still knew about.
+func (s *Store) forgetPartitions() {
+	s.mu.Lock()
+	s.partitions = make(map[time.Time]bool)
+	s.mu.Unlock()
+}
+
+// listPartitions returns the partitions of logs in the catalog, oldest first.
+// Tables not following the naming scheme are ignored.
+func (s *Store) listPartitions(ctx context.Context) ([]partition, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT c.relname
+		FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'logs'::regclass
+		ORDER BY c.relname`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var partitions []partition
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		if len(name) != len(partitionPrefix)+len(partitionLayout) {
+			continue
+		}
+		d, err := time.Parse(partitionLayout, name[len(partitionPrefix):])
+		if err != nil {
+			continue
+		}
+		partitions = append(partitions, partition{name: name, day: d})
+	}
+
+	return partitions, rows.Err()
+}
+
+// dropPartition detaches and drops p. Detaching concurrently only waits for
+// the queries already reading p instead of blocking every query on logs.
+func (s *Store) dropPartition(ctx context.Context, p partition) error {
+	name := pgx.Identifier{p.name}.Sanitize()
+
+	_, err := s.pool.Exec(ctx, `ALTER TABLE logs DETACH PARTITION `+name+` CONCURRENTLY`)
+	if err != nil && pgCode(err) != undefinedTable {
+		return fmt.Errorf("detaching %s: %w", p.name, err)
+	}
+
+	_, err = s.pool.Exec(ctx, `DROP TABLE IF EXISTS `+name)
+	if err != nil {
+		return fmt.Errorf("dropping %s: %w", p.name, err)
+	}
+
+	s.mu.Lock()
+	delete(s.partitions, p.day)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// partitionIDs adds the IDs of the logs in p to ids, by tenant.
+func (s *Store) partitionIDs(ctx context.Context, p partition, ids map[string][]ulid.ULID) error {
+	rows, err := s.pool.Query(ctx, `SELECT tenant, id FROM `+pgx.Identifier{p.name}.Sanitize())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tenant, id string
+		if err := rows.Scan(&tenant, &id); err != nil {
+			return err
+		}
+		if parsed, err := ulid.Parse(id); err == nil {
+			ids[tenant] = append(ids[tenant], parsed)
+		}
+	}
+
+	return rows.Err()
+}
+
+// partitionSize returns the number of logs in p and its size on disk,
+// indexes included.
+func (s *Store) partitionSize(ctx context.Context, p partition) (int64, int64, error) {
+	name := pgx.Identifier{p.name}.Sanitize()
+
+	var count, size int64
+	err := s.pool.QueryRow(ctx,
+		`SELECT (SELECT COUNT(*) FROM `+name+`), pg_total_relation_size($1::regclass)`,
+		p.name,
+	).Scan(&count, &size)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return count, size, nil
+}
+
+func pgCode(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
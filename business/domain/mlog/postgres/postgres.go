@@ -0,0 +1,420 @@
+// Package postgres implements mlog.Store on PostgreSQL 14 or newer.
+//
+// Logs live in a table partitioned by day on their timestamp, so queries
+// over a time range only touch the matching partitions and retention drops
+// whole partitions instead of deleting rows. Metadata is a JSONB column with
+// a GIN index. Batches are ingested with COPY, and exports and streams read
+// through server-side cursors. The schema is created and upgraded by
+// migrations on startup.
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/foundation/blob"
+	"github.com/felipecooper/log-horizon/foundation/compress"
+	"github.com/felipecooper/log-horizon/foundation/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	compressionThreshold = 100
+	defaultPageSize      = 50
+)
+
+type Config struct {
+	URL              string
+	Compression      compress.Algorithm
+	CompressionLevel int
+	ExportPath       string
+	ExportBucket     blob.Bucket
+}
+
+type Store struct {
+	log        logger.Logger
+	pool       *pgxpool.Pool
+	compressor compress.Compressor
+	exports    blob.Bucket
+
+	// mu guards partitions, the days known to have a partition.
+	mu         sync.RWMutex
+	partitions map[time.Time]bool
+}
+
+func NewStore(ctx context.Context, log logger.Logger, cfg Config) (*Store, error) {
+	var compressor compress.Compressor
+	switch cfg.Compression {
+	case compress.None:
+	case "":
+		compressor, _ = compress.NewGzipCompressor(compress.DefaultLevel)
+	default:
+		c, err := compress.New(cfg.Compression, cfg.CompressionLevel)
+		if err != nil {
+			return nil, fmt.Errorf("creating compressor: %w", err)
+		}
+		compressor = c
+	}
+
+	pool, err := pgxpool.New(ctx, cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to PostgreSQL: %w", err)
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("pinging PostgreSQL: %w", err)
+	}
+
+	if err := migrate(ctx, pool); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("migrating schema: %w", err)
+	}
+
+	exports := cfg.ExportBucket
+	if exports == nil {
+		exports = blob.NewLocal(cfg.ExportPath)
+	}
+
+	s := Store{
+		log:        log,
+		pool:       pool,
+		compressor: compressor,
+		exports:    exports,
+	}
+
+	if err := s.loadPartitions(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("loading partitions: %w", err)
+	}
+
+	// Create today's and tomorrow's partitions up front so writes around
+	// midnight do not wait on DDL.
+	now := time.Now()
+	if err := s.ensurePartitions(ctx, now, now.Add(day)); err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+func (s *Store) Close() {
+	s.pool.Close()
+}
+
+// Pool returns the connection pool of the store, for stores of other domains
+// that keep their tables in the same database.
+func (s *Store) Pool() *pgxpool.Pool {
+	return s.pool
+}
+
+// row is a log ready to be stored.
+type row struct {
+	log          *mlog.Log
+	message      []byte
+	algorithm    compress.Algorithm
+	compressedAt *time.Time
+}
+
+// prepare compresses the message of log when that actually saves space.
+func (s *Store) prepare(ctx context.Context, log *mlog.Log) row {
+	r := row{log: log, message: []byte(log.Message)}
+
+	if s.compressor == nil || len(r.message) <= compressionThreshold {
+		return r
+	}
+
+	compressed, err := s.compressor.Compress(r.message)
+	if err != nil {
+		s.log.Error(ctx, "failed to compress log message", "error", err)
+		return r
+	}
+
+	if len(compressed) >= len(r.message) {
+		return r
+	}
+
+	now := time.Now()
+	r.message = compressed
+	r.algorithm = s.compressor.Algorithm()
+	r.compressedAt = &now
+
+	return r
+}
+
+func (r row) values() []any {
+	metadata := r.log.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+
+	return []any{
+		r.log.ID.String(),
+		r.log.Tenant,
+		r.log.Timestamp,
+		string(r.log.Level),
+		r.message,
+		len(r.log.Message),
+		string(r.algorithm),
+		r.compressedAt,
+		metadata,
+	}
+}
+
+// applied reports the compression of r back on its log.
+func (r row) applied() {
+	if r.algorithm != "" {
+		r.log.Compressed = true
+		r.log.CompressedAt = *r.compressedAt
+		r.log.Compression = string(r.algorithm)
+	}
+}
+
+var insertColumns = []string{"id", "tenant", "timestamp", "level", "message", "raw_size", "algorithm", "compressed_at", "metadata"}
+
+// insertLog stores a log unless one with its ID is already stored.
+const insertLog = `INSERT INTO logs (id, tenant, timestamp, level, message, raw_size, algorithm, compressed_at, metadata)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	ON CONFLICT DO NOTHING`
+
+// Write stores log. A log already stored, as replayed again by the spool, is
+// kept as is.
+func (s *Store) Write(ctx context.Context, log *mlog.Log) error {
+	r := s.prepare(ctx, log)
+
+	err := s.withPartitions(ctx, []time.Time{log.Timestamp}, func() error {
+		_, err := s.pool.Exec(ctx, insertLog, r.values()...)
+		return err
+	})
+	if err != nil {
+		s.log.Error(ctx, "failed to insert log in PostgreSQL", "error", err)
+		return err
+	}
+
+	r.applied()
+	return nil
+}
+
+// WriteBatch ingests logs with a single COPY, which is far cheaper than one
+// INSERT per log. COPY cannot skip logs already stored, so a batch holding
+// one, as replayed again by the spool, is inserted row by row instead. The
+// batch is stored entirely or not at all.
+func (s *Store) WriteBatch(ctx context.Context, logs []*mlog.Log) error {
+	rows := make([]row, len(logs))
+	values := make([][]any, len(logs))
+	times := make([]time.Time, len(logs))
+	for i, log := range logs {
+		rows[i] = s.prepare(ctx, log)
+		values[i] = rows[i].values()
+		times[i] = log.Timestamp
+	}
+
+	err := s.withPartitions(ctx, times, func() error {
+		_, err := s.pool.CopyFrom(ctx, pgx.Identifier{"logs"}, insertColumns, pgx.CopyFromRows(values))
+		if pgCode(err) == uniqueViolation {
+			return s.insertBatch(ctx, values)
+		}
+		return err
+	})
+	if err != nil {
+		s.log.Error(ctx, "failed to copy logs into PostgreSQL", "error", err, "logs", len(logs))
+		return err
+	}
+
+	for _, r := range rows {
+		r.applied()
+	}
+	return nil
+}
+
+// insertBatch inserts rows in one transaction, keeping the logs already
+// stored as they are.
+func (s *Store) insertBatch(ctx context.Context, rows [][]any) error {
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		batch := &pgx.Batch{}
+		for _, values := range rows {
+			batch.Queue(insertLog, values...)
+		}
+		return tx.SendBatch(ctx, batch).Close()
+	})
+}
+
+// withPartitions runs insert once the partitions for times exist. When a
+// partition was dropped behind the cache's back the insert finds no
+// partition for its rows, so the cache is reloaded and the insert retried.
+func (s *Store) withPartitions(ctx context.Context, times []time.Time, insert func() error) error {
+	if err := s.ensurePartitions(ctx, times...); err != nil {
+		return err
+	}
+
+	err := insert()
+	if pgCode(err) != checkViolation {
+		return err
+	}
+
+	s.forgetPartitions()
+	if err := s.ensurePartitions(ctx, times...); err != nil {
+		return err
+	}
+	return insert()
+}
+
+func (s *Store) Search(ctx context.Context, criteria mlog.SearchCriteria) (mlog.SearchResult, error) {
+	w := buildWhere(criteria)
+
+	pageSize := criteria.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	query := `SELECT ` + logColumns + ` FROM logs` + w.sql() +
+		` ORDER BY timestamp DESC, id DESC LIMIT ` + w.arg(pageSize) + ` OFFSET ` + w.arg(criteria.Page*pageSize)
+
+	rows, err := s.pool.Query(ctx, query, w.args...)
+	if err != nil {
+		return mlog.SearchResult{}, err
+	}
+
+	var logs []mlog.Log
+	for rows.Next() {
+		log, err := s.scanLog(ctx, rows)
+		if err != nil {
+			rows.Close()
+			return mlog.SearchResult{}, err
+		}
+		logs = append(logs, log)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return mlog.SearchResult{}, err
+	}
+
+	totalCount, err := s.Count(ctx, criteria)
+	if err != nil {
+		s.log.Error(ctx, "failed to count logs", "error", err)
+	}
+
+	hasMore := (criteria.Page+1)*pageSize < totalCount
+	nextPage := criteria.Page + 1
+	if !hasMore {
+		nextPage = criteria.Page
+	}
+
+	return mlog.SearchResult{
+		Logs:     logs,
+		Total:    totalCount,
+		HasMore:  hasMore,
+		NextPage: nextPage,
+	}, nil
+}
+
+func (s *Store) Count(ctx context.Context, criteria mlog.SearchCriteria) (int, error) {
+	w := buildWhere(criteria)
+
+	var count int
+	if err := s.pool.QueryRow(ctx, `SELECT COUNT(*) FROM logs`+w.sql(), w.args...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// ExportToFile reads the matching logs through a server-side cursor and
+// writes them as they arrive.
+func (s *Store) ExportToFile(ctx context.Context, criteria mlog.SearchCriteria) (string, int64, error) {
+	filename := mlog.ExportFilename(criteria, time.Now())
+
+	file, err := s.exports.Create(ctx, filename)
+	if err != nil {
+		return "", 0, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			blob.Abort(file)
+		}
+	}()
+
+	var size int64
+	err = s.Stream(ctx, criteria, func(log mlog.Log) error {
+		line := fmt.Sprintf("[%s] [%s] %s\n", log.Timestamp.Format(time.RFC3339), log.Level, log.Message)
+		n, err := io.WriteString(file, line)
+		size += int64(n)
+		return err
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	if err := file.Close(); err != nil {
+		return "", 0, err
+	}
+	committed = true
+
+	fileURL, err := s.exports.URL(ctx, filename)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return fileURL, size, nil
+}
+
+const logColumns = `id, tenant, timestamp, level, message, algorithm, compressed_at, metadata`
+
+// scanLog decodes a row selected with logColumns, decompressing the message.
+func (s *Store) scanLog(ctx context.Context, rows pgx.Rows) (mlog.Log, error) {
+	var (
+		id           string
+		tenant       string
+		timestamp    time.Time
+		level        string
+		message      []byte
+		algorithm    string
+		compressedAt *time.Time
+		metadata     map[string]string
+	)
+	if err := rows.Scan(&id, &tenant, &timestamp, &level, &message, &algorithm, &compressedAt, &metadata); err != nil {
+		return mlog.Log{}, err
+	}
+
+	parsed, err := ulid.Parse(id)
+	if err != nil {
+		return mlog.Log{}, fmt.Errorf("parsing id %q: %w", id, err)
+	}
+
+	if len(metadata) == 0 {
+		metadata = nil
+	}
+
+	log := mlog.Log{
+		ID:        parsed,
+		Tenant:    tenant,
+		Message:   string(message),
+		Timestamp: timestamp.UTC(),
+		Level:     mlog.Level(level),
+		Metadata:  metadata,
+	}
+
+	if algorithm != "" {
+		log.Compressed = true
+		log.Compression = algorithm
+		if compressedAt != nil {
+			log.CompressedAt = compressedAt.UTC()
+		}
+
+		decompressed, err := compress.Decompress(compress.Algorithm(algorithm), message)
+		if err != nil {
+			s.log.Error(ctx, "failed to decompress log message", "error", err, "id", id)
+		} else {
+			log.Message = string(decompressed)
+		}
+	}
+
+	return log, nil
+}
@@ -0,0 +1,166 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/foundation/compress"
+	"github.com/jackc/pgx/v5"
+	"github.com/oklog/ulid/v2"
+)
+
+// testURLEnv names the PostgreSQL the integration tests run against. They
+// are skipped when it is not set.
+const testURLEnv = "POSTGRES_TEST_URL"
+
+type nopLogger struct{}
+
+func (nopLogger) Info(context.Context, string, ...interface{})  {}
+func (nopLogger) Error(context.Context, string, ...interface{}) {}
+
+// newTestStore returns a store on a schema of its own, dropped when the
+// test ends.
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	base := os.Getenv(testURLEnv)
+	if base == "" {
+		t.Skipf("%s is not set", testURLEnv)
+	}
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, base)
+	if err != nil {
+		t.Fatalf("connecting: %v", err)
+	}
+	defer conn.Close(ctx)
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if _, err := conn.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("creating schema: %v", err)
+	}
+	t.Cleanup(func() {
+		conn, err := pgx.Connect(ctx, base)
+		if err != nil {
+			t.Errorf("connecting: %v", err)
+			return
+		}
+		defer conn.Close(ctx)
+		if _, err := conn.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+			t.Errorf("dropping schema: %v", err)
+		}
+	})
+
+	u, err := url.Parse(base)
+	if err != nil {
+		t.Fatalf("parsing %s: %v", testURLEnv, err)
+	}
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+
+	s, err := NewStore(ctx, nopLogger{}, Config{URL: u.String(), Compression: compress.None})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func newTestLog(message string) *mlog.Log {
+	return &mlog.Log{
+		ID:        ulid.Make(),
+		Message:   message,
+		Timestamp: time.Now().UTC(),
+		Level:     mlog.Info,
+		Metadata:  map[string]string{"service": "test"},
+	}
+}
+
+func countLogs(t *testing.T, s *Store) int {
+	t.Helper()
+
+	n, err := s.Count(context.Background(), mlog.SearchCriteria{AllTenants: true})
+	if err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	return n
+}
+
+func TestWriteKeepsStoredLog(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	log := newTestLog("hello")
+	if err := s.Write(ctx, log); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	again := *log
+	again.Message = "replayed"
+	if err := s.Write(ctx, &again); err != nil {
+		t.Fatalf("Write again: %v", err)
+	}
+
+	if n := countLogs(t, s); n != 1 {
+		t.Fatalf("stored logs = %d, want 1", n)
+	}
+
+	res, err := s.Search(ctx, mlog.SearchCriteria{AllTenants: true})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(res.Logs) != 1 || res.Logs[0].Message != "hello" {
+		t.Fatalf("logs = %+v, want the first write", res.Logs)
+	}
+}
+
+func TestWriteRefusesIDOfStoredLog(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	log := newTestLog("hello")
+	if err := s.Write(ctx, log); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	// The same ID with another timestamp of the same day is not a new log.
+	again := *log
+	again.Timestamp = log.Timestamp.Add(-time.Second)
+	if partitionOf(again.Timestamp).day != partitionOf(log.Timestamp).day {
+		again.Timestamp = log.Timestamp.Add(time.Second)
+	}
+	if err := s.Write(ctx, &again); err != nil {
+		t.Fatalf("Write again: %v", err)
+	}
+
+	if n := countLogs(t, s); n != 1 {
+		t.Fatalf("stored logs = %d, want 1", n)
+	}
+}
+
+func TestWriteBatchSkipsStoredLogs(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	stored := newTestLog("stored")
+	if err := s.Write(ctx, stored); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	replayed := *stored
+	batch := []*mlog.Log{newTestLog("first"), &replayed, newTestLog("second")}
+	if err := s.WriteBatch(ctx, batch); err != nil {
+		t.Fatalf("WriteBatch: %v", err)
+	}
+
+	if n := countLogs(t, s); n != 3 {
+		t.Fatalf("stored logs = %d, want 3", n)
+	}
+}
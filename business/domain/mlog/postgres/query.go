@@ -0,0 +1,242 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/jackc/pgx/v5"
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	cursorBatchSize = 1000
+	deleteBatchSize = 1000
+)
+
+// where accumulates the conditions and positional arguments of a query.
+type where struct {
+	conds []string
+	args  []any
+}
+
+// arg adds v to the arguments and returns its placeholder.
+func (w *where) arg(v any) string {
+	w.args = append(w.args, v)
+	return "$" + strconv.Itoa(len(w.args))
+}
+
+func (w *where) add(cond string) {
+	w.conds = append(w.conds, cond)
+}
+
+func (w *where) sql() string {
+	if len(w.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(w.conds, " AND ")
+}
+
+// buildWhere translates criteria. Time bounds let the planner prune
+// partitions and metadata filters use the GIN index through @>.
+func buildWhere(criteria mlog.SearchCriteria) *where {
+	w := &where{}
+
+	if !criteria.AllTenants {
+		w.add("tenant = " + w.arg(criteria.Tenant))
+	}
+
+	if !criteria.TimeRange.StartTime.IsZero() {
+		w.add("timestamp >= " + w.arg(criteria.TimeRange.StartTime))
+	}
+	if !criteria.TimeRange.EndTime.IsZero() {
+		w.add("timestamp <= " + w.arg(criteria.TimeRange.EndTime))
+	}
+
+	if criteria.Level != "" {
+		w.add("level = " + w.arg(string(criteria.Level)))
+	}
+
+	if len(criteria.Levels) > 0 {
+		levels := make([]string, len(criteria.Levels))
+		for i, level := range criteria.Levels {
+			levels[i] = string(level)
+		}
+		w.add("level = ANY(" + w.arg(levels) + ")")
+	}
+
+	if len(criteria.Metadata) > 0 {
+		w.add("metadata @> " + w.arg(criteria.Metadata))
+	}
+
+	return w
+}
+
+// selector returns the condition matching the logs selected by level and
+// metadata, or "" when it selects every log.
+func (w *where) selector(level mlog.Level, metadata map[string]string) string {
+	var conds []string
+	if level != "" {
+		conds = append(conds, "level = "+w.arg(string(level)))
+	}
+	if len(metadata) > 0 {
+		conds = append(conds, "metadata @> "+w.arg(metadata))
+	}
+	return strings.Join(conds, " AND ")
+}
+
+// Stream walks the logs matching criteria newest first through a
+// server-side cursor.
+func (s *Store) Stream(ctx context.Context, criteria mlog.SearchCriteria, fn func(mlog.Log) error) error {
+	w := buildWhere(criteria)
+	return s.cursor(ctx, `SELECT `+logColumns+` FROM logs`+w.sql()+` ORDER BY timestamp DESC, id DESC`, w.args, fn)
+}
+
+// Scan walks the logs matching criteria oldest first through a server-side
+// cursor.
+func (s *Store) Scan(ctx context.Context, criteria mlog.SearchCriteria, fn func(mlog.Log) error) error {
+	w := buildWhere(criteria)
+	return s.cursor(ctx, `SELECT `+logColumns+` FROM logs`+w.sql()+` ORDER BY timestamp, id`, w.args, fn)
+}
+
+// cursor runs query through a server-side cursor in a read-only transaction,
+// fetching cursorBatchSize rows at a time. Neither the server nor this
+// process ever holds the whole result, however many logs match.
+func (s *Store) cursor(ctx context.Context, query string, args []any, fn func(mlog.Log) error) error {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	if _, err := tx.Exec(ctx, `DECLARE logs_cursor NO SCROLL CURSOR FOR `+query, args...); err != nil {
+		return fmt.Errorf("declaring cursor: %w", err)
+	}
+
+	fetch := fmt.Sprintf(`FETCH FORWARD %d FROM logs_cursor`, cursorBatchSize)
+
+	for {
+		rows, err := tx.Query(ctx, fetch)
+		if err != nil {
+			return fmt.Errorf("fetching logs: %w", err)
+		}
+
+		logs := make([]mlog.Log, 0, cursorBatchSize)
+		for rows.Next() {
+			log, err := s.scanLog(ctx, rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			logs = append(logs, log)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("fetching logs: %w", err)
+		}
+
+		for _, log := range logs {
+			if err := fn(log); err != nil {
+				return err
+			}
+		}
+
+		if len(logs) < cursorBatchSize {
+			return nil
+		}
+	}
+}
+
+// Prune deletes the logs of ids inside tr in batches. Partitions are left in
+// place, even once empty, since a log may still reach them; retention drops
+// them when they expire.
+func (s *Store) Prune(ctx context.Context, tr mlog.TimeRange, ids []ulid.ULID) (int64, error) {
+	var deleted int64
+	for start := 0; start < len(ids); start += deleteBatchSize {
+		end := min(start+deleteBatchSize, len(ids))
+
+		batch := make([]string, 0, end-start)
+		for _, id := range ids[start:end] {
+			batch = append(batch, id.String())
+		}
+
+		w := buildWhere(mlog.SearchCriteria{AllTenants: true, TimeRange: tr})
+		w.add("id = ANY(" + w.arg(batch) + ")")
+
+		n, _, err := s.deleteBatched(ctx, w, nil)
+		deleted += n
+		if err != nil {
+			return deleted, fmt.Errorf("pruning logs: %w", err)
+		}
+	}
+
+	return deleted, nil
+}
+
+// excludePartitions keeps w away from the ranges of partitions that are
+// handled as a whole.
+func excludePartitions(w *where, partitions []partition) {
+	for _, p := range partitions {
+		w.add("NOT (timestamp >= " + w.arg(p.day) + " AND timestamp < " + w.arg(p.end()) + ")")
+	}
+}
+
+// deleteBatched deletes the logs matching w in batches, so no single
+// statement holds row locks on a whole backlog. It returns how many logs
+// were deleted and the size of their stored messages, and adds their IDs to
+// removed by tenant unless it is nil.
+func (s *Store) deleteBatched(ctx context.Context, w *where, removed map[string][]ulid.ULID) (int64, int64, error) {
+	query := `DELETE FROM logs WHERE (timestamp, id) IN (
+		SELECT timestamp, id FROM logs` + w.sql() + ` LIMIT ` + strconv.Itoa(deleteBatchSize) + `
+	) RETURNING tenant, id, octet_length(message)`
+
+	var deleted, size int64
+	for {
+		rows, err := s.pool.Query(ctx, query, w.args...)
+		if err != nil {
+			return deleted, size, err
+		}
+
+		var n int64
+		for rows.Next() {
+			var (
+				tenant, id string
+				length     int64
+			)
+			if err := rows.Scan(&tenant, &id, &length); err != nil {
+				rows.Close()
+				return deleted, size, err
+			}
+			n++
+			size += length
+
+			if removed != nil {
+				if parsed, err := ulid.Parse(id); err == nil {
+					removed[tenant] = append(removed[tenant], parsed)
+				}
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return deleted, size, err
+		}
+
+		deleted += n
+		if n < deleteBatchSize {
+			return deleted, size, nil
+		}
+	}
+}
+
+// measure returns how many logs match w and the size of their stored
+// messages.
+func (s *Store) measure(ctx context.Context, w *where) (int64, int64, error) {
+	var count, size int64
+	err := s.pool.QueryRow(ctx,
+		`SELECT COUNT(*), COALESCE(SUM(octet_length(message)), 0) FROM logs`+w.sql(),
+		w.args...,
+	).Scan(&count, &size)
+	return count, size, err
+}
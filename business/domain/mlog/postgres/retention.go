@@ -0,0 +1,198 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/oklog/ulid/v2"
+)
+
+func (s *Store) RetentionPolicies(ctx context.Context) ([]mlog.RetentionPolicy, error) {
+	rows, err := s.pool.Query(ctx, `SELECT name, level, metadata, max_age, updated_at FROM log_retention_policies ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []mlog.RetentionPolicy
+	for rows.Next() {
+		var (
+			p      mlog.RetentionPolicy
+			level  string
+			maxAge int64
+		)
+		if err := rows.Scan(&p.Name, &level, &p.Metadata, &maxAge, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+
+		p.Level = mlog.Level(level)
+		p.MaxAge = time.Duration(maxAge) * time.Second
+		if len(p.Metadata) == 0 {
+			p.Metadata = nil
+		}
+		policies = append(policies, p)
+	}
+
+	return policies, rows.Err()
+}
+
+func (s *Store) SaveRetentionPolicy(ctx context.Context, policy mlog.RetentionPolicy) error {
+	metadata := policy.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO log_retention_policies (name, level, metadata, max_age, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (name) DO UPDATE SET
+			level = EXCLUDED.level,
+			metadata = EXCLUDED.metadata,
+			max_age = EXCLUDED.max_age,
+			updated_at = EXCLUDED.updated_at`,
+		policy.Name, string(policy.Level), metadata, int64(policy.MaxAge/time.Second), policy.UpdatedAt,
+	)
+	return err
+}
+
+func (s *Store) DeleteRetentionPolicy(ctx context.Context, name string) error {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM log_retention_policies WHERE name = $1`, name)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", name, mlog.ErrRetentionPolicyNotFound)
+	}
+
+	return nil
+}
+
+// ApplyRetention enforces the policies. A policy that selects every log and
+// that no longer-lived policy overlaps drops the partitions it fully expired,
+// skipping the days a legal hold reaches, once their logs are listed. Every
+// other expired log is deleted in batches.
+func (s *Store) ApplyRetention(ctx context.Context, policies []mlog.RetentionPolicy, holds []mlog.LegalHold, now time.Time, dryRun bool) ([]mlog.RetentionResult, error) {
+	results := make([]mlog.RetentionResult, 0, len(policies))
+
+	for _, p := range policies {
+		cutoff := now.Add(-p.MaxAge)
+		protected := p.Protected(policies)
+		result := mlog.RetentionResult{Policy: p.Name, DryRun: dryRun}
+		if !dryRun {
+			result.IDs = make(map[string][]ulid.ULID)
+		}
+
+		var dropped []partition
+		if p.Level == "" && len(p.Metadata) == 0 && len(protected) == 0 {
+			partitions, err := s.listPartitions(ctx)
+			if err != nil {
+				return results, fmt.Errorf("listing partitions: %w", err)
+			}
+
+			for _, part := range partitions {
+				if part.end().After(cutoff) || heldDay(part, holds) {
+					continue
+				}
+
+				count, size, err := s.partitionSize(ctx, part)
+				if err != nil {
+					return append(results, result), fmt.Errorf("measuring %s: %w", part.name, err)
+				}
+
+				if !dryRun {
+					ids := make(map[string][]ulid.ULID)
+					if err := s.partitionIDs(ctx, part, ids); err != nil {
+						return append(results, result), fmt.Errorf("listing logs of %s: %w", part.name, err)
+					}
+					if err := s.dropPartition(ctx, part); err != nil {
+						return append(results, result), fmt.Errorf("enforcing policy %s: %w", p.Name, err)
+					}
+					for tenant, deleted := range ids {
+						result.IDs[tenant] = append(result.IDs[tenant], deleted...)
+					}
+					s.log.Info(ctx, "dropped expired partition", "policy", p.Name, "partition", part.name, "logs", count)
+				}
+
+				result.Deleted += count
+				result.Bytes += size
+				dropped = append(dropped, part)
+			}
+		}
+
+		w := retentionWhere(p, protected, holds, cutoff)
+		excludePartitions(w, dropped)
+
+		var (
+			count, size int64
+			err         error
+		)
+		if dryRun {
+			count, size, err = s.measure(ctx, w)
+		} else {
+			count, size, err = s.deleteBatched(ctx, w, result.IDs)
+		}
+		result.Deleted += count
+		result.Bytes += size
+		results = append(results, result)
+		if err != nil {
+			return results, fmt.Errorf("enforcing policy %s: %w", p.Name, err)
+		}
+	}
+
+	return results, nil
+}
+
+// retentionWhere matches the logs p expired, minus those kept by a
+// longer-lived policy or a legal hold.
+func retentionWhere(p mlog.RetentionPolicy, protected []mlog.RetentionPolicy, holds []mlog.LegalHold, cutoff time.Time) *where {
+	w := &where{}
+
+	if sel := w.selector(p.Level, p.Metadata); sel != "" {
+		w.add(sel)
+	}
+	w.add("timestamp < " + w.arg(cutoff))
+
+	for _, q := range protected {
+		w.add("NOT (" + orTrue(w.selector(q.Level, q.Metadata)) + ")")
+	}
+
+	for _, h := range holds {
+		conds := []string{orTrue(w.selector(h.Level, h.Metadata))}
+		if !h.TimeRange.StartTime.IsZero() {
+			conds = append(conds, "timestamp >= "+w.arg(h.TimeRange.StartTime))
+		}
+		if !h.TimeRange.EndTime.IsZero() {
+			conds = append(conds, "timestamp <= "+w.arg(h.TimeRange.EndTime))
+		}
+		w.add("NOT (" + strings.Join(conds, " AND ") + ")")
+	}
+
+	return w
+}
+
+func orTrue(cond string) string {
+	if cond == "" {
+		return "TRUE"
+	}
+	return cond
+}
+
+// heldDay reports whether a legal hold may cover logs of p. Holds also
+// filtering on level or metadata still keep the whole partition, which is
+// then enforced row by row.
+func heldDay(p partition, holds []mlog.LegalHold) bool {
+	for _, h := range holds {
+		if !h.TimeRange.StartTime.IsZero() && !h.TimeRange.StartTime.Before(p.end()) {
+			continue
+		}
+		if !h.TimeRange.EndTime.IsZero() && h.TimeRange.EndTime.Before(p.day) {
+			continue
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+)
+
+// Stats aggregates the logs table and reports every partition as a
+// collection, with its table and index sizes from the catalog.
+func (s *Store) Stats(ctx context.Context) (mlog.Stats, error) {
+	var stats mlog.Stats
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT algorithm, COUNT(*), COALESCE(SUM(raw_size), 0), COALESCE(SUM(octet_length(message)), 0)
+		FROM logs GROUP BY algorithm ORDER BY algorithm`)
+	if err != nil {
+		return mlog.Stats{}, fmt.Errorf("aggregating algorithms: %w", err)
+	}
+	for rows.Next() {
+		var a mlog.AlgorithmStats
+		if err := rows.Scan(&a.Algorithm, &a.Count, &a.RawBytes, &a.StoredBytes); err != nil {
+			rows.Close()
+			return mlog.Stats{}, err
+		}
+
+		stats.Total += a.Count
+		stats.RawBytes += a.RawBytes
+		stats.StoredBytes += a.StoredBytes
+
+		if a.Algorithm == "" {
+			stats.Uncompressed += a.Count
+			continue
+		}
+		stats.Compressed += a.Count
+		stats.Algorithms = append(stats.Algorithms, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return mlog.Stats{}, err
+	}
+
+	rows, err = s.pool.Query(ctx, `SELECT level, COUNT(*) FROM logs GROUP BY level ORDER BY level`)
+	if err != nil {
+		return mlog.Stats{}, fmt.Errorf("aggregating levels: %w", err)
+	}
+	for rows.Next() {
+		var (
+			level string
+			count int64
+		)
+		if err := rows.Scan(&level, &count); err != nil {
+			rows.Close()
+			return mlog.Stats{}, err
+		}
+		stats.Levels = append(stats.Levels, mlog.LevelStats{Level: mlog.Level(level), Count: count})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return mlog.Stats{}, err
+	}
+
+	rows, err = s.pool.Query(ctx, `SELECT tenant, COUNT(*) FROM logs GROUP BY tenant ORDER BY tenant`)
+	if err != nil {
+		return mlog.Stats{}, fmt.Errorf("aggregating tenants: %w", err)
+	}
+	for rows.Next() {
+		var t mlog.TenantStats
+		if err := rows.Scan(&t.Tenant, &t.Count); err != nil {
+			rows.Close()
+			return mlog.Stats{}, err
+		}
+		stats.Tenants = append(stats.Tenants, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return mlog.Stats{}, err
+	}
+
+	rows, err = s.pool.Query(ctx, `
+		SELECT date_trunc('day', timestamp AT TIME ZONE 'UTC'), COUNT(*)
+		FROM logs GROUP BY 1 ORDER BY 1`)
+	if err != nil {
+		return mlog.Stats{}, fmt.Errorf("aggregating days: %w", err)
+	}
+	counts := make(map[time.Time]int64)
+	for rows.Next() {
+		var (
+			d     time.Time
+			count int64
+		)
+		if err := rows.Scan(&d, &count); err != nil {
+			rows.Close()
+			return mlog.Stats{}, err
+		}
+		d = time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+		counts[d] = count
+		stats.Days = append(stats.Days, mlog.DayStats{Day: d, Count: count})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return mlog.Stats{}, err
+	}
+
+	collections, err := s.partitionStats(ctx, counts)
+	if err != nil {
+		return mlog.Stats{}, fmt.Errorf("reading partition sizes: %w", err)
+	}
+	stats.Collections = collections
+	stats.ComputedAt = time.Now()
+
+	return stats, nil
+}
+
+// partitionStats reports the size of every partition. counts holds the
+// number of logs per day.
+func (s *Store) partitionStats(ctx context.Context, counts map[time.Time]int64) ([]mlog.CollectionStats, error) {
+	partitions, err := s.listPartitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]int, len(partitions))
+	collections := make([]mlog.CollectionStats, len(partitions))
+	for i, p := range partitions {
+		byName[p.name] = i
+		collections[i] = mlog.CollectionStats{
+			Name:       p.name,
+			Count:      counts[p.day],
+			IndexSizes: map[string]int64{},
+		}
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT t.relname, pg_relation_size(t.oid), pg_table_size(t.oid), ic.relname, pg_relation_size(ic.oid)
+		FROM pg_inherits i
+		JOIN pg_class t ON t.oid = i.inhrelid
+		JOIN pg_index x ON x.indrelid = t.oid
+		JOIN pg_class ic ON ic.oid = x.indexrelid
+		WHERE i.inhparent = 'logs'::regclass`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			table, index         string
+			size, storage, bytes int64
+		)
+		if err := rows.Scan(&table, &size, &storage, &index, &bytes); err != nil {
+			return nil, err
+		}
+
+		i, ok := byName[table]
+		if !ok {
+			continue
+		}
+
+		c := &collections[i]
+		c.Size = size
+		c.StorageSize = storage
+		c.IndexSize += bytes
+		c.IndexSizes[index] = bytes
+	}
+
+	return collections, rows.Err()
+}
+
+var (
+	_ mlog.Store            = (*Store)(nil)
+	_ mlog.BatchWriter      = (*Store)(nil)
+	_ mlog.Streamer         = (*Store)(nil)
+	_ mlog.Scanner          = (*Store)(nil)
+	_ mlog.Pruner           = (*Store)(nil)
+	_ mlog.StatsReporter    = (*Store)(nil)
+	_ mlog.RetentionStore   = (*Store)(nil)
+	_ mlog.IdempotencyStore = (*Store)(nil)
+)
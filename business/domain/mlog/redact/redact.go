@@ -0,0 +1,282 @@
+// Package redact implements an mlog.Redactor finding sensitive data with
+// regular expressions. Built-in rules detect emails, card numbers, JWTs, IP
+// addresses and bearer tokens; custom rules add patterns of their own.
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+)
+
+var (
+	ErrInvalidRule    = errors.New("invalid redaction rule")
+	ErrMissingHashKey = errors.New("hash mode requires a hash key")
+)
+
+// Mode names what is done with the values a rule finds.
+type Mode string
+
+const (
+	// ModeMask replaces the value with "[REDACTED:<rule>]".
+	ModeMask Mode = "mask"
+	// ModeHash replaces the value with "[<rule>:<hash>]", so equal values
+	// can still be correlated.
+	ModeHash Mode = "hash"
+	// ModeDrop removes the value from messages, and the whole entry from
+	// metadata.
+	ModeDrop Mode = "drop"
+)
+
+func (m Mode) IsValid() bool {
+	return m == ModeMask || m == ModeHash || m == ModeDrop
+}
+
+// Rule finds one kind of sensitive data. When Pattern has a capture group,
+// only the text of the first group is redacted, so "password=(\S+)" keeps
+// the "password=" prefix.
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+	Mode    Mode
+	// Valid, when set, discards matches that only look like sensitive data,
+	// such as card numbers failing the Luhn check.
+	Valid func(value string) bool
+}
+
+// Validate checks that r can be applied.
+func (r Rule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("%w: missing name", ErrInvalidRule)
+	}
+	if r.Pattern == nil {
+		return fmt.Errorf("%w: %s: missing pattern", ErrInvalidRule, r.Name)
+	}
+	if !r.Mode.IsValid() {
+		return fmt.Errorf("%w: %s: unknown mode %q", ErrInvalidRule, r.Name, r.Mode)
+	}
+	return nil
+}
+
+// Built-in rule names, in the order they are applied. Bearer tokens come
+// before JWTs, so a JWT sent as a bearer token counts as a bearer token.
+const (
+	Bearer     = "bearer"
+	JWT        = "jwt"
+	Email      = "email"
+	CreditCard = "credit_card"
+	IPv6       = "ipv6"
+	IPv4       = "ipv4"
+)
+
+// Builtins lists the built-in rules in the order they are applied.
+var Builtins = []string{Bearer, JWT, Email, CreditCard, IPv6, IPv4}
+
+// Builtin returns the built-in rule called name.
+func Builtin(name string, mode Mode) (Rule, error) {
+	r := Rule{Name: name, Mode: mode}
+
+	switch name {
+	case Bearer:
+		r.Pattern = regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/-]+=*)`)
+	case JWT:
+		r.Pattern = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	case Email:
+		r.Pattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+	case CreditCard:
+		r.Pattern = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+		r.Valid = luhn
+	case IPv6:
+		// Go patterns cannot look around, so the neighbours of the address
+		// are matched outside the group to keep "Foo::bar" whole.
+		r.Pattern = regexp.MustCompile(`(?i)(?:^|[^0-9a-z:_])((?:[0-9a-f]{0,4}:){2,7}(?:\d{1,3}(?:\.\d{1,3}){3}|[0-9a-f]{0,4}))(?:$|[^0-9a-z:_])`)
+		r.Valid = isIP
+	case IPv4:
+		r.Pattern = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+		r.Valid = isIP
+	default:
+		return Rule{}, fmt.Errorf("%w: unknown built-in rule %q", ErrInvalidRule, name)
+	}
+
+	return r, r.Validate()
+}
+
+// Redactor applies its rules in order to the message and metadata values of
+// each log.
+type Redactor struct {
+	rules   []Rule
+	hashKey []byte
+	counts  []atomic.Int64
+}
+
+// New returns a redactor applying rules in order. Hashed values are keyed
+// with hashKey, so they cannot be guessed by hashing likely values; a rule
+// in hash mode without one is refused.
+func New(rules []Rule, hashKey []byte) (*Redactor, error) {
+	for _, r := range rules {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+		if r.Mode == ModeHash && len(hashKey) == 0 {
+			return nil, fmt.Errorf("%w: rule %s", ErrMissingHashKey, r.Name)
+		}
+	}
+
+	return &Redactor{
+		rules:   rules,
+		hashKey: hashKey,
+		counts:  make([]atomic.Int64, len(rules)),
+	}, nil
+}
+
+func (r *Redactor) Redact(log *mlog.Log) {
+	log.Message, _ = r.redact(log.Message)
+
+	var metadata map[string]string
+	for k, v := range log.Metadata {
+		redacted, drop := r.redact(v)
+		if !drop && redacted == v {
+			continue
+		}
+
+		// The map may belong to the caller, so it is copied before the
+		// first change.
+		if metadata == nil {
+			metadata = make(map[string]string, len(log.Metadata))
+			for k, v := range log.Metadata {
+				metadata[k] = v
+			}
+		}
+
+		if drop {
+			delete(metadata, k)
+		} else {
+			metadata[k] = redacted
+		}
+	}
+	if metadata != nil {
+		log.Metadata = metadata
+	}
+}
+
+func (r *Redactor) Redactions() []mlog.RedactionStats {
+	stats := make([]mlog.RedactionStats, len(r.rules))
+	for i, rule := range r.rules {
+		stats[i] = mlog.RedactionStats{
+			Rule:  rule.Name,
+			Count: r.counts[i].Load(),
+		}
+	}
+	return stats
+}
+
+// redact returns s with every rule applied, and whether a rule in drop mode
+// matched it.
+func (r *Redactor) redact(s string) (string, bool) {
+	var dropped bool
+
+	for i, rule := range r.rules {
+		var (
+			b     strings.Builder
+			last  int
+			count int64
+		)
+
+		// The search resumes where the redacted text ends rather than where
+		// the match does, so a neighbour matched outside the group can
+		// start the next match.
+		for pos := 0; pos < len(s); {
+			m := rule.Pattern.FindStringSubmatchIndex(s[pos:])
+			if m == nil {
+				break
+			}
+
+			start, end := pos+m[0], pos+m[1]
+			if len(m) >= 4 && m[2] >= 0 {
+				start, end = pos+m[2], pos+m[3]
+			}
+			if end > pos {
+				pos = end
+			} else {
+				pos++
+			}
+
+			value := s[start:end]
+			if value == "" || rule.Valid != nil && !rule.Valid(value) {
+				continue
+			}
+
+			b.WriteString(s[last:start])
+			b.WriteString(r.replacement(rule, value))
+			last = end
+			count++
+		}
+		if count == 0 {
+			continue
+		}
+		b.WriteString(s[last:])
+
+		s = b.String()
+		r.counts[i].Add(count)
+		if rule.Mode == ModeDrop {
+			dropped = true
+		}
+	}
+
+	return s, dropped
+}
+
+func (r *Redactor) replacement(rule Rule, value string) string {
+	switch rule.Mode {
+	case ModeHash:
+		mac := hmac.New(sha256.New, r.hashKey)
+		mac.Write([]byte(value))
+		return "[" + rule.Name + ":" + hex.EncodeToString(mac.Sum(nil))[:16] + "]"
+	case ModeDrop:
+		return ""
+	default:
+		return "[REDACTED:" + rule.Name + "]"
+	}
+}
+
+// luhn reports whether the digits of s, a candidate card number, pass the
+// Luhn check.
+func luhn(s string) bool {
+	var (
+		sum    int
+		digits int
+		double bool
+	)
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+		double = !double
+	}
+	return digits >= 13 && digits <= 19 && sum%10 == 0
+}
+
+func isIP(s string) bool {
+	return net.ParseIP(s) != nil
+}
+
+var _ mlog.Redactor = (*Redactor)(nil)
@@ -0,0 +1,25 @@
+package mlog
+
+// Redactor removes sensitive data, such as emails or card numbers, from logs
+// before they are stored.
+type Redactor interface {
+	// Redact rewrites the message and metadata of log in place.
+	Redact(log *Log)
+	// Redactions counts the values each rule has redacted since the process
+	// started.
+	Redactions() []RedactionStats
+}
+
+// RedactionStats counts the values redacted by a rule.
+type RedactionStats struct {
+	Rule  string
+	Count int64
+}
+
+// WithRedactor makes Register pass every log through r before it is
+// written, so sensitive data never reaches the writer, spool or store.
+func WithRedactor(r Redactor) Option {
+	return func(b *Business) {
+		b.redactor = r
+	}
+}
@@ -0,0 +1,96 @@
+package mlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+var ErrRestricted = errors.New("outside the caller's access")
+
+// Restriction limits the logs a caller may read. Business merges it into
+// the criteria of every Query, Count, ExportToFile and Stream made with a
+// context carrying it, so callers cannot widen their queries past it.
+type Restriction struct {
+	// Role names the role the restriction comes from.
+	Role string
+	// Levels the caller may read; empty allows every level.
+	Levels []Level
+	// Metadata pairs every log read must carry.
+	Metadata map[string]string
+	// MaxWindow caps the time range of a query, counted back from its end
+	// time or from now. Zero leaves it open.
+	MaxWindow time.Duration
+}
+
+func (r Restriction) Validate() error {
+	for _, level := range r.Levels {
+		if !level.IsValid() {
+			return fmt.Errorf("%w: %s", ErrInvalidLevel, level)
+		}
+	}
+	if r.MaxWindow < 0 {
+		return errors.New("max window must not be negative")
+	}
+	return nil
+}
+
+type restrictionKey struct{}
+
+// ContextWithRestriction returns a copy of ctx carrying r.
+func ContextWithRestriction(ctx context.Context, r Restriction) context.Context {
+	return context.WithValue(ctx, restrictionKey{}, r)
+}
+
+// RestrictionFrom returns the restriction carried by ctx.
+func RestrictionFrom(ctx context.Context) (Restriction, bool) {
+	r, ok := ctx.Value(restrictionKey{}).(Restriction)
+	return r, ok
+}
+
+// restrict narrows criteria to the restriction of ctx. Criteria asking for
+// a level or a metadata value the restriction excludes fail with
+// ErrRestricted; time ranges wider than its window are shortened.
+func restrict(ctx context.Context, criteria *SearchCriteria, now time.Time) error {
+	r, ok := RestrictionFrom(ctx)
+	if !ok {
+		return nil
+	}
+
+	if len(r.Levels) > 0 {
+		if criteria.Level == "" {
+			criteria.Levels = r.Levels
+		} else if !slices.Contains(r.Levels, criteria.Level) {
+			return fmt.Errorf("%w: level %s", ErrRestricted, criteria.Level)
+		}
+	}
+
+	if len(r.Metadata) > 0 {
+		metadata := maps.Clone(criteria.Metadata)
+		if metadata == nil {
+			metadata = make(map[string]string, len(r.Metadata))
+		}
+		for k, v := range r.Metadata {
+			if got, ok := metadata[k]; ok && got != v {
+				return fmt.Errorf("%w: metadata %s=%s", ErrRestricted, k, got)
+			}
+			metadata[k] = v
+		}
+		criteria.Metadata = metadata
+	}
+
+	if r.MaxWindow > 0 {
+		end := criteria.TimeRange.EndTime
+		if end.IsZero() {
+			end = now
+		}
+		if start := end.Add(-r.MaxWindow); criteria.TimeRange.StartTime.Before(start) {
+			criteria.TimeRange.StartTime = start
+		}
+	}
+
+	return nil
+}
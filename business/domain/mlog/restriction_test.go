@@ -0,0 +1,211 @@
+package mlog
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(context.Context, string, ...interface{})  {}
+func (nopLogger) Error(context.Context, string, ...interface{}) {}
+
+// criteriaStore records the criteria it is queried with.
+type criteriaStore struct {
+	criteria []SearchCriteria
+}
+
+func (s *criteriaStore) Write(context.Context, *Log) error { return nil }
+
+func (s *criteriaStore) Search(_ context.Context, criteria SearchCriteria) (SearchResult, error) {
+	s.criteria = append(s.criteria, criteria)
+	return SearchResult{}, nil
+}
+
+func (s *criteriaStore) Count(_ context.Context, criteria SearchCriteria) (int, error) {
+	s.criteria = append(s.criteria, criteria)
+	return 0, nil
+}
+
+func (s *criteriaStore) ExportToFile(_ context.Context, criteria SearchCriteria) (string, int64, error) {
+	s.criteria = append(s.criteria, criteria)
+	return "", 0, nil
+}
+
+func TestRestrict(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	support := Restriction{
+		Role:      "support",
+		Levels:    []Level{Warn, Error},
+		Metadata:  map[string]string{"service": "api"},
+		MaxWindow: 24 * time.Hour,
+	}
+
+	tests := []struct {
+		name     string
+		r        Restriction
+		criteria SearchCriteria
+		want     SearchCriteria
+		wantErr  bool
+	}{
+		{
+			name:     "open criteria",
+			r:        support,
+			criteria: SearchCriteria{},
+			want: SearchCriteria{
+				Levels:    []Level{Warn, Error},
+				Metadata:  map[string]string{"service": "api"},
+				TimeRange: TimeRange{StartTime: now.Add(-24 * time.Hour)},
+			},
+		},
+		{
+			name:     "allowed level",
+			r:        support,
+			criteria: SearchCriteria{Level: Error},
+			want: SearchCriteria{
+				Level:     Error,
+				Metadata:  map[string]string{"service": "api"},
+				TimeRange: TimeRange{StartTime: now.Add(-24 * time.Hour)},
+			},
+		},
+		{
+			name:     "excluded level",
+			r:        support,
+			criteria: SearchCriteria{Level: Debug},
+			wantErr:  true,
+		},
+		{
+			name:     "other metadata kept",
+			r:        support,
+			criteria: SearchCriteria{Metadata: map[string]string{"region": "eu", "service": "api"}},
+			want: SearchCriteria{
+				Levels:    []Level{Warn, Error},
+				Metadata:  map[string]string{"region": "eu", "service": "api"},
+				TimeRange: TimeRange{StartTime: now.Add(-24 * time.Hour)},
+			},
+		},
+		{
+			name:     "excluded metadata value",
+			r:        support,
+			criteria: SearchCriteria{Metadata: map[string]string{"service": "billing"}},
+			wantErr:  true,
+		},
+		{
+			name: "window counted back from the end time",
+			r:    Restriction{MaxWindow: time.Hour},
+			criteria: SearchCriteria{TimeRange: TimeRange{
+				StartTime: now.Add(-48 * time.Hour),
+				EndTime:   now.Add(-24 * time.Hour),
+			}},
+			want: SearchCriteria{TimeRange: TimeRange{
+				StartTime: now.Add(-25 * time.Hour),
+				EndTime:   now.Add(-24 * time.Hour),
+			}},
+		},
+		{
+			name:     "range inside the window",
+			r:        Restriction{MaxWindow: time.Hour},
+			criteria: SearchCriteria{TimeRange: TimeRange{StartTime: now.Add(-time.Minute)}},
+			want:     SearchCriteria{TimeRange: TimeRange{StartTime: now.Add(-time.Minute)}},
+		},
+		{
+			name:     "role without limits",
+			r:        Restriction{Role: "auditor"},
+			criteria: SearchCriteria{Level: Debug, Metadata: map[string]string{"service": "billing"}},
+			want:     SearchCriteria{Level: Debug, Metadata: map[string]string{"service": "billing"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			criteria := tt.criteria
+			original := maps.Clone(tt.criteria.Metadata)
+
+			err := restrict(ContextWithRestriction(context.Background(), tt.r), &criteria, now)
+			if tt.wantErr {
+				if !errors.Is(err, ErrRestricted) {
+					t.Fatalf("restrict = %v, want %v", err, ErrRestricted)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("restrict: %v", err)
+			}
+
+			if criteria.Level != tt.want.Level || !slices.Equal(criteria.Levels, tt.want.Levels) {
+				t.Errorf("levels = %q %v, want %q %v", criteria.Level, criteria.Levels, tt.want.Level, tt.want.Levels)
+			}
+			if !maps.Equal(criteria.Metadata, tt.want.Metadata) {
+				t.Errorf("metadata = %v, want %v", criteria.Metadata, tt.want.Metadata)
+			}
+			if criteria.TimeRange != tt.want.TimeRange {
+				t.Errorf("time range = %+v, want %+v", criteria.TimeRange, tt.want.TimeRange)
+			}
+			if !maps.Equal(tt.criteria.Metadata, original) {
+				t.Errorf("caller metadata changed to %v", tt.criteria.Metadata)
+			}
+		})
+	}
+}
+
+func TestRestrictWithoutRestriction(t *testing.T) {
+	criteria := SearchCriteria{Level: Debug, Metadata: map[string]string{"service": "billing"}}
+	if err := restrict(context.Background(), &criteria, time.Now()); err != nil {
+		t.Fatalf("restrict: %v", err)
+	}
+	if criteria.Level != Debug || len(criteria.Levels) != 0 || len(criteria.Metadata) != 1 || !criteria.TimeRange.StartTime.IsZero() {
+		t.Errorf("criteria = %+v, want it unchanged", criteria)
+	}
+}
+
+// Business applies the restriction of the caller to queries and exports
+// before they reach the store.
+func TestBusinessRestricts(t *testing.T) {
+	var store criteriaStore
+	b := NewMlog(nopLogger{}, &store)
+
+	ctx := ContextWithRestriction(context.Background(), Restriction{
+		Role:     "support",
+		Levels:   []Level{Error},
+		Metadata: map[string]string{"service": "api"},
+	})
+
+	if _, err := b.Query(ctx, time.Time{}, time.Time{}, "", "", 1, 10); err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if _, _, err := b.ExportToFile(ctx, time.Time{}, time.Time{}, "", ""); err != nil {
+		t.Fatalf("ExportToFile: %v", err)
+	}
+
+	if len(store.criteria) != 2 {
+		t.Fatalf("store queried %d times, want 2", len(store.criteria))
+	}
+	for _, criteria := range store.criteria {
+		if !slices.Equal(criteria.Levels, []Level{Error}) || criteria.Metadata["service"] != "api" {
+			t.Errorf("store criteria = %+v, want them restricted to api errors", criteria)
+		}
+	}
+
+	if _, err := b.Query(ctx, time.Time{}, time.Time{}, Info, "", 1, 10); !errors.Is(err, ErrRestricted) {
+		t.Errorf("Query for info logs = %v, want %v", err, ErrRestricted)
+	}
+	if len(store.criteria) != 2 {
+		t.Errorf("restricted query reached the store")
+	}
+}
+
+func TestRestrictionValidate(t *testing.T) {
+	if err := (Restriction{Levels: []Level{"verbose"}}).Validate(); !errors.Is(err, ErrInvalidLevel) {
+		t.Errorf("Validate unknown level = %v, want %v", err, ErrInvalidLevel)
+	}
+	if err := (Restriction{MaxWindow: -time.Hour}).Validate(); err == nil {
+		t.Errorf("Validate negative window succeeded, want an error")
+	}
+	if err := (Restriction{Levels: []Level{Error}, MaxWindow: time.Hour}).Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
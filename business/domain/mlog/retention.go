@@ -0,0 +1,262 @@
+package mlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+var (
+	ErrInvalidRetentionPolicy  = errors.New("invalid retention policy")
+	ErrRetentionPolicyNotFound = errors.New("retention policy not found")
+)
+
+// RetentionPolicy deletes logs older than MaxAge that match Level and every
+// Metadata pair. Empty fields match everything. When several policies match
+// the same log the longest MaxAge wins, so a rule for service=payments keeps
+// its logs even if a shorter rule for their level exists.
+type RetentionPolicy struct {
+	Name      string
+	Level     Level
+	Metadata  map[string]string
+	MaxAge    time.Duration
+	UpdatedAt time.Time
+}
+
+// RetentionResult reports what a run of a policy removed. For a policy left
+// to a TTL index, Deleted and Bytes are an estimate counted ahead of the
+// store expiring the logs, which misses logs written with old timestamps,
+// and Estimated is set.
+type RetentionResult struct {
+	Policy    string
+	Deleted   int64
+	Bytes     int64
+	DryRun    bool
+	TTL       bool
+	Estimated bool
+	// IDs lists the logs deleted, by tenant. It is not reported to clients,
+	// and stays empty for a TTL policy, whose logs the store expires later.
+	IDs map[string][]ulid.ULID
+}
+
+// RetentionMetrics accumulates what a policy removed since the process
+// started. Estimated is set once a run only estimated it.
+type RetentionMetrics struct {
+	Runs      int64
+	Deleted   int64
+	Bytes     int64
+	LastRun   time.Time
+	Estimated bool
+}
+
+func (p RetentionPolicy) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRetentionPolicy)
+	}
+	if p.MaxAge <= 0 {
+		return fmt.Errorf("%w: max age must be positive", ErrInvalidRetentionPolicy)
+	}
+	if p.Level != "" && !p.Level.IsValid() {
+		return fmt.Errorf("%w: %w", ErrInvalidRetentionPolicy, ErrInvalidLevel)
+	}
+	return nil
+}
+
+// Selects reports whether a log falls under the policy, whatever its age.
+func (p RetentionPolicy) Selects(log Log) bool {
+	if p.Level != "" && log.Level != p.Level {
+		return false
+	}
+	for k, v := range p.Metadata {
+		if log.Metadata[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Overlaps reports whether a log could match both policies.
+func (p RetentionPolicy) Overlaps(other RetentionPolicy) bool {
+	if p.Level != "" && other.Level != "" && p.Level != other.Level {
+		return false
+	}
+	for k, v := range p.Metadata {
+		if ov, ok := other.Metadata[k]; ok && ov != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Protected returns the policies that keep logs for longer than p and may
+// match some of its logs. Those logs must be excluded when enforcing p.
+func (p RetentionPolicy) Protected(policies []RetentionPolicy) []RetentionPolicy {
+	var protected []RetentionPolicy
+	for _, other := range policies {
+		if other.Name != p.Name && other.MaxAge > p.MaxAge && p.Overlaps(other) {
+			protected = append(protected, other)
+		}
+	}
+	return protected
+}
+
+type retentionState struct {
+	mu      sync.Mutex
+	metrics map[string]RetentionMetrics
+}
+
+func (b *Business) retentionStore() (RetentionStore, error) {
+	store, ok := b.store.(RetentionStore)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return store, nil
+}
+
+func (b *Business) RetentionPolicies(ctx context.Context) ([]RetentionPolicy, map[string]RetentionMetrics, error) {
+	store, err := b.retentionStore()
+	if err != nil {
+		return nil, nil, fmt.Errorf("retention policies: %w", err)
+	}
+
+	policies, err := store.RetentionPolicies(ctx)
+	if err != nil {
+		b.logger.Error(ctx, "failed to list retention policies", "error", err)
+		return nil, nil, fmt.Errorf("retention policies: %w", err)
+	}
+
+	b.retention.mu.Lock()
+	metrics := make(map[string]RetentionMetrics, len(b.retention.metrics))
+	for name, m := range b.retention.metrics {
+		metrics[name] = m
+	}
+	b.retention.mu.Unlock()
+
+	return policies, metrics, nil
+}
+
+func (b *Business) SetRetentionPolicy(ctx context.Context, policy RetentionPolicy) (RetentionPolicy, error) {
+	if err := policy.Validate(); err != nil {
+		b.logger.Error(ctx, "invalid retention policy", "error", err)
+		return RetentionPolicy{}, fmt.Errorf("set retention policy: %w", err)
+	}
+
+	store, err := b.retentionStore()
+	if err != nil {
+		return RetentionPolicy{}, fmt.Errorf("set retention policy: %w", err)
+	}
+
+	policy.UpdatedAt = time.Now()
+
+	if err := store.SaveRetentionPolicy(ctx, policy); err != nil {
+		b.logger.Error(ctx, "failed to save retention policy", "error", err)
+		return RetentionPolicy{}, fmt.Errorf("set retention policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+func (b *Business) DeleteRetentionPolicy(ctx context.Context, name string) error {
+	store, err := b.retentionStore()
+	if err != nil {
+		return fmt.Errorf("delete retention policy: %w", err)
+	}
+
+	if err := store.DeleteRetentionPolicy(ctx, name); err != nil {
+		b.logger.Error(ctx, "failed to delete retention policy", "error", err)
+		return fmt.Errorf("delete retention policy: %w", err)
+	}
+
+	return nil
+}
+
+// EnforceRetention applies every stored policy. With dryRun nothing is
+// deleted and the results report what would have been removed.
+func (b *Business) EnforceRetention(ctx context.Context, dryRun bool) ([]RetentionResult, error) {
+	store, err := b.retentionStore()
+	if err != nil {
+		return nil, fmt.Errorf("enforce retention: %w", err)
+	}
+
+	policies, err := store.RetentionPolicies(ctx)
+	if err != nil {
+		b.logger.Error(ctx, "failed to list retention policies", "error", err)
+		return nil, fmt.Errorf("enforce retention: %w", err)
+	}
+
+	if len(policies) == 0 {
+		return nil, nil
+	}
+
+	holds, err := b.activeHolds(ctx)
+	if err != nil {
+		b.logger.Error(ctx, "failed to list legal holds", "error", err)
+		return nil, fmt.Errorf("enforce retention: %w", err)
+	}
+
+	now := time.Now()
+
+	results, err := store.ApplyRetention(ctx, policies, holds, now, dryRun)
+	if err != nil {
+		b.logger.Error(ctx, "failed to apply retention", "error", err)
+
+		// Logs deleted before the failure are gone all the same.
+		if !dryRun {
+			b.recordRetention(ctx, results)
+		}
+		return nil, fmt.Errorf("enforce retention: %w", err)
+	}
+
+	if dryRun {
+		return results, nil
+	}
+
+	b.retention.mu.Lock()
+	if b.retention.metrics == nil {
+		b.retention.metrics = make(map[string]RetentionMetrics)
+	}
+	for _, r := range results {
+		m := b.retention.metrics[r.Policy]
+		m.Runs++
+		m.Deleted += r.Deleted
+		m.Bytes += r.Bytes
+		m.LastRun = now
+		m.Estimated = m.Estimated || r.Estimated
+		b.retention.metrics[r.Policy] = m
+	}
+	b.retention.mu.Unlock()
+
+	if err := b.recordRetention(ctx, results); err != nil {
+		return nil, fmt.Errorf("enforce retention: %w", err)
+	}
+
+	return results, nil
+}
+
+// recordRetention records the logs the policies deleted, once per tenant.
+func (b *Business) recordRetention(ctx context.Context, results []RetentionResult) error {
+	ids := make(map[string][]ulid.ULID)
+	for _, r := range results {
+		for tenant, deleted := range r.IDs {
+			ids[tenant] = append(ids[tenant], deleted...)
+		}
+	}
+
+	tenants := make([]string, 0, len(ids))
+	for tenant := range ids {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+
+	for _, tenant := range tenants {
+		if err := b.recordDeletion(ctx, Deletion{Reason: DeletionRetention, Tenant: tenant, IDs: ids[tenant]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
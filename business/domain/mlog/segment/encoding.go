@@ -0,0 +1,132 @@
+package segment
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+)
+
+// Logs are encoded as:
+//
+//	id (16 bytes) | timestamp (varint, unix nanoseconds) | level | message |
+//	metadata pairs (uvarint) | key | value ...
+//
+// Strings are prefixed by their length as an uvarint. The tenant travels as
+// the first metadata pair under tenantKey, which no client key can spell, so
+// logs written before tenants existed decode unchanged.
+
+const tenantKey = "\x00tenant"
+
+// EncodeLog appends the binary form of log to buf.
+func EncodeLog(buf *bytes.Buffer, log mlog.Log) {
+	buf.Write(log.ID[:])
+	buf.Write(binary.AppendVarint(nil, log.Timestamp.UnixNano()))
+	putString(buf, string(log.Level))
+	putString(buf, log.Message)
+
+	keys := make([]string, 0, len(log.Metadata))
+	for k := range log.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := len(keys)
+	if log.Tenant != "" {
+		pairs++
+	}
+
+	buf.Write(binary.AppendUvarint(nil, uint64(pairs)))
+	if log.Tenant != "" {
+		putString(buf, tenantKey)
+		putString(buf, log.Tenant)
+	}
+	for _, k := range keys {
+		putString(buf, k)
+		putString(buf, log.Metadata[k])
+	}
+}
+
+func putString(buf *bytes.Buffer, s string) {
+	buf.Write(binary.AppendUvarint(nil, uint64(len(s))))
+	buf.WriteString(s)
+}
+
+// DecodeLog reads one log written by EncodeLog.
+func DecodeLog(r *bytes.Reader) (mlog.Log, error) {
+	var log mlog.Log
+
+	if _, err := io.ReadFull(r, log.ID[:]); err != nil {
+		return mlog.Log{}, fmt.Errorf("reading id: %w", err)
+	}
+
+	nanos, err := binary.ReadVarint(r)
+	if err != nil {
+		return mlog.Log{}, fmt.Errorf("reading timestamp: %w", err)
+	}
+	log.Timestamp = time.Unix(0, nanos).UTC()
+
+	level, err := getString(r)
+	if err != nil {
+		return mlog.Log{}, fmt.Errorf("reading level: %w", err)
+	}
+	log.Level = mlog.Level(level)
+
+	if log.Message, err = getString(r); err != nil {
+		return mlog.Log{}, fmt.Errorf("reading message: %w", err)
+	}
+
+	pairs, err := binary.ReadUvarint(r)
+	if err != nil {
+		return mlog.Log{}, fmt.Errorf("reading metadata: %w", err)
+	}
+
+	if pairs > 0 {
+		if pairs > uint64(r.Len()) {
+			return mlog.Log{}, errors.New("metadata count out of range")
+		}
+
+		log.Metadata = make(map[string]string, pairs)
+		for i := uint64(0); i < pairs; i++ {
+			k, err := getString(r)
+			if err != nil {
+				return mlog.Log{}, fmt.Errorf("reading metadata key: %w", err)
+			}
+			v, err := getString(r)
+			if err != nil {
+				return mlog.Log{}, fmt.Errorf("reading metadata value: %w", err)
+			}
+			if k == tenantKey {
+				log.Tenant = v
+				continue
+			}
+			log.Metadata[k] = v
+		}
+		if len(log.Metadata) == 0 {
+			log.Metadata = nil
+		}
+	}
+
+	return log, nil
+}
+
+func getString(r *bytes.Reader) (string, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", err
+	}
+	if n > uint64(r.Len()) {
+		return "", io.ErrUnexpectedEOF
+	}
+
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,493 @@
+// Package segment implements an immutable, block compressed file format for
+// logs. A segment holds logs in ascending timestamp order split into blocks;
+// a footer indexes every block with its time range and level counts so
+// readers can skip blocks, or count them, without decompressing.
+//
+//	magic | block... | footer (JSON) | footer length | footer crc32 | magic
+//
+// Every block is stored as crc32 | length | compressed data.
+package segment
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/foundation/compress"
+)
+
+var (
+	ErrCorrupted = errors.New("segment corrupted")
+	ErrEmpty     = errors.New("segment has no logs")
+)
+
+const (
+	magic            = "LHSEG001"
+	magicSize        = 8
+	trailerSize      = 8 + magicSize
+	blockHeaderSize  = 8
+	defaultBlockSize = 1 << 20
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Levels lists the levels tracked by the per block counters, in index order.
+var Levels = [...]mlog.Level{mlog.Error, mlog.Warn, mlog.Info, mlog.Debug}
+
+// LevelCounts counts logs per level, indexed like Levels.
+type LevelCounts [len(Levels)]int64
+
+func (c LevelCounts) Count(level mlog.Level) (int64, bool) {
+	for i, l := range Levels {
+		if l == level {
+			return c[i], true
+		}
+	}
+	return 0, false
+}
+
+func (c *LevelCounts) add(level mlog.Level) {
+	for i, l := range Levels {
+		if l == level {
+			c[i]++
+			return
+		}
+	}
+}
+
+// TenantCounts counts logs per tenant. Logs of no tenant are not counted, so
+// indexes written before tenants existed read as holding only those.
+type TenantCounts map[string]int64
+
+// Untenanted returns how many of the total logs belong to no tenant.
+func (c TenantCounts) Untenanted(total int64) int64 {
+	for _, n := range c {
+		total -= n
+	}
+	return total
+}
+
+func (c LevelCounts) total() int64 {
+	var n int64
+	for _, v := range c {
+		n += v
+	}
+	return n
+}
+
+// BlockInfo describes one block of a segment.
+type BlockInfo struct {
+	Offset   int64        `json:"offset"`
+	Length   int64        `json:"length"`
+	RawBytes int64        `json:"raw"`
+	Count    int64        `json:"count"`
+	MinTime  time.Time    `json:"min"`
+	MaxTime  time.Time    `json:"max"`
+	Levels   LevelCounts  `json:"levels"`
+	Tenants  TenantCounts `json:"tenants,omitempty"`
+}
+
+// Overlaps reports whether the block may hold logs inside tr.
+func (b BlockInfo) Overlaps(tr mlog.TimeRange) bool {
+	return overlaps(b.MinTime, b.MaxTime, tr)
+}
+
+// Matches returns how many logs of the block match criteria when that can be
+// answered from the index alone.
+func (b BlockInfo) Matches(criteria mlog.SearchCriteria) (int64, bool) {
+	return matches(b.MinTime, b.MaxTime, b.Count, b.Levels, b.Tenants, criteria)
+}
+
+// Index is the footer of a segment.
+type Index struct {
+	Algorithm compress.Algorithm `json:"algorithm"`
+	CreatedAt time.Time          `json:"created"`
+	Count     int64              `json:"count"`
+	RawBytes  int64              `json:"raw"`
+	MinTime   time.Time          `json:"min"`
+	MaxTime   time.Time          `json:"max"`
+	Levels    LevelCounts        `json:"levels"`
+	Tenants   TenantCounts       `json:"tenants,omitempty"`
+	Blocks    []BlockInfo        `json:"blocks"`
+	// Replaces names the segments merged into this one, so a reader that
+	// finds both after a crash knows which to discard.
+	Replaces []string `json:"replaces,omitempty"`
+}
+
+func (i Index) Overlaps(tr mlog.TimeRange) bool {
+	return overlaps(i.MinTime, i.MaxTime, tr)
+}
+
+func (i Index) Matches(criteria mlog.SearchCriteria) (int64, bool) {
+	return matches(i.MinTime, i.MaxTime, i.Count, i.Levels, i.Tenants, criteria)
+}
+
+func overlaps(min, max time.Time, tr mlog.TimeRange) bool {
+	if !tr.StartTime.IsZero() && max.Before(tr.StartTime) {
+		return false
+	}
+	if !tr.EndTime.IsZero() && min.After(tr.EndTime) {
+		return false
+	}
+	return true
+}
+
+func matches(min, max time.Time, count int64, levels LevelCounts, tenants TenantCounts, criteria mlog.SearchCriteria) (int64, bool) {
+	if !overlaps(min, max, criteria.TimeRange) {
+		return 0, true
+	}
+
+	tr := criteria.TimeRange
+	if !tr.StartTime.IsZero() && min.Before(tr.StartTime) {
+		return 0, false
+	}
+	if !tr.EndTime.IsZero() && max.After(tr.EndTime) {
+		return 0, false
+	}
+
+	// Level counts span every tenant, so once count is narrowed to a tenant
+	// they no longer add up to it and a level filter needs a scan.
+	if !criteria.AllTenants {
+		n := tenants[criteria.Tenant]
+		if criteria.Tenant == "" {
+			n = tenants.Untenanted(count)
+		}
+		if n == 0 {
+			return 0, true
+		}
+		count = n
+	}
+
+	if criteria.Level != "" {
+		n, ok := levels.Count(criteria.Level)
+		if !ok || levels.total() != count {
+			return 0, false
+		}
+		count = n
+	}
+
+	if len(criteria.Levels) > 0 {
+		if levels.total() != count {
+			return 0, false
+		}
+		var n int64
+		for _, level := range criteria.Levels {
+			c, ok := levels.Count(level)
+			if !ok {
+				return 0, false
+			}
+			n += c
+		}
+		count = n
+	}
+
+	if len(criteria.Metadata) > 0 || criteria.Message != "" {
+		if count == 0 {
+			return 0, true
+		}
+		return 0, false
+	}
+
+	return count, true
+}
+
+// Match reports whether log satisfies the filters of criteria.
+func Match(log mlog.Log, criteria mlog.SearchCriteria) bool {
+	if !criteria.MatchesTenant(log.Tenant) {
+		return false
+	}
+	tr := criteria.TimeRange
+	if !tr.StartTime.IsZero() && log.Timestamp.Before(tr.StartTime) {
+		return false
+	}
+	if !tr.EndTime.IsZero() && log.Timestamp.After(tr.EndTime) {
+		return false
+	}
+	if criteria.Level != "" && log.Level != criteria.Level {
+		return false
+	}
+	if len(criteria.Levels) > 0 && !slices.Contains(criteria.Levels, log.Level) {
+		return false
+	}
+	for k, v := range criteria.Metadata {
+		if log.Metadata[k] != v {
+			return false
+		}
+	}
+	return criteria.MatchesMessage(log.Message)
+}
+
+// Writer builds a segment from logs appended in ascending timestamp order.
+type Writer struct {
+	w          io.Writer
+	compressor compress.Compressor
+	blockSize  int
+	offset     int64
+	buf        bytes.Buffer
+	block      BlockInfo
+	index      Index
+	last       time.Time
+}
+
+// NewWriter starts a segment on w. Blocks are cut once their encoded size
+// reaches blockSize bytes.
+func NewWriter(w io.Writer, compressor compress.Compressor, blockSize int) (*Writer, error) {
+	if blockSize <= 0 {
+		blockSize = defaultBlockSize
+	}
+
+	if _, err := io.WriteString(w, magic); err != nil {
+		return nil, fmt.Errorf("writing header: %w", err)
+	}
+
+	return &Writer{
+		w:          w,
+		compressor: compressor,
+		blockSize:  blockSize,
+		offset:     magicSize,
+		index: Index{
+			Algorithm: compressor.Algorithm(),
+			CreatedAt: time.Now().UTC(),
+		},
+	}, nil
+}
+
+func (w *Writer) Append(log mlog.Log) error {
+	if log.Timestamp.Before(w.last) {
+		return fmt.Errorf("append: log %s is older than the previous one", log.ID)
+	}
+	w.last = log.Timestamp
+
+	if w.block.Count == 0 {
+		w.block.MinTime = log.Timestamp
+	}
+	w.block.MaxTime = log.Timestamp
+	w.block.Count++
+	w.block.Levels.add(log.Level)
+	if log.Tenant != "" {
+		if w.block.Tenants == nil {
+			w.block.Tenants = TenantCounts{}
+		}
+		w.block.Tenants[log.Tenant]++
+	}
+
+	EncodeLog(&w.buf, log)
+
+	if w.buf.Len() >= w.blockSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// Supersede records segments whose logs this segment replaces.
+func (w *Writer) Supersede(names ...string) {
+	w.index.Replaces = append(w.index.Replaces, names...)
+}
+
+// Count returns how many logs were appended so far.
+func (w *Writer) Count() int64 {
+	return w.index.Count + w.block.Count
+}
+
+func (w *Writer) flush() error {
+	if w.block.Count == 0 {
+		return nil
+	}
+
+	data, err := w.compressor.Compress(w.buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("compressing block: %w", err)
+	}
+
+	var header [blockHeaderSize]byte
+	binary.LittleEndian.PutUint32(header[0:4], crc32.Checksum(data, crcTable))
+	binary.LittleEndian.PutUint32(header[4:8], uint32(len(data)))
+
+	if _, err := w.w.Write(header[:]); err != nil {
+		return fmt.Errorf("writing block: %w", err)
+	}
+	if _, err := w.w.Write(data); err != nil {
+		return fmt.Errorf("writing block: %w", err)
+	}
+
+	block := w.block
+	block.Offset = w.offset
+	block.Length = int64(len(data))
+	block.RawBytes = int64(w.buf.Len())
+
+	if w.index.Count == 0 {
+		w.index.MinTime = block.MinTime
+	}
+	w.index.MaxTime = block.MaxTime
+	w.index.Count += block.Count
+	w.index.RawBytes += block.RawBytes
+	for i, n := range block.Levels {
+		w.index.Levels[i] += n
+	}
+	for tenant, n := range block.Tenants {
+		if w.index.Tenants == nil {
+			w.index.Tenants = TenantCounts{}
+		}
+		w.index.Tenants[tenant] += n
+	}
+	w.index.Blocks = append(w.index.Blocks, block)
+
+	w.offset += blockHeaderSize + block.Length
+	w.block = BlockInfo{}
+	w.buf.Reset()
+
+	return nil
+}
+
+// Close flushes the pending block and writes the footer. It does not close
+// the underlying writer.
+func (w *Writer) Close() (Index, error) {
+	if err := w.flush(); err != nil {
+		return Index{}, err
+	}
+
+	if w.index.Count == 0 {
+		return Index{}, ErrEmpty
+	}
+
+	footer, err := json.Marshal(w.index)
+	if err != nil {
+		return Index{}, fmt.Errorf("encoding footer: %w", err)
+	}
+
+	trailer := make([]byte, trailerSize)
+	binary.LittleEndian.PutUint32(trailer[0:4], uint32(len(footer)))
+	binary.LittleEndian.PutUint32(trailer[4:8], crc32.Checksum(footer, crcTable))
+	copy(trailer[8:], magic)
+
+	if _, err := w.w.Write(footer); err != nil {
+		return Index{}, fmt.Errorf("writing footer: %w", err)
+	}
+	if _, err := w.w.Write(trailer); err != nil {
+		return Index{}, fmt.Errorf("writing footer: %w", err)
+	}
+
+	return w.index, nil
+}
+
+// Reader reads blocks of a segment through random access.
+type Reader struct {
+	r     io.ReaderAt
+	index Index
+}
+
+// NewReader validates the trailer of a segment of the given size and loads
+// its index.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	if size < magicSize+trailerSize {
+		return nil, fmt.Errorf("%w: too small", ErrCorrupted)
+	}
+
+	trailer := make([]byte, trailerSize)
+	if _, err := r.ReadAt(trailer, size-trailerSize); err != nil {
+		return nil, fmt.Errorf("reading trailer: %w", err)
+	}
+
+	if string(trailer[8:]) != magic {
+		return nil, fmt.Errorf("%w: bad magic", ErrCorrupted)
+	}
+
+	length := int64(binary.LittleEndian.Uint32(trailer[0:4]))
+	if length > size-trailerSize-magicSize {
+		return nil, fmt.Errorf("%w: bad footer length", ErrCorrupted)
+	}
+
+	footer := make([]byte, length)
+	if _, err := r.ReadAt(footer, size-trailerSize-length); err != nil {
+		return nil, fmt.Errorf("reading footer: %w", err)
+	}
+
+	if crc32.Checksum(footer, crcTable) != binary.LittleEndian.Uint32(trailer[4:8]) {
+		return nil, fmt.Errorf("%w: footer checksum mismatch", ErrCorrupted)
+	}
+
+	var index Index
+	if err := json.Unmarshal(footer, &index); err != nil {
+		return nil, fmt.Errorf("%w: decoding footer: %v", ErrCorrupted, err)
+	}
+
+	return &Reader{
+		r:     r,
+		index: index,
+	}, nil
+}
+
+func (r *Reader) Index() Index {
+	return r.index
+}
+
+// Block decodes the logs of block i in ascending timestamp order.
+func (r *Reader) Block(i int) ([]mlog.Log, error) {
+	info := r.index.Blocks[i]
+
+	data := make([]byte, blockHeaderSize+info.Length)
+	if _, err := r.r.ReadAt(data, info.Offset); err != nil {
+		return nil, fmt.Errorf("reading block %d: %w", i, err)
+	}
+
+	header, data := data[:blockHeaderSize], data[blockHeaderSize:]
+	if int64(binary.LittleEndian.Uint32(header[4:8])) != info.Length ||
+		crc32.Checksum(data, crcTable) != binary.LittleEndian.Uint32(header[0:4]) {
+		return nil, fmt.Errorf("%w: block %d checksum mismatch", ErrCorrupted, i)
+	}
+
+	raw, err := compress.Decompress(r.index.Algorithm, data)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing block %d: %w", i, err)
+	}
+
+	logs := make([]mlog.Log, 0, info.Count)
+	buf := bytes.NewReader(raw)
+	for buf.Len() > 0 {
+		log, err := DecodeLog(buf)
+		if err != nil {
+			return nil, fmt.Errorf("%w: block %d: %v", ErrCorrupted, i, err)
+		}
+
+		log.Compressed = true
+		log.CompressedAt = r.index.CreatedAt
+		log.Compression = string(r.index.Algorithm)
+
+		logs = append(logs, log)
+	}
+
+	return logs, nil
+}
+
+// Scan calls fn for every log of the segment matching criteria, oldest
+// first, skipping blocks outside the time range.
+func (r *Reader) Scan(criteria mlog.SearchCriteria, fn func(mlog.Log) error) error {
+	for i, block := range r.index.Blocks {
+		if !block.Overlaps(criteria.TimeRange) {
+			continue
+		}
+
+		logs, err := r.Block(i)
+		if err != nil {
+			return err
+		}
+
+		for _, log := range logs {
+			if !Match(log, criteria) {
+				continue
+			}
+			if err := fn(log); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,216 @@
+package spool
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/business/domain/mlog/segment"
+)
+
+// The spool is a sequence of segment files named after an increasing
+// sequence number. Each record is crc32 | length | encoded log. The cursor
+// file holds the position of the next record to replay as
+// seq | offset | crc32.
+
+const (
+	segmentExt    = ".spool"
+	seqDigits     = 20
+	headerSize    = 8
+	maxRecordSize = 64 << 20
+	cursorFile    = "cursor"
+	cursorSize    = 20
+	// deadLetterFile keeps, framed like segments, the logs the store kept
+	// rejecting while it took the logs after them.
+	deadLetterFile = "dead-letter"
+)
+
+var (
+	crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+	errTornRecord = errors.New("torn spool record")
+)
+
+func segmentName(seq uint64) string {
+	return fmt.Sprintf("%0*d%s", seqDigits, seq, segmentExt)
+}
+
+// segmentFiles returns the sequence numbers of the segments in dir,
+// ascending.
+func segmentFiles(dir string) ([]uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var seqs []uint64
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), segmentExt)
+		if !ok {
+			continue
+		}
+		seq, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+
+	return seqs, nil
+}
+
+// encodeRecord returns the framed binary form of log.
+func encodeRecord(log mlog.Log) []byte {
+	var payload bytes.Buffer
+	segment.EncodeLog(&payload, log)
+
+	record := make([]byte, headerSize+payload.Len())
+	binary.LittleEndian.PutUint32(record[0:4], crc32.Checksum(payload.Bytes(), crcTable))
+	binary.LittleEndian.PutUint32(record[4:8], uint32(payload.Len()))
+	copy(record[headerSize:], payload.Bytes())
+
+	return record
+}
+
+// readRecord reads the record of r at offset and returns its log and size.
+// It returns io.EOF at the end of r and errTornRecord when the record is
+// incomplete or fails its checksum.
+func readRecord(r io.ReaderAt, offset int64) (mlog.Log, int64, error) {
+	header := make([]byte, headerSize)
+	n, err := r.ReadAt(header, offset)
+	if n == 0 && errors.Is(err, io.EOF) {
+		return mlog.Log{}, 0, io.EOF
+	}
+	if n < headerSize {
+		return mlog.Log{}, 0, errTornRecord
+	}
+
+	length := binary.LittleEndian.Uint32(header[4:8])
+	if length > maxRecordSize {
+		return mlog.Log{}, 0, errTornRecord
+	}
+
+	payload := make([]byte, length)
+	if n, _ := r.ReadAt(payload, offset+headerSize); n < int(length) {
+		return mlog.Log{}, 0, errTornRecord
+	}
+
+	if crc32.Checksum(payload, crcTable) != binary.LittleEndian.Uint32(header[0:4]) {
+		return mlog.Log{}, 0, errTornRecord
+	}
+
+	log, err := segment.DecodeLog(bytes.NewReader(payload))
+	if err != nil {
+		return mlog.Log{}, 0, errTornRecord
+	}
+
+	return log, headerSize + int64(length), nil
+}
+
+// countRecords returns how many intact records the segment at path holds
+// from offset on, and where the last of them ends.
+func countRecords(path string, offset int64) (int64, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+	if offset > info.Size() {
+		return 0, info.Size(), nil
+	}
+
+	r := bufio.NewReaderSize(io.NewSectionReader(file, offset, info.Size()-offset), 64<<10)
+	header := make([]byte, headerSize)
+
+	var count int64
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return count, offset, nil
+		}
+
+		length := binary.LittleEndian.Uint32(header[4:8])
+		if length > maxRecordSize {
+			return count, offset, nil
+		}
+
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return count, offset, nil
+		}
+		if crc32.Checksum(payload, crcTable) != binary.LittleEndian.Uint32(header[0:4]) {
+			return count, offset, nil
+		}
+
+		count++
+		offset += headerSize + int64(length)
+	}
+}
+
+// cursor is the position of the next record to replay.
+type cursor struct {
+	seq    uint64
+	offset int64
+}
+
+func readCursor(dir string) (cursor, bool, error) {
+	data, err := os.ReadFile(filepath.Join(dir, cursorFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return cursor{}, false, nil
+	}
+	if err != nil {
+		return cursor{}, false, err
+	}
+
+	if len(data) != cursorSize || crc32.Checksum(data[:16], crcTable) != binary.LittleEndian.Uint32(data[16:]) {
+		return cursor{}, false, nil
+	}
+
+	return cursor{
+		seq:    binary.LittleEndian.Uint64(data[0:8]),
+		offset: int64(binary.LittleEndian.Uint64(data[8:16])),
+	}, true, nil
+}
+
+// writeCursor replaces the cursor file atomically.
+func writeCursor(dir string, c cursor) error {
+	var data [cursorSize]byte
+	binary.LittleEndian.PutUint64(data[0:8], c.seq)
+	binary.LittleEndian.PutUint64(data[8:16], uint64(c.offset))
+	binary.LittleEndian.PutUint32(data[16:20], crc32.Checksum(data[:16], crcTable))
+
+	tmp := filepath.Join(dir, cursorFile+".tmp")
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data[:]); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, filepath.Join(dir, cursorFile))
+}
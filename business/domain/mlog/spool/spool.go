@@ -0,0 +1,742 @@
+// Package spool implements a durable on-disk spool in front of the store.
+//
+// Writes go straight to the store while it is healthy. When a write fails
+// the log is appended to checksummed segment files instead, and every later
+// log follows it there until a background replay has moved the whole
+// backlog to the store, one log at a time and oldest first. Replay is at
+// least once: a crash between storing a log and saving the cursor stores it
+// twice. A log the store keeps rejecting while it takes the next one is
+// moved to a dead-letter file.
+package spool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/foundation/logger"
+)
+
+var ErrClosed = errors.New("spool is closed")
+
+const (
+	defaultSegmentSize   = 16 << 20
+	defaultMaxSize       = 1 << 30
+	defaultRetryInterval = 5 * time.Second
+	replayBatchSize      = 100
+
+	// maxReplayAttempts is how many times in a row replay fails on the same
+	// log before checking whether the store takes the next one.
+	maxReplayAttempts = 5
+)
+
+type Config struct {
+	Dir string
+	// SegmentSize is the size at which a new segment file is started.
+	SegmentSize int64
+	// MaxSize bounds the bytes waiting in the spool. Writes that do not fit
+	// fail with mlog.ErrOverloaded.
+	MaxSize int64
+	// RetryInterval is how often replay is attempted while the store fails.
+	RetryInterval time.Duration
+}
+
+// segmentInfo describes a segment file still holding logs to replay. The
+// last segment is the one being appended to.
+type segmentInfo struct {
+	seq   uint64
+	size  int64
+	count int64
+}
+
+// Spool is an mlog.Writer that keeps logs on disk while the store fails.
+type Spool struct {
+	log           logger.Logger
+	store         mlog.Writer
+	dir           string
+	segmentSize   int64
+	maxSize       int64
+	retryInterval time.Duration
+
+	// mu guards every field below, and the tail file.
+	mu       sync.Mutex
+	closed   bool
+	segments []segmentInfo
+	tail     *os.File
+	head     cursor
+	pending  int64
+	bytes    int64
+	oldest   time.Time
+	replayed int64
+
+	deadLettered int64
+
+	// order serializes writes, so no log goes straight to the store while
+	// an earlier one is on its way to the spool.
+	order sync.Mutex
+
+	// reader reads the head segment, failures counts the failed attempts to
+	// replay the log at the head, and skip is set when the log at the head
+	// is already stored. They are only used by the replay loop.
+	reader   *os.File
+	failures int
+	skip     bool
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Open loads the spool in cfg.Dir, creating it when needed, and starts
+// replaying what a previous run left behind.
+func Open(log logger.Logger, store mlog.Writer, cfg Config) (*Spool, error) {
+	if cfg.Dir == "" {
+		return nil, errors.New("spool directory is required")
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating spool directory: %w", err)
+	}
+
+	s := Spool{
+		log:           log,
+		store:         store,
+		dir:           cfg.Dir,
+		segmentSize:   cfg.SegmentSize,
+		maxSize:       cfg.MaxSize,
+		retryInterval: cfg.RetryInterval,
+		wake:          make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	if s.segmentSize <= 0 {
+		s.segmentSize = defaultSegmentSize
+	}
+	if s.maxSize <= 0 {
+		s.maxSize = defaultMaxSize
+	}
+	if s.retryInterval <= 0 {
+		s.retryInterval = defaultRetryInterval
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	if s.pending > 0 {
+		log.Info(context.Background(), "replaying spooled logs", "logs", s.pending, "bytes", s.bytes)
+	}
+
+	go s.run()
+
+	return &s, nil
+}
+
+// load rebuilds the spool state from its files and opens a fresh tail
+// segment.
+func (s *Spool) load() error {
+	seqs, err := segmentFiles(s.dir)
+	if err != nil {
+		return fmt.Errorf("listing segments: %w", err)
+	}
+
+	head, ok, err := readCursor(s.dir)
+	if err != nil {
+		return fmt.Errorf("reading cursor: %w", err)
+	}
+
+	var next uint64 = 1
+	for _, seq := range seqs {
+		next = seq + 1
+		path := filepath.Join(s.dir, segmentName(seq))
+
+		if ok && seq < head.seq {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("removing replayed segment: %w", err)
+			}
+			continue
+		}
+
+		var offset int64
+		if ok && seq == head.seq {
+			offset = head.offset
+		}
+
+		count, end, err := countRecords(path, offset)
+		if err != nil {
+			return fmt.Errorf("reading segment %d: %w", seq, err)
+		}
+
+		// Whatever follows the last intact record was torn by a crash.
+		if err := os.Truncate(path, end); err != nil {
+			return fmt.Errorf("truncating segment %d: %w", seq, err)
+		}
+
+		if count == 0 {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("removing replayed segment: %w", err)
+			}
+			continue
+		}
+
+		if len(s.segments) == 0 {
+			s.head = cursor{seq: seq, offset: offset}
+		}
+		s.segments = append(s.segments, segmentInfo{seq: seq, size: end, count: count})
+		s.pending += count
+		s.bytes += end - offset
+	}
+
+	if err := s.openTail(next); err != nil {
+		return err
+	}
+	if s.pending == 0 {
+		s.head = cursor{seq: next}
+	}
+
+	if s.pending > 0 {
+		file, err := os.Open(filepath.Join(s.dir, segmentName(s.head.seq)))
+		if err != nil {
+			return err
+		}
+		log, _, err := readRecord(file, s.head.offset)
+		file.Close()
+		if err == nil {
+			s.oldest = log.Timestamp
+		}
+	}
+
+	return writeCursor(s.dir, s.head)
+}
+
+// openTail starts the segment seq for appending. s.mu must be held once the
+// spool is running.
+func (s *Spool) openTail(seq uint64) error {
+	file, err := os.OpenFile(filepath.Join(s.dir, segmentName(seq)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening segment: %w", err)
+	}
+
+	if s.tail != nil {
+		s.tail.Close()
+	}
+	s.tail = file
+	s.segments = append(s.segments, segmentInfo{seq: seq})
+
+	return nil
+}
+
+// Write stores log directly while nothing is spooled, and spools it when
+// the store fails or older logs are still waiting.
+func (s *Spool) Write(ctx context.Context, log *mlog.Log) error {
+	s.order.Lock()
+	defer s.order.Unlock()
+
+	if !s.spooling() {
+		err := s.store.Write(ctx, log)
+		if err == nil {
+			return nil
+		}
+		s.log.Error(ctx, "store write failed, spooling log", "error", err)
+	}
+
+	return s.append(log)
+}
+
+// WriteBatch stores logs directly while nothing is spooled and spools the
+// logs the store rejected.
+func (s *Spool) WriteBatch(ctx context.Context, logs []*mlog.Log) error {
+	s.order.Lock()
+	defer s.order.Unlock()
+
+	failed := logs
+	indexes := make([]int, len(logs))
+	for i := range indexes {
+		indexes[i] = i
+	}
+
+	if !s.spooling() {
+		err := mlog.WriteBatch(ctx, s.store, logs)
+		if err == nil {
+			return nil
+		}
+		s.log.Error(ctx, "store write failed, spooling logs", "error", err, "logs", len(logs))
+
+		var batchErr *mlog.BatchError
+		if errors.As(err, &batchErr) {
+			indexes = indexes[:0]
+			for i := range batchErr.Failed {
+				indexes = append(indexes, i)
+			}
+			sort.Ints(indexes)
+
+			failed = make([]*mlog.Log, len(indexes))
+			for i, index := range indexes {
+				failed[i] = logs[index]
+			}
+		}
+	}
+
+	err := s.append(failed...)
+	if err == nil || len(failed) == len(logs) {
+		return err
+	}
+
+	batchErr := &mlog.BatchError{Failed: make(map[int]error, len(indexes))}
+	for _, i := range indexes {
+		batchErr.Failed[i] = err
+	}
+	return batchErr
+}
+
+func (s *Spool) spooling() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pending > 0
+}
+
+// append adds logs to the tail segment with a single sync.
+func (s *Spool) append(logs ...*mlog.Log) error {
+	var records []byte
+	for _, log := range logs {
+		records = append(records, encodeRecord(*log)...)
+	}
+	size := int64(len(records))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+
+	if s.bytes+size > s.maxSize {
+		return fmt.Errorf("spool holds %d bytes: %w", s.bytes, mlog.ErrOverloaded)
+	}
+
+	tail := &s.segments[len(s.segments)-1]
+	if tail.size >= s.segmentSize {
+		if err := s.openTail(tail.seq + 1); err != nil {
+			return err
+		}
+		tail = &s.segments[len(s.segments)-1]
+	}
+
+	if _, err := s.tail.Write(records); err != nil {
+		s.tail.Truncate(tail.size)
+		return fmt.Errorf("appending to spool: %w", err)
+	}
+	if err := s.tail.Sync(); err != nil {
+		s.tail.Truncate(tail.size)
+		return fmt.Errorf("syncing spool: %w", err)
+	}
+
+	if s.pending == 0 {
+		s.oldest = logs[0].Timestamp
+	}
+	tail.size += size
+	tail.count += int64(len(logs))
+	s.pending += int64(len(logs))
+	s.bytes += size
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Backlog reports the logs waiting in the spool.
+func (s *Spool) Backlog() mlog.Backlog {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	segments := 0
+	for _, seg := range s.segments {
+		if seg.count > 0 {
+			segments++
+		}
+	}
+
+	return mlog.Backlog{
+		Spooled:       s.pending,
+		SpoolBytes:    s.bytes,
+		SpoolSegments: segments,
+		OldestSpooled: s.oldest,
+		Replayed:      s.replayed,
+		DeadLettered:  s.deadLettered,
+		Spooling:      s.pending > 0,
+	}
+}
+
+// Close stops the replay. Logs still spooled are replayed by the next Open.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	close(s.stop)
+	<-s.done
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.reader != nil {
+		s.reader.Close()
+	}
+	if err := s.tail.Close(); err != nil {
+		return err
+	}
+	return writeCursor(s.dir, s.head)
+}
+
+// run replays the spool whenever logs are spooled and then every
+// RetryInterval until it is empty.
+func (s *Spool) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.retryInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.replay(); err != nil {
+			s.log.Error(context.Background(), "failed to replay spooled logs", "error", err)
+		}
+
+		select {
+		case <-s.stop:
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// replay moves spooled logs to the store, oldest first, until the spool is
+// empty or the store fails.
+func (s *Spool) replay() error {
+	ctx := context.Background()
+
+	for {
+		select {
+		case <-s.stop:
+			return nil
+		default:
+		}
+
+		s.mu.Lock()
+		if s.pending == 0 {
+			s.mu.Unlock()
+			return nil
+		}
+		head := s.head
+		seg := s.segments[0]
+		s.mu.Unlock()
+
+		if head.offset >= seg.size {
+			if err := s.advanceSegment(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		logs, sizes, err := s.readBatch(head, seg.size)
+		if err != nil {
+			return err
+		}
+
+		done, dead, err := s.replayBatch(ctx, head, logs, sizes)
+
+		var size int64
+		for _, n := range sizes[:done] {
+			size += n
+		}
+
+		s.mu.Lock()
+		s.head.offset += size
+		s.segments[0].count -= int64(done)
+		s.pending -= int64(done)
+		s.bytes -= size
+		s.replayed += int64(done - dead)
+		s.deadLettered += int64(dead)
+		if done < len(logs) {
+			s.oldest = logs[done].Timestamp
+		}
+		drained := s.pending == 0
+		if drained {
+			s.oldest = time.Time{}
+		}
+		c := s.head
+		s.mu.Unlock()
+
+		if done > 0 {
+			if err := writeCursor(s.dir, c); err != nil {
+				return fmt.Errorf("saving cursor: %w", err)
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if drained {
+			s.log.Info(ctx, "spool drained, writing to the store again")
+			return s.reset()
+		}
+	}
+}
+
+// replayBatch stores logs one at a time, in order, and stops at the first
+// the store rejects, so no later log is stored ahead of it. It returns how
+// many logs it is done with, of which dead were moved to the dead-letter
+// file. The batch starts at c in the head segment and sizes are the sizes of
+// its records.
+func (s *Spool) replayBatch(ctx context.Context, c cursor, logs []*mlog.Log, sizes []int64) (int, int, error) {
+	var done, dead int
+
+	if s.skip && len(logs) > 0 {
+		s.skip = false
+		done++
+	}
+
+	for done < len(logs) {
+		err := s.store.Write(ctx, logs[done])
+		if err == nil {
+			s.failures = 0
+			done++
+			continue
+		}
+
+		s.failures++
+		if s.failures < maxReplayAttempts {
+			return done, dead, err
+		}
+
+		next, inBatch, ok := s.next(c, logs, sizes, done)
+		if !ok {
+			return done, dead, err
+		}
+		if nextErr := s.store.Write(ctx, next); nextErr != nil {
+			return done, dead, err
+		}
+
+		// The store is up and takes the next log, so it rejects this one
+		// for good.
+		if err := s.deadLetter(logs[done]); err != nil {
+			return done, dead, err
+		}
+		s.log.Error(ctx, "store keeps rejecting spooled log, moved to dead letters", "error", err, "id", logs[done].ID.String())
+
+		s.failures = 0
+		done++
+		dead++
+		if !inBatch {
+			s.skip = true
+			break
+		}
+		done++
+	}
+
+	return done, dead, nil
+}
+
+// next returns the log spooled after logs[i]: the next one of logs, the
+// record following the batch in the head segment, or the first of the
+// following segment when logs[i] ends its segment. The second result
+// reports whether it is one of logs.
+func (s *Spool) next(c cursor, logs []*mlog.Log, sizes []int64, i int) (*mlog.Log, bool, bool) {
+	if i+1 < len(logs) {
+		return logs[i+1], true, true
+	}
+
+	offset := c.offset
+	for _, n := range sizes[:i+1] {
+		offset += n
+	}
+
+	s.mu.Lock()
+	end := s.segments[0].size
+	var seq uint64
+	var more bool
+	if len(s.segments) > 1 && s.segments[1].count > 0 {
+		seq, more = s.segments[1].seq, true
+	}
+	s.mu.Unlock()
+
+	if offset < end {
+		log, _, err := s.readRecord(offset)
+		if err != nil {
+			return nil, false, false
+		}
+		return &log, false, true
+	}
+
+	if !more {
+		return nil, false, false
+	}
+
+	file, err := os.Open(filepath.Join(s.dir, segmentName(seq)))
+	if err != nil {
+		return nil, false, false
+	}
+	defer file.Close()
+
+	log, _, err := readRecord(file, 0)
+	if err != nil {
+		return nil, false, false
+	}
+	return &log, false, true
+}
+
+// deadLetter appends log to the dead-letter file.
+func (s *Spool) deadLetter(log *mlog.Log) error {
+	file, err := os.OpenFile(filepath.Join(s.dir, deadLetterFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening dead letters: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(encodeRecord(*log)); err != nil {
+		return fmt.Errorf("writing dead letter: %w", err)
+	}
+	return file.Sync()
+}
+
+// readBatch reads up to replayBatchSize logs of the head segment from c on.
+// A corrupted record drops the rest of its segment, which cannot be framed
+// past it.
+func (s *Spool) readBatch(c cursor, end int64) ([]*mlog.Log, []int64, error) {
+	if s.reader == nil || s.reader.Name() != filepath.Join(s.dir, segmentName(c.seq)) {
+		if s.reader != nil {
+			s.reader.Close()
+		}
+		file, err := os.Open(filepath.Join(s.dir, segmentName(c.seq)))
+		if err != nil {
+			s.reader = nil
+			return nil, nil, fmt.Errorf("opening segment: %w", err)
+		}
+		s.reader = file
+	}
+
+	var (
+		logs  []*mlog.Log
+		sizes []int64
+	)
+	offset := c.offset
+	for len(logs) < replayBatchSize && offset < end {
+		log, size, err := s.readRecord(offset)
+		if errors.Is(err, errTornRecord) {
+			if len(logs) > 0 {
+				break
+			}
+			return nil, nil, s.dropSegment(c)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		logs = append(logs, &log)
+		sizes = append(sizes, size)
+		offset += size
+	}
+
+	return logs, sizes, nil
+}
+
+func (s *Spool) readRecord(offset int64) (mlog.Log, int64, error) {
+	log, size, err := readRecord(s.reader, offset)
+	if errors.Is(err, io.EOF) {
+		return mlog.Log{}, 0, errTornRecord
+	}
+	return log, size, err
+}
+
+// dropSegment gives up on the rest of the head segment after a corrupted
+// record.
+func (s *Spool) dropSegment(c cursor) error {
+	s.mu.Lock()
+	seg := &s.segments[0]
+	lost := seg.count
+	s.pending -= seg.count
+	s.bytes -= seg.size - c.offset
+	seg.count = 0
+	s.head.offset = seg.size
+	s.mu.Unlock()
+
+	s.log.Error(context.Background(), "dropped corrupted spool segment", "segment", c.seq, "offset", c.offset, "logs", lost)
+	return nil
+}
+
+// advanceSegment removes the fully replayed head segment.
+func (s *Spool) advanceSegment() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.segments) == 1 {
+		return nil
+	}
+
+	seq := s.segments[0].seq
+	s.segments = s.segments[1:]
+	s.head = cursor{seq: s.segments[0].seq}
+
+	if err := writeCursor(s.dir, s.head); err != nil {
+		return fmt.Errorf("saving cursor: %w", err)
+	}
+	if s.reader != nil {
+		s.reader.Close()
+		s.reader = nil
+	}
+	return os.Remove(filepath.Join(s.dir, segmentName(seq)))
+}
+
+// reset removes every segment once the spool is empty and starts a new
+// tail.
+func (s *Spool) reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending > 0 || s.closed {
+		return nil
+	}
+
+	if s.reader != nil {
+		s.reader.Close()
+		s.reader = nil
+	}
+
+	old := s.segments
+	next := old[len(old)-1].seq + 1
+	s.segments = nil
+	if err := s.openTail(next); err != nil {
+		return err
+	}
+	s.head = cursor{seq: next}
+	if err := writeCursor(s.dir, s.head); err != nil {
+		return fmt.Errorf("saving cursor: %w", err)
+	}
+
+	for _, seg := range old {
+		if err := os.Remove(filepath.Join(s.dir, segmentName(seg.seq))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	_ mlog.Writer          = (*Spool)(nil)
+	_ mlog.BatchWriter     = (*Spool)(nil)
+	_ mlog.BacklogReporter = (*Spool)(nil)
+)
@@ -0,0 +1,190 @@
+package spool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/oklog/ulid/v2"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(context.Context, string, ...interface{})  {}
+func (nopLogger) Error(context.Context, string, ...interface{}) {}
+
+// testStore fails every write while down and always rejects poison.
+type testStore struct {
+	mu     sync.Mutex
+	down   bool
+	poison ulid.ULID
+	stored []ulid.ULID
+}
+
+func (s *testStore) Write(_ context.Context, log *mlog.Log) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.down {
+		return errors.New("store is down")
+	}
+	if log.ID == s.poison {
+		return errors.New("log rejected")
+	}
+	s.stored = append(s.stored, log.ID)
+	return nil
+}
+
+func (s *testStore) setDown(down bool) {
+	s.mu.Lock()
+	s.down = down
+	s.mu.Unlock()
+}
+
+func (s *testStore) storedIDs() []ulid.ULID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ulid.ULID(nil), s.stored...)
+}
+
+// A log the store rejects for good at the end of a replay batch is moved to
+// the dead letters once the store takes the record after it in the same
+// segment, even with no later segment.
+func TestReplayDeadLettersPoisonEndingBatch(t *testing.T) {
+	const (
+		total  = 250
+		poison = replayBatchSize - 1
+	)
+
+	logs := make([]*mlog.Log, total)
+	for i := range logs {
+		logs[i] = &mlog.Log{
+			ID:        ulid.Make(),
+			Message:   fmt.Sprintf("log %d", i),
+			Timestamp: time.Now().UTC(),
+			Level:     mlog.Info,
+		}
+	}
+
+	store := testStore{down: true, poison: logs[poison].ID}
+	s, err := Open(nopLogger{}, &store, Config{Dir: t.TempDir(), RetryInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := s.WriteBatch(context.Background(), logs); err != nil {
+		t.Fatalf("WriteBatch: %v", err)
+	}
+
+	// Stop the replay loop and drive the batch by hand, as if the poison
+	// log had already failed enough times.
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	s.reader = nil
+	if len(s.segments) != 1 || s.segments[0].count != total {
+		t.Fatalf("spooled %d segments, want 1 of %d logs", len(s.segments), total)
+	}
+
+	store.setDown(false)
+
+	// The batch holding the poison log was read while it was the last one
+	// spooled, so it ends there.
+	all, sizes, err := s.readBatch(s.head, s.segments[0].size)
+	if err != nil {
+		t.Fatalf("readBatch: %v", err)
+	}
+	c := s.head
+	for _, n := range sizes[:poison] {
+		c.offset += n
+	}
+	batch, sizes, err := s.readBatch(c, c.offset+sizes[poison])
+	if err != nil {
+		t.Fatalf("readBatch: %v", err)
+	}
+	if len(batch) != 1 || batch[0].ID != all[poison].ID {
+		t.Fatalf("batch = %d logs, want the poison log only", len(batch))
+	}
+
+	s.failures = maxReplayAttempts - 1
+	done, dead, err := s.replayBatch(context.Background(), c, batch, sizes)
+	if err != nil {
+		t.Fatalf("replayBatch: %v", err)
+	}
+	if done != 1 || dead != 1 || !s.skip {
+		t.Fatalf("done %d, dead %d, skip %v; want 1, 1, true", done, dead, s.skip)
+	}
+
+	// The log after it in the segment was stored to check the store is up,
+	// and is skipped by the next batch.
+	want := []ulid.ULID{logs[poison+1].ID}
+	got := store.storedIDs()
+	if len(got) != len(want) {
+		t.Fatalf("stored logs = %d, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("stored log %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+// Replay stores every spooled log in order except the poison one, which is
+// dead lettered, and the spool drains.
+func TestReplaySkipsPoison(t *testing.T) {
+	const (
+		total  = 250
+		poison = replayBatchSize - 1
+	)
+
+	logs := make([]*mlog.Log, total)
+	for i := range logs {
+		logs[i] = &mlog.Log{
+			ID:        ulid.Make(),
+			Message:   fmt.Sprintf("log %d", i),
+			Timestamp: time.Now().UTC(),
+			Level:     mlog.Info,
+		}
+	}
+
+	store := testStore{down: true, poison: logs[poison].ID}
+	s, err := Open(nopLogger{}, &store, Config{Dir: t.TempDir(), RetryInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+
+	if err := s.WriteBatch(context.Background(), logs); err != nil {
+		t.Fatalf("WriteBatch: %v", err)
+	}
+	store.setDown(false)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for s.Backlog().Spooled > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("spool not drained: %d logs left", s.Backlog().Spooled)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if b := s.Backlog(); b.Replayed != total-1 || b.DeadLettered != 1 {
+		t.Errorf("replayed %d, dead lettered %d; want %d, 1", b.Replayed, b.DeadLettered, total-1)
+	}
+
+	got := store.storedIDs()
+	if len(got) != total-1 {
+		t.Fatalf("stored logs = %d, want %d", len(got), total-1)
+	}
+	for i, id := range got {
+		want := i
+		if i >= poison {
+			want++
+		}
+		if id != logs[want].ID {
+			t.Fatalf("stored log %d = %s, want %s", i, id, logs[want].ID)
+		}
+	}
+}
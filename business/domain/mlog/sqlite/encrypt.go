@@ -0,0 +1,237 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/foundation/envelope"
+)
+
+// messageAAD binds a sealed message to its log, so it cannot be swapped
+// with the message of another one.
+func messageAAD(id string) []byte {
+	return []byte(id)
+}
+
+// metadataAAD binds a sealed metadata value to its log and key.
+func metadataAAD(id, key string) []byte {
+	return []byte(id + "\x00" + key)
+}
+
+// loadKeys adds the stored data keys to the keyring, creating the first one
+// on a new database.
+func (s *Store) loadKeys(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, wrapped, created_at FROM data_keys`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			dk        envelope.DataKey
+			createdAt int64
+		)
+		if err := rows.Scan(&dk.ID, &dk.Wrapped, &createdAt); err != nil {
+			return err
+		}
+		dk.CreatedAt = time.Unix(0, createdAt).UTC()
+
+		if err := s.keyring.Add(dk); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if _, ok := s.keyring.Current(); ok {
+		return nil
+	}
+
+	_, err = s.RotateKey(ctx)
+	return err
+}
+
+// RotateKey makes a new data key current. Logs sealed with older keys stay
+// readable, since keys are never deleted, until Reencrypt moves them over.
+func (s *Store) RotateKey(ctx context.Context) (string, error) {
+	if s.keyring == nil {
+		return "", mlog.ErrNotSupported
+	}
+
+	dk, err := s.keyring.NewDataKey()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO data_keys (id, wrapped, created_at) VALUES (?, ?, ?)`,
+		dk.ID, dk.Wrapped, dk.CreatedAt.UnixNano(),
+	)
+	if err != nil {
+		return "", fmt.Errorf("storing data key: %w", err)
+	}
+
+	return dk.ID, nil
+}
+
+// Reencrypt seals up to limit logs not sealed with the current key with it,
+// including logs written before encryption was turned on, and returns how
+// many it rewrote.
+func (s *Store) Reencrypt(ctx context.Context, limit int) (int64, error) {
+	if s.keyring == nil {
+		return 0, mlog.ErrNotSupported
+	}
+
+	current, ok := s.keyring.Current()
+	if !ok {
+		return 0, envelope.ErrNoCurrentKey
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	type row struct {
+		seq     int64
+		id      string
+		message []byte
+		keyID   string
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT seq, id, message, key_id FROM logs WHERE key_id != ? LIMIT ?`, current.ID, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	var stale []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.seq, &r.id, &r.message, &r.keyID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		stale = append(stale, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, r := range stale {
+		message := r.message
+		if r.keyID != "" {
+			if message, err = s.open(r.keyID, message, messageAAD(r.id)); err != nil {
+				return 0, fmt.Errorf("opening log %s: %w", r.id, err)
+			}
+		}
+
+		keyID, sealed, err := s.keyring.Seal(message, messageAAD(r.id))
+		if err != nil {
+			return 0, fmt.Errorf("sealing log %s: %w", r.id, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE logs SET message = ?, key_id = ? WHERE seq = ?`, sealed, keyID, r.seq); err != nil {
+			return 0, err
+		}
+
+		if err := s.reencryptMetadata(ctx, tx, r.seq, r.id, r.keyID, keyID); err != nil {
+			return 0, err
+		}
+
+		// Plaintext logs were indexed for message search, which would keep
+		// their words in the file.
+		if r.keyID == "" {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM logs_fts WHERE rowid = ?`, r.seq); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return int64(len(stale)), nil
+}
+
+// reencryptMetadata moves the metadata of a log from the key it was sealed
+// with, empty for plaintext, to keyID.
+func (s *Store) reencryptMetadata(ctx context.Context, tx *sql.Tx, seq int64, id, oldKeyID, keyID string) error {
+	rows, err := tx.QueryContext(ctx, `SELECT key, value, sealed FROM log_metadata WHERE log_seq = ?`, seq)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]string)
+	for rows.Next() {
+		var (
+			key, value string
+			sealed     []byte
+		)
+		if err := rows.Scan(&key, &value, &sealed); err != nil {
+			rows.Close()
+			return err
+		}
+
+		if sealed != nil {
+			opened, err := s.open(oldKeyID, sealed, metadataAAD(id, key))
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("opening metadata of log %s: %w", id, err)
+			}
+			value = string(opened)
+		}
+		values[key] = value
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for k, v := range values {
+		value, sealed, err := s.sealValue(keyID, id, k, v)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE log_metadata SET value = ?, sealed = ? WHERE log_seq = ? AND key = ?`, value, sealed, seq, k)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sealValue returns what is stored for the metadata value v of key: v
+// itself when the log is not sealed, else its keyed hash for filters and v
+// sealed with the key of the log.
+func (s *Store) sealValue(keyID, id, key, v string) (string, []byte, error) {
+	if keyID == "" {
+		return v, nil, nil
+	}
+
+	sealed, err := s.keyring.SealWith(keyID, []byte(v), metadataAAD(id, key))
+	if err != nil {
+		return "", nil, fmt.Errorf("sealing metadata: %w", err)
+	}
+
+	return s.keyring.Index(v), sealed, nil
+}
+
+// open decrypts data sealed with the data key keyID. Metadata values are
+// sealed with the key of their log.
+func (s *Store) open(keyID string, sealed, aad []byte) ([]byte, error) {
+	if s.keyring == nil {
+		return nil, fmt.Errorf("%w: %s", envelope.ErrUnknownKey, keyID)
+	}
+	return s.keyring.Open(keyID, sealed, aad)
+}
+
+var _ mlog.KeyRotator = (*Store)(nil)
@@ -0,0 +1,240 @@
+package sqlite
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/foundation/envelope"
+	"github.com/oklog/ulid/v2"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(context.Context, string, ...interface{})  {}
+func (nopLogger) Error(context.Context, string, ...interface{}) {}
+
+func newMasterKey(t *testing.T) []byte {
+	t.Helper()
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("generating master key: %v", err)
+	}
+	return key
+}
+
+// newTestStore opens the database at path, sealed with master unless it is
+// nil.
+func newTestStore(t *testing.T, path string, master []byte) (*Store, error) {
+	t.Helper()
+
+	cfg := Config{Path: path, ExportPath: t.TempDir()}
+	if master != nil {
+		k, err := envelope.NewKeyring(master)
+		if err != nil {
+			t.Fatalf("NewKeyring: %v", err)
+		}
+		cfg.Keyring = k
+	}
+
+	return NewStore(context.Background(), nopLogger{}, cfg)
+}
+
+func testLog(message, service string) *mlog.Log {
+	return &mlog.Log{
+		ID:        ulid.Make(),
+		Message:   message,
+		Timestamp: time.Now().UTC(),
+		Level:     mlog.Info,
+		Metadata:  map[string]string{"service": service},
+	}
+}
+
+func searchService(t *testing.T, s *Store, service string) []mlog.Log {
+	t.Helper()
+
+	res, err := s.Search(context.Background(), mlog.SearchCriteria{
+		Metadata: map[string]string{"service": service},
+		PageSize: 10,
+	})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	return res.Logs
+}
+
+// Messages and metadata values are stored sealed, read back in the clear,
+// and metadata filters match through the keyed hash of the value.
+func TestEncryptedRoundTrip(t *testing.T) {
+	s, err := newTestStore(t, filepath.Join(t.TempDir(), "logs.db"), newMasterKey(t))
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer s.Close()
+
+	ctx := context.Background()
+	log := testLog("card charged", "billing")
+	if err := s.Write(ctx, log); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := s.Write(ctx, testLog("user logged in", "api")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	var message []byte
+	if err := s.db.QueryRow(`SELECT message FROM logs WHERE id = ?`, log.ID.String()).Scan(&message); err != nil {
+		t.Fatalf("reading message: %v", err)
+	}
+	if bytes.Contains(message, []byte("card charged")) {
+		t.Errorf("stored message is plaintext")
+	}
+
+	var (
+		value  string
+		sealed []byte
+	)
+	err = s.db.QueryRow(`SELECT m.value, m.sealed FROM log_metadata m JOIN logs l ON l.seq = m.log_seq WHERE l.id = ? AND m.key = 'service'`,
+		log.ID.String()).Scan(&value, &sealed)
+	if err != nil {
+		t.Fatalf("reading metadata: %v", err)
+	}
+	if value == "billing" || value != s.keyring.Index("billing") || bytes.Contains(sealed, []byte("billing")) {
+		t.Errorf("stored metadata value = %q, want the keyed hash of billing", value)
+	}
+
+	logs := searchService(t, s, "billing")
+	if len(logs) != 1 {
+		t.Fatalf("found %d logs, want 1", len(logs))
+	}
+	if logs[0].ID != log.ID || logs[0].Message != "card charged" || logs[0].Metadata["service"] != "billing" {
+		t.Errorf("log = %+v, want %+v", logs[0], *log)
+	}
+}
+
+// A sealed message is bound to its log, so one moved onto another log
+// fails to open instead of being read as that log's.
+func TestSwappedMessage(t *testing.T) {
+	s, err := newTestStore(t, filepath.Join(t.TempDir(), "logs.db"), newMasterKey(t))
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer s.Close()
+
+	ctx := context.Background()
+	first, second := testLog("first", "api"), testLog("second", "api")
+	for _, log := range []*mlog.Log{first, second} {
+		if err := s.Write(ctx, log); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	_, err = s.db.Exec(`UPDATE logs SET message = (SELECT message FROM logs WHERE id = ?) WHERE id = ?`,
+		first.ID.String(), second.ID.String())
+	if err != nil {
+		t.Fatalf("swapping message: %v", err)
+	}
+
+	if _, err := s.Search(ctx, mlog.SearchCriteria{PageSize: 10}); !errors.Is(err, envelope.ErrDecrypt) {
+		t.Fatalf("Search = %v, want %v", err, envelope.ErrDecrypt)
+	}
+}
+
+// Reencrypt moves logs sealed with an older key, and logs written before
+// encryption was turned on, to the current key.
+func TestReencrypt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.db")
+	ctx := context.Background()
+
+	plain, err := newTestStore(t, path, nil)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	old := testLog("written in the clear", "api")
+	if err := plain.Write(ctx, old); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	plain.Close()
+
+	master := newMasterKey(t)
+	s, err := newTestStore(t, path, master)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer s.Close()
+
+	sealed := testLog("sealed before rotation", "api")
+	if err := s.Write(ctx, sealed); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	keyID, err := s.RotateKey(ctx)
+	if err != nil {
+		t.Fatalf("RotateKey: %v", err)
+	}
+
+	n, err := s.Reencrypt(ctx, 10)
+	if err != nil {
+		t.Fatalf("Reencrypt: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("reencrypted %d logs, want 2", n)
+	}
+	if n, err := s.Reencrypt(ctx, 10); err != nil || n != 0 {
+		t.Errorf("second Reencrypt = %d, %v; want 0, nil", n, err)
+	}
+
+	var stale int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM logs WHERE key_id != ?`, keyID).Scan(&stale); err != nil {
+		t.Fatalf("counting logs: %v", err)
+	}
+	if stale != 0 {
+		t.Errorf("%d logs not sealed with %s", stale, keyID)
+	}
+
+	var indexed int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM logs_fts`).Scan(&indexed); err != nil {
+		t.Fatalf("counting indexed messages: %v", err)
+	}
+	if indexed != 0 {
+		t.Errorf("%d plaintext messages still indexed", indexed)
+	}
+
+	logs := searchService(t, s, "api")
+	want := map[ulid.ULID]string{old.ID: old.Message, sealed.ID: sealed.Message}
+	if len(logs) != len(want) {
+		t.Fatalf("found %d logs, want %d", len(logs), len(want))
+	}
+	for _, log := range logs {
+		if log.Message != want[log.ID] {
+			t.Errorf("log %s message = %q, want %q", log.ID, log.Message, want[log.ID])
+		}
+	}
+}
+
+// Reopening the database with another master key fails instead of
+// creating a fresh data key beside the stored ones.
+func TestWrongMasterKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.db")
+
+	s, err := newTestStore(t, path, newMasterKey(t))
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if err := s.Write(context.Background(), testLog("user logged in", "api")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	s.Close()
+
+	if s, err := newTestStore(t, path, newMasterKey(t)); !errors.Is(err, envelope.ErrDecrypt) {
+		if err == nil {
+			s.Close()
+		}
+		t.Fatalf("NewStore = %v, want %v", err, envelope.ErrDecrypt)
+	}
+}
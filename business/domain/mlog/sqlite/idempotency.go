@@ -0,0 +1,63 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// ClaimIdempotencyKey inserts the binding of key, replacing it only when it
+// is older than since. The transaction takes the write lock up front, so
+// claims of the same key never interleave.
+func (s *Store) ClaimIdempotencyKey(ctx context.Context, key string, id ulid.ULID, at, since time.Time) (ulid.ULID, bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ulid.ULID{}, false, err
+	}
+	defer tx.Rollback()
+
+	var bound string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO idempotency_keys (key, log_id, created_at) VALUES (?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET log_id = excluded.log_id, created_at = excluded.created_at
+		WHERE idempotency_keys.created_at < ?
+		RETURNING log_id`,
+		key, id.String(), at.UnixNano(), since.UnixNano(),
+	).Scan(&bound)
+
+	claimed := err == nil
+	if errors.Is(err, sql.ErrNoRows) {
+		err = tx.QueryRowContext(ctx, `SELECT log_id FROM idempotency_keys WHERE key = ?`, key).Scan(&bound)
+	}
+	if err != nil {
+		return ulid.ULID{}, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ulid.ULID{}, false, err
+	}
+
+	owner, err := ulid.Parse(bound)
+	if err != nil {
+		return ulid.ULID{}, false, fmt.Errorf("parsing id %q: %w", bound, err)
+	}
+
+	return owner, claimed, nil
+}
+
+func (s *Store) ReleaseIdempotencyKey(ctx context.Context, key string, id ulid.ULID) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = ? AND log_id = ?`, key, id.String())
+	return err
+}
+
+func (s *Store) PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE created_at < ?`, before.UnixNano())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
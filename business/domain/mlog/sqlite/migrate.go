@@ -0,0 +1,235 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// migrations are applied in order and recorded in schema_migrations. Never
+// edit an entry that has shipped; append a new one instead.
+var migrations = []string{
+	// 1: logs, one row per log. Timestamps are unix nanoseconds and message
+	// holds the compressed bytes when algorithm is set.
+	`CREATE TABLE logs (
+		seq           INTEGER PRIMARY KEY AUTOINCREMENT,
+		id            TEXT    NOT NULL UNIQUE,
+		timestamp     INTEGER NOT NULL,
+		level         TEXT    NOT NULL,
+		message       BLOB    NOT NULL,
+		raw_size      INTEGER NOT NULL,
+		algorithm     TEXT    NOT NULL DEFAULT '',
+		compressed_at INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX logs_timestamp_level ON logs (timestamp, level);`,
+
+	// 2: metadata side table, indexed for equality filters.
+	`CREATE TABLE log_metadata (
+		log_seq INTEGER NOT NULL REFERENCES logs (seq) ON DELETE CASCADE,
+		key     TEXT    NOT NULL,
+		value   TEXT    NOT NULL,
+		PRIMARY KEY (log_seq, key)
+	) WITHOUT ROWID;
+	CREATE INDEX log_metadata_key_value ON log_metadata (key, value, log_seq);`,
+
+	// 3: full-text index of the uncompressed messages. It stores no copy of
+	// the text, rows are keyed by logs.seq.
+	`CREATE VIRTUAL TABLE logs_fts USING fts5 (
+		message,
+		content = '',
+		contentless_delete = 1,
+		tokenize = 'unicode61'
+	);
+	CREATE TRIGGER logs_fts_delete AFTER DELETE ON logs BEGIN
+		DELETE FROM logs_fts WHERE rowid = old.seq;
+	END;`,
+
+	// 4: idempotency keys and the log each one produced.
+	`CREATE TABLE idempotency_keys (
+		key        TEXT    PRIMARY KEY,
+		log_id     TEXT    NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX idempotency_keys_created_at ON idempotency_keys (created_at);`,
+
+	// 5: the tenant owning each log, '' for none.
+	`ALTER TABLE logs ADD COLUMN tenant TEXT NOT NULL DEFAULT '';
+	CREATE INDEX logs_tenant_timestamp ON logs (tenant, timestamp);`,
+
+	// 6: API keys, read by the apikey/sqlite store. Scopes are comma
+	// separated and times are Unix nanoseconds, 0 for none.
+	`CREATE TABLE api_keys (
+		id             TEXT    PRIMARY KEY,
+		name           TEXT    NOT NULL,
+		hash           TEXT    NOT NULL UNIQUE,
+		scopes         TEXT    NOT NULL,
+		tenant         TEXT    NOT NULL DEFAULT '',
+		created_by     TEXT    NOT NULL DEFAULT '',
+		created_at     INTEGER NOT NULL,
+		expires_at     INTEGER NOT NULL DEFAULT 0,
+		previous_hash  TEXT    NOT NULL DEFAULT '',
+		previous_until INTEGER NOT NULL DEFAULT 0,
+		rotated_at     INTEGER NOT NULL DEFAULT 0,
+		revoked_by     TEXT    NOT NULL DEFAULT '',
+		revoked_at     INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX api_keys_previous_hash ON api_keys (previous_hash);`,
+
+	// 7: the role restricting what each API key reads, '' for none.
+	`ALTER TABLE api_keys ADD COLUMN role TEXT NOT NULL DEFAULT '';`,
+
+	// 8: the audit trail, read by the audit/sqlite store. Triggers keep it
+	// append-only; time is Unix milliseconds and duration nanoseconds.
+	`CREATE TABLE audit_log (
+		seq       INTEGER PRIMARY KEY AUTOINCREMENT,
+		id        TEXT    NOT NULL UNIQUE,
+		time      INTEGER NOT NULL,
+		actor     TEXT    NOT NULL DEFAULT '',
+		tenant    TEXT    NOT NULL DEFAULT '',
+		method    TEXT    NOT NULL,
+		request   TEXT    NOT NULL DEFAULT '',
+		results   INTEGER NOT NULL DEFAULT 0,
+		bytes     INTEGER NOT NULL DEFAULT 0,
+		duration  INTEGER NOT NULL DEFAULT 0,
+		code      TEXT    NOT NULL,
+		prev_hash TEXT    NOT NULL DEFAULT '',
+		hash      TEXT    NOT NULL
+	);
+	CREATE INDEX audit_log_time ON audit_log (time);
+	CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+	BEGIN
+		SELECT RAISE(ABORT, 'audit_log is append-only');
+	END;
+	CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+	BEGIN
+		SELECT RAISE(ABORT, 'audit_log is append-only');
+	END;`,
+
+	// 9: encryption at rest. key_id names the data key sealing the message,
+	// '' for plaintext. Sealed metadata values live in sealed, with value
+	// holding their keyed hash for equality filters. Data keys are stored
+	// wrapped by the master key.
+	`ALTER TABLE logs ADD COLUMN key_id TEXT NOT NULL DEFAULT '';
+	CREATE INDEX logs_key_id ON logs (key_id);
+	ALTER TABLE log_metadata ADD COLUMN sealed BLOB;
+	CREATE TABLE data_keys (
+		id         TEXT    PRIMARY KEY,
+		wrapped    BLOB    NOT NULL,
+		created_at INTEGER NOT NULL
+	);`,
+
+	// 10: the hash chains of the logs and their signed checkpoints, read by
+	// the chain/sqlite store. Triggers keep both append-only; times are in
+	// milliseconds.
+	`CREATE TABLE log_chain (
+		tenant    TEXT    NOT NULL,
+		seq       INTEGER NOT NULL,
+		log_id    TEXT    NOT NULL,
+		timestamp INTEGER NOT NULL,
+		digest    TEXT    NOT NULL,
+		prev_hash TEXT    NOT NULL DEFAULT '',
+		hash      TEXT    NOT NULL,
+		PRIMARY KEY (tenant, seq)
+	) WITHOUT ROWID;
+	CREATE INDEX log_chain_timestamp ON log_chain (tenant, timestamp);
+	CREATE TABLE chain_checkpoints (
+		tenant    TEXT    NOT NULL,
+		seq       INTEGER NOT NULL,
+		hash      TEXT    NOT NULL,
+		time      INTEGER NOT NULL,
+		key_id    TEXT    NOT NULL,
+		signature BLOB    NOT NULL,
+		PRIMARY KEY (tenant, seq)
+	) WITHOUT ROWID;
+	CREATE TRIGGER log_chain_no_update BEFORE UPDATE ON log_chain
+	BEGIN
+		SELECT RAISE(ABORT, 'log_chain is append-only');
+	END;
+	CREATE TRIGGER log_chain_no_delete BEFORE DELETE ON log_chain
+	BEGIN
+		SELECT RAISE(ABORT, 'log_chain is append-only');
+	END;
+	CREATE TRIGGER chain_checkpoints_no_update BEFORE UPDATE ON chain_checkpoints
+	BEGIN
+		SELECT RAISE(ABORT, 'chain_checkpoints is append-only');
+	END;
+	CREATE TRIGGER chain_checkpoints_no_delete BEFORE DELETE ON chain_checkpoints
+	BEGIN
+		SELECT RAISE(ABORT, 'chain_checkpoints is append-only');
+	END;`,
+
+	// 11: signed tombstones of the chained logs erased, redacted or expired
+	// on purpose. log_ids holds a JSON array and expired_before is 0 unless the
+	// tombstone is for retention.
+	`CREATE TABLE chain_tombstones (
+		tenant         TEXT    NOT NULL,
+		reason         TEXT    NOT NULL,
+		log_ids        TEXT    NOT NULL DEFAULT '[]',
+		expired_before INTEGER NOT NULL DEFAULT 0,
+		time           INTEGER NOT NULL,
+		key_id         TEXT    NOT NULL,
+		signature      BLOB    NOT NULL
+	);
+	CREATE INDEX chain_tombstones_tenant ON chain_tombstones (tenant);
+	CREATE TRIGGER chain_tombstones_no_update BEFORE UPDATE ON chain_tombstones
+	BEGIN
+		SELECT RAISE(ABORT, 'chain_tombstones is append-only');
+	END;
+	CREATE TRIGGER chain_tombstones_no_delete BEFORE DELETE ON chain_tombstones
+	BEGIN
+		SELECT RAISE(ABORT, 'chain_tombstones is append-only');
+	END;`,
+
+	// 12: the audit entry recorded when a call started, on the entry
+	// recording how it ended. The unique prev_hash refuses a second entry
+	// linking to the same one.
+	`ALTER TABLE audit_log ADD COLUMN pending_id TEXT NOT NULL DEFAULT '';
+	CREATE UNIQUE INDEX audit_log_prev_hash ON audit_log (prev_hash);`,
+}
+
+func migrate(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("creating schema_migrations: %w", err)
+	}
+
+	var current int
+	err = db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current)
+	if err != nil {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+
+	if current > len(migrations) {
+		return fmt.Errorf("schema version %d is newer than this binary (%d)", current, len(migrations))
+	}
+
+	for i := current; i < len(migrations); i++ {
+		version := i + 1
+
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("applying migration %d: %w", version, err)
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, version, time.Now().Unix())
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("recording migration %d: %w", version, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("committing migration %d: %w", version, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,251 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	scanBatchSize   = 1000
+	deleteBatchSize = 1000
+)
+
+// buildWhere translates criteria into a WHERE clause over logs. Metadata
+// filters go through the (key, value) index of log_metadata and the message
+// filter through the FTS5 index.
+func (s *Store) buildWhere(criteria mlog.SearchCriteria) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+
+	if !criteria.AllTenants {
+		conds = append(conds, "tenant = ?")
+		args = append(args, criteria.Tenant)
+	}
+
+	if !criteria.TimeRange.StartTime.IsZero() {
+		conds = append(conds, "timestamp >= ?")
+		args = append(args, criteria.TimeRange.StartTime.UnixNano())
+	}
+	if !criteria.TimeRange.EndTime.IsZero() {
+		conds = append(conds, "timestamp <= ?")
+		args = append(args, criteria.TimeRange.EndTime.UnixNano())
+	}
+
+	if criteria.Level != "" {
+		conds = append(conds, "level = ?")
+		args = append(args, string(criteria.Level))
+	}
+
+	if len(criteria.Levels) > 0 {
+		conds = append(conds, "level IN (?"+strings.Repeat(", ?", len(criteria.Levels)-1)+")")
+		for _, level := range criteria.Levels {
+			args = append(args, string(level))
+		}
+	}
+
+	for k, v := range criteria.Metadata {
+		if s.keyring != nil {
+			// Sealed values are found by their keyed hash, values of logs not
+			// yet reencrypted by themselves.
+			conds = append(conds, "seq IN (SELECT log_seq FROM log_metadata WHERE key = ? AND value IN (?, ?))")
+			args = append(args, k, s.keyring.Index(v), v)
+			continue
+		}
+		conds = append(conds, "seq IN (SELECT log_seq FROM log_metadata WHERE key = ? AND value = ?)")
+		args = append(args, k, v)
+	}
+
+	if match := ftsQuery(criteria.Message); match != "" {
+		conds = append(conds, "seq IN (SELECT rowid FROM logs_fts WHERE logs_fts MATCH ?)")
+		args = append(args, match)
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// ftsQuery quotes every word of message so FTS5 syntax in user input is
+// matched literally. Quoted strings separated by spaces must all match.
+func ftsQuery(message string) string {
+	terms := strings.Fields(message)
+	for i, term := range terms {
+		terms[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	}
+	return strings.Join(terms, " ")
+}
+
+// queryLogs runs a query selecting logColumns and loads the metadata of the
+// returned logs. It also returns their row ids.
+func (s *Store) queryLogs(ctx context.Context, query string, args ...any) ([]mlog.Log, []int64, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var (
+		logs []mlog.Log
+		seqs []int64
+	)
+	for rows.Next() {
+		log, seq, err := s.scanLog(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		logs = append(logs, log)
+		seqs = append(seqs, seq)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	rows.Close()
+
+	if err := s.loadMetadata(ctx, logs, seqs); err != nil {
+		return nil, nil, fmt.Errorf("loading metadata: %w", err)
+	}
+
+	return logs, seqs, nil
+}
+
+// loadMetadata fills the metadata of logs, whose row ids are seqs.
+func (s *Store) loadMetadata(ctx context.Context, logs []mlog.Log, seqs []int64) error {
+	if len(seqs) == 0 {
+		return nil
+	}
+
+	index := make(map[int64]int, len(seqs))
+	args := make([]any, len(seqs))
+	for i, seq := range seqs {
+		index[seq] = i
+		args[i] = seq
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(seqs)), ",")
+	rows, err := s.db.QueryContext(ctx, `SELECT m.log_seq, m.key, m.value, m.sealed, l.key_id FROM log_metadata m JOIN logs l ON l.seq = m.log_seq WHERE m.log_seq IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			seq        int64
+			key, value string
+			sealed     []byte
+			keyID      string
+		)
+		if err := rows.Scan(&seq, &key, &value, &sealed, &keyID); err != nil {
+			return err
+		}
+
+		log := &logs[index[seq]]
+		if sealed != nil {
+			opened, err := s.open(keyID, sealed, metadataAAD(log.ID.String(), key))
+			if err != nil {
+				return fmt.Errorf("opening metadata of log %s: %w", log.ID, err)
+			}
+			value = string(opened)
+		}
+
+		if log.Metadata == nil {
+			log.Metadata = make(map[string]string)
+		}
+		log.Metadata[key] = value
+	}
+
+	return rows.Err()
+}
+
+// Scan walks the logs matching criteria oldest first. Logs are read in
+// batches resuming after the last (timestamp, seq) seen, so no read
+// transaction stays open while fn runs.
+func (s *Store) Scan(ctx context.Context, criteria mlog.SearchCriteria, fn func(mlog.Log) error) error {
+	where, args := s.buildWhere(criteria)
+
+	var (
+		lastTimestamp int64
+		lastSeq       int64
+		started       bool
+	)
+
+	for {
+		query := `SELECT ` + logColumns + ` FROM logs` + where
+		batchArgs := append([]any(nil), args...)
+
+		if started {
+			keyset := "(timestamp > ? OR (timestamp = ? AND seq > ?))"
+			if where == "" {
+				query += " WHERE " + keyset
+			} else {
+				query += " AND " + keyset
+			}
+			batchArgs = append(batchArgs, lastTimestamp, lastTimestamp, lastSeq)
+		}
+
+		query += ` ORDER BY timestamp, seq LIMIT ?`
+		batchArgs = append(batchArgs, scanBatchSize)
+
+		logs, seqs, err := s.queryLogs(ctx, query, batchArgs...)
+		if err != nil {
+			return fmt.Errorf("scanning logs: %w", err)
+		}
+
+		for _, log := range logs {
+			if err := fn(log); err != nil {
+				return err
+			}
+		}
+
+		if len(logs) < scanBatchSize {
+			return nil
+		}
+
+		started = true
+		lastTimestamp = logs[len(logs)-1].Timestamp.UnixNano()
+		lastSeq = seqs[len(seqs)-1]
+	}
+}
+
+// Prune deletes the logs of ids inside tr in batches, so writers are never
+// blocked behind one long delete. Metadata and index rows follow through the
+// foreign key and the FTS trigger.
+func (s *Store) Prune(ctx context.Context, tr mlog.TimeRange, ids []ulid.ULID) (int64, error) {
+	where, args := s.buildWhere(mlog.SearchCriteria{AllTenants: true, TimeRange: tr})
+	if where == "" {
+		where = " WHERE "
+	} else {
+		where += " AND "
+	}
+
+	var deleted int64
+	for start := 0; start < len(ids); start += deleteBatchSize {
+		end := min(start+deleteBatchSize, len(ids))
+
+		batch := append([]any(nil), args...)
+		for _, id := range ids[start:end] {
+			batch = append(batch, id.String())
+		}
+
+		query := `DELETE FROM logs` + where + `id IN (?` + strings.Repeat(", ?", end-start-1) + `)`
+		res, err := s.db.ExecContext(ctx, query, batch...)
+		if err != nil {
+			return deleted, fmt.Errorf("pruning logs: %w", err)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return deleted, fmt.Errorf("pruning logs: %w", err)
+		}
+		deleted += n
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,392 @@
+// Package sqlite implements mlog.Store on a single SQLite database file, for
+// edge deployments running on one box.
+//
+// The database runs in WAL mode so queries read while logs are written.
+// Metadata lives in a side table indexed by key and value, and messages are
+// indexed with FTS5 so SearchCriteria.Message is answered without
+// decompressing them. The schema is created and upgraded by migrations on
+// startup.
+//
+// With a keyring, messages and metadata values are sealed after compression
+// and the FTS5 index is left empty, so no plaintext reaches the file.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/foundation/blob"
+	"github.com/felipecooper/log-horizon/foundation/compress"
+	"github.com/felipecooper/log-horizon/foundation/envelope"
+	"github.com/felipecooper/log-horizon/foundation/logger"
+	"github.com/oklog/ulid/v2"
+
+	_ "modernc.org/sqlite"
+)
+
+const (
+	compressionThreshold = 100
+	defaultPageSize      = 50
+	busyTimeout          = 5 * time.Second
+)
+
+type Config struct {
+	Path             string
+	Compression      compress.Algorithm
+	CompressionLevel int
+	ExportPath       string
+	ExportBucket     blob.Bucket
+	// Keyring, when set, encrypts messages and metadata values at rest.
+	Keyring *envelope.Keyring
+}
+
+type Store struct {
+	log        logger.Logger
+	db         *sql.DB
+	compressor compress.Compressor
+	exports    blob.Bucket
+	keyring    *envelope.Keyring
+}
+
+func NewStore(ctx context.Context, log logger.Logger, cfg Config) (*Store, error) {
+	var compressor compress.Compressor
+	switch cfg.Compression {
+	case compress.None:
+	case "":
+		compressor, _ = compress.NewGzipCompressor(compress.DefaultLevel)
+	default:
+		c, err := compress.New(cfg.Compression, cfg.CompressionLevel)
+		if err != nil {
+			return nil, fmt.Errorf("creating compressor: %w", err)
+		}
+		compressor = c
+	}
+
+	db, err := sql.Open("sqlite", dsn(cfg.Path))
+	if err != nil {
+		return nil, fmt.Errorf("opening SQLite: %w", err)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("opening SQLite: %w", err)
+	}
+
+	if err := migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating schema: %w", err)
+	}
+
+	exports := cfg.ExportBucket
+	if exports == nil {
+		exports = blob.NewLocal(cfg.ExportPath)
+	}
+
+	s := Store{
+		log:        log,
+		db:         db,
+		compressor: compressor,
+		exports:    exports,
+		keyring:    cfg.Keyring,
+	}
+
+	if s.keyring != nil {
+		if err := s.loadKeys(ctx); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("loading data keys: %w", err)
+		}
+	}
+
+	return &s, nil
+}
+
+// dsn sets the pragmas on every pooled connection. Transactions start with
+// BEGIN IMMEDIATE so concurrent writers wait on busy_timeout instead of
+// failing when they upgrade a read lock.
+func dsn(path string) string {
+	params := url.Values{}
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "synchronous(NORMAL)")
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds()))
+	params.Set("_txlock", "immediate")
+
+	return "file:" + path + "?" + params.Encode()
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// DB returns the database of the store, for stores of other domains that
+// keep their tables in it.
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+func (s *Store) Write(ctx context.Context, log *mlog.Log) error {
+	message := []byte(log.Message)
+	rawSize := len(message)
+
+	var (
+		algorithm    compress.Algorithm
+		compressedAt time.Time
+	)
+	if s.compressor != nil && rawSize > compressionThreshold {
+		compressed, err := s.compressor.Compress(message)
+		if err != nil {
+			s.log.Error(ctx, "failed to compress log message", "error", err)
+		} else if len(compressed) < rawSize {
+			message = compressed
+			algorithm = s.compressor.Algorithm()
+			compressedAt = time.Now()
+		}
+	}
+
+	var keyID string
+	if s.keyring != nil {
+		id, sealed, err := s.keyring.Seal(message, messageAAD(log.ID.String()))
+		if err != nil {
+			return fmt.Errorf("sealing message: %w", err)
+		}
+		keyID, message = id, sealed
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO logs (id, tenant, timestamp, level, message, raw_size, algorithm, compressed_at, key_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO NOTHING`,
+		log.ID.String(), log.Tenant, log.Timestamp.UnixNano(), string(log.Level), message, rawSize, string(algorithm), nanos(compressedAt), keyID,
+	)
+	if err != nil {
+		s.log.Error(ctx, "failed to insert log in SQLite", "error", err)
+		return err
+	}
+
+	// A log already stored, as replayed again by the spool, is kept as is.
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return nil
+	}
+
+	seq, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for k, v := range log.Metadata {
+		value, sealed, err := s.sealValue(keyID, log.ID.String(), k, v)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO log_metadata (log_seq, key, value, sealed) VALUES (?, ?, ?, ?)`, seq, k, value, sealed)
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.keyring == nil {
+		_, err = tx.ExecContext(ctx, `INSERT INTO logs_fts (rowid, message) VALUES (?, ?)`, seq, log.Message)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if algorithm != "" {
+		log.Compressed = true
+		log.CompressedAt = compressedAt
+		log.Compression = string(algorithm)
+	}
+
+	return nil
+}
+
+func (s *Store) Search(ctx context.Context, criteria mlog.SearchCriteria) (mlog.SearchResult, error) {
+	where, args := s.buildWhere(criteria)
+
+	pageSize := criteria.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	query := `SELECT ` + logColumns + ` FROM logs` + where +
+		` ORDER BY timestamp DESC, seq DESC LIMIT ? OFFSET ?`
+	args = append(args, pageSize, criteria.Page*pageSize)
+
+	logs, _, err := s.queryLogs(ctx, query, args...)
+	if err != nil {
+		return mlog.SearchResult{}, err
+	}
+
+	totalCount, err := s.Count(ctx, criteria)
+	if err != nil {
+		s.log.Error(ctx, "failed to count logs", "error", err)
+	}
+
+	hasMore := (criteria.Page+1)*pageSize < totalCount
+	nextPage := criteria.Page + 1
+	if !hasMore {
+		nextPage = criteria.Page
+	}
+
+	return mlog.SearchResult{
+		Logs:     logs,
+		Total:    totalCount,
+		HasMore:  hasMore,
+		NextPage: nextPage,
+	}, nil
+}
+
+func (s *Store) Count(ctx context.Context, criteria mlog.SearchCriteria) (int, error) {
+	where, args := s.buildWhere(criteria)
+
+	var count int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM logs`+where, args...).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// ExportToFile streams the matching rows straight into the export file, so
+// memory use does not depend on how many logs are exported.
+func (s *Store) ExportToFile(ctx context.Context, criteria mlog.SearchCriteria) (string, int64, error) {
+	where, args := s.buildWhere(criteria)
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+logColumns+` FROM logs`+where+` ORDER BY timestamp DESC, seq DESC`, args...)
+	if err != nil {
+		return "", 0, err
+	}
+	defer rows.Close()
+
+	filename := mlog.ExportFilename(criteria, time.Now())
+
+	file, err := s.exports.Create(ctx, filename)
+	if err != nil {
+		return "", 0, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			blob.Abort(file)
+		}
+	}()
+
+	var size int64
+	for rows.Next() {
+		log, _, err := s.scanLog(ctx, rows)
+		if err != nil {
+			s.log.Error(ctx, "failed to read log row", "error", err)
+			return "", 0, fmt.Errorf("reading log: %w", err)
+		}
+
+		line := fmt.Sprintf("[%s] [%s] %s\n", log.Timestamp.Format(time.RFC3339), log.Level, log.Message)
+		n, err := io.WriteString(file, line)
+		if err != nil {
+			s.log.Error(ctx, "error writing to export file", "error", err)
+			return "", 0, fmt.Errorf("writing export: %w", err)
+		}
+		size += int64(n)
+	}
+	if err := rows.Err(); err != nil {
+		return "", 0, err
+	}
+
+	if err := file.Close(); err != nil {
+		return "", 0, err
+	}
+	committed = true
+
+	fileURL, err := s.exports.URL(ctx, filename)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return fileURL, size, nil
+}
+
+// SearchMessages reports that message filters are answered by the FTS5
+// index, which is not kept when messages are encrypted.
+func (s *Store) SearchMessages() bool {
+	return s.keyring == nil
+}
+
+const logColumns = `seq, id, tenant, timestamp, level, message, algorithm, compressed_at, key_id`
+
+// scanLog decodes a row selected with logColumns, decrypting and
+// decompressing the message. Metadata is loaded separately by loadMetadata.
+func (s *Store) scanLog(ctx context.Context, rows *sql.Rows) (mlog.Log, int64, error) {
+	var (
+		seq          int64
+		id           string
+		tenant       string
+		timestamp    int64
+		level        string
+		message      []byte
+		algorithm    string
+		compressedAt int64
+		keyID        string
+	)
+	if err := rows.Scan(&seq, &id, &tenant, &timestamp, &level, &message, &algorithm, &compressedAt, &keyID); err != nil {
+		return mlog.Log{}, 0, err
+	}
+
+	if keyID != "" {
+		opened, err := s.open(keyID, message, messageAAD(id))
+		if err != nil {
+			return mlog.Log{}, 0, fmt.Errorf("opening log %s: %w", id, err)
+		}
+		message = opened
+	}
+
+	parsed, err := ulid.Parse(id)
+	if err != nil {
+		return mlog.Log{}, 0, fmt.Errorf("parsing id %q: %w", id, err)
+	}
+
+	log := mlog.Log{
+		ID:        parsed,
+		Tenant:    tenant,
+		Message:   string(message),
+		Timestamp: time.Unix(0, timestamp).UTC(),
+		Level:     mlog.Level(level),
+	}
+
+	if algorithm != "" {
+		log.Compressed = true
+		log.Compression = algorithm
+		log.CompressedAt = time.Unix(0, compressedAt).UTC()
+
+		decompressed, err := compress.Decompress(compress.Algorithm(algorithm), message)
+		if err != nil {
+			s.log.Error(ctx, "failed to decompress log message", "error", err, "id", id)
+		} else {
+			log.Message = string(decompressed)
+		}
+	}
+
+	return log, seq, nil
+}
+
+func nanos(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
@@ -0,0 +1,185 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+)
+
+// Stats aggregates the logs table and reports the on-disk size of every
+// table and index from the dbstat virtual table.
+func (s *Store) Stats(ctx context.Context) (mlog.Stats, error) {
+	var stats mlog.Stats
+
+	rows, err := s.db.QueryContext(ctx, `SELECT algorithm, COUNT(*), SUM(raw_size), SUM(LENGTH(message)) FROM logs GROUP BY algorithm ORDER BY algorithm`)
+	if err != nil {
+		return mlog.Stats{}, fmt.Errorf("aggregating algorithms: %w", err)
+	}
+	for rows.Next() {
+		var a mlog.AlgorithmStats
+		if err := rows.Scan(&a.Algorithm, &a.Count, &a.RawBytes, &a.StoredBytes); err != nil {
+			rows.Close()
+			return mlog.Stats{}, err
+		}
+
+		stats.Total += a.Count
+		stats.RawBytes += a.RawBytes
+		stats.StoredBytes += a.StoredBytes
+
+		if a.Algorithm == "" {
+			stats.Uncompressed += a.Count
+			continue
+		}
+		stats.Compressed += a.Count
+		stats.Algorithms = append(stats.Algorithms, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return mlog.Stats{}, err
+	}
+
+	rows, err = s.db.QueryContext(ctx, `SELECT level, COUNT(*) FROM logs GROUP BY level ORDER BY level`)
+	if err != nil {
+		return mlog.Stats{}, fmt.Errorf("aggregating levels: %w", err)
+	}
+	for rows.Next() {
+		var l mlog.LevelStats
+		if err := rows.Scan(&l.Level, &l.Count); err != nil {
+			rows.Close()
+			return mlog.Stats{}, err
+		}
+		stats.Levels = append(stats.Levels, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return mlog.Stats{}, err
+	}
+
+	rows, err = s.db.QueryContext(ctx, `SELECT tenant, COUNT(*) FROM logs GROUP BY tenant ORDER BY tenant`)
+	if err != nil {
+		return mlog.Stats{}, fmt.Errorf("aggregating tenants: %w", err)
+	}
+	for rows.Next() {
+		var t mlog.TenantStats
+		if err := rows.Scan(&t.Tenant, &t.Count); err != nil {
+			rows.Close()
+			return mlog.Stats{}, err
+		}
+		stats.Tenants = append(stats.Tenants, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return mlog.Stats{}, err
+	}
+
+	rows, err = s.db.QueryContext(ctx, `SELECT timestamp / ?, COUNT(*) FROM logs GROUP BY 1 ORDER BY 1`, int64(24*time.Hour))
+	if err != nil {
+		return mlog.Stats{}, fmt.Errorf("aggregating days: %w", err)
+	}
+	for rows.Next() {
+		var (
+			d     int64
+			count int64
+		)
+		if err := rows.Scan(&d, &count); err != nil {
+			rows.Close()
+			return mlog.Stats{}, err
+		}
+		stats.Days = append(stats.Days, mlog.DayStats{
+			Day:   time.Unix(0, d*int64(24*time.Hour)).UTC(),
+			Count: count,
+		})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return mlog.Stats{}, err
+	}
+
+	collections, err := s.collectionStats(ctx)
+	if err != nil {
+		return mlog.Stats{}, fmt.Errorf("reading table sizes: %w", err)
+	}
+	stats.Collections = collections
+	stats.ComputedAt = time.Now()
+
+	return stats, nil
+}
+
+// collectionStats reports logs, log_metadata and the FTS5 index as
+// collections, with the indexes of each table attributed to it.
+func (s *Store) collectionStats(ctx context.Context) ([]mlog.CollectionStats, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT m.name, m.tbl_name, m.type, COALESCE(SUM(d.pgsize), 0), COALESCE(SUM(d.payload), 0)
+		FROM sqlite_schema m LEFT JOIN dbstat d ON d.name = m.name
+		WHERE m.type IN ('table', 'index')
+		GROUP BY m.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := []mlog.CollectionStats{
+		{Name: "logs", IndexSizes: map[string]int64{}},
+		{Name: "log_metadata", IndexSizes: map[string]int64{}},
+		{Name: "logs_fts", IndexSizes: map[string]int64{}},
+	}
+
+	for rows.Next() {
+		var (
+			name, table, kind string
+			pages, payload    int64
+		)
+		if err := rows.Scan(&name, &table, &kind, &pages, &payload); err != nil {
+			return nil, err
+		}
+
+		var c *mlog.CollectionStats
+		switch {
+		case table == "logs":
+			c = &collections[0]
+		case table == "log_metadata":
+			c = &collections[1]
+		case strings.HasPrefix(table, "logs_fts"):
+			// The shadow tables of the FTS5 index are all index data.
+			c = &collections[2]
+			kind = "index"
+		default:
+			continue
+		}
+
+		if kind == "index" {
+			c.IndexSize += pages
+			c.IndexSizes[name] = pages
+			continue
+		}
+		c.Size += payload
+		c.StorageSize += pages
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM logs`).Scan(&collections[0].Count)
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM log_metadata`).Scan(&collections[1].Count)
+	if err != nil {
+		return nil, err
+	}
+	collections[2].Count = collections[0].Count
+
+	return collections, nil
+}
+
+var (
+	_ mlog.Store            = (*Store)(nil)
+	_ mlog.Scanner          = (*Store)(nil)
+	_ mlog.Pruner           = (*Store)(nil)
+	_ mlog.StatsReporter    = (*Store)(nil)
+	_ mlog.MessageSearcher  = (*Store)(nil)
+	_ mlog.IdempotencyStore = (*Store)(nil)
+)
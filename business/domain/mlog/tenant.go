@@ -0,0 +1,89 @@
+package mlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+)
+
+var (
+	ErrTenantRequired = errors.New("tenant is required")
+	ErrInvalidTenant  = errors.New("invalid tenant")
+)
+
+// Tenants are lowercase so they can name collections, databases and export
+// directories on every backend.
+var tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+type tenantKey struct{}
+
+// ContextWithTenant returns a copy of ctx carrying tenant. Every call of
+// Business made with it only writes and reads logs of that tenant.
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFrom returns the tenant carried by ctx, or "" for none.
+func TenantFrom(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+func ValidateTenant(tenant string) error {
+	if !tenantPattern.MatchString(tenant) {
+		return fmt.Errorf("%w: %q must match %s", ErrInvalidTenant, tenant, tenantPattern)
+	}
+	return nil
+}
+
+// WithTenantRequired rejects calls whose context carries no tenant. Without
+// it, such calls use the logs that belong to no tenant.
+func WithTenantRequired(required bool) Option {
+	return func(b *Business) {
+		b.tenantRequired = required
+	}
+}
+
+// tenant returns the tenant of ctx, checking it is allowed.
+func (b *Business) tenant(ctx context.Context) (string, error) {
+	tenant := TenantFrom(ctx)
+	if tenant == "" {
+		if b.tenantRequired {
+			return "", ErrTenantRequired
+		}
+		return "", nil
+	}
+
+	if err := ValidateTenant(tenant); err != nil {
+		return "", err
+	}
+	return tenant, nil
+}
+
+// ExportFilename names the export file of criteria, inside a directory per
+// tenant so exports of different tenants never share a listing.
+func ExportFilename(criteria SearchCriteria, now time.Time) string {
+	filename := fmt.Sprintf("logs_export_%d.txt", now.Unix())
+	if criteria.Tenant == "" {
+		return filename
+	}
+	return criteria.Tenant + "/" + filename
+}
+
+// SortTenantStats turns per tenant counts into stats ordered by tenant,
+// leaving out tenants with no logs.
+func SortTenantStats(counts map[string]int64) []TenantStats {
+	var stats []TenantStats
+	for tenant, count := range counts {
+		if count > 0 {
+			stats = append(stats, TenantStats{Tenant: tenant, Count: count})
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Tenant < stats[j].Tenant
+	})
+	return stats
+}
@@ -0,0 +1,410 @@
+// Package tenants implements an mlog.Store that keeps the logs of each tenant
+// in a store of its own, such as a separate collection or database.
+//
+// Logs of no tenant, and searches made without one, go to the base store,
+// which also keeps idempotency keys, legal holds and deletion audits for
+// every tenant. Tenant stores are opened on first use and stay open.
+// Retention policies are not supported, since they would have to be applied
+// to every store.
+package tenants
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/oklog/ulid/v2"
+)
+
+type Config struct {
+	// Open returns the store holding the logs of tenant, creating it if
+	// needed.
+	Open func(ctx context.Context, tenant string) (mlog.Store, error)
+	// List returns the tenants that already have a store, so Stats and
+	// Compact reach them before they are used.
+	List func(ctx context.Context) ([]string, error)
+}
+
+type Store struct {
+	base mlog.Store
+	open func(ctx context.Context, tenant string) (mlog.Store, error)
+	list func(ctx context.Context) ([]string, error)
+
+	mu     sync.Mutex
+	stores map[string]mlog.Store
+}
+
+func NewStore(base mlog.Store, cfg Config) *Store {
+	return &Store{
+		base:   base,
+		open:   cfg.Open,
+		list:   cfg.List,
+		stores: make(map[string]mlog.Store),
+	}
+}
+
+// store returns the store holding the logs of tenant.
+func (s *Store) store(ctx context.Context, tenant string) (mlog.Store, error) {
+	if tenant == "" {
+		return s.base, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if store, ok := s.stores[tenant]; ok {
+		return store, nil
+	}
+
+	store, err := s.open(ctx, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("opening store of tenant %q: %w", tenant, err)
+	}
+	s.stores[tenant] = store
+
+	return store, nil
+}
+
+// all returns the store of every known tenant.
+func (s *Store) all(ctx context.Context) (map[string]mlog.Store, error) {
+	if s.list != nil {
+		tenants, err := s.list(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing tenants: %w", err)
+		}
+		for _, tenant := range tenants {
+			if err := mlog.ValidateTenant(tenant); err != nil {
+				continue
+			}
+			if _, err := s.store(ctx, tenant); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stores := make(map[string]mlog.Store, len(s.stores))
+	for tenant, store := range s.stores {
+		stores[tenant] = store
+	}
+
+	return stores, nil
+}
+
+func (s *Store) Write(ctx context.Context, log *mlog.Log) error {
+	store, err := s.store(ctx, log.Tenant)
+	if err != nil {
+		return err
+	}
+	return store.Write(ctx, log)
+}
+
+// WriteBatch splits logs by tenant and writes each group to its store,
+// reporting failures by their index in logs.
+func (s *Store) WriteBatch(ctx context.Context, logs []*mlog.Log) error {
+	groups := make(map[string][]int)
+	for i, log := range logs {
+		groups[log.Tenant] = append(groups[log.Tenant], i)
+	}
+
+	failed := make(map[int]error)
+	for tenant, indexes := range groups {
+		batch := make([]*mlog.Log, len(indexes))
+		for j, i := range indexes {
+			batch[j] = logs[i]
+		}
+
+		store, err := s.store(ctx, tenant)
+		if err == nil {
+			err = mlog.WriteBatch(ctx, store, batch)
+		}
+		if err == nil {
+			continue
+		}
+
+		var batchErr *mlog.BatchError
+		if errors.As(err, &batchErr) {
+			for j, err := range batchErr.Failed {
+				failed[indexes[j]] = err
+			}
+			continue
+		}
+		for _, i := range indexes {
+			failed[i] = err
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+	if len(failed) == len(logs) {
+		for _, err := range failed {
+			return err
+		}
+	}
+	return &mlog.BatchError{Failed: failed}
+}
+
+func (s *Store) Search(ctx context.Context, criteria mlog.SearchCriteria) (mlog.SearchResult, error) {
+	store, err := s.store(ctx, criteria.Tenant)
+	if err != nil {
+		return mlog.SearchResult{}, err
+	}
+	return store.Search(ctx, criteria)
+}
+
+func (s *Store) Count(ctx context.Context, criteria mlog.SearchCriteria) (int, error) {
+	store, err := s.store(ctx, criteria.Tenant)
+	if err != nil {
+		return 0, err
+	}
+	return store.Count(ctx, criteria)
+}
+
+func (s *Store) ExportToFile(ctx context.Context, criteria mlog.SearchCriteria) (string, int64, error) {
+	store, err := s.store(ctx, criteria.Tenant)
+	if err != nil {
+		return "", 0, err
+	}
+	return store.ExportToFile(ctx, criteria)
+}
+
+// SearchMessages reports message search as supported only when the base
+// store supports it, since every tenant store is built the same way.
+func (s *Store) SearchMessages() bool {
+	searcher, ok := s.base.(mlog.MessageSearcher)
+	return ok && searcher.SearchMessages()
+}
+
+// Compact compacts the base store and every tenant store, adding up their
+// reports.
+func (s *Store) Compact(ctx context.Context, olderThan time.Time) (mlog.CompactionReport, error) {
+	stores, err := s.all(ctx)
+	if err != nil {
+		return mlog.CompactionReport{}, err
+	}
+
+	var report mlog.CompactionReport
+	compact := func(tenant string, store mlog.Store) error {
+		compactor, ok := store.(mlog.Compactor)
+		if !ok {
+			return nil
+		}
+
+		r, err := compactor.Compact(ctx, olderThan)
+		report.Logs += r.Logs
+		report.Blocks += r.Blocks
+		report.BytesBefore += r.BytesBefore
+		report.BytesAfter += r.BytesAfter
+		if err != nil {
+			return fmt.Errorf("compacting tenant %q: %w", tenant, err)
+		}
+		return nil
+	}
+
+	if err := compact("", s.base); err != nil {
+		return report, err
+	}
+	for tenant, store := range stores {
+		if err := compact(tenant, store); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// Stats adds up the stats of the base store and every tenant store. The
+// collections of tenant stores are listed under tenant/name.
+func (s *Store) Stats(ctx context.Context) (mlog.Stats, error) {
+	stores, err := s.all(ctx)
+	if err != nil {
+		return mlog.Stats{}, err
+	}
+
+	var (
+		stats      mlog.Stats
+		levels     = make(map[mlog.Level]int64)
+		days       = make(map[time.Time]int64)
+		algorithms = make(map[string]*mlog.AlgorithmStats)
+		tenants    = make(map[string]int64)
+	)
+
+	add := func(tenant string, store mlog.Store) error {
+		reporter, ok := store.(mlog.StatsReporter)
+		if !ok {
+			return nil
+		}
+
+		st, err := reporter.Stats(ctx)
+		if err != nil {
+			return fmt.Errorf("stats of tenant %q: %w", tenant, err)
+		}
+
+		stats.Total += st.Total
+		stats.Compressed += st.Compressed
+		stats.Uncompressed += st.Uncompressed
+		stats.RawBytes += st.RawBytes
+		stats.StoredBytes += st.StoredBytes
+
+		for _, l := range st.Levels {
+			levels[l.Level] += l.Count
+		}
+		for _, d := range st.Days {
+			days[d.Day] += d.Count
+		}
+		for _, a := range st.Algorithms {
+			sum, ok := algorithms[a.Algorithm]
+			if !ok {
+				sum = &mlog.AlgorithmStats{Algorithm: a.Algorithm}
+				algorithms[a.Algorithm] = sum
+			}
+			sum.Count += a.Count
+			sum.RawBytes += a.RawBytes
+			sum.StoredBytes += a.StoredBytes
+		}
+		for _, c := range st.Collections {
+			if tenant != "" {
+				c.Name = tenant + "/" + c.Name
+			}
+			stats.Collections = append(stats.Collections, c)
+		}
+
+		if tenant == "" {
+			for _, t := range st.Tenants {
+				tenants[t.Tenant] += t.Count
+			}
+		} else {
+			tenants[tenant] += st.Total
+		}
+
+		return nil
+	}
+
+	if err := add("", s.base); err != nil {
+		return mlog.Stats{}, err
+	}
+	for tenant, store := range stores {
+		if err := add(tenant, store); err != nil {
+			return mlog.Stats{}, err
+		}
+	}
+
+	for level, count := range levels {
+		stats.Levels = append(stats.Levels, mlog.LevelStats{Level: level, Count: count})
+	}
+	sort.Slice(stats.Levels, func(i, j int) bool {
+		return stats.Levels[i].Level < stats.Levels[j].Level
+	})
+
+	for d, count := range days {
+		stats.Days = append(stats.Days, mlog.DayStats{Day: d, Count: count})
+	}
+	sort.Slice(stats.Days, func(i, j int) bool {
+		return stats.Days[i].Day.Before(stats.Days[j].Day)
+	})
+
+	for _, a := range algorithms {
+		stats.Algorithms = append(stats.Algorithms, *a)
+	}
+	sort.Slice(stats.Algorithms, func(i, j int) bool {
+		return stats.Algorithms[i].Algorithm < stats.Algorithms[j].Algorithm
+	})
+
+	stats.Tenants = mlog.SortTenantStats(tenants)
+	stats.ComputedAt = time.Now()
+
+	return stats, nil
+}
+
+// Erase erases from the store of the tenant of criteria.
+func (s *Store) Erase(ctx context.Context, criteria mlog.DeleteCriteria, holds []mlog.LegalHold) (mlog.DeleteResult, error) {
+	store, err := s.store(ctx, criteria.Tenant)
+	if err != nil {
+		return mlog.DeleteResult{}, err
+	}
+
+	eraser, ok := store.(mlog.Eraser)
+	if !ok {
+		return mlog.DeleteResult{}, mlog.ErrNotSupported
+	}
+
+	return eraser.Erase(ctx, criteria, holds)
+}
+
+func (s *Store) SaveDeletionAudit(ctx context.Context, audit mlog.DeletionAudit) error {
+	eraser, ok := s.base.(mlog.Eraser)
+	if !ok {
+		return mlog.ErrNotSupported
+	}
+	return eraser.SaveDeletionAudit(ctx, audit)
+}
+
+// CreateLegalHold, LegalHolds and ReleaseLegalHold keep legal holds in the
+// base store, so they protect the logs of every tenant store.
+func (s *Store) CreateLegalHold(ctx context.Context, hold mlog.LegalHold) error {
+	store, ok := s.base.(mlog.HoldStore)
+	if !ok {
+		return mlog.ErrNotSupported
+	}
+	return store.CreateLegalHold(ctx, hold)
+}
+
+func (s *Store) LegalHolds(ctx context.Context, includeReleased bool) ([]mlog.LegalHold, error) {
+	store, ok := s.base.(mlog.HoldStore)
+	if !ok {
+		return nil, mlog.ErrNotSupported
+	}
+	return store.LegalHolds(ctx, includeReleased)
+}
+
+func (s *Store) ReleaseLegalHold(ctx context.Context, id ulid.ULID, by string, at time.Time) (mlog.LegalHold, error) {
+	store, ok := s.base.(mlog.HoldStore)
+	if !ok {
+		return mlog.LegalHold{}, mlog.ErrNotSupported
+	}
+	return store.ReleaseLegalHold(ctx, id, by, at)
+}
+
+// ClaimIdempotencyKey, ReleaseIdempotencyKey and PurgeIdempotencyKeys keep
+// idempotency keys in the base store. Keys are already scoped by tenant.
+func (s *Store) ClaimIdempotencyKey(ctx context.Context, key string, id ulid.ULID, at, since time.Time) (ulid.ULID, bool, error) {
+	store, ok := s.base.(mlog.IdempotencyStore)
+	if !ok {
+		return ulid.ULID{}, false, mlog.ErrNotSupported
+	}
+	return store.ClaimIdempotencyKey(ctx, key, id, at, since)
+}
+
+func (s *Store) ReleaseIdempotencyKey(ctx context.Context, key string, id ulid.ULID) error {
+	store, ok := s.base.(mlog.IdempotencyStore)
+	if !ok {
+		return mlog.ErrNotSupported
+	}
+	return store.ReleaseIdempotencyKey(ctx, key, id)
+}
+
+func (s *Store) PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	store, ok := s.base.(mlog.IdempotencyStore)
+	if !ok {
+		return 0, mlog.ErrNotSupported
+	}
+	return store.PurgeIdempotencyKeys(ctx, before)
+}
+
+var (
+	_ mlog.Store            = (*Store)(nil)
+	_ mlog.BatchWriter      = (*Store)(nil)
+	_ mlog.MessageSearcher  = (*Store)(nil)
+	_ mlog.Compactor        = (*Store)(nil)
+	_ mlog.StatsReporter    = (*Store)(nil)
+	_ mlog.Eraser           = (*Store)(nil)
+	_ mlog.HoldStore        = (*Store)(nil)
+	_ mlog.IdempotencyStore = (*Store)(nil)
+)
@@ -0,0 +1,301 @@
+package tiered
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/business/domain/mlog/segment"
+	"github.com/felipecooper/log-horizon/foundation/blob"
+	"github.com/oklog/ulid/v2"
+)
+
+// ArchiveReport summarizes an Archive run.
+type ArchiveReport struct {
+	Days        int
+	Logs        int64
+	Pruned      int64
+	RawBytes    int64
+	StoredBytes int64
+}
+
+// Archive moves every whole day before olderThan from the primary store into
+// segment files, one segment per UTC day. A day that already has a segment is
+// merged with it into a new one, so reruns after a failure are safe: logs are
+// only pruned from the primary store once their segment is in place, and only
+// the logs copied into it, so logs reaching the day during the run wait for
+// the next one.
+func (s *Store) Archive(ctx context.Context, olderThan time.Time) (ArchiveReport, error) {
+	s.archiving.Lock()
+	defer s.archiving.Unlock()
+
+	cutoff := olderThan.UTC().Truncate(day)
+
+	var report ArchiveReport
+	var from time.Time
+	for {
+		oldest, ok, err := s.oldest(ctx, from, cutoff)
+		if err != nil {
+			return report, err
+		}
+		if !ok {
+			break
+		}
+
+		d := oldest.UTC().Truncate(day)
+		if err := s.archiveDay(ctx, d, &report); err != nil {
+			return report, fmt.Errorf("archiving %s: %w", d.Format(dayLayout), err)
+		}
+		from = d.Add(day)
+	}
+
+	return report, nil
+}
+
+// oldest returns the timestamp of the oldest primary log in [from, before).
+func (s *Store) oldest(ctx context.Context, from, before time.Time) (time.Time, bool, error) {
+	criteria := mlog.SearchCriteria{
+		AllTenants: true,
+		TimeRange: mlog.TimeRange{
+			StartTime: from,
+			EndTime:   before.Add(-time.Nanosecond),
+		},
+	}
+
+	var ts time.Time
+	err := s.scanner.Scan(ctx, criteria, func(log mlog.Log) error {
+		ts = log.Timestamp
+		return errStop
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		return time.Time{}, false, fmt.Errorf("finding oldest log: %w", err)
+	}
+
+	return ts, !ts.IsZero(), nil
+}
+
+func (s *Store) archiveDay(ctx context.Context, d time.Time, report *ArchiveReport) error {
+	tr := mlog.TimeRange{
+		StartTime: d,
+		EndTime:   d.Add(day - time.Nanosecond),
+	}
+
+	previous, merged, err := s.existing(ctx, d)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[ulid.ULID]bool, len(merged))
+	for _, log := range merged {
+		seen[log.ID] = true
+	}
+
+	// Both inputs are sorted by timestamp; interleave them. Every primary log
+	// seen ends up in the segment, so they are the ones pruned afterwards.
+	var (
+		archived int64
+		copied   []ulid.ULID
+	)
+	ref, err := s.createSegment(ctx, d, func(w *segment.Writer) error {
+		err := s.scanner.Scan(ctx, mlog.SearchCriteria{AllTenants: true, TimeRange: tr}, func(log mlog.Log) error {
+			copied = append(copied, log.ID)
+			if seen[log.ID] {
+				return nil
+			}
+
+			for len(merged) > 0 && !merged[0].Timestamp.After(log.Timestamp) {
+				if err := w.Append(merged[0]); err != nil {
+					return err
+				}
+				merged = merged[1:]
+			}
+
+			archived++
+			return w.Append(log)
+		})
+		if err != nil {
+			return fmt.Errorf("copying logs: %w", err)
+		}
+
+		for _, log := range merged {
+			if err := w.Append(log); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.replace(ctx, previous, ref)
+
+	pruned, err := s.pruner.Prune(ctx, tr, copied)
+	if err != nil {
+		return fmt.Errorf("pruning primary: %w", err)
+	}
+
+	var stored int64
+	for _, b := range ref.index.Blocks {
+		stored += b.Length
+	}
+
+	report.Days++
+	report.Logs += archived
+	report.Pruned += pruned
+	report.RawBytes += ref.index.RawBytes
+	report.StoredBytes += stored
+
+	s.log.Info(ctx, "archived day", "day", d.Format(dayLayout), "key", ref.key, "logs", ref.index.Count, "pruned", pruned)
+
+	return nil
+}
+
+// createSegment writes a new segment for day d with the logs fill appends.
+// A failed run aborts the write, so no partial segment is published.
+func (s *Store) createSegment(ctx context.Context, d time.Time, fill func(*segment.Writer) error) (segmentRef, error) {
+	key := s.segmentKey(d, ulid.Make().String())
+
+	file, err := s.archive.Create(ctx, key)
+	if err != nil {
+		return segmentRef{}, fmt.Errorf("creating segment: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+		if err := blob.Abort(file); err != nil {
+			s.log.Error(ctx, "failed to abort partial segment", "key", key, "error", err)
+		}
+	}()
+
+	w, err := segment.NewWriter(file, s.compressor, s.blockSize)
+	if err != nil {
+		return segmentRef{}, err
+	}
+
+	if err := fill(w); err != nil {
+		return segmentRef{}, err
+	}
+
+	index, err := w.Close()
+	if err != nil {
+		return segmentRef{}, err
+	}
+
+	if err := file.Close(); err != nil {
+		return segmentRef{}, fmt.Errorf("uploading segment: %w", err)
+	}
+	committed = true
+
+	return segmentRef{key: key, day: d, index: index}, nil
+}
+
+// replace installs ref in the manifest and removes the segment it
+// supersedes, if any.
+func (s *Store) replace(ctx context.Context, previous string, ref segmentRef) {
+	s.install(ref)
+
+	if previous != "" {
+		if err := s.archive.Delete(ctx, previous); err != nil {
+			s.log.Error(ctx, "failed to remove superseded segment", "key", previous, "error", err)
+		}
+	}
+}
+
+// existing returns the key and the logs of the current segment of day d.
+func (s *Store) existing(ctx context.Context, d time.Time) (string, []mlog.Log, error) {
+	s.mu.RLock()
+	var ref segmentRef
+	for _, r := range s.segments {
+		if r.day.Equal(d) {
+			ref = r
+			break
+		}
+	}
+	s.mu.RUnlock()
+
+	if ref.key == "" {
+		return "", nil, nil
+	}
+
+	r, closer, err := s.open(ctx, ref)
+	if err != nil {
+		return "", nil, err
+	}
+	defer closer.Close()
+
+	logs := make([]mlog.Log, 0, ref.index.Count)
+	err = r.Scan(mlog.SearchCriteria{AllTenants: true}, func(log mlog.Log) error {
+		logs = append(logs, log)
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return ref.key, logs, nil
+}
+
+// rewriteDay replaces the segment of ref by one holding logs, or removes it
+// when logs is empty. Callers hold archiving.
+func (s *Store) rewriteDay(ctx context.Context, ref segmentRef, logs []mlog.Log) error {
+	if len(logs) == 0 {
+		s.uninstall(ref.day)
+		if err := s.archive.Delete(ctx, ref.key); err != nil {
+			return fmt.Errorf("removing segment: %w", err)
+		}
+		return nil
+	}
+
+	next, err := s.createSegment(ctx, ref.day, func(w *segment.Writer) error {
+		for _, log := range logs {
+			if err := w.Append(log); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.replace(ctx, ref.key, next)
+	return nil
+}
+
+// install adds or replaces the manifest entry of a day.
+func (s *Store) install(ref segmentRef) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, r := range s.segments {
+		if r.day.Equal(ref.day) {
+			s.segments[i] = ref
+			return
+		}
+	}
+
+	s.segments = append(s.segments, ref)
+	sort.Slice(s.segments, func(i, j int) bool {
+		return s.segments[i].day.Before(s.segments[j].day)
+	})
+}
+
+// uninstall removes the manifest entry of day d.
+func (s *Store) uninstall(d time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, r := range s.segments {
+		if r.day.Equal(d) {
+			s.segments = append(s.segments[:i:i], s.segments[i+1:]...)
+			return
+		}
+	}
+}
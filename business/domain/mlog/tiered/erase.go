@@ -0,0 +1,97 @@
+package tiered
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+)
+
+func (s *Store) eraser() (mlog.Eraser, error) {
+	store, ok := s.primary.(mlog.Eraser)
+	if !ok {
+		return nil, mlog.ErrNotSupported
+	}
+	return store, nil
+}
+
+// Erase erases the logs selected by criteria from the primary store, then
+// rewrites the archived days holding any of them. Logs covered by a legal
+// hold are counted but left untouched.
+func (s *Store) Erase(ctx context.Context, criteria mlog.DeleteCriteria, holds []mlog.LegalHold) (mlog.DeleteResult, error) {
+	store, err := s.eraser()
+	if err != nil {
+		return mlog.DeleteResult{}, err
+	}
+
+	result, err := store.Erase(ctx, criteria, holds)
+	if err != nil {
+		return result, err
+	}
+
+	s.archiving.Lock()
+	defer s.archiving.Unlock()
+
+	selected := mlog.SearchCriteria{
+		Tenant:    criteria.Tenant,
+		TimeRange: criteria.TimeRange,
+		Level:     criteria.Level,
+	}
+
+	for _, ref := range s.snapshot(criteria.TimeRange) {
+		if n, ok := ref.index.Matches(selected); ok && n == 0 {
+			continue
+		}
+
+		_, logs, err := s.existing(ctx, ref.day)
+		if err != nil {
+			return result, err
+		}
+
+		kept := make([]mlog.Log, 0, len(logs))
+		changed := false
+		for _, log := range logs {
+			if !criteria.Matches(log) {
+				kept = append(kept, log)
+				continue
+			}
+
+			result.Matched++
+			if coveredByHold(log, holds) {
+				result.Held++
+				kept = append(kept, log)
+				continue
+			}
+			if criteria.DryRun {
+				kept = append(kept, log)
+				continue
+			}
+
+			changed = true
+			result.IDs = append(result.IDs, log.ID)
+			if criteria.Mode == mlog.EraseDelete {
+				result.Deleted++
+				continue
+			}
+			result.Redacted++
+			kept = append(kept, criteria.Redact(log))
+		}
+
+		if !changed {
+			continue
+		}
+		if err := s.rewriteDay(ctx, ref, kept); err != nil {
+			return result, fmt.Errorf("erasing archived %s: %w", ref.day.Format(dayLayout), err)
+		}
+	}
+
+	return result, nil
+}
+
+func (s *Store) SaveDeletionAudit(ctx context.Context, audit mlog.DeletionAudit) error {
+	store, err := s.eraser()
+	if err != nil {
+		return err
+	}
+	return store.SaveDeletionAudit(ctx, audit)
+}
@@ -0,0 +1,52 @@
+package tiered
+
+import (
+	"context"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/oklog/ulid/v2"
+)
+
+// Legal holds are kept by the primary store and honored by both tiers.
+
+func (s *Store) holdStore() (mlog.HoldStore, error) {
+	store, ok := s.primary.(mlog.HoldStore)
+	if !ok {
+		return nil, mlog.ErrNotSupported
+	}
+	return store, nil
+}
+
+func (s *Store) CreateLegalHold(ctx context.Context, hold mlog.LegalHold) error {
+	store, err := s.holdStore()
+	if err != nil {
+		return err
+	}
+	return store.CreateLegalHold(ctx, hold)
+}
+
+func (s *Store) LegalHolds(ctx context.Context, includeReleased bool) ([]mlog.LegalHold, error) {
+	store, err := s.holdStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.LegalHolds(ctx, includeReleased)
+}
+
+func (s *Store) ReleaseLegalHold(ctx context.Context, id ulid.ULID, by string, at time.Time) (mlog.LegalHold, error) {
+	store, err := s.holdStore()
+	if err != nil {
+		return mlog.LegalHold{}, err
+	}
+	return store.ReleaseLegalHold(ctx, id, by, at)
+}
+
+func coveredByHold(log mlog.Log, holds []mlog.LegalHold) bool {
+	for _, h := range holds {
+		if h.Covers(log) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package tiered
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/oklog/ulid/v2"
+)
+
+// Retention policies are kept by the primary store and enforced on both
+// tiers.
+
+func (s *Store) retentionStore() (mlog.RetentionStore, error) {
+	store, ok := s.primary.(mlog.RetentionStore)
+	if !ok {
+		return nil, mlog.ErrNotSupported
+	}
+	return store, nil
+}
+
+func (s *Store) RetentionPolicies(ctx context.Context) ([]mlog.RetentionPolicy, error) {
+	store, err := s.retentionStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.RetentionPolicies(ctx)
+}
+
+func (s *Store) SaveRetentionPolicy(ctx context.Context, policy mlog.RetentionPolicy) error {
+	store, err := s.retentionStore()
+	if err != nil {
+		return err
+	}
+	return store.SaveRetentionPolicy(ctx, policy)
+}
+
+func (s *Store) DeleteRetentionPolicy(ctx context.Context, name string) error {
+	store, err := s.retentionStore()
+	if err != nil {
+		return err
+	}
+	return store.DeleteRetentionPolicy(ctx, name)
+}
+
+// ApplyRetention enforces the policies on the primary store, then rewrites
+// the archived days holding expired logs. An archived log expired by several
+// policies is counted under the first one, and its size is that of its
+// message.
+func (s *Store) ApplyRetention(ctx context.Context, policies []mlog.RetentionPolicy, holds []mlog.LegalHold, now time.Time, dryRun bool) ([]mlog.RetentionResult, error) {
+	store, err := s.retentionStore()
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := store.ApplyRetention(ctx, policies, holds, now, dryRun)
+	if err != nil {
+		return results, err
+	}
+	if len(policies) == 0 {
+		return results, nil
+	}
+
+	s.archiving.Lock()
+	defer s.archiving.Unlock()
+
+	index := make(map[string]int, len(results))
+	for i, r := range results {
+		index[r.Policy] = i
+	}
+
+	var (
+		cutoffs   = make([]time.Time, len(policies))
+		protected = make([][]mlog.RetentionPolicy, len(policies))
+		latest    time.Time
+	)
+	for i, p := range policies {
+		cutoffs[i] = now.Add(-p.MaxAge)
+		protected[i] = p.Protected(policies)
+		if cutoffs[i].After(latest) {
+			latest = cutoffs[i]
+		}
+
+		if _, ok := index[p.Name]; !ok {
+			index[p.Name] = len(results)
+			results = append(results, mlog.RetentionResult{Policy: p.Name, DryRun: dryRun})
+		}
+	}
+
+	// expiredBy returns the policy expiring log, if any.
+	expiredBy := func(log mlog.Log) (int, bool) {
+		for i, p := range policies {
+			if !p.Selects(log) || !log.Timestamp.Before(cutoffs[i]) {
+				continue
+			}
+
+			kept := false
+			for _, q := range protected[i] {
+				if q.Selects(log) {
+					kept = true
+					break
+				}
+			}
+			if !kept {
+				return i, true
+			}
+		}
+		return 0, false
+	}
+
+	for _, ref := range s.snapshot(mlog.TimeRange{EndTime: latest}) {
+		_, logs, err := s.existing(ctx, ref.day)
+		if err != nil {
+			return results, err
+		}
+
+		kept := make([]mlog.Log, 0, len(logs))
+		expired := make(map[int][]mlog.Log)
+		for _, log := range logs {
+			i, ok := expiredBy(log)
+			if !ok || coveredByHold(log, holds) {
+				kept = append(kept, log)
+				continue
+			}
+			expired[i] = append(expired[i], log)
+		}
+
+		if !dryRun && len(kept) < len(logs) {
+			if err := s.rewriteDay(ctx, ref, kept); err != nil {
+				return results, fmt.Errorf("expiring archived %s: %w", ref.day.Format(dayLayout), err)
+			}
+			s.log.Info(ctx, "expired archived logs", "day", ref.day.Format(dayLayout), "logs", len(logs)-len(kept))
+		}
+
+		for i, logs := range expired {
+			r := &results[index[policies[i].Name]]
+			for _, log := range logs {
+				r.Deleted++
+				r.Bytes += int64(len(log.Message))
+				if !dryRun {
+					if r.IDs == nil {
+						r.IDs = make(map[string][]ulid.ULID)
+					}
+					r.IDs[log.Tenant] = append(r.IDs[log.Tenant], log.ID)
+				}
+			}
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,121 @@
+package tiered
+
+import (
+	"context"
+	"sort"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/business/domain/mlog/segment"
+)
+
+// Stats adds the archived segments to the statistics of the primary store.
+// Their counts come from the segment indexes, so nothing is decoded.
+func (s *Store) Stats(ctx context.Context) (mlog.Stats, error) {
+	reporter, ok := s.primary.(mlog.StatsReporter)
+	if !ok {
+		return mlog.Stats{}, mlog.ErrNotSupported
+	}
+
+	stats, err := reporter.Stats(ctx)
+	if err != nil {
+		return mlog.Stats{}, err
+	}
+
+	s.mu.RLock()
+	segments := s.segments
+	s.mu.RUnlock()
+
+	var (
+		levels     = make(map[mlog.Level]int64)
+		tenants    = make(map[string]int64)
+		days       = make(map[int64]mlog.DayStats)
+		algorithms = make(map[string]*mlog.AlgorithmStats)
+	)
+	for _, l := range stats.Levels {
+		levels[l.Level] += l.Count
+	}
+	for _, t := range stats.Tenants {
+		tenants[t.Tenant] += t.Count
+	}
+	for _, d := range stats.Days {
+		days[d.Day.Unix()] = d
+	}
+	for _, a := range stats.Algorithms {
+		a := a
+		algorithms[a.Algorithm] = &a
+	}
+
+	archive := mlog.CollectionStats{Name: "archive"}
+
+	for _, ref := range segments {
+		idx := ref.index
+
+		var stored int64
+		for _, b := range idx.Blocks {
+			stored += b.Length
+		}
+
+		stats.Total += idx.Count
+		stats.Compressed += idx.Count
+		stats.RawBytes += idx.RawBytes
+		stats.StoredBytes += stored
+
+		for i, level := range segment.Levels {
+			levels[level] += idx.Levels[i]
+		}
+		for tenant, count := range idx.Tenants {
+			tenants[tenant] += count
+		}
+		tenants[""] += idx.Tenants.Untenanted(idx.Count)
+
+		d := days[ref.day.Unix()]
+		d.Day = ref.day
+		d.Count += idx.Count
+		days[ref.day.Unix()] = d
+
+		a, ok := algorithms[string(idx.Algorithm)]
+		if !ok {
+			a = &mlog.AlgorithmStats{Algorithm: string(idx.Algorithm)}
+			algorithms[a.Algorithm] = a
+		}
+		a.Count += idx.Count
+		a.RawBytes += idx.RawBytes
+		a.StoredBytes += stored
+
+		archive.Count++
+		archive.Size += idx.RawBytes
+		archive.StorageSize += stored
+	}
+
+	stats.Levels = stats.Levels[:0]
+	for level, count := range levels {
+		if count > 0 {
+			stats.Levels = append(stats.Levels, mlog.LevelStats{Level: level, Count: count})
+		}
+	}
+	sort.Slice(stats.Levels, func(i, j int) bool {
+		return stats.Levels[i].Level < stats.Levels[j].Level
+	})
+
+	stats.Tenants = mlog.SortTenantStats(tenants)
+
+	stats.Days = stats.Days[:0]
+	for _, d := range days {
+		stats.Days = append(stats.Days, d)
+	}
+	sort.Slice(stats.Days, func(i, j int) bool {
+		return stats.Days[i].Day.Before(stats.Days[j].Day)
+	})
+
+	stats.Algorithms = stats.Algorithms[:0]
+	for _, a := range algorithms {
+		stats.Algorithms = append(stats.Algorithms, *a)
+	}
+	sort.Slice(stats.Algorithms, func(i, j int) bool {
+		return stats.Algorithms[i].Algorithm < stats.Algorithms[j].Algorithm
+	})
+
+	stats.Collections = append(stats.Collections, archive)
+
+	return stats, nil
+}
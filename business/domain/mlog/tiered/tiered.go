@@ -0,0 +1,634 @@
+// Package tiered implements an mlog.Store that keeps recent logs in a primary
+// store and moves whole days of older logs into immutable segment files.
+//
+// The tiers never overlap: every archived day ends before the watermark, the
+// end of the newest archived day, and the primary store is only queried from
+// the watermark on. Logs that reach the primary store with an older timestamp
+// stay hidden until the next Archive run folds them into their day segment.
+package tiered
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/business/domain/mlog/segment"
+	"github.com/felipecooper/log-horizon/foundation/blob"
+	"github.com/felipecooper/log-horizon/foundation/compress"
+	"github.com/felipecooper/log-horizon/foundation/logger"
+	"github.com/oklog/ulid/v2"
+)
+
+var ErrPrimaryNotSupported = errors.New("primary store must implement mlog.Scanner and mlog.Pruner")
+
+const (
+	defaultPrefix   = "segments/"
+	defaultPageSize = 50
+	exportPageSize  = 1000
+	segmentExt      = ".seg"
+	dayLayout       = "2006-01-02"
+	day             = 24 * time.Hour
+)
+
+type Config struct {
+	// Archive holds the segment files, on local disk or in object storage.
+	Archive          blob.Archive
+	Prefix           string
+	Compression      compress.Algorithm
+	CompressionLevel int
+	// BlockSize is the uncompressed size at which segment blocks are cut.
+	BlockSize    int
+	ExportPath   string
+	ExportBucket blob.Bucket
+}
+
+type Store struct {
+	log        logger.Logger
+	primary    mlog.Store
+	scanner    mlog.Scanner
+	pruner     mlog.Pruner
+	archive    blob.Archive
+	prefix     string
+	compressor compress.Compressor
+	blockSize  int
+	exports    blob.Bucket
+
+	mu       sync.RWMutex
+	segments []segmentRef
+
+	archiving sync.Mutex
+}
+
+// segmentRef is the in-memory manifest entry of one archived day.
+type segmentRef struct {
+	key   string
+	day   time.Time
+	index segment.Index
+}
+
+func NewStore(ctx context.Context, log logger.Logger, primary mlog.Store, cfg Config) (*Store, error) {
+	scanner, ok := primary.(mlog.Scanner)
+	if !ok {
+		return nil, ErrPrimaryNotSupported
+	}
+
+	pruner, ok := primary.(mlog.Pruner)
+	if !ok {
+		return nil, ErrPrimaryNotSupported
+	}
+
+	algorithm := cfg.Compression
+	if algorithm == "" {
+		algorithm = compress.Zstd
+	}
+
+	compressor, err := compress.New(algorithm, cfg.CompressionLevel)
+	if err != nil {
+		return nil, fmt.Errorf("creating compressor: %w", err)
+	}
+
+	prefix := cfg.Prefix
+	if prefix == "" {
+		prefix = defaultPrefix
+	}
+
+	exports := cfg.ExportBucket
+	if exports == nil {
+		exports = blob.NewLocal(cfg.ExportPath)
+	}
+
+	s := Store{
+		log:        log,
+		primary:    primary,
+		scanner:    scanner,
+		pruner:     pruner,
+		archive:    cfg.Archive,
+		prefix:     prefix,
+		compressor: compressor,
+		blockSize:  cfg.BlockSize,
+		exports:    exports,
+	}
+
+	if err := s.loadSegments(ctx); err != nil {
+		return nil, fmt.Errorf("loading segments: %w", err)
+	}
+
+	return &s, nil
+}
+
+func (s *Store) Write(ctx context.Context, log *mlog.Log) error {
+	return s.primary.Write(ctx, log)
+}
+
+func (s *Store) WriteBatch(ctx context.Context, logs []*mlog.Log) error {
+	return mlog.WriteBatch(ctx, s.primary, logs)
+}
+
+// ClaimIdempotencyKey, ReleaseIdempotencyKey and PurgeIdempotencyKeys keep
+// idempotency keys in the primary store when it supports them.
+func (s *Store) ClaimIdempotencyKey(ctx context.Context, key string, id ulid.ULID, at, since time.Time) (ulid.ULID, bool, error) {
+	store, ok := s.primary.(mlog.IdempotencyStore)
+	if !ok {
+		return ulid.ULID{}, false, mlog.ErrNotSupported
+	}
+	return store.ClaimIdempotencyKey(ctx, key, id, at, since)
+}
+
+func (s *Store) ReleaseIdempotencyKey(ctx context.Context, key string, id ulid.ULID) error {
+	store, ok := s.primary.(mlog.IdempotencyStore)
+	if !ok {
+		return mlog.ErrNotSupported
+	}
+	return store.ReleaseIdempotencyKey(ctx, key, id)
+}
+
+func (s *Store) PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	store, ok := s.primary.(mlog.IdempotencyStore)
+	if !ok {
+		return 0, mlog.ErrNotSupported
+	}
+	return store.PurgeIdempotencyKeys(ctx, before)
+}
+
+func (s *Store) Search(ctx context.Context, criteria mlog.SearchCriteria) (mlog.SearchResult, error) {
+	pageSize := criteria.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	hot, cold, hasHot, hasCold := s.split(criteria)
+
+	var hotCount int
+	if hasHot {
+		n, err := s.primary.Count(ctx, hot)
+		if err != nil {
+			return mlog.SearchResult{}, fmt.Errorf("counting primary: %w", err)
+		}
+		hotCount = n
+	}
+
+	var coldCount int
+	if hasCold {
+		n, err := s.coldCount(ctx, cold)
+		if err != nil {
+			return mlog.SearchResult{}, fmt.Errorf("counting archive: %w", err)
+		}
+		coldCount = n
+	}
+
+	offset := criteria.Page * pageSize
+
+	// The primary tier holds the newest logs, so it fills pages first.
+	var logs []mlog.Log
+	if offset < hotCount {
+		hot.Page = criteria.Page
+		hot.PageSize = pageSize
+
+		result, err := s.primary.Search(ctx, hot)
+		if err != nil {
+			return mlog.SearchResult{}, fmt.Errorf("searching primary: %w", err)
+		}
+		logs = result.Logs
+	}
+
+	if missing := pageSize - len(logs); hasCold && missing > 0 && offset+len(logs) < hotCount+coldCount {
+		skip := offset + len(logs) - hotCount
+		if skip < 0 {
+			skip = 0
+		}
+
+		archived, err := s.coldSearch(ctx, cold, skip, missing)
+		if err != nil {
+			return mlog.SearchResult{}, fmt.Errorf("searching archive: %w", err)
+		}
+		logs = append(logs, archived...)
+	}
+
+	totalCount := hotCount + coldCount
+
+	hasMore := (criteria.Page+1)*pageSize < totalCount
+	nextPage := criteria.Page + 1
+	if !hasMore {
+		nextPage = criteria.Page
+	}
+
+	return mlog.SearchResult{
+		Logs:     logs,
+		Total:    totalCount,
+		HasMore:  hasMore,
+		NextPage: nextPage,
+	}, nil
+}
+
+func (s *Store) Count(ctx context.Context, criteria mlog.SearchCriteria) (int, error) {
+	hot, cold, hasHot, hasCold := s.split(criteria)
+
+	var total int
+	if hasHot {
+		n, err := s.primary.Count(ctx, hot)
+		if err != nil {
+			return 0, fmt.Errorf("counting primary: %w", err)
+		}
+		total += n
+	}
+
+	if hasCold {
+		n, err := s.coldCount(ctx, cold)
+		if err != nil {
+			return 0, fmt.Errorf("counting archive: %w", err)
+		}
+		total += n
+	}
+
+	return total, nil
+}
+
+// ExportToFile writes both tiers newest first, like the primary store does.
+func (s *Store) ExportToFile(ctx context.Context, criteria mlog.SearchCriteria) (string, int64, error) {
+	// Pin the end of the range so paging is not shifted by new writes.
+	if criteria.TimeRange.EndTime.IsZero() {
+		criteria.TimeRange.EndTime = time.Now()
+	}
+
+	hot, cold, hasHot, hasCold := s.split(criteria)
+
+	filename := mlog.ExportFilename(criteria, time.Now())
+
+	file, err := s.exports.Create(ctx, filename)
+	if err != nil {
+		return "", 0, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			blob.Abort(file)
+		}
+	}()
+
+	var size int64
+	write := func(log mlog.Log) error {
+		line := fmt.Sprintf("[%s] [%s] %s\n", log.Timestamp.Format(time.RFC3339), log.Level, log.Message)
+		n, err := io.WriteString(file, line)
+		size += int64(n)
+		return err
+	}
+
+	if hasHot {
+		hot.PageSize = exportPageSize
+		for {
+			result, err := s.primary.Search(ctx, hot)
+			if err != nil {
+				return "", 0, fmt.Errorf("searching primary: %w", err)
+			}
+
+			for _, log := range result.Logs {
+				if err := write(log); err != nil {
+					return "", 0, fmt.Errorf("writing export: %w", err)
+				}
+			}
+
+			if !result.HasMore {
+				break
+			}
+			hot.Page = result.NextPage
+		}
+	}
+
+	if hasCold {
+		err := s.scanDesc(ctx, cold, nil, func(log mlog.Log) error {
+			if err := write(log); err != nil {
+				return fmt.Errorf("writing export: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return "", 0, err
+		}
+	}
+
+	if err := file.Close(); err != nil {
+		return "", 0, err
+	}
+	committed = true
+
+	fileURL, err := s.exports.URL(ctx, filename)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return fileURL, size, nil
+}
+
+// SearchMessages follows the primary store; segments always evaluate
+// message filters on the decoded logs.
+func (s *Store) SearchMessages() bool {
+	searcher, ok := s.primary.(mlog.MessageSearcher)
+	return ok && searcher.SearchMessages()
+}
+
+// watermark returns the end of the newest archived day.
+func (s *Store) watermark() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.segments) == 0 {
+		return time.Time{}
+	}
+	return s.segments[len(s.segments)-1].day.Add(day)
+}
+
+// split divides criteria into the part served by the primary store and the
+// part served by the archive.
+func (s *Store) split(criteria mlog.SearchCriteria) (hot, cold mlog.SearchCriteria, hasHot, hasCold bool) {
+	watermark := s.watermark()
+	if watermark.IsZero() {
+		return criteria, mlog.SearchCriteria{}, true, false
+	}
+
+	tr := criteria.TimeRange
+
+	hot = criteria
+	hasHot = tr.EndTime.IsZero() || !tr.EndTime.Before(watermark)
+	if tr.StartTime.IsZero() || tr.StartTime.Before(watermark) {
+		hot.TimeRange.StartTime = watermark
+	}
+
+	cold = criteria
+	hasCold = tr.StartTime.IsZero() || tr.StartTime.Before(watermark)
+	if tr.EndTime.IsZero() || !tr.EndTime.Before(watermark) {
+		cold.TimeRange.EndTime = watermark.Add(-time.Nanosecond)
+	}
+
+	return hot, cold, hasHot, hasCold
+}
+
+// snapshot returns the manifest entries overlapping tr, newest first.
+func (s *Store) snapshot(tr mlog.TimeRange) []segmentRef {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var refs []segmentRef
+	for i := len(s.segments) - 1; i >= 0; i-- {
+		if s.segments[i].index.Overlaps(tr) {
+			refs = append(refs, s.segments[i])
+		}
+	}
+	return refs
+}
+
+func (s *Store) open(ctx context.Context, ref segmentRef) (*segment.Reader, io.Closer, error) {
+	obj, err := s.archive.Open(ctx, ref.key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening segment %s: %w", ref.key, err)
+	}
+
+	r, err := segment.NewReader(obj, obj.Size())
+	if err != nil {
+		obj.Close()
+		return nil, nil, fmt.Errorf("reading segment %s: %w", ref.key, err)
+	}
+
+	return r, obj, nil
+}
+
+func (s *Store) coldCount(ctx context.Context, criteria mlog.SearchCriteria) (int, error) {
+	var total int64
+
+	for _, ref := range s.snapshot(criteria.TimeRange) {
+		if n, ok := ref.index.Matches(criteria); ok {
+			total += n
+			continue
+		}
+
+		n, err := s.countSegment(ctx, ref, criteria)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+
+	return int(total), nil
+}
+
+func (s *Store) countSegment(ctx context.Context, ref segmentRef, criteria mlog.SearchCriteria) (int64, error) {
+	r, closer, err := s.open(ctx, ref)
+	if err != nil {
+		return 0, err
+	}
+	defer closer.Close()
+
+	var total int64
+	for i, block := range ref.index.Blocks {
+		if n, ok := block.Matches(criteria); ok {
+			total += n
+			continue
+		}
+
+		logs, err := r.Block(i)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, log := range logs {
+			if segment.Match(log, criteria) {
+				total++
+			}
+		}
+	}
+
+	return total, nil
+}
+
+// coldSearch returns up to limit archived logs matching criteria, newest
+// first, after skipping the first skip matches.
+func (s *Store) coldSearch(ctx context.Context, criteria mlog.SearchCriteria, skip, limit int) ([]mlog.Log, error) {
+	var logs []mlog.Log
+
+	err := s.scanDesc(ctx, criteria, &skip, func(log mlog.Log) error {
+		if skip > 0 {
+			skip--
+			return nil
+		}
+		logs = append(logs, log)
+		if len(logs) == limit {
+			return errStop
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+var errStop = errors.New("stop")
+
+// scanDesc calls fn for every archived log matching criteria, newest first.
+// When skip is not nil, whole segments and blocks that fall inside it are
+// skipped using the index instead of being decoded.
+func (s *Store) scanDesc(ctx context.Context, criteria mlog.SearchCriteria, skip *int, fn func(mlog.Log) error) error {
+	for _, ref := range s.snapshot(criteria.TimeRange) {
+		if skip != nil {
+			if n, ok := ref.index.Matches(criteria); ok && int64(*skip) >= n {
+				*skip -= int(n)
+				continue
+			}
+		}
+
+		if err := s.scanSegmentDesc(ctx, ref, criteria, skip, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) scanSegmentDesc(ctx context.Context, ref segmentRef, criteria mlog.SearchCriteria, skip *int, fn func(mlog.Log) error) error {
+	r, closer, err := s.open(ctx, ref)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	for i := len(ref.index.Blocks) - 1; i >= 0; i-- {
+		block := ref.index.Blocks[i]
+		if !block.Overlaps(criteria.TimeRange) {
+			continue
+		}
+
+		if skip != nil {
+			if n, ok := block.Matches(criteria); ok && int64(*skip) >= n {
+				*skip -= int(n)
+				continue
+			}
+		}
+
+		logs, err := r.Block(i)
+		if err != nil {
+			return err
+		}
+
+		for j := len(logs) - 1; j >= 0; j-- {
+			if !segment.Match(logs[j], criteria) {
+				continue
+			}
+			if err := fn(logs[j]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// loadSegments rebuilds the manifest from the archive. A day may be left
+// with several segments when an Archive run stopped half way: the newest
+// readable one is a superset of the older ones, and newer unreadable ones are
+// incomplete uploads. Both kinds of leftovers are removed.
+func (s *Store) loadSegments(ctx context.Context) error {
+	keys, err := s.archive.List(ctx, s.prefix)
+	if err != nil {
+		return err
+	}
+
+	days := make(map[time.Time][]string)
+	for _, key := range keys {
+		if d, ok := s.parseKey(key); ok {
+			days[d] = append(days[d], key)
+		}
+	}
+
+	segments := make([]segmentRef, 0, len(days))
+	for d, keys := range days {
+		sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+
+		chosen := -1
+		for i, key := range keys {
+			ref := segmentRef{key: key, day: d}
+
+			r, closer, err := s.open(ctx, ref)
+			if errors.Is(err, segment.ErrCorrupted) {
+				s.log.Error(ctx, "skipping unreadable segment", "key", key, "error", err)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			ref.index = r.Index()
+			closer.Close()
+
+			segments = append(segments, ref)
+			chosen = i
+			break
+		}
+
+		if chosen < 0 {
+			s.log.Error(ctx, "no readable segment for day", "day", d.Format(dayLayout))
+			continue
+		}
+
+		for i, key := range keys {
+			if i == chosen {
+				continue
+			}
+			if err := s.archive.Delete(ctx, key); err != nil {
+				return err
+			}
+			s.log.Info(ctx, "removed leftover segment", "key", key)
+		}
+	}
+
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].day.Before(segments[j].day)
+	})
+
+	s.mu.Lock()
+	s.segments = segments
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Segment keys look like <prefix><day>/<ulid>.seg so that the lexical order
+// of the keys of a day follows their creation order.
+func (s *Store) segmentKey(d time.Time, id string) string {
+	return s.prefix + path.Join(d.Format(dayLayout), id+segmentExt)
+}
+
+func (s *Store) parseKey(key string) (time.Time, bool) {
+	rest, ok := strings.CutPrefix(key, s.prefix)
+	if !ok || !strings.HasSuffix(rest, segmentExt) {
+		return time.Time{}, false
+	}
+
+	dir, _, ok := strings.Cut(rest, "/")
+	if !ok {
+		return time.Time{}, false
+	}
+
+	d, err := time.Parse(dayLayout, dir)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return d, true
+}
+
+var (
+	_ mlog.Store            = (*Store)(nil)
+	_ mlog.BatchWriter      = (*Store)(nil)
+	_ mlog.IdempotencyStore = (*Store)(nil)
+	_ mlog.MessageSearcher  = (*Store)(nil)
+	_ mlog.Eraser           = (*Store)(nil)
+	_ mlog.HoldStore        = (*Store)(nil)
+	_ mlog.RetentionStore   = (*Store)(nil)
+	_ mlog.StatsReporter    = (*Store)(nil)
+)
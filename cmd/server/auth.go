@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	protomlog "github.com/felipecooper/log-horizon/app/sdk/proto/mlog"
+	"github.com/felipecooper/log-horizon/business/domain/apikey"
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/foundation/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// apiKeyHeader is the metadata key clients that cannot set an authorization
+// header pass their API key in.
+const apiKeyHeader = "x-api-key"
+
+// methodScopes maps each RPC to the scope it needs. Methods missing from the
+// map need admin, so new RPCs stay closed until they are listed here.
+var methodScopes = map[string]apikey.Scope{
+	protomlog.LogWriter_Register_FullMethodName:     apikey.ScopeWrite,
+	protomlog.LogReader_Search_FullMethodName:       apikey.ScopeRead,
+	protomlog.LogReader_StreamFile_FullMethodName:   apikey.ScopeRead,
+	protomlog.LogReader_ExportToFile_FullMethodName: apikey.ScopeExport,
+}
+
+// globalMethods are the admin RPCs whose effect or answer spans every
+// tenant: retention policies and legal holds apply to the logs of all
+// tenants and name their selectors, and the encryption key is shared. Keys
+// bound to a tenant may not call them.
+var globalMethods = map[string]bool{
+	protomlog.LogAdmin_ListRetentionPolicies_FullMethodName: true,
+	protomlog.LogAdmin_SetRetentionPolicy_FullMethodName:    true,
+	protomlog.LogAdmin_DeleteRetentionPolicy_FullMethodName: true,
+	protomlog.LogAdmin_EnforceRetention_FullMethodName:      true,
+	protomlog.LogAdmin_CreateLegalHold_FullMethodName:       true,
+	protomlog.LogAdmin_ListLegalHolds_FullMethodName:        true,
+	protomlog.LogAdmin_ReleaseLegalHold_FullMethodName:      true,
+	protomlog.LogAdmin_RotateEncryptionKey_FullMethodName:   true,
+}
+
+// scopeFor returns the scope needed to call method. Reflection only needs a
+// valid key.
+func scopeFor(method string) (apikey.Scope, bool) {
+	if strings.HasPrefix(method, "/grpc.reflection.") {
+		return "", false
+	}
+	if scope, ok := methodScopes[method]; ok {
+		return scope, true
+	}
+	return apikey.ScopeAdmin, true
+}
+
+// bearerToken returns the API key of the call, from an "authorization:
+// Bearer <key>" header or the x-api-key header.
+func bearerToken(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	if values := md.Get("authorization"); len(values) > 0 {
+		scheme, token, found := strings.Cut(values[0], " ")
+		if found && strings.EqualFold(scheme, "bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	if values := md.Get(apiKeyHeader); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// authenticator checks the credentials of every call and applies the role
+// of the caller.
+type authenticator struct {
+	log   logger.Logger
+	keys  *apikey.Business
+	roles map[string]mlog.Restriction
+}
+
+// authenticate checks the API key of a call to method and returns ctx
+// carrying the key and, for keys bound to them, its tenant and the
+// restriction of its role. Calls without a key are checked against the
+// scopes of their client certificate, if any.
+func (a *authenticator) authenticate(ctx context.Context, method string) (context.Context, error) {
+	token := bearerToken(ctx)
+	if cert, ok := certFrom(ctx); ok && token == "" {
+		if err := a.authorize(ctx, cert, method); err != nil {
+			return nil, err
+		}
+		return a.withRole(ctx, cert.Role)
+	}
+
+	key, err := a.keys.Authenticate(ctx, token)
+	if err != nil {
+		if errors.Is(err, apikey.ErrUnauthenticated) {
+			return nil, status.Error(codes.Unauthenticated, "missing or invalid api key")
+		}
+		a.log.Error(ctx, "error authenticating api key", "error", err)
+		return nil, status.Error(codes.Internal, "failed to authenticate")
+	}
+
+	if err := a.authorize(ctx, key, method); err != nil {
+		return nil, err
+	}
+
+	ctx = apikey.ContextWithKey(ctx, key)
+	if key.Tenant != "" {
+		ctx = mlog.ContextWithTenant(ctx, key.Tenant)
+	}
+	return a.withRole(ctx, key.Role)
+}
+
+// authorize checks that key holds the scope of method, and that it is not
+// bound to a tenant when method acts on every tenant.
+func (a *authenticator) authorize(ctx context.Context, key apikey.Key, method string) error {
+	if scope, ok := scopeFor(method); ok && !key.Allows(scope) {
+		a.log.Info(ctx, "caller lacks scope", "caller", key.Name, "method", method, "scope", scope)
+		return status.Errorf(codes.PermissionDenied, "caller lacks scope %s", scope)
+	}
+	if globalMethods[method] && key.Tenant != "" {
+		a.log.Info(ctx, "tenant caller called a global method", "caller", key.Name, "tenant", key.Tenant, "method", method)
+		return status.Error(codes.PermissionDenied, "method acts on every tenant and is not available to callers bound to a tenant")
+	}
+	return nil
+}
+
+// withRole returns ctx carrying the restriction of role. Unknown roles are
+// refused, so a role missing from the roles file never widens access.
+func (a *authenticator) withRole(ctx context.Context, role string) (context.Context, error) {
+	if role == "" {
+		return ctx, nil
+	}
+
+	r, ok := a.roles[role]
+	if !ok {
+		a.log.Error(ctx, "caller has an unknown role", "role", role)
+		return nil, status.Errorf(codes.PermissionDenied, "unknown role %q", role)
+	}
+	return mlog.ContextWithRestriction(ctx, r), nil
+}
+
+// unaryInterceptor rejects unary calls without a valid API key holding the
+// scope of the method.
+func (a *authenticator) unaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := a.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// streamInterceptor rejects streaming calls without a valid API key holding
+// the scope of the method.
+func (a *authenticator) streamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authenticate(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// serverStream replaces the context of a grpc.ServerStream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	protomlog "github.com/felipecooper/log-horizon/app/sdk/proto/mlog"
+	"github.com/felipecooper/log-horizon/business/domain/apikey"
+	"github.com/felipecooper/log-horizon/business/domain/apikey/jsonfile"
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(context.Context, string, ...interface{})  {}
+func (nopLogger) Error(context.Context, string, ...interface{}) {}
+
+func newTestAuthenticator(t *testing.T) *authenticator {
+	t.Helper()
+
+	store, err := jsonfile.Open(filepath.Join(t.TempDir(), "keys.json"))
+	if err != nil {
+		t.Fatalf("jsonfile.Open: %v", err)
+	}
+
+	return &authenticator{
+		log:   nopLogger{},
+		keys:  apikey.NewBusiness(nopLogger{}, store, apikey.WithRoles([]string{"support"})),
+		roles: map[string]mlog.Restriction{"support": {Role: "support", Levels: []mlog.Level{mlog.Error}}},
+	}
+}
+
+func newTestKey(t *testing.T, a *authenticator, nk apikey.NewKey) string {
+	t.Helper()
+
+	nk.Name = "test"
+	_, secret, err := a.keys.Create(context.Background(), nk)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return secret
+}
+
+func withBearer(secret string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+secret))
+}
+
+func TestAuthenticateScopes(t *testing.T) {
+	a := newTestAuthenticator(t)
+
+	read := newTestKey(t, a, apikey.NewKey{Scopes: []apikey.Scope{apikey.ScopeRead}})
+	write := newTestKey(t, a, apikey.NewKey{Scopes: []apikey.Scope{apikey.ScopeWrite}})
+	admin := newTestKey(t, a, apikey.NewKey{Scopes: []apikey.Scope{apikey.ScopeAdmin}})
+
+	tests := []struct {
+		name   string
+		secret string
+		method string
+		want   codes.Code
+	}{
+		{name: "read searches", secret: read, method: protomlog.LogReader_Search_FullMethodName, want: codes.OK},
+		{name: "read streams", secret: read, method: protomlog.LogReader_StreamFile_FullMethodName, want: codes.OK},
+		{name: "read registers", secret: read, method: protomlog.LogWriter_Register_FullMethodName, want: codes.PermissionDenied},
+		{name: "read exports", secret: read, method: protomlog.LogReader_ExportToFile_FullMethodName, want: codes.PermissionDenied},
+		{name: "write registers", secret: write, method: protomlog.LogWriter_Register_FullMethodName, want: codes.OK},
+		{name: "write searches", secret: write, method: protomlog.LogReader_Search_FullMethodName, want: codes.PermissionDenied},
+		{name: "write lists policies", secret: write, method: protomlog.LogAdmin_ListRetentionPolicies_FullMethodName, want: codes.PermissionDenied},
+		{name: "admin exports", secret: admin, method: protomlog.LogReader_ExportToFile_FullMethodName, want: codes.OK},
+		{name: "admin rotates key", secret: admin, method: protomlog.LogAdmin_RotateEncryptionKey_FullMethodName, want: codes.OK},
+		{name: "unlisted method needs admin", secret: read, method: "/mlog.LogAdmin/Unlisted", want: codes.PermissionDenied},
+		{name: "reflection needs a key only", secret: write, method: "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo", want: codes.OK},
+		{name: "unknown key", secret: "lhk_unknown", method: protomlog.LogReader_Search_FullMethodName, want: codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := a.authenticate(withBearer(tt.secret), tt.method)
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("authenticate = %v, want %s", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := a.authenticate(context.Background(), protomlog.LogReader_Search_FullMethodName); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("authenticate without a key = %v, want %s", err, codes.Unauthenticated)
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		name string
+		md   metadata.MD
+		want string
+	}{
+		{name: "bearer", md: metadata.Pairs("authorization", "Bearer lhk_a"), want: "lhk_a"},
+		{name: "scheme case", md: metadata.Pairs("authorization", "bearer lhk_a"), want: "lhk_a"},
+		{name: "api key header", md: metadata.Pairs(apiKeyHeader, "lhk_b"), want: "lhk_b"},
+		{name: "other scheme", md: metadata.Pairs("authorization", "Basic dXNlcg=="), want: ""},
+		{name: "none", md: metadata.MD{}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			if got := bearerToken(ctx); got != tt.want {
+				t.Errorf("bearerToken = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Keys bound to a tenant carry it into the call, and may not call the
+// methods acting on every tenant even with the admin scope.
+func TestAuthenticateTenant(t *testing.T) {
+	a := newTestAuthenticator(t)
+	secret := newTestKey(t, a, apikey.NewKey{Scopes: []apikey.Scope{apikey.ScopeAdmin}, Tenant: "acme"})
+
+	ctx, err := a.authenticate(withBearer(secret), protomlog.LogReader_Search_FullMethodName)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if tenant := mlog.TenantFrom(ctx); tenant != "acme" {
+		t.Errorf("tenant = %q, want acme", tenant)
+	}
+	if key, ok := apikey.KeyFrom(ctx); !ok || key.Tenant != "acme" {
+		t.Errorf("key = %+v, want the acme key", key)
+	}
+
+	for method := range globalMethods {
+		if _, err := a.authenticate(withBearer(secret), method); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("authenticate %s = %v, want %s", method, err, codes.PermissionDenied)
+		}
+	}
+}
+
+func TestAuthenticateRole(t *testing.T) {
+	a := newTestAuthenticator(t)
+	secret := newTestKey(t, a, apikey.NewKey{Scopes: []apikey.Scope{apikey.ScopeRead}, Role: "support"})
+
+	ctx, err := a.authenticate(withBearer(secret), protomlog.LogReader_Search_FullMethodName)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if r, ok := mlog.RestrictionFrom(ctx); !ok || r.Role != "support" {
+		t.Errorf("restriction = %+v, want the support role", r)
+	}
+
+	// A role dropped from the roles file refuses its keys.
+	delete(a.roles, "support")
+	if _, err := a.authenticate(withBearer(secret), protomlog.LogReader_Search_FullMethodName); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("authenticate with an unknown role = %v, want %s", err, codes.PermissionDenied)
+	}
+}
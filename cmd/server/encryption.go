@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/foundation/envelope"
+	"github.com/felipecooper/log-horizon/foundation/logger"
+)
+
+// reencryptJob rotates the data key once it is older than maxAge, when set,
+// and moves every log still sealed with an older key, or not sealed at all,
+// over to the current one.
+func reencryptJob(log logger.Logger, keyring *envelope.Keyring, store mlog.KeyRotator, maxAge time.Duration) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if current, ok := keyring.Current(); ok && maxAge > 0 && time.Since(current.CreatedAt) > maxAge {
+			id, err := store.RotateKey(ctx)
+			if err != nil {
+				return err
+			}
+			log.Info(ctx, "encryption key rotated", "keyID", id)
+		}
+
+		var total int64
+		for {
+			n, err := store.Reencrypt(ctx, 1000)
+			if err != nil {
+				return err
+			}
+			total += n
+			if n == 0 {
+				break
+			}
+		}
+		if total > 0 {
+			log.Info(ctx, "reencryption finished", "logs", total)
+		}
+		return nil
+	}
+}
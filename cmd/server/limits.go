@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/felipecooper/log-horizon/app/domain/quotaapp"
+)
+
+// fileLimits are the limits of a subject as written in the limits file.
+type fileLimits struct {
+	LogsPerSecond  int64 `json:"logs_per_second"`
+	LogsBurst      int64 `json:"logs_burst"`
+	BytesPerSecond int64 `json:"bytes_per_second"`
+	BytesBurst     int64 `json:"bytes_burst"`
+	DailyLogs      int64 `json:"daily_logs"`
+	DailyBytes     int64 `json:"daily_bytes"`
+	MaxQueries     int   `json:"max_queries"`
+}
+
+// loadLimits reads the limits file at path, a JSON object mapping each
+// subject, a tenant or "key:<id>", to its limits.
+func loadLimits(path string) (map[string]quotaapp.Limits, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading limits: %w", err)
+	}
+
+	var entries map[string]fileLimits
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("decoding limits: %w", err)
+	}
+
+	limits := make(map[string]quotaapp.Limits, len(entries))
+	for subject, e := range entries {
+		l := quotaapp.Limits(e)
+		if err := l.Validate(); err != nil {
+			return nil, fmt.Errorf("subject %q: %w", subject, err)
+		}
+		limits[subject] = l
+	}
+
+	return limits, nil
+}
@@ -2,19 +2,56 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"log"
 	"net"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
 	"syscall"
+	"time"
 
+	"github.com/felipecooper/log-horizon/app/domain/apikeyapp"
+	"github.com/felipecooper/log-horizon/app/domain/auditapp"
+	"github.com/felipecooper/log-horizon/app/domain/chainapp"
 	"github.com/felipecooper/log-horizon/app/domain/mlogapp"
+	"github.com/felipecooper/log-horizon/app/domain/quotaapp"
 	protomlog "github.com/felipecooper/log-horizon/app/sdk/proto/mlog"
+	"github.com/felipecooper/log-horizon/app/sdk/tenant"
+	"github.com/felipecooper/log-horizon/business/domain/apikey"
+	"github.com/felipecooper/log-horizon/business/domain/apikey/jsonfile"
+	apikeymongodb "github.com/felipecooper/log-horizon/business/domain/apikey/mongodb"
+	apikeypostgres "github.com/felipecooper/log-horizon/business/domain/apikey/postgres"
+	apikeysqlite "github.com/felipecooper/log-horizon/business/domain/apikey/sqlite"
+	"github.com/felipecooper/log-horizon/business/domain/audit"
+	"github.com/felipecooper/log-horizon/business/domain/audit/jsonl"
+	auditmongodb "github.com/felipecooper/log-horizon/business/domain/audit/mongodb"
+	auditpostgres "github.com/felipecooper/log-horizon/business/domain/audit/postgres"
+	auditsqlite "github.com/felipecooper/log-horizon/business/domain/audit/sqlite"
+	"github.com/felipecooper/log-horizon/business/domain/chain"
+	chainmongodb "github.com/felipecooper/log-horizon/business/domain/chain/mongodb"
+	chainpostgres "github.com/felipecooper/log-horizon/business/domain/chain/postgres"
+	chainsqlite "github.com/felipecooper/log-horizon/business/domain/chain/sqlite"
 	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"github.com/felipecooper/log-horizon/business/domain/mlog/embedded"
+	"github.com/felipecooper/log-horizon/business/domain/mlog/ingest"
 	"github.com/felipecooper/log-horizon/business/domain/mlog/mongodb"
+	"github.com/felipecooper/log-horizon/business/domain/mlog/postgres"
+	"github.com/felipecooper/log-horizon/business/domain/mlog/redact"
+	"github.com/felipecooper/log-horizon/business/domain/mlog/spool"
+	"github.com/felipecooper/log-horizon/business/domain/mlog/sqlite"
+	"github.com/felipecooper/log-horizon/business/domain/mlog/tenants"
+	"github.com/felipecooper/log-horizon/business/domain/mlog/tiered"
+	"github.com/felipecooper/log-horizon/foundation/blob"
+	"github.com/felipecooper/log-horizon/foundation/compress"
+	"github.com/felipecooper/log-horizon/foundation/envelope"
 	"github.com/felipecooper/log-horizon/foundation/logger"
+	"github.com/felipecooper/log-horizon/foundation/tlsconfig"
+	"github.com/felipecooper/log-horizon/foundation/worker"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/reflection"
 )
 
@@ -27,28 +64,608 @@ func main() {
 	mongoCollection := getEnv("MONGODB_COLLECTION", "logs")
 	exportPath := getEnv("EXPORT_PATH", "./exports")
 
+	compression := getEnv("COMPRESSION_ALGORITHM", "zstd")
+	compressionLevel := getEnvInt("COMPRESSION_LEVEL", compress.DefaultLevel)
+	dictionaryInterval := getEnvDuration("DICTIONARY_TRAIN_INTERVAL", 24*time.Hour)
+	compactionInterval := getEnvDuration("COMPACTION_INTERVAL", time.Hour)
+	compactionAge := getEnvDuration("COMPACTION_AGE", 7*24*time.Hour)
+	retentionInterval := getEnvDuration("RETENTION_INTERVAL", time.Hour)
+	retentionDryRun := getEnvBool("RETENTION_DRY_RUN", false)
+
 	grpcPort := getEnv("GRPC_PORT", "50051")
 
 	mongoConfig := mongodb.Config{
-		URI:              mongoURI,
-		DatabaseName:     mongoDBName,
-		CollectionName:   mongoCollection,
-		ExportPath:       exportPath,
-		CompressionLevel: 9,
+		URI:               mongoURI,
+		DatabaseName:      mongoDBName,
+		CollectionName:    mongoCollection,
+		ExportPath:        exportPath,
+		Compression:       compress.Algorithm(compression),
+		CompressionLevel:  compressionLevel,
+		DictionarySize:    getEnvInt("DICTIONARY_SIZE", 64<<10),
+		DictionarySamples: getEnvInt("DICTIONARY_SAMPLES", 2000),
+
+		BlockCompression:      compress.Algorithm(getEnv("COMPACTION_ALGORITHM", "zstd")),
+		BlockCompressionLevel: getEnvInt("COMPACTION_LEVEL", 19),
+		BlockSize:             getEnvInt("COMPACTION_BLOCK_SIZE", 1000),
+
+		RetentionTTL: getEnvBool("RETENTION_TTL", true),
 	}
 
 	ctx := context.Background()
-	store, err := mongodb.NewStore(ctx, logger, mongoConfig)
+	exports, err := newExportBucket(ctx, exportPath)
 	if err != nil {
-		logger.Error(context.Background(), "failed to create MongoDB store", "error", err)
+		logger.Error(context.Background(), "failed to create export sink", "error", err)
 		os.Exit(1)
 	}
+	mongoConfig.ExportBucket = exports
+
+	jobs, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	var (
+		logStore   mlog.Store
+		closeStore = func(context.Context) error { return nil }
+		keyStore   apikey.Storer
+		auditStore audit.Storer
+	)
+
+	// API keys live in API_KEYS_FILE when set, in the log store's database
+	// otherwise.
+	keysFile := getEnv("API_KEYS_FILE", "")
+
+	// The audit trail lives in AUDIT_FILE when set, in the log store's
+	// database otherwise, apart from the logs so retention never reaches it.
+	auditEnabled := getEnvBool("AUDIT_ENABLED", false)
+	auditFile := getEnv("AUDIT_FILE", "")
+
+	// The hash chains of the logs live in the log store's database. TTL
+	// indexes expire logs without naming them, which the chain must record,
+	// so retention deletes in batches instead.
+	chainEnabled := getEnvBool("CHAIN_ENABLED", false)
+	var chainStore chain.Storer
+	mongoConfig.RetentionTTL = mongoConfig.RetentionTTL && !chainEnabled
+
+	// Logs are encrypted at rest when ENCRYPTION_KEYFILE names the master
+	// key. Only the MongoDB and SQLite stores encrypt, MongoDB only with
+	// every tenant in one collection, and archived segments would not.
+	var keyring *envelope.Keyring
+	keyMaxAge := getEnvDuration("ENCRYPTION_KEY_MAX_AGE", 0)
+	reencryptInterval := getEnvDuration("ENCRYPTION_REENCRYPT_INTERVAL", time.Minute)
+	if keyfile := getEnv("ENCRYPTION_KEYFILE", ""); keyfile != "" {
+		if backend := getEnv("STORE_BACKEND", "mongodb"); backend != "mongodb" && backend != "sqlite" {
+			logger.Error(context.Background(), "encryption at rest needs the mongodb or sqlite backend", "backend", backend)
+			os.Exit(1)
+		}
+		if isolation := getEnv("TENANT_ISOLATION", string(mongodb.IsolationShared)); isolation != string(mongodb.IsolationShared) {
+			logger.Error(context.Background(), "encryption at rest does not support isolated tenants", "isolation", isolation)
+			os.Exit(1)
+		}
+		if mongoConfig.Compression == compress.ZstdDict {
+			logger.Error(context.Background(), "encryption at rest does not support dictionary compression")
+			os.Exit(1)
+		}
+		if tier := getEnv("TIER_ARCHIVE", "none"); tier != "none" {
+			logger.Error(context.Background(), "encryption at rest does not support archive tiers", "tier", tier)
+			os.Exit(1)
+		}
+
+		master, err := envelope.LoadMasterKey(keyfile)
+		if err != nil {
+			logger.Error(context.Background(), "failed to load master key", "error", err)
+			os.Exit(1)
+		}
+		keyring, err = envelope.NewKeyring(master)
+		if err != nil {
+			logger.Error(context.Background(), "failed to create keyring", "error", err)
+			os.Exit(1)
+		}
+		mongoConfig.Keyring = keyring
+	}
+
+	switch backend := getEnv("STORE_BACKEND", "mongodb"); backend {
+	case "mongodb":
+		store, err := mongodb.NewStore(ctx, logger, mongoConfig)
+		if err != nil {
+			logger.Error(context.Background(), "failed to create MongoDB store", "error", err)
+			os.Exit(1)
+		}
+		logStore = store
+
+		if keysFile == "" {
+			keyStore, err = apikeymongodb.NewStore(ctx, store.Client().Database(mongoDBName), mongoCollection+"_apikeys")
+			if err != nil {
+				logger.Error(context.Background(), "failed to create API key store", "error", err)
+				os.Exit(1)
+			}
+		}
+
+		if auditEnabled && auditFile == "" {
+			auditStore, err = auditmongodb.NewStore(ctx, store.Client().Database(mongoDBName), mongoCollection+"_audit")
+			if err != nil {
+				logger.Error(context.Background(), "failed to create audit store", "error", err)
+				os.Exit(1)
+			}
+		}
+
+		if chainEnabled {
+			chainStore, err = chainmongodb.NewStore(ctx, store.Client().Database(mongoDBName), mongoCollection+"_chain")
+			if err != nil {
+				logger.Error(context.Background(), "failed to create chain store", "error", err)
+				os.Exit(1)
+			}
+		}
+
+		switch isolation := mongodb.Isolation(getEnv("TENANT_ISOLATION", string(mongodb.IsolationShared))); isolation {
+		case mongodb.IsolationShared:
+		case mongodb.IsolationCollection, mongodb.IsolationDatabase:
+			tenantConfig := mongoConfig
+			tenantConfig.Client = store.Client()
+			logStore = tenants.NewStore(store, tenants.Config{
+				Open: func(ctx context.Context, name string) (mlog.Store, error) {
+					return mongodb.NewStore(ctx, logger, tenantConfig.ForTenant(isolation, name))
+				},
+				List: func(ctx context.Context) ([]string, error) {
+					return mongodb.Tenants(ctx, tenantConfig.Client, tenantConfig, isolation)
+				},
+			})
+		default:
+			logger.Error(context.Background(), "unknown tenant isolation", "isolation", isolation)
+			os.Exit(1)
+		}
+
+		if keyring != nil && reencryptInterval > 0 {
+			go worker.Run(jobs, logger, "reencrypt", reencryptInterval, reencryptJob(logger, keyring, store, keyMaxAge))
+		}
+
+		if mongoConfig.Compression == compress.ZstdDict && dictionaryInterval > 0 {
+			train := func(ctx context.Context) error {
+				_, err := store.TrainDictionary(ctx)
+				return err
+			}
+			go func() {
+				// A dictionary missing or older than the interval is trained
+				// right away instead of a whole interval later.
+				if time.Since(store.DictionaryTrainedAt()) >= dictionaryInterval {
+					if err := train(jobs); err != nil {
+						logger.Error(jobs, "failed to train dictionary at startup", "error", err)
+					}
+				}
+				worker.Run(jobs, logger, "dictionary-training", dictionaryInterval, train)
+			}()
+		}
+
+	case "embedded":
+		dir := getEnv("EMBEDDED_DIR", "./data")
+		store, err := embedded.NewStore(ctx, logger, embedded.Config{
+			Dir:              dir,
+			Compression:      compress.Algorithm(getEnv("COMPACTION_ALGORITHM", "zstd")),
+			CompressionLevel: getEnvInt("COMPACTION_LEVEL", 19),
+			BlockSize:        getEnvInt("EMBEDDED_BLOCK_SIZE", 1<<20),
+			MemtableSize:     getEnvInt("EMBEDDED_MEMTABLE_SIZE", 10000),
+			NoSync:           !getEnvBool("EMBEDDED_SYNC", true),
+			ExportPath:       exportPath,
+			ExportBucket:     exports,
+		})
+		if err != nil {
+			logger.Error(context.Background(), "failed to create embedded store", "error", err)
+			os.Exit(1)
+		}
+		logStore = store
+		closeStore = store.Close
+
+		if keysFile == "" {
+			keysFile = filepath.Join(dir, "apikeys.json")
+		}
+		if auditFile == "" {
+			auditFile = filepath.Join(dir, "audit.jsonl")
+		}
+
+		if flushInterval := getEnvDuration("EMBEDDED_FLUSH_INTERVAL", 10*time.Second); flushInterval > 0 {
+			go worker.Run(jobs, logger, "flush", flushInterval, store.Flush)
+		}
+
+	case "sqlite":
+		store, err := sqlite.NewStore(ctx, logger, sqlite.Config{
+			Path:             getEnv("SQLITE_PATH", "./loghorizon.db"),
+			Compression:      compress.Algorithm(compression),
+			CompressionLevel: compressionLevel,
+			ExportPath:       exportPath,
+			ExportBucket:     exports,
+			Keyring:          keyring,
+		})
+		if err != nil {
+			logger.Error(context.Background(), "failed to create SQLite store", "error", err)
+			os.Exit(1)
+		}
+		logStore = store
+		closeStore = func(context.Context) error { return store.Close() }
+		keyStore = apikeysqlite.NewStore(store.DB())
+		auditStore = auditsqlite.NewStore(store.DB())
+		chainStore = chainsqlite.NewStore(store.DB())
+
+		if keyring != nil && reencryptInterval > 0 {
+			go worker.Run(jobs, logger, "reencrypt", reencryptInterval, reencryptJob(logger, keyring, store, keyMaxAge))
+		}
+
+	case "postgres":
+		store, err := postgres.NewStore(ctx, logger, postgres.Config{
+			URL:              getEnv("POSTGRES_URL", "postgres://localhost:5432/loghorizon?sslmode=disable"),
+			Compression:      compress.Algorithm(compression),
+			CompressionLevel: compressionLevel,
+			ExportPath:       exportPath,
+			ExportBucket:     exports,
+		})
+		if err != nil {
+			logger.Error(context.Background(), "failed to create PostgreSQL store", "error", err)
+			os.Exit(1)
+		}
+		logStore = store
+		closeStore = func(context.Context) error {
+			store.Close()
+			return nil
+		}
+		keyStore = apikeypostgres.NewStore(store.Pool())
+		auditStore = auditpostgres.NewStore(store.Pool())
+		chainStore = chainpostgres.NewStore(store.Pool())
+
+	default:
+		logger.Error(context.Background(), "unknown store backend", "backend", backend)
+		os.Exit(1)
+	}
+
+	if compactor, ok := logStore.(mlog.Compactor); ok && compactionInterval > 0 {
+		go worker.Run(jobs, logger, "compaction", compactionInterval, func(ctx context.Context) error {
+			report, err := compactor.Compact(ctx, time.Now().Add(-compactionAge))
+			if err != nil {
+				return err
+			}
+			logger.Info(ctx, "compaction finished",
+				"logs", report.Logs,
+				"blocks", report.Blocks,
+				"bytesBefore", report.BytesBefore,
+				"bytesAfter", report.BytesAfter,
+				"saved", report.Saved(),
+			)
+			return nil
+		})
+	}
+
+	// The tiered store forwards retention to the primary store, so whether
+	// retention is enforced depends on the primary store.
+	_, retains := logStore.(mlog.RetentionStore)
+
+	if tier := getEnv("TIER_ARCHIVE", "none"); tier != "none" {
+		archive, err := newArchive(ctx, tier)
+		if err != nil {
+			logger.Error(context.Background(), "failed to create archive", "error", err)
+			os.Exit(1)
+		}
+
+		tieredStore, err := tiered.NewStore(ctx, logger, logStore, tiered.Config{
+			Archive:          archive,
+			Compression:      compress.Algorithm(getEnv("TIER_COMPRESSION", "zstd")),
+			CompressionLevel: getEnvInt("TIER_COMPRESSION_LEVEL", 19),
+			BlockSize:        getEnvInt("TIER_BLOCK_SIZE", 1<<20),
+			ExportPath:       exportPath,
+			ExportBucket:     exports,
+		})
+		if err != nil {
+			logger.Error(context.Background(), "failed to create tiered store", "error", err)
+			os.Exit(1)
+		}
+		logStore = tieredStore
+
+		tierAge := getEnvDuration("TIER_AGE", 30*24*time.Hour)
+		if tierInterval := getEnvDuration("TIER_INTERVAL", time.Hour); tierInterval > 0 {
+			go worker.Run(jobs, logger, "archive", tierInterval, func(ctx context.Context) error {
+				report, err := tieredStore.Archive(ctx, time.Now().Add(-tierAge))
+				if err != nil {
+					return err
+				}
+				logger.Info(ctx, "archive finished",
+					"days", report.Days,
+					"logs", report.Logs,
+					"pruned", report.Pruned,
+					"rawBytes", report.RawBytes,
+					"storedBytes", report.StoredBytes,
+				)
+				return nil
+			})
+		}
+	}
+
+	mlogOptions := []mlog.Option{
+		mlog.WithStatsCacheTTL(getEnvDuration("STATS_CACHE_TTL", 30*time.Second)),
+		mlog.WithMaxEraseMatches(getEnvInt("ERASE_MAX_MATCHES", 100000)),
+		mlog.WithIdempotencyWindow(getEnvDuration("IDEMPOTENCY_WINDOW", 24*time.Hour)),
+		mlog.WithTenantRequired(getEnvBool("TENANT_REQUIRED", false)),
+	}
+
+	var writer mlog.Writer = logStore
+
+	// Logs are chained as they reach the store, so the spool and the ingest
+	// buffer wrap the chain writer rather than the store.
+	var chainBusiness *chain.Business
+	if chainEnabled {
+		if chainStore == nil {
+			logger.Error(context.Background(), "the log chain is not supported by this backend")
+			os.Exit(1)
+		}
+
+		keyFile := getEnv("CHAIN_SIGNING_KEY", "")
+		if keyFile == "" {
+			logger.Error(context.Background(), "the log chain needs CHAIN_SIGNING_KEY")
+			os.Exit(1)
+		}
+		signer, err := chain.LoadSigner(keyFile)
+		if err != nil {
+			logger.Error(context.Background(), "failed to load chain signing key", "error", err)
+			os.Exit(1)
+		}
+
+		chainBusiness = chain.NewBusiness(logger, chainStore, logStore, signer)
+		chainWriter := chain.NewWriter(logStore, chainBusiness)
+		writer = chainWriter
+		mlogOptions = append(mlogOptions, mlog.WithDeletionRecorder(chainBusiness), mlog.WithTxWriter(chainWriter))
+
+		if interval := getEnvDuration("CHAIN_CHECKPOINT_INTERVAL", time.Hour); interval > 0 {
+			go worker.Run(jobs, logger, "chain-checkpoint", interval, func(ctx context.Context) error {
+				checkpoints, err := chainBusiness.Checkpoint(ctx)
+				for _, c := range checkpoints {
+					logger.Info(ctx, "chain checkpoint signed",
+						"tenant", c.Partition,
+						"seq", c.Seq,
+						"hash", c.Hash,
+						"signature", base64.StdEncoding.EncodeToString(c.Signature),
+					)
+				}
+				return err
+			})
+		}
+	}
+
+	closeSpool := func() error { return nil }
+	if dir := getEnv("SPOOL_DIR", ""); dir != "" {
+		sp, err := spool.Open(logger, writer, spool.Config{
+			Dir:           dir,
+			SegmentSize:   int64(getEnvInt("SPOOL_SEGMENT_SIZE", 16<<20)),
+			MaxSize:       int64(getEnvInt("SPOOL_MAX_SIZE", 1<<30)),
+			RetryInterval: getEnvDuration("SPOOL_RETRY_INTERVAL", 5*time.Second),
+		})
+		if err != nil {
+			logger.Error(context.Background(), "failed to open spool", "error", err)
+			os.Exit(1)
+		}
+		writer = sp
+		closeSpool = sp.Close
+	}
+
+	closeBuffer := func(context.Context) error { return nil }
+	if getEnvBool("INGEST_BUFFER", false) {
+		buffer, err := ingest.New(logger, writer, ingest.Config{
+			BatchSize:     getEnvInt("INGEST_BATCH_SIZE", 500),
+			FlushInterval: getEnvDuration("INGEST_FLUSH_INTERVAL", 100*time.Millisecond),
+			QueueSize:     getEnvInt("INGEST_QUEUE_SIZE", 10000),
+			Ack:           ingest.AckMode(getEnv("INGEST_ACK", string(ingest.AckDurable))),
+			WriteTimeout:  getEnvDuration("INGEST_WRITE_TIMEOUT", 30*time.Second),
+		})
+		if err != nil {
+			logger.Error(context.Background(), "failed to create ingest buffer", "error", err)
+			os.Exit(1)
+		}
+		writer = buffer
+		closeBuffer = buffer.Close
+	}
+	mlogOptions = append(mlogOptions, mlog.WithWriter(writer))
+
+	redactionRules, err := loadRedactionRules(
+		getEnv("REDACT_RULES", ""),
+		redact.Mode(getEnv("REDACT_MODE", string(redact.ModeMask))),
+		getEnv("REDACT_FILE", ""),
+	)
+	if err != nil {
+		logger.Error(context.Background(), "failed to load redaction rules", "error", err)
+		os.Exit(1)
+	}
+	if len(redactionRules) > 0 {
+		redactor, err := redact.New(redactionRules, []byte(getEnv("REDACT_HASH_KEY", "")))
+		if err != nil {
+			logger.Error(context.Background(), "failed to create redactor", "error", err)
+			os.Exit(1)
+		}
+		mlogOptions = append(mlogOptions, mlog.WithRedactor(redactor))
+	}
+
+	mlogBusiness := mlog.NewMlog(logger, logStore, mlogOptions...)
+	if retains && retentionInterval > 0 {
+		go worker.Run(jobs, logger, "retention", retentionInterval, func(ctx context.Context) error {
+			results, err := mlogBusiness.EnforceRetention(ctx, retentionDryRun)
+			if err != nil {
+				return err
+			}
+			for _, r := range results {
+				logger.Info(ctx, "retention policy applied",
+					"policy", r.Policy,
+					"deleted", r.Deleted,
+					"bytes", r.Bytes,
+					"dryRun", r.DryRun,
+					"ttl", r.TTL,
+					"estimated", r.Estimated,
+				)
+			}
+			return nil
+		})
+	}
+
+	go worker.Run(jobs, logger, "idempotency-purge", getEnvDuration("IDEMPOTENCY_PURGE_INTERVAL", time.Hour), func(ctx context.Context) error {
+		purged, err := mlogBusiness.PurgeIdempotencyKeys(ctx)
+		if err != nil {
+			return err
+		}
+		logger.Info(ctx, "idempotency keys purged", "keys", purged)
+		return nil
+	})
+
+	if keysFile != "" {
+		keyStore, err = jsonfile.Open(keysFile)
+		if err != nil {
+			logger.Error(context.Background(), "failed to open API key file", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	var (
+		roles     map[string]mlog.Restriction
+		roleNames []string
+	)
+	if rolesFile := getEnv("ROLES_FILE", ""); rolesFile != "" {
+		roles, err = loadRoles(rolesFile)
+		if err != nil {
+			logger.Error(context.Background(), "failed to load roles", "error", err)
+			os.Exit(1)
+		}
+		for name := range roles {
+			roleNames = append(roleNames, name)
+		}
+	}
+	keyBusiness := apikey.NewBusiness(logger, keyStore, apikey.WithRoles(roleNames))
+
+	unary := []grpc.UnaryServerInterceptor{tenant.UnaryServerInterceptor()}
+	stream := []grpc.StreamServerInterceptor{tenant.StreamServerInterceptor()}
+	authEnabled := getEnvBool("AUTH_ENABLED", false)
+	if authEnabled {
+		keys, err := keyBusiness.Keys(ctx, false)
+		if err != nil {
+			logger.Error(context.Background(), "failed to list API keys", "error", err)
+			os.Exit(1)
+		}
+		if len(keys) == 0 {
+			logger.Error(context.Background(), "authentication is enabled but there are no API keys; every call will be rejected")
+		}
+
+		// Authentication runs first so the tenant of a key wins over the
+		// tenant header.
+		auth := authenticator{log: logger, keys: keyBusiness, roles: roles}
+		unary = append([]grpc.UnaryServerInterceptor{auth.unaryInterceptor()}, unary...)
+		stream = append([]grpc.StreamServerInterceptor{auth.streamInterceptor()}, stream...)
+	}
+
+	// Limits apply once the caller and its tenant are known.
+	limits := quotaapp.Limits{
+		LogsPerSecond:  int64(getEnvInt("RATE_LIMIT_LOGS", 0)),
+		LogsBurst:      int64(getEnvInt("RATE_LIMIT_LOGS_BURST", 0)),
+		BytesPerSecond: int64(getEnvInt("RATE_LIMIT_BYTES", 0)),
+		BytesBurst:     int64(getEnvInt("RATE_LIMIT_BYTES_BURST", 0)),
+		DailyLogs:      int64(getEnvInt("QUOTA_DAILY_LOGS", 0)),
+		DailyBytes:     int64(getEnvInt("QUOTA_DAILY_BYTES", 0)),
+		MaxQueries:     getEnvInt("QUERY_CONCURRENCY", 0),
+	}
+	var subjectLimits map[string]quotaapp.Limits
+	if limitsFile := getEnv("LIMITS_FILE", ""); limitsFile != "" {
+		subjectLimits, err = loadLimits(limitsFile)
+		if err != nil {
+			logger.Error(context.Background(), "failed to load limits", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	var limiter *quotaapp.Limiter
+	if !limits.IsZero() || len(subjectLimits) > 0 {
+		if err := limits.Validate(); err != nil {
+			logger.Error(context.Background(), "invalid limits", "error", err)
+			os.Exit(1)
+		}
+		by := quotaapp.By(getEnv("RATE_LIMIT_BY", string(quotaapp.ByTenant)))
+		if !by.IsValid() {
+			logger.Error(context.Background(), "unknown rate limit grouping", "by", by)
+			os.Exit(1)
+		}
+
+		var limiterOptions []quotaapp.Option
+		if authEnabled {
+			limiterOptions = append(limiterOptions, quotaapp.WithAuthentication())
+		}
+		limiter = quotaapp.NewLimiter(by, limits, subjectLimits, limiterOptions...)
+		unary = append(unary, quotaapp.UnaryServerInterceptor(logger, limiter))
+		stream = append(stream, quotaapp.StreamServerInterceptor(logger, limiter))
+	}
+
+	// Auditing runs last, once the caller and its tenant are known.
+	var auditBusiness *audit.Business
+	closeAudit := func() error { return nil }
+	if auditEnabled {
+		if auditFile != "" {
+			trail, err := jsonl.Open(auditFile)
+			if err != nil {
+				logger.Error(context.Background(), "failed to open audit file", "error", err)
+				os.Exit(1)
+			}
+			auditStore = trail
+			closeAudit = trail.Close
+		}
+
+		auditBusiness = audit.NewBusiness(logger, auditStore)
+		unary = append(unary, auditapp.UnaryServerInterceptor(logger, auditBusiness))
+		stream = append(stream, auditapp.StreamServerInterceptor(logger, auditBusiness))
+	}
+
+	serverOptions := []grpc.ServerOption{}
+	if certFile := getEnv("TLS_CERT_FILE", ""); certFile != "" {
+		certs, err := tlsconfig.New(tlsconfig.Config{
+			CertFile:     certFile,
+			KeyFile:      getEnv("TLS_KEY_FILE", ""),
+			ClientCAFile: getEnv("TLS_CLIENT_CA_FILE", ""),
+			ClientAuth:   tlsconfig.ClientAuth(getEnv("TLS_CLIENT_AUTH", "")),
+		})
+		if err != nil {
+			logger.Error(context.Background(), "failed to load TLS certificates", "error", err)
+			os.Exit(1)
+		}
+		serverOptions = append(serverOptions, grpc.Creds(credentials.NewTLS(certs.TLSConfig())))
+
+		if reloadInterval := getEnvDuration("TLS_RELOAD_INTERVAL", time.Minute); reloadInterval > 0 {
+			go worker.Run(jobs, logger, "tls-reload", reloadInterval, func(ctx context.Context) error {
+				reloaded, err := certs.Reload(ctx)
+				if err != nil {
+					return err
+				}
+				if reloaded {
+					logger.Info(ctx, "TLS certificates reloaded")
+				}
+				return nil
+			})
+		}
+
+		// The client certificate identity comes first, so an API key sent
+		// along still wins.
+		if getEnvBool("TLS_CLIENT_IDENTITY", false) {
+			unary = append([]grpc.UnaryServerInterceptor{certUnaryInterceptor()}, unary...)
+			stream = append([]grpc.StreamServerInterceptor{certStreamInterceptor()}, stream...)
+		}
+	}
 
-	mlogBusiness := mlog.NewMlog(logger, store)
 	app := mlogapp.NewApp(logger, mlogBusiness)
-	server := grpc.NewServer()
+	server := grpc.NewServer(append(serverOptions,
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	)...)
 	protomlog.RegisterLogWriterServer(server, app)
 	protomlog.RegisterLogReaderServer(server, app)
+	protomlog.RegisterLogAdminServer(server, app)
+	// Without authentication anyone could mint keys, so keys are only
+	// managed once they are checked.
+	if authEnabled {
+		protomlog.RegisterKeyAdminServer(server, apikeyapp.NewApp(logger, keyBusiness))
+	}
+	if limiter != nil {
+		protomlog.RegisterQuotaServer(server, quotaapp.NewApp(logger, limiter))
+	}
+	if chainBusiness != nil {
+		protomlog.RegisterLogChainServer(server, chainapp.NewApp(logger, chainBusiness))
+	}
+	if auditBusiness != nil {
+		protomlog.RegisterAuditLogServer(server, auditapp.NewApp(logger, auditBusiness))
+	}
 	reflection.Register(server)
 	addr := fmt.Sprintf(":%s", grpcPort)
 	listener, err := net.Listen("tcp", addr)
@@ -71,7 +688,22 @@ func main() {
 	<-shutdown
 
 	logger.Info(context.Background(), "shutting down server")
+	stopJobs()
 	server.GracefulStop()
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), getEnvDuration("INGEST_FLUSH_TIMEOUT", 30*time.Second))
+	if err := closeBuffer(flushCtx); err != nil {
+		logger.Error(context.Background(), "failed to flush ingest buffer", "error", err)
+	}
+	cancelFlush()
+	if err := closeSpool(); err != nil {
+		logger.Error(context.Background(), "failed to close spool", "error", err)
+	}
+	if err := closeAudit(); err != nil {
+		logger.Error(context.Background(), "failed to close audit file", "error", err)
+	}
+	if err := closeStore(context.Background()); err != nil {
+		logger.Error(context.Background(), "failed to close store", "error", err)
+	}
 	logger.Info(context.Background(), "server stopped")
 }
 
@@ -89,9 +721,76 @@ func (l *simpleLogger) Error(ctx context.Context, msg string, keyValues ...inter
 	log.Printf("ERROR: %s %v\n", msg, keyValues)
 }
 
+func newExportBucket(ctx context.Context, exportPath string) (blob.Bucket, error) {
+	switch sink := getEnv("EXPORT_SINK", "local"); sink {
+	case "local":
+		return blob.NewLocal(exportPath), nil
+	case "s3":
+		return blob.NewS3(ctx, blob.S3Config{
+			Endpoint:      getEnv("S3_ENDPOINT", "localhost:9000"),
+			Region:        getEnv("S3_REGION", ""),
+			AccessKey:     getEnv("S3_ACCESS_KEY", ""),
+			SecretKey:     getEnv("S3_SECRET_KEY", ""),
+			Bucket:        getEnv("S3_BUCKET", "loghorizon-exports"),
+			Prefix:        getEnv("S3_PREFIX", "exports"),
+			UseSSL:        getEnvBool("S3_USE_SSL", false),
+			PartSize:      uint64(getEnvInt("S3_PART_SIZE", 16<<20)),
+			PresignExpiry: getEnvDuration("S3_PRESIGN_EXPIRY", 24*time.Hour),
+		})
+	default:
+		return nil, fmt.Errorf("unknown export sink %q", sink)
+	}
+}
+
+func newArchive(ctx context.Context, kind string) (blob.Archive, error) {
+	switch kind {
+	case "local":
+		return blob.NewLocal(getEnv("TIER_PATH", "./archive")), nil
+	case "s3":
+		return blob.NewS3(ctx, blob.S3Config{
+			Endpoint:  getEnv("S3_ENDPOINT", "localhost:9000"),
+			Region:    getEnv("S3_REGION", ""),
+			AccessKey: getEnv("S3_ACCESS_KEY", ""),
+			SecretKey: getEnv("S3_SECRET_KEY", ""),
+			Bucket:    getEnv("TIER_S3_BUCKET", "loghorizon-archive"),
+			UseSSL:    getEnvBool("S3_USE_SSL", false),
+			PartSize:  uint64(getEnvInt("S3_PART_SIZE", 16<<20)),
+		})
+	default:
+		return nil, fmt.Errorf("unknown archive %q", kind)
+	}
+}
+
 func getEnv(key, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok {
 		return value
 	}
 	return fallback
 }
+
+func getEnvBool(key string, fallback bool) bool {
+	if value, ok := os.LookupEnv(key); ok {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) int {
+	if value, ok := os.LookupEnv(key); ok {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return fallback
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if value, ok := os.LookupEnv(key); ok {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog/redact"
+)
+
+// fileRule is a redaction rule as written in the redaction file.
+type fileRule struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+	Mode    string `json:"mode"`
+}
+
+// loadRedactionRules returns the built-in rules named in builtins, a comma
+// separated list, followed by the rules of the JSON array in path, if any.
+// A file rule with the name of a built-in rule and no pattern sets the mode
+// of that rule.
+func loadRedactionRules(builtins string, mode redact.Mode, path string) ([]redact.Rule, error) {
+	modes := map[string]redact.Mode{}
+	for _, name := range strings.Split(builtins, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			modes[name] = mode
+		}
+	}
+
+	var custom []redact.Rule
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading redaction rules: %w", err)
+		}
+
+		var entries []fileRule
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("decoding redaction rules: %w", err)
+		}
+
+		for _, e := range entries {
+			m := redact.Mode(e.Mode)
+			if m == "" {
+				m = mode
+			}
+
+			if e.Pattern == "" && slices.Contains(redact.Builtins, e.Name) {
+				modes[e.Name] = m
+				continue
+			}
+
+			pattern, err := regexp.Compile(e.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: %w", e.Name, err)
+			}
+			custom = append(custom, redact.Rule{Name: e.Name, Pattern: pattern, Mode: m})
+		}
+	}
+
+	var rules []redact.Rule
+	for _, name := range redact.Builtins {
+		m, ok := modes[name]
+		if !ok {
+			continue
+		}
+		delete(modes, name)
+
+		r, err := redact.Builtin(name, m)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	for name := range modes {
+		return nil, fmt.Errorf("%w: unknown built-in rule %q", redact.ErrInvalidRule, name)
+	}
+
+	return append(rules, custom...), nil
+}
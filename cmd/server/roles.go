@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+)
+
+// fileRole is a role as written in the roles file.
+type fileRole struct {
+	Levels    []mlog.Level      `json:"levels"`
+	Metadata  map[string]string `json:"metadata"`
+	MaxWindow string            `json:"max_window"`
+}
+
+// loadRoles reads the roles file at path, a JSON object mapping each role
+// name to the restriction of its callers.
+func loadRoles(path string) (map[string]mlog.Restriction, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading roles: %w", err)
+	}
+
+	var entries map[string]fileRole
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("decoding roles: %w", err)
+	}
+
+	roles := make(map[string]mlog.Restriction, len(entries))
+	for name, e := range entries {
+		r := mlog.Restriction{
+			Role:     name,
+			Levels:   e.Levels,
+			Metadata: e.Metadata,
+		}
+
+		if e.MaxWindow != "" {
+			r.MaxWindow, err = time.ParseDuration(e.MaxWindow)
+			if err != nil {
+				return nil, fmt.Errorf("role %q: parsing max window: %w", name, err)
+			}
+		}
+
+		if err := r.Validate(); err != nil {
+			return nil, fmt.Errorf("role %q: %w", name, err)
+		}
+		roles[name] = r
+	}
+
+	return roles, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/felipecooper/log-horizon/app/sdk/actor"
+	"github.com/felipecooper/log-horizon/business/domain/apikey"
+	"github.com/felipecooper/log-horizon/business/domain/mlog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type certCtxKey struct{}
+
+// certIdentity returns the caller named by a verified client certificate:
+// the common name identifies it, the first organization is its tenant, the
+// organizational units naming a scope are its scopes and one written
+// "role:<name>" is its role. It is returned as an apikey.Key so the auth
+// interceptors can check its scopes.
+func certIdentity(cert *x509.Certificate) (apikey.Key, error) {
+	if cert.Subject.CommonName == "" {
+		return apikey.Key{}, errors.New("client certificate has no common name")
+	}
+
+	key := apikey.Key{Name: cert.Subject.CommonName}
+
+	if len(cert.Subject.Organization) > 0 {
+		key.Tenant = cert.Subject.Organization[0]
+		if err := mlog.ValidateTenant(key.Tenant); err != nil {
+			return apikey.Key{}, fmt.Errorf("client certificate organization: %w", err)
+		}
+	}
+
+	for _, ou := range cert.Subject.OrganizationalUnit {
+		if scope := apikey.Scope(ou); scope.IsValid() {
+			key.Scopes = append(key.Scopes, scope)
+		}
+		if role, ok := strings.CutPrefix(ou, "role:"); ok {
+			if key.Role != "" {
+				return apikey.Key{}, errors.New("client certificate names more than one role")
+			}
+			key.Role = role
+		}
+	}
+
+	return key, nil
+}
+
+// identify returns ctx carrying the identity and tenant of the verified
+// client certificate of the call, if any.
+func identify(ctx context.Context) (context.Context, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ctx, nil
+	}
+
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return ctx, nil
+	}
+
+	key, err := certIdentity(info.State.VerifiedChains[0][0])
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	ctx = context.WithValue(ctx, certCtxKey{}, key)
+	ctx = actor.ContextWithActor(ctx, "cert:"+key.Name)
+	if key.Tenant != "" {
+		ctx = mlog.ContextWithTenant(ctx, key.Tenant)
+	}
+	return ctx, nil
+}
+
+// certFrom returns the client certificate identity carried by ctx.
+func certFrom(ctx context.Context) (apikey.Key, bool) {
+	key, ok := ctx.Value(certCtxKey{}).(apikey.Key)
+	return key, ok
+}
+
+// certUnaryInterceptor names unary callers after their client certificate.
+func certUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := identify(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// certStreamInterceptor names streaming callers after their client
+// certificate.
+func certStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := identify(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"slices"
+	"testing"
+
+	"github.com/felipecooper/log-horizon/business/domain/apikey"
+)
+
+func TestCertIdentity(t *testing.T) {
+	tests := []struct {
+		name    string
+		subject pkix.Name
+		want    apikey.Key
+		wantErr bool
+	}{
+		{
+			name: "full",
+			subject: pkix.Name{
+				CommonName:         "billing-worker",
+				Organization:       []string{"acme"},
+				OrganizationalUnit: []string{"logs:write", "logs:read", "ops", "role:support"},
+			},
+			want: apikey.Key{
+				Name:   "billing-worker",
+				Tenant: "acme",
+				Scopes: []apikey.Scope{apikey.ScopeWrite, apikey.ScopeRead},
+				Role:   "support",
+			},
+		},
+		{
+			name:    "no tenant or scopes",
+			subject: pkix.Name{CommonName: "probe"},
+			want:    apikey.Key{Name: "probe"},
+		},
+		{
+			name:    "no common name",
+			subject: pkix.Name{Organization: []string{"acme"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid tenant",
+			subject: pkix.Name{CommonName: "worker", Organization: []string{"Acme Corp!"}},
+			wantErr: true,
+		},
+		{
+			name:    "two roles",
+			subject: pkix.Name{CommonName: "worker", OrganizationalUnit: []string{"role:support", "role:auditor"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := certIdentity(&x509.Certificate{Subject: tt.subject})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("certIdentity = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("certIdentity: %v", err)
+			}
+
+			if got.Name != tt.want.Name || got.Tenant != tt.want.Tenant || got.Role != tt.want.Role || !slices.Equal(got.Scopes, tt.want.Scopes) {
+				t.Errorf("identity = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var (
+	ErrNotFound = errors.New("object not found")
+	ErrAborted  = errors.New("write aborted")
+)
+
+type Bucket interface {
+	Create(ctx context.Context, key string) (io.WriteCloser, error)
+	URL(ctx context.Context, key string) (string, error)
+}
+
+// Archive is a Bucket whose objects can be read back, listed and removed.
+type Archive interface {
+	Bucket
+	Open(ctx context.Context, key string) (Object, error)
+	List(ctx context.Context, prefix string) ([]string, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Object gives random access to a stored object.
+type Object interface {
+	io.ReaderAt
+	io.Closer
+	Size() int64
+}
+
+// Abort closes w without publishing what was written to it, for writes that
+// failed half way, so a failed export leaves no truncated file behind.
+// Writers that cannot discard their content, unlike those of Local and S3,
+// are simply closed.
+func Abort(w io.WriteCloser) error {
+	if a, ok := w.(interface{ CloseWithError(error) error }); ok {
+		return a.CloseWithError(ErrAborted)
+	}
+	return w.Close()
+}
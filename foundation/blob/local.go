@@ -0,0 +1,130 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type Local struct {
+	dir string
+}
+
+func NewLocal(dir string) *Local {
+	return &Local{
+		dir: dir,
+	}
+}
+
+func (l *Local) Create(ctx context.Context, key string) (io.WriteCloser, error) {
+	path := filepath.Join(l.dir, key)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating directory: %w", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("creating file: %w", err)
+	}
+
+	return &localWriter{File: file}, nil
+}
+
+func (l *Local) URL(ctx context.Context, key string) (string, error) {
+	return key, nil
+}
+
+func (l *Local) Open(ctx context.Context, key string) (Object, error) {
+	file, err := os.Open(filepath.Join(l.dir, key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("opening %q: %w", key, ErrNotFound)
+		}
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("stating file: %w", err)
+	}
+
+	return &localObject{File: file, size: info.Size()}, nil
+}
+
+// List returns the keys below prefix in lexical order.
+func (l *Local) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+
+	err := filepath.WalkDir(l.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(l.dir, path)
+		if err != nil {
+			return err
+		}
+
+		key := filepath.ToSlash(rel)
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing files: %w", err)
+	}
+
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	err := os.Remove(filepath.Join(l.dir, key))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("removing file: %w", err)
+	}
+	return nil
+}
+
+type localObject struct {
+	*os.File
+	size int64
+}
+
+func (o *localObject) Size() int64 {
+	return o.size
+}
+
+// localWriter is a file that is removed when the write is aborted.
+type localWriter struct {
+	*os.File
+}
+
+func (w *localWriter) CloseWithError(cause error) error {
+	if cause == nil {
+		return w.Close()
+	}
+	w.Close()
+	if err := os.Remove(w.Name()); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("removing aborted file: %w", err)
+	}
+	return nil
+}
+
+var _ Archive = (*Local)(nil)
@@ -0,0 +1,206 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+const (
+	defaultPartSize      = 16 << 20
+	defaultPresignExpiry = 24 * time.Hour
+)
+
+type S3Config struct {
+	Endpoint      string
+	Region        string
+	AccessKey     string
+	SecretKey     string
+	Bucket        string
+	Prefix        string
+	UseSSL        bool
+	PartSize      uint64
+	PresignExpiry time.Duration
+}
+
+type S3 struct {
+	client        *minio.Client
+	bucket        string
+	prefix        string
+	partSize      uint64
+	presignExpiry time.Duration
+}
+
+func NewS3(ctx context.Context, cfg S3Config) (*S3, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating S3 client: %w", err)
+	}
+
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("checking bucket %q: %w", cfg.Bucket, err)
+	}
+
+	if !exists {
+		err := client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{Region: cfg.Region})
+		if err != nil {
+			return nil, fmt.Errorf("creating bucket %q: %w", cfg.Bucket, err)
+		}
+	}
+
+	partSize := cfg.PartSize
+	if partSize == 0 {
+		partSize = defaultPartSize
+	}
+
+	presignExpiry := cfg.PresignExpiry
+	if presignExpiry <= 0 {
+		presignExpiry = defaultPresignExpiry
+	}
+
+	return &S3{
+		client:        client,
+		bucket:        cfg.Bucket,
+		prefix:        cfg.Prefix,
+		partSize:      partSize,
+		presignExpiry: presignExpiry,
+	}, nil
+}
+
+// Create streams everything written to the returned writer into a multipart
+// upload. The object only becomes visible once Close returns without error;
+// CloseWithError abandons the upload instead.
+func (s *S3) Create(ctx context.Context, key string) (io.WriteCloser, error) {
+	pr, pw := io.Pipe()
+
+	w := &s3Writer{
+		pw:   pw,
+		done: make(chan error, 1),
+	}
+
+	go func() {
+		_, err := s.client.PutObject(ctx, s.bucket, s.objectName(key), pr, -1, minio.PutObjectOptions{
+			PartSize:    s.partSize,
+			ContentType: "text/plain",
+		})
+		pr.CloseWithError(err)
+		w.done <- err
+	}()
+
+	return w, nil
+}
+
+func (s *S3) URL(ctx context.Context, key string) (string, error) {
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, s.objectName(key), s.presignExpiry, nil)
+	if err != nil {
+		return "", fmt.Errorf("presigning %q: %w", key, err)
+	}
+	return u.String(), nil
+}
+
+func (s *S3) Open(ctx context.Context, key string) (Object, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, s.objectName(key), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("getting %q: %w", key, err)
+	}
+
+	info, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, fmt.Errorf("getting %q: %w", key, ErrNotFound)
+		}
+		return nil, fmt.Errorf("stating %q: %w", key, err)
+	}
+
+	return &s3Object{Object: obj, size: info.Size}, nil
+}
+
+// List returns the keys below prefix in lexical order, relative to the
+// configured bucket prefix.
+func (s *S3) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+
+	root := s.objectName("") + "/"
+	if s.prefix == "" {
+		root = ""
+	}
+
+	for obj := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{
+		Prefix:    root + prefix,
+		Recursive: true,
+	}) {
+		if obj.Err != nil {
+			return nil, fmt.Errorf("listing objects: %w", obj.Err)
+		}
+		keys = append(keys, strings.TrimPrefix(obj.Key, root))
+	}
+
+	return keys, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	err := s.client.RemoveObject(ctx, s.bucket, s.objectName(key), minio.RemoveObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("removing %q: %w", key, err)
+	}
+	return nil
+}
+
+func (s *S3) objectName(key string) string {
+	return path.Join(s.prefix, key)
+}
+
+type s3Object struct {
+	*minio.Object
+	size int64
+}
+
+func (o *s3Object) Size() int64 {
+	return o.size
+}
+
+type s3Writer struct {
+	pw   *io.PipeWriter
+	done chan error
+	once sync.Once
+	err  error
+}
+
+func (w *s3Writer) Write(p []byte) (int, error) {
+	return w.pw.Write(p)
+}
+
+func (w *s3Writer) Close() error {
+	return w.CloseWithError(nil)
+}
+
+// CloseWithError fails the upload with cause, so the object is never
+// completed, unless cause is nil.
+func (w *s3Writer) CloseWithError(cause error) error {
+	w.once.Do(func() {
+		w.pw.CloseWithError(cause)
+		err := <-w.done
+		switch {
+		case cause != nil:
+			w.err = fmt.Errorf("uploading object: %w", cause)
+		case err != nil:
+			w.err = fmt.Errorf("uploading object: %w", err)
+		}
+	})
+	return w.err
+}
+
+var _ Archive = (*S3)(nil)
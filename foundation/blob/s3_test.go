@@ -0,0 +1,229 @@
+package blob
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 is a stand-in for MinIO serving the calls made by S3: bucket
+// checks and multipart uploads.
+type fakeS3 struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	uploads  map[string][][]byte
+	aborted  int
+	failPart bool
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{
+		objects: make(map[string][]byte),
+		uploads: make(map[string][][]byte),
+	}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	query := r.URL.Query()
+
+	switch {
+	case key == "":
+		// Bucket checks and creation.
+		w.WriteHeader(http.StatusOK)
+
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		id := strconv.Itoa(len(f.uploads) + 1)
+		f.uploads[id] = nil
+		fmt.Fprintf(w, `<InitiateMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><UploadId>%s</UploadId></InitiateMultipartUploadResult>`, bucket, key, id)
+
+	case r.Method == http.MethodPut && query.Has("partNumber"):
+		if f.failPart {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<Error><Code>AccessDenied</Code><Message>part rejected</Message></Error>`)
+			return
+		}
+
+		body, err := readBody(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		id := query.Get("uploadId")
+		f.uploads[id] = append(f.uploads[id], body)
+		w.Header().Set("ETag", fmt.Sprintf(`"part-%d"`, len(f.uploads[id])))
+
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		io.Copy(io.Discard, r.Body)
+		id := query.Get("uploadId")
+		f.objects[key] = bytes.Join(f.uploads[id], nil)
+		delete(f.uploads, id)
+		fmt.Fprintf(w, `<CompleteMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><ETag>"object"</ETag></CompleteMultipartUploadResult>`, bucket, key)
+
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		delete(f.uploads, query.Get("uploadId"))
+		f.aborted++
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+// readBody returns the payload of a part, decoding the aws-chunked framing
+// the client uses over plain HTTP.
+func readBody(r *http.Request) ([]byte, error) {
+	if !strings.Contains(r.Header.Get("Content-Encoding"), "aws-chunked") &&
+		!strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var body []byte
+	br := bufio.NewReader(r.Body)
+	for {
+		header, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(header), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return body, nil
+		}
+
+		chunk := make([]byte, size)
+		if _, err := io.ReadFull(br, chunk); err != nil {
+			return nil, err
+		}
+		body = append(body, chunk...)
+
+		if _, err := br.Discard(2); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (f *fakeS3) object(key string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, ok := f.objects[key]
+	return data, ok
+}
+
+func newTestS3(t *testing.T, fake *fakeS3) *S3 {
+	t.Helper()
+
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	s3, err := NewS3(context.Background(), S3Config{
+		Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+		Region:    "us-east-1",
+		AccessKey: "minioadmin",
+		SecretKey: "minioadmin",
+		Bucket:    "exports",
+		Prefix:    "logs",
+		PartSize:  5 << 20,
+	})
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+	return s3
+}
+
+func TestS3WriterCloseCompletesUpload(t *testing.T) {
+	fake := newFakeS3()
+	s3 := newTestS3(t, fake)
+
+	w, err := s3.Create(context.Background(), "export.txt")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := io.WriteString(w, "[2024-01-01T00:00:00Z] [info] hello\n"); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	data, ok := fake.object("logs/export.txt")
+	if !ok {
+		t.Fatal("object was not stored")
+	}
+	if got, want := string(data), "[2024-01-01T00:00:00Z] [info] hello\n"; got != want {
+		t.Fatalf("object = %q, want %q", got, want)
+	}
+
+	u, err := s3.URL(context.Background(), "export.txt")
+	if err != nil {
+		t.Fatalf("URL: %v", err)
+	}
+	if !strings.Contains(u, "/exports/logs/export.txt?") || !strings.Contains(u, "X-Amz-Signature=") {
+		t.Fatalf("URL = %q, want a presigned URL of the object", u)
+	}
+}
+
+func TestS3WriterAbortPublishesNothing(t *testing.T) {
+	fake := newFakeS3()
+	s3 := newTestS3(t, fake)
+
+	w, err := s3.Create(context.Background(), "export.txt")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := io.WriteString(w, "partial line"); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	if err := Abort(w); !errors.Is(err, ErrAborted) {
+		t.Fatalf("Abort = %v, want %v", err, ErrAborted)
+	}
+	if err := w.Close(); !errors.Is(err, ErrAborted) {
+		t.Fatalf("Close after Abort = %v, want %v", err, ErrAborted)
+	}
+
+	if _, ok := fake.object("logs/export.txt"); ok {
+		t.Fatal("aborted object was published")
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if fake.aborted != 1 {
+		t.Fatalf("aborted uploads = %d, want 1", fake.aborted)
+	}
+}
+
+func TestS3WriterCloseReportsFailedUpload(t *testing.T) {
+	fake := newFakeS3()
+	fake.failPart = true
+	s3 := newTestS3(t, fake)
+
+	w, err := s3.Create(context.Background(), "export.txt")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	io.WriteString(w, "line\n")
+
+	if err := w.Close(); err == nil {
+		t.Fatal("Close succeeded although the upload failed")
+	}
+	if _, ok := fake.object("logs/export.txt"); ok {
+		t.Fatal("failed object was published")
+	}
+}
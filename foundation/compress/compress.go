@@ -1,52 +1,81 @@
 package compress
 
 import (
-	"bytes"
-	"compress/gzip"
-	"io"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
 )
 
+var ErrUnknownAlgorithm = errors.New("unknown compression algorithm")
+
+type Algorithm string
+
+const (
+	None   Algorithm = "none"
+	Gzip   Algorithm = "gzip"
+	Zstd   Algorithm = "zstd"
+	Snappy Algorithm = "snappy"
+	LZ4    Algorithm = "lz4"
+)
+
+// DefaultLevel selects the default level of the chosen algorithm. It is no
+// level of any algorithm, so level 0 keeps its own meaning, such as no
+// compression for gzip.
+const DefaultLevel = math.MinInt32
+
 type Compressor interface {
 	Compress(data []byte) ([]byte, error)
 	Decompress(data []byte) ([]byte, error)
+	Algorithm() Algorithm
 }
 
-type GzipCompressor struct {
-	level int
-}
-
-func NewGzipCompressor() *GzipCompressor {
-	return &GzipCompressor{
-		level: gzip.BestCompression,
+func (a Algorithm) IsValid() bool {
+	switch a {
+	case None, Gzip, Zstd, ZstdDict, Snappy, LZ4:
+		return true
 	}
+	return false
 }
 
-func (c *GzipCompressor) Compress(data []byte) ([]byte, error) {
-	var buf bytes.Buffer
-
-	writer, err := gzip.NewWriterLevel(&buf, c.level)
-	if err != nil {
-		return nil, err
+// New returns a Compressor for the algorithm at the given level. Levels follow
+// the conventions of each algorithm; snappy has no levels and ignores it.
+func New(alg Algorithm, level int) (Compressor, error) {
+	switch alg {
+	case Gzip:
+		return NewGzipCompressor(level)
+	case Zstd:
+		return NewZstdCompressor(level)
+	case Snappy:
+		return NewSnappyCompressor(), nil
+	case LZ4:
+		return NewLZ4Compressor(level)
+	case ZstdDict:
+		return nil, ErrDictionaryRequired
 	}
-
-	_, err = writer.Write(data)
-	if err != nil {
-		return nil, err
-	}
-
-	if err := writer.Close(); err != nil {
-		return nil, err
-	}
-
-	return buf.Bytes(), nil
+	return nil, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, alg)
 }
 
-func (c *GzipCompressor) Decompress(data []byte) ([]byte, error) {
-	reader, err := gzip.NewReader(bytes.NewReader(data))
-	if err != nil {
-		return nil, err
+var (
+	decodersMu sync.Mutex
+	decoders   = map[Algorithm]Compressor{}
+)
+
+// Decompress decodes data produced by any supported algorithm, so records
+// written with different settings can be read back by the same process.
+func Decompress(alg Algorithm, data []byte) ([]byte, error) {
+	decodersMu.Lock()
+	c, ok := decoders[alg]
+	if !ok {
+		var err error
+		c, err = New(alg, DefaultLevel)
+		if err != nil {
+			decodersMu.Unlock()
+			return nil, err
+		}
+		decoders[alg] = c
 	}
-	defer reader.Close()
+	decodersMu.Unlock()
 
-	return io.ReadAll(reader)
+	return c.Decompress(data)
 }
@@ -0,0 +1,119 @@
+package compress
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var testData = []byte(strings.Repeat("user alice logged in from the api service; ", 50))
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		alg    Algorithm
+		levels []int
+	}{
+		{alg: Gzip, levels: []int{DefaultLevel, 0, 1, 9}},
+		{alg: Zstd, levels: []int{DefaultLevel, 1, 3, 19}},
+		{alg: Snappy, levels: []int{DefaultLevel}},
+		{alg: LZ4, levels: []int{DefaultLevel, 0, 1, 9}},
+	}
+
+	for _, tt := range tests {
+		for _, level := range tt.levels {
+			name := fmt.Sprintf("%s/%d", tt.alg, level)
+			if level == DefaultLevel {
+				name = fmt.Sprintf("%s/default", tt.alg)
+			}
+
+			t.Run(name, func(t *testing.T) {
+				c, err := New(tt.alg, level)
+				if err != nil {
+					t.Fatalf("New: %v", err)
+				}
+				if c.Algorithm() != tt.alg {
+					t.Errorf("algorithm = %s, want %s", c.Algorithm(), tt.alg)
+				}
+
+				compressed, err := c.Compress(testData)
+				if err != nil {
+					t.Fatalf("Compress: %v", err)
+				}
+
+				got, err := c.Decompress(compressed)
+				if err != nil {
+					t.Fatalf("Decompress: %v", err)
+				}
+				if !bytes.Equal(got, testData) {
+					t.Fatalf("Decompress returned %d bytes, want the %d compressed", len(got), len(testData))
+				}
+
+				// Records are read back by algorithm alone, whatever level
+				// they were written at.
+				got, err = Decompress(tt.alg, compressed)
+				if err != nil {
+					t.Fatalf("Decompress by algorithm: %v", err)
+				}
+				if !bytes.Equal(got, testData) {
+					t.Fatalf("Decompress by algorithm returned %d bytes, want %d", len(got), len(testData))
+				}
+			})
+		}
+	}
+}
+
+// The default level compresses, while gzip level 0 stores the data as is.
+func TestGzipDefaultLevel(t *testing.T) {
+	def, err := NewGzipCompressor(DefaultLevel)
+	if err != nil {
+		t.Fatalf("NewGzipCompressor: %v", err)
+	}
+	compressed, err := def.Compress(testData)
+	if err != nil {
+		t.Fatalf("Compress: %v", err)
+	}
+	if len(compressed) >= len(testData)/2 {
+		t.Errorf("default level compressed %d bytes to %d", len(testData), len(compressed))
+	}
+
+	none, err := NewGzipCompressor(0)
+	if err != nil {
+		t.Fatalf("NewGzipCompressor: %v", err)
+	}
+	stored, err := none.Compress(testData)
+	if err != nil {
+		t.Fatalf("Compress: %v", err)
+	}
+	if len(stored) < len(testData) {
+		t.Errorf("level 0 compressed %d bytes to %d, want them stored", len(testData), len(stored))
+	}
+}
+
+func TestInvalidLevel(t *testing.T) {
+	tests := []struct {
+		alg   Algorithm
+		level int
+	}{
+		{alg: Gzip, level: 10},
+		{alg: Gzip, level: -3},
+		{alg: LZ4, level: 10},
+		{alg: LZ4, level: -1},
+	}
+
+	for _, tt := range tests {
+		if _, err := New(tt.alg, tt.level); err == nil {
+			t.Errorf("New(%s, %d) succeeded, want an error", tt.alg, tt.level)
+		}
+	}
+}
+
+func TestUnknownAlgorithm(t *testing.T) {
+	if _, err := New("brotli", DefaultLevel); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("New = %v, want %v", err, ErrUnknownAlgorithm)
+	}
+	if _, err := Decompress("brotli", testData); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("Decompress = %v, want %v", err, ErrUnknownAlgorithm)
+	}
+}
@@ -0,0 +1,124 @@
+package compress
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ZstdDict marks data compressed with a trained zstd dictionary. The dictionary
+// ID travels in the zstd frame header, so decoding needs a ZstdDictDecoder that
+// knows every dictionary that may have been used.
+const ZstdDict Algorithm = "zstd-dict"
+
+var (
+	ErrDictionaryRequired = errors.New("compression algorithm requires a dictionary")
+	ErrNotEnoughSamples   = errors.New("not enough samples to train a dictionary")
+	ErrTrainingFailed     = errors.New("samples cannot train a dictionary")
+)
+
+const minDictionarySamples = 8
+
+func TrainZstdDictionary(id uint32, samples [][]byte, maxSize int) (dict []byte, err error) {
+	// BuildDict panics on samples the history leaves no literals in, such
+	// as a service logging the same message over and over.
+	defer func() {
+		if r := recover(); r != nil {
+			dict, err = nil, fmt.Errorf("%w: %v", ErrTrainingFailed, r)
+		}
+	}()
+
+	if len(samples) < minDictionarySamples {
+		return nil, fmt.Errorf("%w: got %d, need %d", ErrNotEnoughSamples, len(samples), minDictionarySamples)
+	}
+
+	var history []byte
+	for i := len(samples) - 1; i >= 0 && len(history) < maxSize; i-- {
+		history = append(history, samples[i]...)
+	}
+	if len(history) > maxSize {
+		history = history[:maxSize]
+	}
+
+	dict, err = zstd.BuildDict(zstd.BuildDictOptions{
+		ID:       id,
+		Contents: samples,
+		History:  history,
+		Offsets:  [3]int{1, 4, 8},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("building dictionary: %w", err)
+	}
+
+	return dict, nil
+}
+
+type ZstdDictCompressor struct {
+	id      uint32
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+func NewZstdDictCompressor(dict []byte, level int) (*ZstdDictCompressor, error) {
+	info, err := zstd.InspectDictionary(dict)
+	if err != nil {
+		return nil, fmt.Errorf("inspecting dictionary: %w", err)
+	}
+
+	encoderLevel := zstd.SpeedDefault
+	if level != DefaultLevel {
+		encoderLevel = zstd.EncoderLevelFromZstd(level)
+	}
+
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(encoderLevel), zstd.WithEncoderDict(dict))
+	if err != nil {
+		return nil, err
+	}
+
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderDicts(dict))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ZstdDictCompressor{
+		id:      info.ID(),
+		encoder: encoder,
+		decoder: decoder,
+	}, nil
+}
+
+func (c *ZstdDictCompressor) Compress(data []byte) ([]byte, error) {
+	return c.encoder.EncodeAll(data, nil), nil
+}
+
+func (c *ZstdDictCompressor) Decompress(data []byte) ([]byte, error) {
+	return c.decoder.DecodeAll(data, nil)
+}
+
+func (c *ZstdDictCompressor) Algorithm() Algorithm {
+	return ZstdDict
+}
+
+func (c *ZstdDictCompressor) ID() uint32 {
+	return c.id
+}
+
+type ZstdDictDecoder struct {
+	decoder *zstd.Decoder
+}
+
+func NewZstdDictDecoder(dicts ...[]byte) (*ZstdDictDecoder, error) {
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderDicts(dicts...))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ZstdDictDecoder{
+		decoder: decoder,
+	}, nil
+}
+
+func (d *ZstdDictDecoder) Decompress(data []byte) ([]byte, error) {
+	return d.decoder.DecodeAll(data, nil)
+}
@@ -0,0 +1,89 @@
+package compress
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// testSamples returns short messages alike enough to share a dictionary
+// but varied enough to train one.
+func testSamples(n int) [][]byte {
+	users := []string{"alice", "bob", "carol", "dave", "erin", "frank"}
+	actions := []string{"logged in", "logged out", "updated the profile", "paid invoice", "reset the password"}
+	services := []string{"api", "billing", "auth", "search"}
+
+	samples := make([][]byte, n)
+	for i := range samples {
+		samples[i] = []byte(fmt.Sprintf("user %s %s from the %s service in %dms (request %x)",
+			users[i%len(users)], actions[i*7%len(actions)], services[i*3%len(services)], i*37%1000, i*2654435761))
+	}
+	return samples
+}
+
+func TestZstdDictRoundTrip(t *testing.T) {
+	dict, err := TrainZstdDictionary(7, testSamples(256), 4096)
+	if err != nil {
+		t.Fatalf("TrainZstdDictionary: %v", err)
+	}
+
+	c, err := NewZstdDictCompressor(dict, DefaultLevel)
+	if err != nil {
+		t.Fatalf("NewZstdDictCompressor: %v", err)
+	}
+	if c.ID() != 7 || c.Algorithm() != ZstdDict {
+		t.Errorf("compressor = %s dictionary %d, want %s dictionary 7", c.Algorithm(), c.ID(), ZstdDict)
+	}
+
+	message := []byte("user alice paid invoice from the billing service in 12ms (request 9f3a)")
+	compressed, err := c.Compress(message)
+	if err != nil {
+		t.Fatalf("Compress: %v", err)
+	}
+
+	// Another dictionary known to the decoder does not get in the way.
+	other, err := TrainZstdDictionary(8, testSamples(128), 2048)
+	if err != nil {
+		t.Fatalf("TrainZstdDictionary: %v", err)
+	}
+	d, err := NewZstdDictDecoder(other, dict)
+	if err != nil {
+		t.Fatalf("NewZstdDictDecoder: %v", err)
+	}
+	got, err := d.Decompress(compressed)
+	if err != nil {
+		t.Fatalf("Decompress: %v", err)
+	}
+	if !bytes.Equal(got, message) {
+		t.Errorf("Decompress = %q, want %q", got, message)
+	}
+}
+
+func TestTrainZstdDictionaryFails(t *testing.T) {
+	repeated := make([][]byte, 64)
+	for i := range repeated {
+		repeated[i] = []byte("user logged in from the api service")
+	}
+
+	tests := []struct {
+		name    string
+		samples [][]byte
+		want    error
+	}{
+		{name: "too few samples", samples: testSamples(minDictionarySamples - 1), want: ErrNotEnoughSamples},
+		{name: "one message repeated", samples: repeated, want: ErrTrainingFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := TrainZstdDictionary(1, tt.samples, 4096); !errors.Is(err, tt.want) {
+				t.Fatalf("TrainZstdDictionary = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := New(ZstdDict, DefaultLevel); !errors.Is(err, ErrDictionaryRequired) {
+		t.Errorf("New(%s) = %v, want %v", ZstdDict, err, ErrDictionaryRequired)
+	}
+}
@@ -0,0 +1,59 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+type GzipCompressor struct {
+	level int
+}
+
+func NewGzipCompressor(level int) (*GzipCompressor, error) {
+	if level == DefaultLevel {
+		level = gzip.DefaultCompression
+	}
+
+	if _, err := gzip.NewWriterLevel(io.Discard, level); err != nil {
+		return nil, err
+	}
+
+	return &GzipCompressor{
+		level: level,
+	}, nil
+}
+
+func (c *GzipCompressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	writer, err := gzip.NewWriterLevel(&buf, c.level)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = writer.Write(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (c *GzipCompressor) Decompress(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+func (c *GzipCompressor) Algorithm() Algorithm {
+	return Gzip
+}
@@ -0,0 +1,60 @@
+package compress
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/pierrec/lz4/v4"
+)
+
+type LZ4Compressor struct {
+	level lz4.CompressionLevel
+}
+
+// NewLZ4Compressor returns an LZ4 compressor. Level 0, the default, is the
+// fast mode; levels 1 to 9 compress harder.
+func NewLZ4Compressor(level int) (*LZ4Compressor, error) {
+	if level == DefaultLevel {
+		level = 0
+	}
+	if level < 0 || level > 9 {
+		return nil, fmt.Errorf("lz4: invalid compression level %d", level)
+	}
+
+	c := LZ4Compressor{
+		level: lz4.Fast,
+	}
+	if level > 0 {
+		c.level = lz4.CompressionLevel(1 << (8 + level))
+	}
+
+	return &c, nil
+}
+
+func (c *LZ4Compressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	writer := lz4.NewWriter(&buf)
+	if err := writer.Apply(lz4.CompressionLevelOption(c.level)); err != nil {
+		return nil, err
+	}
+
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (c *LZ4Compressor) Decompress(data []byte) ([]byte, error) {
+	return io.ReadAll(lz4.NewReader(bytes.NewReader(data)))
+}
+
+func (c *LZ4Compressor) Algorithm() Algorithm {
+	return LZ4
+}
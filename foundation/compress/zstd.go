@@ -0,0 +1,44 @@
+package compress
+
+import (
+	"github.com/klauspost/compress/zstd"
+)
+
+type ZstdCompressor struct {
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+func NewZstdCompressor(level int) (*ZstdCompressor, error) {
+	encoderLevel := zstd.SpeedDefault
+	if level != DefaultLevel {
+		encoderLevel = zstd.EncoderLevelFromZstd(level)
+	}
+
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(encoderLevel))
+	if err != nil {
+		return nil, err
+	}
+
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ZstdCompressor{
+		encoder: encoder,
+		decoder: decoder,
+	}, nil
+}
+
+func (c *ZstdCompressor) Compress(data []byte) ([]byte, error) {
+	return c.encoder.EncodeAll(data, nil), nil
+}
+
+func (c *ZstdCompressor) Decompress(data []byte) ([]byte, error) {
+	return c.decoder.DecodeAll(data, nil)
+}
+
+func (c *ZstdCompressor) Algorithm() Algorithm {
+	return Zstd
+}
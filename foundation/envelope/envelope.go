@@ -0,0 +1,236 @@
+// Package envelope implements envelope encryption. Data is sealed with
+// AES-256-GCM under data keys, and data keys are stored wrapped by a master
+// key that never leaves the keyfile, so rotating data keys only needs the
+// master key and rotating the master key only needs the data keys rewrapped.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+var (
+	ErrUnknownKey   = errors.New("unknown data key")
+	ErrNoCurrentKey = errors.New("no current data key")
+	ErrDecrypt      = errors.New("decryption failed")
+)
+
+const keySize = 32
+
+// DataKey is a data key as it is stored: wrapped by the master key.
+type DataKey struct {
+	ID        string
+	Wrapped   []byte
+	CreatedAt time.Time
+}
+
+// LoadMasterKey reads the master key from the keyfile at path, holding the
+// 32 byte key in base64 or hex.
+func LoadMasterKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading keyfile: %w", err)
+	}
+
+	text := strings.TrimSpace(string(data))
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == keySize {
+		return key, nil
+	}
+	if key, err := hex.DecodeString(text); err == nil && len(key) == keySize {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("keyfile must hold a %d byte key in base64 or hex", keySize)
+}
+
+// Keyring holds the unwrapped data keys and the one new data is sealed with.
+// It is safe for concurrent use.
+type Keyring struct {
+	master   cipher.AEAD
+	indexKey []byte
+
+	mu      sync.RWMutex
+	keys    map[string]cipher.AEAD
+	current DataKey
+}
+
+// NewKeyring returns an empty keyring wrapping data keys with master.
+func NewKeyring(master []byte) (*Keyring, error) {
+	aead, err := newAEAD(master)
+	if err != nil {
+		return nil, fmt.Errorf("master key: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, master)
+	mac.Write([]byte("log-horizon index key"))
+
+	return &Keyring{
+		master:   aead,
+		indexKey: mac.Sum(nil),
+		keys:     make(map[string]cipher.AEAD),
+	}, nil
+}
+
+// NewDataKey generates a data key, adds it to the keyring and makes it
+// current. The caller stores the returned key.
+func (k *Keyring) NewDataKey() (DataKey, error) {
+	raw := make([]byte, keySize)
+	if _, err := rand.Read(raw); err != nil {
+		return DataKey{}, fmt.Errorf("generating data key: %w", err)
+	}
+
+	dk := DataKey{
+		ID:        ulid.Make().String(),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	wrapped, err := seal(k.master, raw, []byte(dk.ID))
+	if err != nil {
+		return DataKey{}, err
+	}
+	dk.Wrapped = wrapped
+
+	aead, err := newAEAD(raw)
+	if err != nil {
+		return DataKey{}, err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.keys[dk.ID] = aead
+	k.current = dk
+
+	return dk, nil
+}
+
+// Add unwraps a stored data key into the keyring. The newest key added
+// becomes current.
+func (k *Keyring) Add(dk DataKey) error {
+	raw, err := open(k.master, dk.Wrapped, []byte(dk.ID))
+	if err != nil {
+		return fmt.Errorf("unwrapping data key %s, is the master key right?: %w", dk.ID, err)
+	}
+
+	aead, err := newAEAD(raw)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.keys[dk.ID] = aead
+	if k.current.ID == "" || dk.CreatedAt.After(k.current.CreatedAt) {
+		k.current = dk
+	}
+
+	return nil
+}
+
+// Current returns the data key new data is sealed with.
+func (k *Keyring) Current() (DataKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.current, k.current.ID != ""
+}
+
+// Seal encrypts plaintext with the current data key, binding it to aad, and
+// returns the ID of the key used.
+func (k *Keyring) Seal(plaintext, aad []byte) (string, []byte, error) {
+	k.mu.RLock()
+	id := k.current.ID
+	k.mu.RUnlock()
+
+	if id == "" {
+		return "", nil, ErrNoCurrentKey
+	}
+
+	sealed, err := k.SealWith(id, plaintext, aad)
+	if err != nil {
+		return "", nil, err
+	}
+	return id, sealed, nil
+}
+
+// SealWith encrypts plaintext with the data key id, for data that must be
+// sealed with the same key as data sealed before it.
+func (k *Keyring) SealWith(id string, plaintext, aad []byte) ([]byte, error) {
+	k.mu.RLock()
+	aead := k.keys[id]
+	k.mu.RUnlock()
+
+	if aead == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+	return seal(aead, plaintext, aad)
+}
+
+// Open decrypts data sealed with the data key id and aad.
+func (k *Keyring) Open(id string, sealed, aad []byte) ([]byte, error) {
+	k.mu.RLock()
+	aead := k.keys[id]
+	k.mu.RUnlock()
+
+	if aead == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+	return open(aead, sealed, aad)
+}
+
+// Index returns a keyed hash of value, for finding sealed values by
+// equality without decrypting them. It depends on the master key only, so
+// it survives data key rotation.
+func (k *Keyring) Index(value string) string {
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", keySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal returns the nonce followed by the ciphertext.
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(aead cipher.AEAD, sealed, aad []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,229 @@
+package envelope
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newMasterKey(t *testing.T) []byte {
+	t.Helper()
+
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("generating master key: %v", err)
+	}
+	return key
+}
+
+func newTestKeyring(t *testing.T, master []byte) (*Keyring, DataKey) {
+	t.Helper()
+
+	k, err := NewKeyring(master)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	dk, err := k.NewDataKey()
+	if err != nil {
+		t.Fatalf("NewDataKey: %v", err)
+	}
+	return k, dk
+}
+
+func TestSealOpen(t *testing.T) {
+	k, dk := newTestKeyring(t, newMasterKey(t))
+
+	plaintext := []byte("user logged in")
+	id, sealed, err := k.Seal(plaintext, []byte("log-1"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if id != dk.ID {
+		t.Errorf("sealed with key %s, want %s", id, dk.ID)
+	}
+	if bytes.Contains(sealed, plaintext) {
+		t.Errorf("sealed data holds the plaintext")
+	}
+
+	opened, err := k.Open(id, sealed, []byte("log-1"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if !bytes.Equal(opened, plaintext) {
+		t.Errorf("opened = %q, want %q", opened, plaintext)
+	}
+}
+
+func TestOpenTampered(t *testing.T) {
+	k, _ := newTestKeyring(t, newMasterKey(t))
+
+	id, sealed, err := k.Seal([]byte("user logged in"), []byte("log-1"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	flipped := bytes.Clone(sealed)
+	flipped[len(flipped)-1] ^= 1
+
+	tests := []struct {
+		name   string
+		id     string
+		sealed []byte
+		aad    []byte
+		want   error
+	}{
+		{name: "other log", id: id, sealed: sealed, aad: []byte("log-2"), want: ErrDecrypt},
+		{name: "flipped bit", id: id, sealed: flipped, aad: []byte("log-1"), want: ErrDecrypt},
+		{name: "truncated", id: id, sealed: sealed[:4], aad: []byte("log-1"), want: ErrDecrypt},
+		{name: "unknown key", id: "missing", sealed: sealed, aad: []byte("log-1"), want: ErrUnknownKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := k.Open(tt.id, tt.sealed, tt.aad); !errors.Is(err, tt.want) {
+				t.Fatalf("Open = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSealWithoutKey(t *testing.T) {
+	k, err := NewKeyring(newMasterKey(t))
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+
+	if _, _, err := k.Seal([]byte("x"), nil); !errors.Is(err, ErrNoCurrentKey) {
+		t.Fatalf("Seal = %v, want %v", err, ErrNoCurrentKey)
+	}
+}
+
+// A keyring rebuilt from the stored data keys opens what the old one
+// sealed, and rotating keeps older keys readable.
+func TestRotation(t *testing.T) {
+	master := newMasterKey(t)
+	k, first := newTestKeyring(t, master)
+
+	_, sealed, err := k.Seal([]byte("before rotation"), []byte("log-1"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	second, err := k.NewDataKey()
+	if err != nil {
+		t.Fatalf("NewDataKey: %v", err)
+	}
+	if current, _ := k.Current(); current.ID != second.ID {
+		t.Errorf("current key = %s, want %s", current.ID, second.ID)
+	}
+
+	reloaded, err := NewKeyring(master)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	for _, dk := range []DataKey{second, first} {
+		if err := reloaded.Add(dk); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	// The newest key is current whatever the order keys are added in.
+	if current, _ := reloaded.Current(); current.ID != second.ID {
+		t.Errorf("current key after reload = %s, want %s", current.ID, second.ID)
+	}
+
+	opened, err := reloaded.Open(first.ID, sealed, []byte("log-1"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if string(opened) != "before rotation" {
+		t.Errorf("opened = %q, want %q", opened, "before rotation")
+	}
+}
+
+func TestWrongMasterKey(t *testing.T) {
+	_, dk := newTestKeyring(t, newMasterKey(t))
+
+	other, err := NewKeyring(newMasterKey(t))
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	if err := other.Add(dk); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("Add = %v, want %v", err, ErrDecrypt)
+	}
+}
+
+// Index depends on the master key only, so filters keep matching values
+// indexed before a data key rotation.
+func TestIndex(t *testing.T) {
+	master := newMasterKey(t)
+	k, _ := newTestKeyring(t, master)
+
+	before := k.Index("api")
+	if before == "api" || before != k.Index("api") {
+		t.Fatalf("Index(api) = %s, want a stable keyed hash", before)
+	}
+	if k.Index("billing") == before {
+		t.Errorf("different values share an index")
+	}
+
+	if _, err := k.NewDataKey(); err != nil {
+		t.Fatalf("NewDataKey: %v", err)
+	}
+	if got := k.Index("api"); got != before {
+		t.Errorf("index after rotation = %s, want %s", got, before)
+	}
+
+	same, _ := newTestKeyring(t, master)
+	if got := same.Index("api"); got != before {
+		t.Errorf("index with the same master key = %s, want %s", got, before)
+	}
+
+	other, _ := newTestKeyring(t, newMasterKey(t))
+	if other.Index("api") == before {
+		t.Errorf("index with another master key matches")
+	}
+}
+
+func TestLoadMasterKey(t *testing.T) {
+	key := newMasterKey(t)
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "base64", content: base64.StdEncoding.EncodeToString(key) + "\n"},
+		{name: "hex", content: hex.EncodeToString(key)},
+		{name: "short", content: hex.EncodeToString(key[:16]), wantErr: true},
+		{name: "garbage", content: "not a key", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "keyfile")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+
+			got, err := LoadMasterKey(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("LoadMasterKey = %x, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadMasterKey: %v", err)
+			}
+			if !bytes.Equal(got, key) {
+				t.Errorf("key = %x, want %x", got, key)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// Package ratelimit provides a token bucket for rate limiting.
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// Bucket holds up to burst tokens and gains rate tokens per second. It is
+// not safe for concurrent use.
+type Bucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewBucket returns a full bucket.
+func NewBucket(rate, burst float64, now time.Time) *Bucket {
+	if burst < 1 {
+		burst = math.Max(rate, 1)
+	}
+	return &Bucket{
+		rate:   rate,
+		burst:  burst,
+		tokens: burst,
+		last:   now,
+	}
+}
+
+// Delay returns how long until n tokens are available, zero when they are
+// now. Requests larger than the bucket wait until it is full.
+func (b *Bucket) Delay(n float64, now time.Time) time.Duration {
+	b.refill(now)
+
+	n = math.Min(n, b.burst)
+	if b.tokens >= n {
+		return 0
+	}
+	if b.rate <= 0 {
+		return math.MaxInt64
+	}
+	return time.Duration((n - b.tokens) / b.rate * float64(time.Second))
+}
+
+// Take removes n tokens, capped at the size of the bucket. Callers check
+// Delay first.
+func (b *Bucket) Take(n float64, now time.Time) {
+	b.refill(now)
+	b.tokens -= math.Min(n, b.burst)
+}
+
+// Tokens returns the tokens available at now.
+func (b *Bucket) Tokens(now time.Time) float64 {
+	b.refill(now)
+	return b.tokens
+}
+
+// Full reports whether the bucket holds all the tokens it can at now, so it
+// behaves as a new one would.
+func (b *Bucket) Full(now time.Time) bool {
+	return b.Tokens(now) >= b.burst
+}
+
+func (b *Bucket) refill(now time.Time) {
+	if now.After(b.last) {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+	}
+}
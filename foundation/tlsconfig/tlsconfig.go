@@ -0,0 +1,174 @@
+// Package tlsconfig builds server TLS configurations whose certificate and
+// client CA bundle are reloaded when their files change, so certificates can
+// be renewed without a restart.
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// ClientAuth selects how client certificates are checked.
+type ClientAuth string
+
+const (
+	// ClientAuthNone ignores client certificates.
+	ClientAuthNone ClientAuth = "none"
+	// ClientAuthRequest verifies client certificates when clients send one.
+	ClientAuthRequest ClientAuth = "request"
+	// ClientAuthRequire rejects clients without a certificate signed by the
+	// client CA.
+	ClientAuthRequire ClientAuth = "require"
+)
+
+type Config struct {
+	CertFile string
+	KeyFile  string
+
+	// ClientCAFile holds the PEM bundle client certificates are verified
+	// against. It is needed unless ClientAuth is none.
+	ClientCAFile string
+	// ClientAuth defaults to require when ClientCAFile is set, none
+	// otherwise.
+	ClientAuth ClientAuth
+}
+
+// Reloader serves the certificate and client CAs last loaded from the files
+// of its Config.
+type Reloader struct {
+	cfg Config
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  map[string]time.Time
+}
+
+// New loads the files of cfg.
+func New(cfg Config) (*Reloader, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("certificate and key files are required")
+	}
+
+	if cfg.ClientAuth == "" {
+		cfg.ClientAuth = ClientAuthNone
+		if cfg.ClientCAFile != "" {
+			cfg.ClientAuth = ClientAuthRequire
+		}
+	}
+
+	switch cfg.ClientAuth {
+	case ClientAuthNone:
+	case ClientAuthRequest, ClientAuthRequire:
+		if cfg.ClientCAFile == "" {
+			return nil, fmt.Errorf("client auth %q needs a client CA file", cfg.ClientAuth)
+		}
+	default:
+		return nil, fmt.Errorf("unknown client auth %q", cfg.ClientAuth)
+	}
+
+	r := Reloader{cfg: cfg}
+	if _, err := r.Reload(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// TLSConfig returns a configuration that picks up every reload.
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*r.cert},
+				ClientCAs:    r.clientCAs,
+				ClientAuth:   r.clientAuthType(),
+			}, nil
+		},
+	}
+}
+
+func (r *Reloader) clientAuthType() tls.ClientAuthType {
+	switch r.cfg.ClientAuth {
+	case ClientAuthRequest:
+		return tls.VerifyClientCertIfGiven
+	case ClientAuthRequire:
+		return tls.RequireAndVerifyClientCert
+	default:
+		return tls.NoClientCert
+	}
+}
+
+// Reload loads the files again when any of them changed since the last
+// load, and reports whether it did. On failure the previous certificate and
+// CAs stay in use.
+func (r *Reloader) Reload(ctx context.Context) (bool, error) {
+	modTimes, err := r.stat()
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	changed := r.modTimes == nil
+	for name, t := range modTimes {
+		if !r.modTimes[name].Equal(t) {
+			changed = true
+		}
+	}
+	r.mu.RUnlock()
+
+	if !changed {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return false, fmt.Errorf("loading certificate: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return false, fmt.Errorf("reading client CAs: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return false, fmt.Errorf("no certificates found in %s", r.cfg.ClientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.modTimes = modTimes
+	r.mu.Unlock()
+
+	return true, nil
+}
+
+func (r *Reloader) stat() (map[string]time.Time, error) {
+	modTimes := make(map[string]time.Time)
+	for _, name := range []string{r.cfg.CertFile, r.cfg.KeyFile, r.cfg.ClientCAFile} {
+		if name == "" {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return nil, fmt.Errorf("checking %s: %w", name, err)
+		}
+		modTimes[name] = info.ModTime()
+	}
+	return modTimes, nil
+}
@@ -0,0 +1,264 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCert writes a self-signed certificate for name and its key to the
+// files of cfg, dated mod so reloads see the change.
+func writeCert(t *testing.T, cfg Config, name string, mod time.Time) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey: %v", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	writeFile(t, cfg.CertFile, certPEM, mod)
+	writeFile(t, cfg.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), mod)
+	if cfg.ClientCAFile != "" {
+		writeFile(t, cfg.ClientCAFile, certPEM, mod)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate: %v", err)
+	}
+	return cert
+}
+
+func writeFile(t *testing.T, name string, data []byte, mod time.Time) {
+	t.Helper()
+
+	if err := os.WriteFile(name, data, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := os.Chtimes(name, mod, mod); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+}
+
+func testConfig(t *testing.T) Config {
+	dir := t.TempDir()
+	return Config{
+		CertFile: filepath.Join(dir, "server.crt"),
+		KeyFile:  filepath.Join(dir, "server.key"),
+	}
+}
+
+// served returns the common name of the certificate r serves.
+func served(t *testing.T, r *Reloader) string {
+	t.Helper()
+
+	cfg, err := r.TLSConfig().GetConfigForClient(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatalf("GetConfigForClient: %v", err)
+	}
+	cert, err := x509.ParseCertificate(cfg.Certificates[0].Certificate[0])
+	if err != nil {
+		t.Fatalf("ParseCertificate: %v", err)
+	}
+	return cert.Subject.CommonName
+}
+
+func TestReload(t *testing.T) {
+	cfg := testConfig(t)
+	start := time.Now().Add(-time.Hour)
+	writeCert(t, cfg, "first", start)
+
+	r, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	tlsConfig := r.TLSConfig()
+	if name := served(t, r); name != "first" {
+		t.Fatalf("serving %q, want first", name)
+	}
+
+	ctx := context.Background()
+	if reloaded, err := r.Reload(ctx); err != nil || reloaded {
+		t.Fatalf("Reload of unchanged files = %v, %v; want false, nil", reloaded, err)
+	}
+
+	writeCert(t, cfg, "second", start.Add(time.Minute))
+	if reloaded, err := r.Reload(ctx); err != nil || !reloaded {
+		t.Fatalf("Reload of renewed files = %v, %v; want true, nil", reloaded, err)
+	}
+
+	// Configurations handed out before the reload serve the new
+	// certificate too.
+	got, err := tlsConfig.GetConfigForClient(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatalf("GetConfigForClient: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(got.Certificates[0].Certificate[0])
+	if err != nil {
+		t.Fatalf("ParseCertificate: %v", err)
+	}
+	if leaf.Subject.CommonName != "second" {
+		t.Errorf("serving %q, want second", leaf.Subject.CommonName)
+	}
+}
+
+// A renewal caught half written keeps the previous certificate in use.
+func TestReloadFailureKeepsCertificate(t *testing.T) {
+	cfg := testConfig(t)
+	start := time.Now().Add(-time.Hour)
+	writeCert(t, cfg, "first", start)
+
+	r, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	writeFile(t, cfg.KeyFile, []byte("not a key"), start.Add(time.Minute))
+	if reloaded, err := r.Reload(context.Background()); err == nil || reloaded {
+		t.Fatalf("Reload of a broken key = %v, %v; want false and an error", reloaded, err)
+	}
+	if name := served(t, r); name != "first" {
+		t.Errorf("serving %q, want first", name)
+	}
+
+	// Once the files are whole again they are picked up.
+	writeCert(t, cfg, "second", start.Add(2*time.Minute))
+	if reloaded, err := r.Reload(context.Background()); err != nil || !reloaded {
+		t.Fatalf("Reload = %v, %v; want true, nil", reloaded, err)
+	}
+	if name := served(t, r); name != "second" {
+		t.Errorf("serving %q, want second", name)
+	}
+}
+
+// A client certificate signed by a CA added in a reload is accepted without
+// a restart.
+func TestReloadClientCAs(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.ClientCAFile = filepath.Join(filepath.Dir(cfg.CertFile), "clients.pem")
+	start := time.Now().Add(-time.Hour)
+	writeCert(t, cfg, "first", start)
+
+	r, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	// The client presents a certificate that is also the new CA bundle.
+	clientCfg := Config{
+		CertFile: filepath.Join(t.TempDir(), "client.crt"),
+		KeyFile:  filepath.Join(t.TempDir(), "client.key"),
+	}
+	clientCert := writeCert(t, clientCfg, "client", start)
+	pair, err := tls.LoadX509KeyPair(clientCfg.CertFile, clientCfg.KeyFile)
+	if err != nil {
+		t.Fatalf("LoadX509KeyPair: %v", err)
+	}
+
+	if err := handshake(r, pair); err == nil {
+		t.Fatalf("handshake with an unknown client CA succeeded")
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: clientCert.Raw})
+	writeFile(t, cfg.ClientCAFile, certPEM, start.Add(time.Minute))
+	if reloaded, err := r.Reload(context.Background()); err != nil || !reloaded {
+		t.Fatalf("Reload = %v, %v; want true, nil", reloaded, err)
+	}
+
+	if err := handshake(r, pair); err != nil {
+		t.Fatalf("handshake after the reload: %v", err)
+	}
+}
+
+// handshake runs a TLS handshake against r with the client certificate.
+func handshake(r *Reloader, cert tls.Certificate) error {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	errs := make(chan error, 1)
+	go func() {
+		conn := tls.Server(server, r.TLSConfig())
+		err := conn.Handshake()
+		// Wake up the client when the server refuses it.
+		server.Close()
+		errs <- err
+	}()
+
+	conn := tls.Client(client, &tls.Config{
+		Certificates:       []tls.Certificate{cert},
+		InsecureSkipVerify: true,
+	})
+	conn.Handshake()
+
+	// In TLS 1.3 the client learns of a refused certificate on its first
+	// read.
+	conn.Read(make([]byte, 1))
+
+	return <-errs
+}
+
+func TestNewValidates(t *testing.T) {
+	cfg := testConfig(t)
+	writeCert(t, cfg, "server", time.Now())
+
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{name: "no key", cfg: Config{CertFile: cfg.CertFile}},
+		{name: "client auth without CAs", cfg: Config{CertFile: cfg.CertFile, KeyFile: cfg.KeyFile, ClientAuth: ClientAuthRequire}},
+		{name: "unknown client auth", cfg: Config{CertFile: cfg.CertFile, KeyFile: cfg.KeyFile, ClientAuth: "optional"}},
+		{name: "missing file", cfg: Config{CertFile: cfg.CertFile + ".missing", KeyFile: cfg.KeyFile}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); err == nil {
+				t.Fatalf("New succeeded, want an error")
+			}
+		})
+	}
+
+	withCAs := cfg
+	withCAs.ClientCAFile = cfg.CertFile
+	r, err := New(withCAs)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if got := r.clientAuthType(); got != tls.RequireAndVerifyClientCert {
+		t.Errorf("client auth = %v, want client certificates required with a CA file", got)
+	}
+}
@@ -10,3 +10,9 @@ type CommitRollbacker interface {
 	
 	Rollback(ctx context.Context) error
 }
+
+// Beginner starts transactions. Every call made through the value bound to
+// the returned CommitRollbacker is applied or discarded as a whole.
+type Beginner interface {
+	Begin(ctx context.Context) (CommitRollbacker, error)
+}
@@ -0,0 +1,32 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/felipecooper/log-horizon/foundation/logger"
+)
+
+// Run calls fn every interval until ctx is cancelled. Failures are logged and
+// retried on the next tick.
+func Run(ctx context.Context, log logger.Logger, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Info(ctx, "background job started", "job", name, "interval", interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info(ctx, "background job stopped", "job", name)
+			return
+		case <-ticker.C:
+			start := time.Now()
+			if err := fn(ctx); err != nil {
+				log.Error(ctx, "background job failed", "job", name, "error", err)
+				continue
+			}
+			log.Info(ctx, "background job finished", "job", name, "duration", time.Since(start))
+		}
+	}
+}